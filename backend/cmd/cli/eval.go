@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/aiflows/eval"
+	"github.com/sethvargo/go-envconfig"
+)
+
+const (
+	defaultEvalDataset   = "internal/aiflows/eval/testdata/generate_request.json"
+	defaultEvalResponses = "internal/aiflows/eval/testdata/generate_request_responses.json"
+)
+
+// runEvalGenerate runs the golden dataset through the generate-request flow
+// and prints a field-level precision/recall report. Model responses are
+// replayed from the response store, so it runs offline; with -record they
+// come from the LLM_* provider instead and the store is rewritten, unless any
+// case failed.
+func runEvalGenerate(ctx context.Context, _ config.Config, args []string) error {
+	fs := flag.NewFlagSet("eval-generate", flag.ContinueOnError)
+	datasetPath := fs.String("dataset", defaultEvalDataset, "golden dataset to evaluate")
+	responsesPath := fs.String("responses", defaultEvalResponses, "recorded model responses to replay, or to record into")
+	record := fs.Bool("record", false, "query the configured LLM provider and re-record every response")
+	format := fs.String("format", "text", "report format: text or json")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	ds, err := eval.LoadDataset(*datasetPath)
+	if err != nil {
+		return err
+	}
+	store, err := aiflows.LoadResponseStore(*responsesPath)
+	if err != nil {
+		return err
+	}
+
+	var recordFrom aiflows.Provider
+	if *record {
+		var llm config.LLM
+		if err := envconfig.ProcessWith(ctx, &envconfig.Config{
+			Target:   &llm,
+			Lookuper: envconfig.PrefixLookuper("LLM_", envconfig.OsLookuper()),
+		}); err != nil {
+			return fmt.Errorf("failed to process LLM config: %w", err)
+		}
+		if recordFrom, err = aiflows.NewProvider(&llm); err != nil {
+			return fmt.Errorf("failed to set up LLM provider: %w", err)
+		}
+		// Start over so responses to prompts no longer in use are dropped.
+		store = &aiflows.ResponseStore{Responses: map[string]aiflows.RecordedResponse{}}
+	}
+
+	svc, err := eval.NewService(ctx, ds, aiflows.NewReplayProvider(store, recordFrom))
+	if err != nil {
+		return fmt.Errorf("failed to set up generate flows: %w", err)
+	}
+
+	report := eval.Run(ctx, svc, ds)
+	report.Model = store.Model
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		err = report.WriteJSON(w)
+	} else {
+		err = report.WriteText(w)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *record {
+		// A partial store would fail every errored case on replay, so keep
+		// the previous recording until the provider answers every prompt.
+		if report.Errors > 0 {
+			return fmt.Errorf("not saving recorded responses: %d of %d cases failed", report.Errors, report.Cases)
+		}
+		if err := store.Save(*responsesPath); err != nil {
+			return fmt.Errorf("failed to save recorded responses: %w", err)
+		}
+	}
+	return nil
+}
@@ -14,7 +14,10 @@ import (
 // command is a runnable CLI subcommand.
 type command struct {
 	description string
-	run         func(ctx context.Context, cfg config.Config, args []string) error
+	// standalone commands run without the server environment and get a zero
+	// config; they read whatever settings they need themselves.
+	standalone bool
+	run        func(ctx context.Context, cfg config.Config, args []string) error
 }
 
 // commands is the registry of all available CLI subcommands.
@@ -33,6 +36,14 @@ var commands = map[string]command{
 		description: "Fetch all guests from the database and reindex them in OpenSearch",
 		run:         runReindexGuests,
 	},
+	"reindex-requests": {
+		description: "Fetch the latest version of every request from the database and reindex them in OpenSearch",
+		run:         runReindexRequests,
+	},
+	"backfill-requests-current": {
+		description: "Rebuild the requests_current table from request history, for the given hotel IDs or all hotels",
+		run:         runBackfillRequestsCurrent,
+	},
 	"backfill-hotel-departments": {
 		description: "Seed default departments for hotels that have no departments",
 		run:         runBackfillHotelDepartments,
@@ -41,6 +52,11 @@ var commands = map[string]command{
 		description: "Seed requests and tasks for the hotel belonging to a given user",
 		run:         runSeedData,
 	},
+	"eval-generate": {
+		description: "Score the generate-request prompt against the golden dataset, offline from recorded model responses (-record to re-record)",
+		standalone:  true,
+		run:         runEvalGenerate,
+	},
 }
 
 func main() {
@@ -63,8 +79,10 @@ func main() {
 
 	ctx := context.Background()
 	var cfg config.Config
-	if err := envconfig.Process(ctx, &cfg); err != nil {
-		log.Fatal("failed to process config:", err)
+	if !cmd.standalone {
+		if err := envconfig.Process(ctx, &cfg); err != nil {
+			log.Fatal("failed to process config:", err)
+		}
 	}
 
 	if err := cmd.run(ctx, cfg, args[1:]); err != nil {
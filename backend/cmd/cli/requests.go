@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/generate/selfserve/internal/repository"
+	opensearchstorage "github.com/generate/selfserve/internal/service/storage/opensearch"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+)
+
+func runReindexRequests(ctx context.Context, cfg config.Config, _ []string) error {
+	pgRepo, err := storage.NewRepository(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer pgRepo.Close()
+
+	osClient, err := opensearchstorage.NewClient(cfg.OpenSearch)
+	if err != nil {
+		return fmt.Errorf("failed to connect to opensearch: %w", err)
+	}
+
+	if err := opensearchstorage.EnsureRequestsIndex(ctx, osClient); err != nil {
+		return fmt.Errorf("failed to ensure requests index: %w", err)
+	}
+
+	requestsRepo := repository.NewRequestsRepo(pgRepo.DB)
+	osRequestsRepo := repository.NewOpenSearchRequestsRepository(osClient)
+
+	var total int
+	batch := make([]*models.RequestDocument, 0, reindexBatchSize)
+
+	flush := func() error {
+		if err := osRequestsRepo.BulkIndexRequests(ctx, batch); err != nil {
+			return err
+		}
+		total += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for doc, err := range requestsRepo.AllRequestDocuments(ctx) {
+		if err != nil {
+			return fmt.Errorf("failed to fetch request documents: %w", err)
+		}
+		batch = append(batch, doc)
+		if len(batch) == reindexBatchSize {
+			if err := flush(); err != nil {
+				return fmt.Errorf("failed to bulk index batch: %w", err)
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return fmt.Errorf("failed to bulk index batch: %w", err)
+		}
+	}
+
+	fmt.Printf("reindex-requests completed: %d indexed\n", total)
+	return nil
+}
+
+// runBackfillRequestsCurrent rebuilds requests_current from the version
+// history for the given hotels, or for every hotel with requests when none
+// are given.
+func runBackfillRequestsCurrent(ctx context.Context, cfg config.Config, args []string) error {
+	pgRepo, err := storage.NewRepository(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer pgRepo.Close()
+
+	requestsRepo := repository.NewRequestsRepo(pgRepo.DB)
+
+	hotelIDs := args
+	if len(hotelIDs) == 0 {
+		hotelIDs, err = requestsRepo.RequestHotelIDs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch hotels: %w", err)
+		}
+	}
+
+	var total int64
+	for _, hotelID := range hotelIDs {
+		n, err := requestsRepo.BackfillCurrentRequests(ctx, hotelID)
+		if err != nil {
+			return fmt.Errorf("failed to backfill requests for hotel %s: %w", hotelID, err)
+		}
+		total += n
+	}
+
+	fmt.Printf("backfill-requests-current completed: %d hotels, %d requests written\n", len(hotelIDs), total)
+	return nil
+}
@@ -1,7 +1,12 @@
 package config
 
 type LLM struct {
+	Provider        string `env:"PROVIDER"` // gemini, openai (any OpenAI-compatible endpoint, e.g. Ollama), stub or none; empty picks gemini when an API key is set
 	APIKey          string `env:"API_KEY"`
-	Model           string `env:"MODEL" envDefault:"gemini-3-flash-preview"`
+	BaseURL         string `env:"BASE_URL"` // OpenAI-compatible endpoint, e.g. http://127.0.0.1:11434/v1
+	Model           string `env:"MODEL"`    // defaults to the provider's default model
 	MaxOutputTokens int    `env:"MAX_OUTPUT_TOKENS" envDefault:"1024"`
+	// ReviewThreshold is the confidence below which persisted generated
+	// requests are held as drafts for review.
+	ReviewThreshold float64 `env:"REVIEW_THRESHOLD" envDefault:"0.7"`
 }
@@ -0,0 +1,61 @@
+package config
+
+import "time"
+
+// Notifications configures the channels notifications are delivered on
+// besides in-app. Expo push is always on, as are webhooks, which each hotel
+// sets with PUT /hotels/:id/settings; the others are on once configured.
+type Notifications struct {
+	Expo Expo `env:",prefix=EXPO_"`
+	APNs APNs `env:",prefix=APNS_"`
+	FCM  FCM  `env:",prefix=FCM_"`
+	SMTP SMTP `env:",prefix=SMTP_"`
+	SMS  SMS  `env:",prefix=SMS_"`
+
+	// DigestWindow coalesces a user's notifications on a channel into one
+	// digest ("5 new tasks assigned") when they come less than the window
+	// apart, e.g. "2m". Off when unset.
+	DigestWindow time.Duration `env:"DIGEST_WINDOW"`
+	// DedupeWindow drops repeated notifications of a type about the same
+	// request within the window, e.g. "10m". Off when unset.
+	DedupeWindow time.Duration `env:"DEDUPE_WINDOW"`
+	// DailySummaryAt is when, as "HH:MM" in each user's timezone, department
+	// members are sent the open and overdue requests of their departments.
+	// Off when unset.
+	DailySummaryAt string `env:"DAILY_SUMMARY_AT"`
+}
+
+type Expo struct {
+	URL         string `env:"URL"`          // defaults to Expo's push API
+	AccessToken string `env:"ACCESS_TOKEN"` // only needed with enhanced push security
+}
+
+// APNs delivers to iOS device tokens registered without Expo.
+type APNs struct {
+	Key    string `env:"KEY"` // contents of the .p8 signing key; APNs is off without it
+	KeyID  string `env:"KEY_ID"`
+	TeamID string `env:"TEAM_ID"`
+	Topic  string `env:"TOPIC"` // the app's bundle ID
+	URL    string `env:"URL"`   // defaults to production; https://api.sandbox.push.apple.com for development builds
+}
+
+// FCM delivers to Android device tokens registered without Expo.
+type FCM struct {
+	Credentials string `env:"CREDENTIALS"` // contents of the service account JSON key; FCM is off without it
+	URL         string `env:"URL"`         // defaults to https://fcm.googleapis.com
+}
+
+type SMTP struct {
+	Host     string `env:"HOST"` // email is off without it
+	Port     int    `env:"PORT"` // defaults to 587
+	Username string `env:"USERNAME"`
+	Password string `env:"PASSWORD"`
+	From     string `env:"FROM"`
+}
+
+// SMS posts {"from", "to", "body"} as JSON to an HTTP gateway.
+type SMS struct {
+	GatewayURL   string `env:"GATEWAY_URL"` // SMS is off without it
+	GatewayToken string `env:"GATEWAY_TOKEN"`
+	From         string `env:"FROM"`
+}
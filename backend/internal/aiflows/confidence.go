@@ -0,0 +1,69 @@
+package aiflows
+
+import "strings"
+
+// defaultFieldConfidence scores a field the model extracted without rating it.
+const defaultFieldConfidence = 0.5
+
+// confidenceFieldAliases maps the model's own field names onto the keys of
+// FieldConfidence.
+var confidenceFieldAliases = map[string]string{
+	"room_reference": "room",
+	"room_id":        "room",
+	"guest_name":     "guest",
+	"guest_id":       "guest",
+	"user_name":      "user",
+	"user_id":        "user",
+	"department_id":  "department",
+}
+
+// scoreConfidence rates every field the request carries, starting from the
+// model's own ratings: unrated fields get defaultFieldConfidence and a room,
+// guest, staff member or department that could not be resolved scores 0.
+// The overall confidence is the lowest field score.
+func scoreConfidence(output EnrichedGenerateRequestOutput) EnrichedGenerateRequestOutput {
+	rated := make(map[string]float64, len(output.FieldConfidence))
+	for field, score := range output.FieldConfidence {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if alias, ok := confidenceFieldAliases[field]; ok {
+			field = alias
+		}
+		rated[field] = min(max(score, 0), 1)
+	}
+
+	scores := map[string]float64{}
+	score := func(field string, resolved bool) {
+		s, ok := rated[field]
+		if !ok {
+			s = defaultFieldConfidence
+		}
+		if !resolved {
+			s = 0
+		}
+		scores[field] = s
+	}
+
+	score("name", true)
+	score("request_type", true)
+	score("status", true)
+	score("priority", true)
+	if output.Department != nil {
+		score("department", output.DepartmentID != nil)
+	}
+	if output.RoomMentioned != nil && *output.RoomMentioned && output.RoomReference != nil {
+		score("room", output.RoomID != nil)
+	}
+	if output.GuestName != nil {
+		score("guest", output.GuestID != nil)
+	}
+	if output.UserName != nil {
+		score("user", output.UserID != nil)
+	}
+
+	output.Confidence = 1
+	for _, s := range scores {
+		output.Confidence = min(output.Confidence, s)
+	}
+	output.FieldConfidence = scores
+	return output
+}
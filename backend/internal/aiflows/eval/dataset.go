@@ -0,0 +1,102 @@
+// Package eval scores the generate-request flow against a golden dataset of
+// guest messages paired with the fields a person would extract from them.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+// Dataset is a golden set of messages for one fixture hotel.
+type Dataset struct {
+	Hotel Hotel  `json:"hotel"`
+	Cases []Case `json:"cases"`
+}
+
+// Hotel is the fixture the flow's room, guest, staff and department lookups
+// resolve against. Rooms resolve to their number, and guests, staff and
+// departments to their name, so expected values read as plain text.
+// StaffLanguage defaults to English.
+type Hotel struct {
+	ID            string         `json:"id"`
+	StaffLanguage string         `json:"staff_language,omitempty"`
+	Departments   []string       `json:"departments"`
+	Rooms         []int          `json:"rooms"`
+	Guests        []FixtureGuest `json:"guests"`
+	Staff         []string       `json:"staff"`
+}
+
+// FixtureGuest is a guest with an active booking in Room.
+type FixtureGuest struct {
+	Name string `json:"name"`
+	Room int    `json:"room"`
+}
+
+// Case is one message and what should be extracted from it.
+type Case struct {
+	ID       string   `json:"id"`
+	RawText  string   `json:"raw_text"`
+	Expected Expected `json:"expected"`
+}
+
+// Expected holds the fields of a case. Name lists every acceptable name,
+// compared case-insensitively. An omitted department, room or guest means the
+// message does not mention one, so extracting any counts against precision.
+// An omitted language means the message is in English.
+type Expected struct {
+	Name       []string `json:"name"`
+	Priority   string   `json:"priority"`
+	Department string   `json:"department,omitempty"`
+	Room       string   `json:"room,omitempty"`
+	Guest      string   `json:"guest,omitempty"`
+	Language   string   `json:"language,omitempty"`
+}
+
+// LoadDataset reads and checks a dataset file.
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds Dataset
+	if err := json.Unmarshal(data, &ds); err != nil {
+		return nil, fmt.Errorf("parse dataset %s: %w", path, err)
+	}
+	if err := ds.validate(); err != nil {
+		return nil, fmt.Errorf("dataset %s: %w", path, err)
+	}
+	return &ds, nil
+}
+
+func (ds *Dataset) validate() error {
+	if ds.Hotel.ID == "" {
+		return fmt.Errorf("hotel.id is required")
+	}
+
+	seen := map[string]bool{}
+	for i, c := range ds.Cases {
+		switch {
+		case c.ID == "":
+			return fmt.Errorf("case %d: id is required", i)
+		case seen[c.ID]:
+			return fmt.Errorf("case %q: duplicate id", c.ID)
+		case c.RawText == "":
+			return fmt.Errorf("case %q: raw_text is required", c.ID)
+		case len(c.Expected.Name) == 0 || c.Expected.Priority == "":
+			return fmt.Errorf("case %q: expected name and priority are required", c.ID)
+		}
+		seen[c.ID] = true
+	}
+	return nil
+}
+
+func (h Hotel) staffLanguage() string {
+	if h.StaffLanguage == "" {
+		return models.DefaultStaffLanguage
+	}
+	return h.StaffLanguage
+}
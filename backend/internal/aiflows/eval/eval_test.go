@@ -0,0 +1,105 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockGenerateService struct {
+	outputs map[string]aiflows.EnrichedGenerateRequestOutput
+}
+
+func (m *mockGenerateService) RunGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+	out, ok := m.outputs[input.RawText]
+	if !ok {
+		return aiflows.EnrichedGenerateRequestOutput{}, errors.New("model unavailable")
+	}
+	return out, nil
+}
+
+func (m *mockGenerateService) RunGenerateRequestBatch(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.GenerateRequestBatchOutput, error) {
+	return aiflows.GenerateRequestBatchOutput{}, nil
+}
+
+func (m *mockGenerateService) RunTranscribeAudio(ctx context.Context, input aiflows.TranscribeAudioInput) (aiflows.TranscribeAudioOutput, error) {
+	return aiflows.TranscribeAudioOutput{}, nil
+}
+
+func (m *mockGenerateService) RunGeneratePhotoRequest(ctx context.Context, input aiflows.GeneratePhotoRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+	return aiflows.EnrichedGenerateRequestOutput{}, nil
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	room504, room312, maria := "504", "312", "Maria Lopez"
+	ds := &Dataset{
+		Hotel: Hotel{ID: "org_eval"},
+		Cases: []Case{
+			{ID: "right", RawText: "towels 504", Expected: Expected{Name: []string{"Extra Towels", "Towels"}, Priority: "medium", Room: "504", Guest: "Maria Lopez"}},
+			{ID: "wrong-room", RawText: "ac 504", Expected: Expected{Name: []string{"AC Repair"}, Priority: "high", Room: "504"}},
+			{ID: "extra-guest", RawText: "lobby", Expected: Expected{Name: []string{"Lobby Cleaning"}, Priority: "low"}},
+			{ID: "error", RawText: "broken", Expected: Expected{Name: []string{"Repair"}, Priority: "medium", Room: "312"}},
+		},
+	}
+	svc := &mockGenerateService{outputs: map[string]aiflows.EnrichedGenerateRequestOutput{
+		"towels 504": {RoomID: &room504, GuestID: &maria, GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "towels", Priority: "medium", Language: "en"}},
+		"ac 504":     {RoomID: &room312, GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "AC Repair", Priority: "high", Language: "en"}},
+		"lobby":      {GuestID: &maria, GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "Lobby Cleaning", Priority: "low", Language: "es"}},
+	}}
+
+	report := Run(context.Background(), svc, ds)
+
+	assert.Equal(t, 4, report.Cases)
+	assert.Equal(t, 1, report.Errors)
+
+	scores := map[string]FieldScore{}
+	for _, f := range report.Fields {
+		scores[f.Field] = f
+	}
+	assert.Equal(t, [3]int{3, 0, 1}, [3]int{scores["name"].TP, scores["name"].FP, scores["name"].FN})
+	assert.Equal(t, [3]int{1, 1, 2}, [3]int{scores["room"].TP, scores["room"].FP, scores["room"].FN})
+	assert.Equal(t, [3]int{1, 1, 0}, [3]int{scores["guest"].TP, scores["guest"].FP, scores["guest"].FN})
+	assert.Equal(t, [3]int{2, 1, 2}, [3]int{scores["language"].TP, scores["language"].FP, scores["language"].FN})
+	assert.Nil(t, scores["department"].Precision)
+	require.NotNil(t, scores["room"].Precision)
+	assert.InDelta(t, 0.5, *scores["room"].Precision, 1e-9)
+	require.NotNil(t, scores["room"].Recall)
+	assert.InDelta(t, 1.0/3, *scores["room"].Recall, 1e-9)
+
+	require.Len(t, report.Failures, 3)
+	assert.Equal(t, CaseResult{ID: "wrong-room", Mismatches: []Mismatch{{Field: "room", Expected: "504", Got: "312"}}}, report.Failures[0])
+	assert.Equal(t, CaseResult{ID: "extra-guest", Mismatches: []Mismatch{{Field: "guest", Got: "Maria Lopez"}, {Field: "language", Expected: "en", Got: "es"}}}, report.Failures[1])
+	assert.Equal(t, "error", report.Failures[2].ID)
+	assert.Equal(t, "model unavailable", report.Failures[2].Error)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), `wrong-room: room expected "504" got "312"`)
+}
+
+// TestGoldenDatasetReplays fails when the generate prompt or output schema
+// changes without re-recording the golden responses
+// (cli eval-generate -record).
+func TestGoldenDatasetReplays(t *testing.T) {
+	t.Parallel()
+
+	ds, err := LoadDataset("testdata/generate_request.json")
+	require.NoError(t, err)
+	store, err := aiflows.LoadResponseStore("testdata/generate_request_responses.json")
+	require.NoError(t, err)
+
+	svc, err := NewService(context.Background(), ds, aiflows.NewReplayProvider(store, nil))
+	require.NoError(t, err)
+
+	report := Run(context.Background(), svc, ds)
+	for _, c := range report.Failures {
+		assert.Empty(t, c.Error, "case %s", c.ID)
+	}
+}
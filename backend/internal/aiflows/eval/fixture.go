@@ -0,0 +1,115 @@
+package eval
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/models"
+)
+
+// NewService sets up the generate flows on provider with lookups served from
+// the dataset's fixture hotel instead of Postgres.
+func NewService(ctx context.Context, ds *Dataset, provider aiflows.Provider) (*aiflows.GenkitService, error) {
+	f := &fixture{hotel: ds.Hotel}
+	return aiflows.InitGenkitWithProvider(ctx, provider, f, f, f, f)
+}
+
+// fixture resolves lookups against a Hotel. Matching is deliberately simpler
+// than the Postgres lookups: it only has to tell whether the model extracted
+// the right reference, not rank messy real-world data.
+type fixture struct {
+	hotel Hotel
+}
+
+var _ interface {
+	aiflows.RoomLookupRepository
+	aiflows.GuestLookupRepository
+	aiflows.UserLookupRepository
+	aiflows.HotelLookupRepository
+} = (*fixture)(nil)
+
+func (f *fixture) GetStaffLanguageByHotelID(_ context.Context, _ string) (string, error) {
+	return f.hotel.staffLanguage(), nil
+}
+
+func (f *fixture) GetDepartmentsByHotelID(_ context.Context, _ string) ([]*models.Department, error) {
+	departments := make([]*models.Department, len(f.hotel.Departments))
+	for i, name := range f.hotel.Departments {
+		departments[i] = &models.Department{ID: name, HotelID: f.hotel.ID, Name: name}
+	}
+	return departments, nil
+}
+
+func (f *fixture) FindRoomCandidates(_ context.Context, _ string, ref models.RoomReference, _ float64, _ int) ([]*models.LookupCandidate, error) {
+	number := -1
+	switch {
+	case ref.Number != nil:
+		number = *ref.Number
+	case ref.Floor != nil && ref.Unit != nil:
+		number = *ref.Floor*100 + *ref.Unit
+	}
+	if !slices.Contains(f.hotel.Rooms, number) {
+		return nil, nil
+	}
+	id := strconv.Itoa(number)
+	return []*models.LookupCandidate{{ID: id, Label: "Room " + id, Score: 1}}, nil
+}
+
+func (f *fixture) FindGuestCandidates(_ context.Context, _, name string, roomID *string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	var names []string
+	for _, g := range f.hotel.Guests {
+		if roomID == nil || *roomID == strconv.Itoa(g.Room) {
+			names = append(names, g.Name)
+		}
+	}
+	return nameCandidates(names, name, minScore, limit), nil
+}
+
+func (f *fixture) FindUserCandidates(_ context.Context, _, name string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	return nameCandidates(f.hotel.Staff, name, minScore, limit), nil
+}
+
+// nameCandidates scores a full-name match 1 and a match on some of the
+// name's words (e.g. "Maria" for "Maria Lopez") 0.8.
+func nameCandidates(names []string, query string, minScore float64, limit int) []*models.LookupCandidate {
+	queryWords := strings.Fields(strings.ToLower(query))
+	var candidates []*models.LookupCandidate
+	for _, name := range names {
+		var score float64
+		nameWords := strings.Fields(strings.ToLower(name))
+		switch {
+		case slices.Equal(queryWords, nameWords):
+			score = 1
+		case len(queryWords) > 0 && allIn(queryWords, nameWords):
+			score = 0.8
+		}
+		if score > 0 && score >= minScore {
+			candidates = append(candidates, &models.LookupCandidate{ID: name, Label: name, Score: score})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b *models.LookupCandidate) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+func allIn(words, in []string) bool {
+	for _, w := range words {
+		if !slices.Contains(in, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteText writes the report as aligned plain text.
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "generate-request eval\n")
+	fmt.Fprintf(w, "model:  %s\n", r.Model)
+	fmt.Fprintf(w, "prompt: %s\n", r.PromptVersion)
+	fmt.Fprintf(w, "cases:  %d (%d errors)\n\n", r.Cases, r.Errors)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "field\ttp\tfp\tfn\tprecision\trecall")
+	for _, f := range r.Fields {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\n", f.Field, f.TP, f.FP, f.FN, formatRatio(f.Precision), formatRatio(f.Recall))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nfailures\n")
+	for _, c := range r.Failures {
+		if c.Error != "" {
+			fmt.Fprintf(w, "%s: error: %s\n", c.ID, c.Error)
+			continue
+		}
+		for _, m := range c.Mismatches {
+			fmt.Fprintf(w, "%s: %s expected %q got %q\n", c.ID, m.Field, m.Expected, m.Got)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func formatRatio(r *float64) string {
+	if r == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f", *r)
+}
@@ -0,0 +1,168 @@
+package eval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/aiflows/prompts"
+)
+
+// Fields are the scored fields, in report order.
+var Fields = []string{"name", "priority", "department", "room", "guest", "language"}
+
+// Report is the outcome of running a dataset. It holds no timestamps or
+// durations, so reports of two prompt versions diff cleanly.
+type Report struct {
+	Model         string       `json:"model"`
+	PromptVersion string       `json:"prompt_version"`
+	Cases         int          `json:"cases"`
+	Errors        int          `json:"errors"`
+	Fields        []FieldScore `json:"fields"`
+	// Failures lists the cases that errored or got any field wrong, in
+	// dataset order.
+	Failures []CaseResult `json:"failures"`
+}
+
+// FieldScore counts, over all cases, extracted values that were expected (TP),
+// extracted values that were not (FP) and expected values that were missed
+// (FN). A wrong value counts as both FP and FN. Precision and recall are nil
+// when nothing was extracted or expected.
+type FieldScore struct {
+	Field     string   `json:"field"`
+	TP        int      `json:"tp"`
+	FP        int      `json:"fp"`
+	FN        int      `json:"fn"`
+	Precision *float64 `json:"precision"`
+	Recall    *float64 `json:"recall"`
+}
+
+type CaseResult struct {
+	ID         string     `json:"id"`
+	Error      string     `json:"error,omitempty"`
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+type Mismatch struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Got      string `json:"got"`
+}
+
+// Run sends every case through svc and scores the extracted fields. A case
+// the flow fails on counts every expected field as missed.
+func Run(ctx context.Context, svc aiflows.GenerateRequestService, ds *Dataset) *Report {
+	report := &Report{
+		PromptVersion: PromptVersion(ds),
+		Cases:         len(ds.Cases),
+	}
+	scores := map[string]*FieldScore{}
+	for _, field := range Fields {
+		scores[field] = &FieldScore{Field: field}
+	}
+
+	for _, c := range ds.Cases {
+		expected := expectedValues(c.Expected)
+
+		out, err := svc.RunGenerateRequest(ctx, aiflows.GenerateRequestInput{RawText: c.RawText, HotelID: ds.Hotel.ID})
+		if err != nil {
+			report.Errors++
+			for _, field := range Fields {
+				if expected[field] != "" {
+					scores[field].FN++
+				}
+			}
+			report.Failures = append(report.Failures, CaseResult{ID: c.ID, Error: err.Error()})
+			continue
+		}
+
+		got := extractedValues(out)
+		result := CaseResult{ID: c.ID}
+		for _, field := range Fields {
+			want, have := expected[field], got[field]
+			score := scores[field]
+			switch {
+			case want == "" && have == "":
+				continue
+			case want != "" && matches(field, c.Expected, have):
+				score.TP++
+				continue
+			case want == "":
+				score.FP++
+			case have == "":
+				score.FN++
+			default:
+				score.FP++
+				score.FN++
+			}
+			result.Mismatches = append(result.Mismatches, Mismatch{Field: field, Expected: want, Got: have})
+		}
+		if len(result.Mismatches) > 0 {
+			report.Failures = append(report.Failures, result)
+		}
+	}
+
+	for _, field := range Fields {
+		score := scores[field]
+		score.Precision = ratio(score.TP, score.TP+score.FP)
+		score.Recall = ratio(score.TP, score.TP+score.FN)
+		report.Fields = append(report.Fields, *score)
+	}
+	return report
+}
+
+// PromptVersion identifies the generate prompt as sent for the dataset's
+// hotel, so reports show when the prompt changed between runs.
+func PromptVersion(ds *Dataset) string {
+	sum := sha256.Sum256([]byte(prompts.GenerateRequestPrompt("", ds.Hotel.Departments, ds.Hotel.staffLanguage())))
+	return hex.EncodeToString(sum[:6])
+}
+
+func expectedValues(e Expected) map[string]string {
+	language := e.Language
+	if language == "" {
+		language = "en"
+	}
+	return map[string]string{
+		"name":       strings.Join(e.Name, " | "),
+		"priority":   e.Priority,
+		"department": e.Department,
+		"room":       e.Room,
+		"guest":      e.Guest,
+		"language":   language,
+	}
+}
+
+// extractedValues reads the scored fields off the flow's output. The room,
+// guest and department only count once resolved against the fixture.
+func extractedValues(out aiflows.EnrichedGenerateRequestOutput) map[string]string {
+	values := map[string]string{
+		"name":     out.Name,
+		"priority": out.Priority,
+		"language": out.Language,
+	}
+	for field, id := range map[string]*string{"department": out.DepartmentID, "room": out.RoomID, "guest": out.GuestID} {
+		if id != nil {
+			values[field] = *id
+		}
+	}
+	return values
+}
+
+func matches(field string, expected Expected, got string) bool {
+	if field == "name" {
+		return slices.ContainsFunc(expected.Name, func(name string) bool { return strings.EqualFold(name, got) })
+	}
+	return strings.EqualFold(expectedValues(expected)[field], got)
+}
+
+func ratio(n, d int) *float64 {
+	if d == 0 {
+		return nil
+	}
+	r := float64(n) / float64(d)
+	return &r
+}
@@ -2,59 +2,169 @@ package aiflows
 
 import (
 	"context"
-	"fmt"
+	"encoding/base64"
+	"strings"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/genkit"
 	"github.com/generate/selfserve/internal/aiflows/prompts"
 	"github.com/generate/selfserve/internal/models"
-	"google.golang.org/genai"
 )
 
-func DefineGenerateRequest(genkitInstance *genkit.Genkit, model ai.Model, generationConfig *genai.GenerateContentConfig, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, deptLookupRepo DepartmentLookupRepository) *core.Flow[GenerateRequestInput, EnrichedGenerateRequestOutput, struct{}] {
+func DefineGenerateRequest(genkitInstance *genkit.Genkit, model ai.Model, generationConfig any, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, hotelLookupRepo HotelLookupRepository) *core.Flow[GenerateRequestInput, EnrichedGenerateRequestOutput, struct{}] {
 	generateRequestFlow := genkit.DefineFlow(genkitInstance, "generateRequestFlow",
 		func(ctx context.Context, input GenerateRequestInput) (EnrichedGenerateRequestOutput, error) {
-			departments, err := deptLookupRepo.GetDepartmentsByHotelID(ctx, input.HotelID)
+			hotel, err := lookupHotelSettings(ctx, hotelLookupRepo, input.HotelID)
 			if err != nil {
-				return EnrichedGenerateRequestOutput{}, fmt.Errorf("fetch departments: %w", err)
-			}
-
-			deptNames := make([]string, len(departments))
-			for i, d := range departments {
-				deptNames[i] = d.Name
+				return EnrichedGenerateRequestOutput{}, err
 			}
 
-			prompt := prompts.GenerateRequestPrompt(input.RawText, deptNames)
+			prompt := prompts.GenerateRequestPrompt(input.RawText, departmentNames(hotel.departments), hotel.staffLanguage)
 			resp, _, err := genkit.GenerateData[GenerateRequestOutput](ctx, genkitInstance, ai.WithPrompt(prompt), ai.WithModel(model), ai.WithConfig(generationConfig))
 			if err != nil {
 				return EnrichedGenerateRequestOutput{}, err
 			}
 
-			enriched := EnrichedGenerateRequestOutput{
-				GenerateRequestOutput: *resp,
+			return enrichGeneratedRequest(ctx, roomLookupRepo, guestLookupRepo, userLookupRepo, hotel.departments, input.HotelID, *resp)
+		},
+	)
+
+	return generateRequestFlow
+}
+
+// DefineGenerateRequestBatch defines a flow that extracts every request in a
+// message describing several tasks. Each request is enriched on its own, so
+// its lookups and warning only concern that request.
+func DefineGenerateRequestBatch(genkitInstance *genkit.Genkit, model ai.Model, generationConfig any, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, hotelLookupRepo HotelLookupRepository) *core.Flow[GenerateRequestInput, GenerateRequestBatchOutput, struct{}] {
+	generateRequestBatchFlow := genkit.DefineFlow(genkitInstance, "generateRequestBatchFlow",
+		func(ctx context.Context, input GenerateRequestInput) (GenerateRequestBatchOutput, error) {
+			hotel, err := lookupHotelSettings(ctx, hotelLookupRepo, input.HotelID)
+			if err != nil {
+				return GenerateRequestBatchOutput{}, err
 			}
 
-			output, err := enrichWithRoomLookup(ctx, roomLookupRepo, input.HotelID, enriched)
+			prompt := prompts.GenerateRequestsPrompt(input.RawText, departmentNames(hotel.departments), hotel.staffLanguage)
+			resp, _, err := genkit.GenerateData[GenerateRequestsOutput](ctx, genkitInstance, ai.WithPrompt(prompt), ai.WithModel(model), ai.WithConfig(generationConfig))
 			if err != nil {
-				return EnrichedGenerateRequestOutput{}, err
+				return GenerateRequestBatchOutput{}, err
+			}
+
+			output := GenerateRequestBatchOutput{Requests: make([]EnrichedGenerateRequestOutput, 0, len(resp.Requests))}
+			for _, generated := range resp.Requests {
+				// The message's language and translation are given once for
+				// all of its requests.
+				generated.Language, generated.EnglishText = resp.Language, resp.EnglishText
+				enriched, err := enrichGeneratedRequest(ctx, roomLookupRepo, guestLookupRepo, userLookupRepo, hotel.departments, input.HotelID, generated)
+				if err != nil {
+					return GenerateRequestBatchOutput{}, err
+				}
+				output.Requests = append(output.Requests, enriched)
+			}
+			return output, nil
+		},
+	)
+
+	return generateRequestBatchFlow
+}
+
+// DefineTranscribeAudio defines a flow that transcribes a voice note, so it
+// can be fed to the generate flows as text.
+func DefineTranscribeAudio(genkitInstance *genkit.Genkit, model ai.Model, generationConfig any) *core.Flow[TranscribeAudioInput, TranscribeAudioOutput, struct{}] {
+	transcribeFlow := genkit.DefineFlow(genkitInstance, "transcribeAudioFlow",
+		func(ctx context.Context, input TranscribeAudioInput) (TranscribeAudioOutput, error) {
+			message := ai.NewUserMessage(
+				ai.NewTextPart(prompts.TranscribeAudioPrompt),
+				ai.NewMediaPart(input.Audio.ContentType, dataURI(input.Audio)),
+			)
+			resp, _, err := genkit.GenerateData[TranscribeAudioOutput](ctx, genkitInstance, ai.WithMessages(message), ai.WithModel(model), ai.WithConfig(generationConfig))
+			if err != nil {
+				return TranscribeAudioOutput{}, err
 			}
 
-			output, err = enrichWithGuestLookup(ctx, guestLookupRepo, input.HotelID, output)
+			resp.Transcript = strings.TrimSpace(resp.Transcript)
+			return *resp, nil
+		},
+	)
+
+	return transcribeFlow
+}
+
+// DefineGeneratePhotoRequest defines a flow that generates a request from
+// photos of the problem and any text sent with them. Photos that show nothing
+// a hotel's staff could act on get an unrelated_image warning, which takes
+// precedence over lookup warnings.
+func DefineGeneratePhotoRequest(genkitInstance *genkit.Genkit, model ai.Model, generationConfig any, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, hotelLookupRepo HotelLookupRepository) *core.Flow[GeneratePhotoRequestInput, EnrichedGenerateRequestOutput, struct{}] {
+	generatePhotoFlow := genkit.DefineFlow(genkitInstance, "generatePhotoRequestFlow",
+		func(ctx context.Context, input GeneratePhotoRequestInput) (EnrichedGenerateRequestOutput, error) {
+			hotel, err := lookupHotelSettings(ctx, hotelLookupRepo, input.HotelID)
 			if err != nil {
 				return EnrichedGenerateRequestOutput{}, err
 			}
 
-			output, err = enrichWithUserLookup(ctx, userLookupRepo, input.HotelID, output)
+			parts := []*ai.Part{ai.NewTextPart(prompts.GeneratePhotoRequestPrompt(input.Text, departmentNames(hotel.departments), hotel.staffLanguage))}
+			for _, image := range input.Images {
+				parts = append(parts, ai.NewMediaPart(image.ContentType, dataURI(image)))
+			}
+			resp, _, err := genkit.GenerateData[GeneratePhotoRequestOutput](ctx, genkitInstance, ai.WithMessages(ai.NewUserMessage(parts...)), ai.WithModel(model), ai.WithConfig(generationConfig))
 			if err != nil {
 				return EnrichedGenerateRequestOutput{}, err
 			}
 
-			return enrichWithDepartmentLookup(departments, output), nil
+			output, err := enrichGeneratedRequest(ctx, roomLookupRepo, guestLookupRepo, userLookupRepo, hotel.departments, input.HotelID, resp.GenerateRequestOutput)
+			if err != nil {
+				return EnrichedGenerateRequestOutput{}, err
+			}
+			if !resp.HotelRelated {
+				output.Warning = &GenerateRequestWarning{
+					Code:    "unrelated_image",
+					Message: "the photos do not appear to show anything related to hotel operations",
+				}
+			}
+			return output, nil
 		},
 	)
 
-	return generateRequestFlow
+	return generatePhotoFlow
+}
+
+// dataURI inlines media as a base64 data URI, the form every provider accepts.
+func dataURI(media Media) string {
+	return "data:" + media.ContentType + ";base64," + base64.StdEncoding.EncodeToString(media.Data)
+}
+
+// enrichGeneratedRequest resolves the room, guest, staff member and department
+// named in a generated request to their IDs for the hotel and scores how
+// confident the extraction is.
+func enrichGeneratedRequest(ctx context.Context, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, departments []*models.Department, hotelID string, generated GenerateRequestOutput) (EnrichedGenerateRequestOutput, error) {
+	enriched := EnrichedGenerateRequestOutput{
+		GenerateRequestOutput: generated,
+	}
+
+	output, err := enrichWithRoomLookup(ctx, roomLookupRepo, hotelID, enriched)
+	if err != nil {
+		return EnrichedGenerateRequestOutput{}, err
+	}
+
+	output, err = enrichWithGuestLookup(ctx, guestLookupRepo, hotelID, output)
+	if err != nil {
+		return EnrichedGenerateRequestOutput{}, err
+	}
+
+	output, err = enrichWithUserLookup(ctx, userLookupRepo, hotelID, output)
+	if err != nil {
+		return EnrichedGenerateRequestOutput{}, err
+	}
+
+	return scoreConfidence(enrichWithDepartmentLookup(departments, output)), nil
+}
+
+func departmentNames(departments []*models.Department) []string {
+	names := make([]string, len(departments))
+	for i, d := range departments {
+		names[i] = d.Name
+	}
+	return names
 }
 
 func enrichWithRoomLookup(ctx context.Context, roomLookupRepo RoomLookupRepository, hotelID string, output EnrichedGenerateRequestOutput) (EnrichedGenerateRequestOutput, error) {
@@ -77,8 +187,9 @@ func enrichWithRoomLookup(ctx context.Context, roomLookupRepo RoomLookupReposito
 			code = "room_ambiguous"
 		}
 		output.Warning = &GenerateRequestWarning{
-			Code:    code,
-			Message: *roomResult.Message,
+			Code:       code,
+			Message:    *roomResult.Message,
+			Candidates: roomResult.Candidates,
 		}
 	}
 
@@ -91,20 +202,19 @@ func enrichWithGuestLookup(ctx context.Context, guestLookupRepo GuestLookupRepos
 		return output, nil
 	}
 
-	guestID, warning, err := LookupGuest(ctx, guestLookupRepo, GuestLookupInput{
+	// Runs after the room lookup, so a resolved room narrows the guests.
+	result, err := LookupGuest(ctx, guestLookupRepo, GuestLookupInput{
 		HotelID:   hotelID,
 		GuestName: *output.GuestName,
+		RoomID:    output.RoomID,
 	})
 	if err != nil {
 		return EnrichedGenerateRequestOutput{}, err
 	}
 
-	output.GuestID = guestID
-	if warning != nil {
-		output.Warning = &GenerateRequestWarning{
-			Code:    "guest_not_found",
-			Message: *warning,
-		}
+	output.GuestID = result.ID
+	if result.Message != nil {
+		output.Warning = lookupWarning("guest", result)
 	}
 
 	return output, nil
@@ -115,7 +225,7 @@ func enrichWithUserLookup(ctx context.Context, userLookupRepo UserLookupReposito
 		return output, nil
 	}
 
-	userID, warning, err := LookupUser(ctx, userLookupRepo, UserLookupInput{
+	result, err := LookupUser(ctx, userLookupRepo, UserLookupInput{
 		HotelID:  hotelID,
 		UserName: *output.UserName,
 	})
@@ -123,17 +233,28 @@ func enrichWithUserLookup(ctx context.Context, userLookupRepo UserLookupReposito
 		return EnrichedGenerateRequestOutput{}, err
 	}
 
-	output.UserID = userID
-	if warning != nil {
-		output.Warning = &GenerateRequestWarning{
-			Code:    "user_not_found",
-			Message: *warning,
-		}
+	output.UserID = result.ID
+	if result.Message != nil {
+		output.Warning = lookupWarning("user", result)
 	}
 
 	return output, nil
 }
 
+// lookupWarning turns an unresolved guest or staff lookup into a warning
+// coded "<kind>_not_found" or "<kind>_ambiguous".
+func lookupWarning(kind string, result LookupResult) *GenerateRequestWarning {
+	code := kind + "_not_found"
+	if result.Ambiguous {
+		code = kind + "_ambiguous"
+	}
+	return &GenerateRequestWarning{
+		Code:       code,
+		Message:    *result.Message,
+		Candidates: result.Candidates,
+	}
+}
+
 func enrichWithDepartmentLookup(departments []*models.Department, output EnrichedGenerateRequestOutput) EnrichedGenerateRequestOutput {
 	if output.Department == nil {
 		return output
@@ -5,18 +5,17 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/generate/selfserve/internal/errs"
 	"github.com/generate/selfserve/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type mockRoomLookupRepository struct {
-	findRoomByNumberFunc func(ctx context.Context, hotelID string, roomReference string) (*models.Room, error)
+	findRoomCandidatesFunc func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error)
 }
 
-func (m *mockRoomLookupRepository) FindRoomByNumber(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
-	return m.findRoomByNumberFunc(ctx, hotelID, roomReference)
+func (m *mockRoomLookupRepository) FindRoomCandidates(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	return m.findRoomCandidatesFunc(ctx, hotelID, ref, minScore, limit)
 }
 
 func TestEnrichWithRoomLookup(t *testing.T) {
@@ -28,10 +27,11 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 		roomMentioned := true
 		roomReference := "301"
 		repo := &mockRoomLookupRepository{
-			findRoomByNumberFunc: func(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
+			findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
 				require.Equal(t, "550e8400-e29b-41d4-a716-446655440000", hotelID)
-				require.Equal(t, "301", roomReference)
-				return &models.Room{ID: "room-uuid-301"}, nil
+				require.NotNil(t, ref.Number)
+				require.Equal(t, 301, *ref.Number)
+				return []*models.LookupCandidate{{ID: "room-uuid-301", Label: "Room 301", Score: 1}}, nil
 			},
 		}
 
@@ -57,8 +57,8 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 		roomMentioned := true
 		roomReference := "999"
 		repo := &mockRoomLookupRepository{
-			findRoomByNumberFunc: func(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
-				return nil, errs.ErrNotFoundInDB
+			findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+				return nil, nil
 			},
 		}
 
@@ -83,10 +83,14 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 		t.Parallel()
 
 		roomMentioned := true
-		roomReference := "301"
+		roomReference := "deluxe suite"
 		repo := &mockRoomLookupRepository{
-			findRoomByNumberFunc: func(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
-				return nil, errs.ErrAlreadyExistsInDB
+			findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+				require.Equal(t, "deluxe suite", ref.Suite)
+				return []*models.LookupCandidate{
+					{ID: "room-uuid-301", Label: "Room 301 (Deluxe Suite)", Score: 1},
+					{ID: "room-uuid-302", Label: "Room 302 (Deluxe Suite)", Score: 1},
+				}, nil
 			},
 		}
 
@@ -104,7 +108,9 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 		assert.Nil(t, output.RoomID)
 		require.NotNil(t, output.Warning)
 		assert.Equal(t, "room_ambiguous", output.Warning.Code)
-		assert.Equal(t, "Room reference was ambiguous. Be more specific.", output.Warning.Message)
+		assert.Equal(t, `Room reference "deluxe suite" matched several rooms. Did you mean "Room 301 (Deluxe Suite)" or "Room 302 (Deluxe Suite)"?`, output.Warning.Message)
+		require.Len(t, output.Warning.Candidates, 2)
+		assert.Equal(t, "room-uuid-302", output.Warning.Candidates[1].ID)
 	})
 
 	t.Run("skips lookup when no room was mentioned", func(t *testing.T) {
@@ -112,7 +118,7 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 
 		called := false
 		repo := &mockRoomLookupRepository{
-			findRoomByNumberFunc: func(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
+			findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
 				called = true
 				return nil, nil
 			},
@@ -138,7 +144,7 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 		roomMentioned := true
 		roomReference := "301"
 		repo := &mockRoomLookupRepository{
-			findRoomByNumberFunc: func(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
+			findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
 				return nil, errors.New("db offline")
 			},
 		}
@@ -157,3 +163,116 @@ func TestEnrichWithRoomLookup(t *testing.T) {
 		assert.EqualError(t, err, "db offline")
 	})
 }
+
+type mockGuestLookupRepository struct {
+	guests map[string][]*models.LookupCandidate
+}
+
+func (m *mockGuestLookupRepository) FindGuestCandidates(ctx context.Context, hotelID, name string, roomID *string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	return m.guests[name], nil
+}
+
+type mockUserLookupRepository struct{}
+
+func (m *mockUserLookupRepository) FindUserCandidates(ctx context.Context, hotelID, name string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	return nil, nil
+}
+
+func TestEnrichGeneratedRequest_ItemsAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	rooms := &mockRoomLookupRepository{
+		findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+			if ref.Number != nil && *ref.Number == 504 {
+				return []*models.LookupCandidate{{ID: "room-uuid-504", Label: "Room 504", Score: 1}}, nil
+			}
+			return nil, nil
+		},
+	}
+	guests := &mockGuestLookupRepository{guests: map[string][]*models.LookupCandidate{
+		"Maria": {{ID: "guest-uuid-maria", Label: "Maria Lopez", Score: 1}},
+	}}
+	departments := []*models.Department{{ID: "dept-uuid-hk", Name: "Housekeeping"}}
+
+	roomMentioned := true
+	towelsRoom, acRoom := "504", "999"
+	housekeeping := "housekeeping"
+	maria := "Maria"
+
+	generated := []GenerateRequestOutput{
+		{Name: "Extra Towels", RequestType: "one-time", Status: "pending", Priority: "medium", RoomMentioned: &roomMentioned, RoomReference: &towelsRoom, Department: &housekeeping},
+		{Name: "Noisy AC", RequestType: "one-time", Status: "pending", Priority: "medium", RoomMentioned: &roomMentioned, RoomReference: &acRoom},
+		{Name: "Late Checkout", RequestType: "one-time", Status: "pending", Priority: "low", GuestName: &maria},
+	}
+
+	var outputs []EnrichedGenerateRequestOutput
+	for _, g := range generated {
+		out, err := enrichGeneratedRequest(context.Background(), rooms, guests, &mockUserLookupRepository{}, departments, "org_1", g)
+		require.NoError(t, err)
+		outputs = append(outputs, out)
+	}
+
+	require.NotNil(t, outputs[0].RoomID)
+	assert.Equal(t, "room-uuid-504", *outputs[0].RoomID)
+	assert.Equal(t, "dept-uuid-hk", *outputs[0].DepartmentID)
+	assert.Nil(t, outputs[0].Warning)
+
+	assert.Nil(t, outputs[1].RoomID)
+	require.NotNil(t, outputs[1].Warning)
+	assert.Equal(t, "room_not_found", outputs[1].Warning.Code)
+
+	assert.Nil(t, outputs[2].RoomID)
+	assert.Equal(t, "guest-uuid-maria", *outputs[2].GuestID)
+	assert.Nil(t, outputs[2].Warning)
+}
+
+func TestEnrichGeneratedRequest_Confidence(t *testing.T) {
+	t.Parallel()
+
+	rooms := &mockRoomLookupRepository{
+		findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+			if ref.Number != nil && *ref.Number == 504 {
+				return []*models.LookupCandidate{{ID: "room-uuid-504", Label: "Room 504", Score: 1}}, nil
+			}
+			return nil, nil
+		},
+	}
+	roomMentioned := true
+
+	t.Run("uses the model's ratings and defaults unrated fields", func(t *testing.T) {
+		t.Parallel()
+
+		room := "504"
+		out, err := enrichGeneratedRequest(context.Background(), rooms, &mockGuestLookupRepository{}, &mockUserLookupRepository{}, nil, "org_1", GenerateRequestOutput{
+			Name: "Extra Towels", RequestType: "one-time", Status: "pending", Priority: "high",
+			RoomMentioned: &roomMentioned, RoomReference: &room,
+			FieldConfidence: map[string]float64{"name": 0.9, "Priority": 1.4, "room_reference": 0.95, "request_type": 0.9, "status": 0.9},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]float64{"name": 0.9, "request_type": 0.9, "status": 0.9, "priority": 1, "room": 0.95}, out.FieldConfidence)
+		assert.InDelta(t, 0.9, out.Confidence, 1e-9)
+
+		out, err = enrichGeneratedRequest(context.Background(), rooms, &mockGuestLookupRepository{}, &mockUserLookupRepository{}, nil, "org_1", GenerateRequestOutput{
+			Name: "Extra Towels", RequestType: "one-time", Status: "pending", Priority: "high",
+		})
+		require.NoError(t, err)
+		assert.InDelta(t, defaultFieldConfidence, out.Confidence, 1e-9)
+	})
+
+	t.Run("an unresolved lookup scores zero", func(t *testing.T) {
+		t.Parallel()
+
+		room := "999"
+		out, err := enrichGeneratedRequest(context.Background(), rooms, &mockGuestLookupRepository{}, &mockUserLookupRepository{}, nil, "org_1", GenerateRequestOutput{
+			Name: "Noisy AC", RequestType: "one-time", Status: "pending", Priority: "medium",
+			RoomMentioned: &roomMentioned, RoomReference: &room,
+			FieldConfidence: map[string]float64{"name": 0.9, "room": 0.9},
+		})
+		require.NoError(t, err)
+
+		assert.Zero(t, out.FieldConfidence["room"])
+		assert.Zero(t, out.Confidence)
+		require.NotNil(t, out.Warning)
+	})
+}
@@ -4,52 +4,50 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
-	"github.com/firebase/genkit/go/plugins/googlegenai"
 	"github.com/generate/selfserve/config"
-	"google.golang.org/genai"
 )
 
-const defaultGeminiModel = "gemini-3-flash-preview"
+// InitGenkit sets up the generate flows on the provider selected by llmConfig.
+// It returns ErrNoLLMProvider (possibly wrapped) when no provider is
+// configured, and never panics: a provider failing to initialise is returned
+// as an error so the server can start without request generation.
+func InitGenkit(ctx context.Context, llmConfig *config.LLM, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, hotelLookupRepo HotelLookupRepository) (*GenkitService, error) {
+	provider, err := NewProvider(llmConfig)
+	if err != nil {
+		return nil, err
+	}
 
-func InitGenkit(ctx context.Context, llmConfig *config.LLM, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, deptLookupRepo DepartmentLookupRepository) *GenkitService {
+	return InitGenkitWithProvider(ctx, provider, roomLookupRepo, guestLookupRepo, userLookupRepo, hotelLookupRepo)
+}
 
-	modelName := llmConfig.Model
-	if modelName == "" {
-		modelName = defaultGeminiModel
-	}
+// InitGenkitWithProvider sets up the generate flows on the given provider,
+// e.g. a replay provider for offline evaluation.
+func InitGenkitWithProvider(ctx context.Context, provider Provider, roomLookupRepo RoomLookupRepository, guestLookupRepo GuestLookupRepository, userLookupRepo UserLookupRepository, hotelLookupRepo HotelLookupRepository) (svc *GenkitService, err error) {
+	// Genkit and its plugins report initialisation failures by panicking.
+	defer func() {
+		if r := recover(); r != nil {
+			svc, err = nil, fmt.Errorf("InitGenkit: %v", r)
+		}
+	}()
 
-	llmProvider := &googlegenai.GoogleAI{
-		APIKey: llmConfig.APIKey,
-	}
+	genkitInstance := genkit.Init(ctx, genkit.WithPlugins(provider.Plugins()...))
 
-	genkitInstance := genkit.Init(ctx, genkit.WithPlugins(llmProvider))
-
-	// gemini-3-flash-preview is not yet in the plugin's known-models list,
-	// so pass explicit capabilities to avoid "unknown model" error.
-	model, err := llmProvider.DefineModel(genkitInstance, modelName, &ai.ModelOptions{
-		Supports: &ai.ModelSupports{
-			Multiturn:   true,
-			Tools:       true,
-			ToolChoice:  true,
-			SystemRole:  true,
-			Media:       true,
-			Constrained: ai.ConstrainedSupportNoTools,
-		},
-	})
+	model, generationConfig, err := provider.Model(genkitInstance)
 	if err != nil {
-		panic(fmt.Errorf("InitGenkit: define model %q: %w", modelName, err))
-	}
-
-	generationConfig := &genai.GenerateContentConfig{
-		MaxOutputTokens: int32(llmConfig.MaxOutputTokens),
+		return nil, fmt.Errorf("InitGenkit: %w", err)
 	}
 
-	generateRequestFlow := DefineGenerateRequest(genkitInstance, model, generationConfig, roomLookupRepo, guestLookupRepo, userLookupRepo, deptLookupRepo)
+	generateRequestFlow := DefineGenerateRequest(genkitInstance, model, generationConfig, roomLookupRepo, guestLookupRepo, userLookupRepo, hotelLookupRepo)
+	generateBatchFlow := DefineGenerateRequestBatch(genkitInstance, model, generationConfig, roomLookupRepo, guestLookupRepo, userLookupRepo, hotelLookupRepo)
+	transcribeFlow := DefineTranscribeAudio(genkitInstance, model, generationConfig)
+	generatePhotoFlow := DefineGeneratePhotoRequest(genkitInstance, model, generationConfig, roomLookupRepo, guestLookupRepo, userLookupRepo, hotelLookupRepo)
 
 	return &GenkitService{
 		genkit:              genkitInstance,
 		generateRequestFlow: generateRequestFlow,
-	}
+		generateBatchFlow:   generateBatchFlow,
+		transcribeFlow:      transcribeFlow,
+		generatePhotoFlow:   generatePhotoFlow,
+	}, nil
 }
@@ -3,28 +3,42 @@ package aiflows
 import (
 	"context"
 	"fmt"
+
+	"github.com/generate/selfserve/internal/models"
 )
 
 type GuestLookupRepository interface {
-	FindGuestByName(ctx context.Context, hotelID, name string) ([]string, error)
+	// FindGuestCandidates returns guests with an active booking at the hotel
+	// (in roomID, when set) whose name scores at least minScore, best first.
+	FindGuestCandidates(ctx context.Context, hotelID, name string, roomID *string, minScore float64, limit int) ([]*models.LookupCandidate, error)
 }
 
-// LookupGuest returns (guestID, warningMessage, error).
-// warningMessage is non-nil when the name matched 0 or >1 guests.
-func LookupGuest(ctx context.Context, repo GuestLookupRepository, input GuestLookupInput) (guestID *string, warning *string, err error) {
-	ids, err := repo.FindGuestByName(ctx, input.HotelID, input.GuestName)
-	if err != nil {
-		return nil, nil, err
+// LookupGuest resolves a guest name, restricted to the guests staying in
+// input.RoomID when the request also names a room.
+func LookupGuest(ctx context.Context, repo GuestLookupRepository, input GuestLookupInput) (LookupResult, error) {
+	name := NormalizePersonName(input.GuestName)
+
+	var candidates []*models.LookupCandidate
+	if name != "" {
+		var err error
+		candidates, err = repo.FindGuestCandidates(ctx, input.HotelID, name, input.RoomID, minCandidateScore, maxLookupCandidates)
+		if err != nil {
+			return LookupResult{}, err
+		}
 	}
 
-	switch len(ids) {
-	case 1:
-		return &ids[0], nil, nil
-	case 0:
-		msg := fmt.Sprintf("Guest %q could not be found for this hotel.", input.GuestName)
-		return nil, &msg, nil
+	match, ambiguous := resolveCandidates(candidates, nameAcceptScore)
+	switch {
+	case match != nil:
+		return LookupResult{ID: &match.ID}, nil
+	case ambiguous:
+		msg := fmt.Sprintf("Guest name %q matched multiple guests.%s", input.GuestName, didYouMean(candidates))
+		return LookupResult{Ambiguous: true, Message: &msg, Candidates: candidateValues(candidates)}, nil
+	case input.RoomID != nil:
+		msg := fmt.Sprintf("Guest %q could not be found among the guests staying in this room.%s", input.GuestName, didYouMean(candidates))
+		return LookupResult{Message: &msg, Candidates: candidateValues(candidates)}, nil
 	default:
-		msg := fmt.Sprintf("Guest name %q matched multiple guests. Be more specific.", input.GuestName)
-		return nil, &msg, nil
+		msg := fmt.Sprintf("Guest %q could not be found for this hotel.%s", input.GuestName, didYouMean(candidates))
+		return LookupResult{Message: &msg, Candidates: candidateValues(candidates)}, nil
 	}
 }
@@ -0,0 +1,38 @@
+package aiflows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+// HotelLookupRepository serves the hotel settings the generate flows are
+// prompted with.
+type HotelLookupRepository interface {
+	DepartmentLookupRepository
+	GetStaffLanguageByHotelID(ctx context.Context, hotelID string) (string, error)
+}
+
+// hotelSettings are what the generate prompts need to know about a hotel.
+type hotelSettings struct {
+	departments   []*models.Department
+	staffLanguage string
+}
+
+func lookupHotelSettings(ctx context.Context, hotelLookupRepo HotelLookupRepository, hotelID string) (hotelSettings, error) {
+	departments, err := hotelLookupRepo.GetDepartmentsByHotelID(ctx, hotelID)
+	if err != nil {
+		return hotelSettings{}, fmt.Errorf("fetch departments: %w", err)
+	}
+
+	staffLanguage, err := hotelLookupRepo.GetStaffLanguageByHotelID(ctx, hotelID)
+	if err != nil {
+		return hotelSettings{}, fmt.Errorf("fetch staff language: %w", err)
+	}
+	if staffLanguage == "" {
+		staffLanguage = models.DefaultStaffLanguage
+	}
+
+	return hotelSettings{departments: departments, staffLanguage: staffLanguage}, nil
+}
@@ -0,0 +1,169 @@
+package aiflows
+
+import (
+	"context"
+	"testing"
+
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestParseRoomReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ref  string
+		want models.RoomReference
+	}{
+		{"504", models.RoomReference{Number: intPtr(504)}},
+		{"rm 504", models.RoomReference{Number: intPtr(504)}},
+		{"Room #504", models.RoomReference{Number: intPtr(504)}},
+		{"no. 12", models.RoomReference{Number: intPtr(12)}},
+		{"floor 5 room 4", models.RoomReference{Floor: intPtr(5), Unit: intPtr(4)}},
+		{"5th floor, room 04", models.RoomReference{Floor: intPtr(5), Unit: intPtr(4)}},
+		{"5th floor room 504", models.RoomReference{Number: intPtr(504), Floor: intPtr(5)}},
+		{"the Presidential Suite", models.RoomReference{Suite: "presidential suite"}},
+		{"room", models.RoomReference{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, ParseRoomReference(tt.ref))
+		})
+	}
+}
+
+func TestNormalizePersonName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Lopez", NormalizePersonName("the Lopez family"))
+	assert.Equal(t, "Maria Lopez", NormalizePersonName("Mrs. Maria Lopez's"))
+	assert.Equal(t, "Johnny", NormalizePersonName("Johnny"))
+	assert.Equal(t, "", NormalizePersonName("the guest"))
+}
+
+func TestResolveCandidates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("picks a clear leader above the accept score", func(t *testing.T) {
+		t.Parallel()
+
+		match, ambiguous := resolveCandidates([]*models.LookupCandidate{
+			{ID: "john", Score: 0.57},
+			{ID: "joan", Score: 0.31},
+		}, nameAcceptScore)
+		require.NotNil(t, match)
+		assert.Equal(t, "john", match.ID)
+		assert.False(t, ambiguous)
+	})
+
+	t.Run("a tie at the top is ambiguous", func(t *testing.T) {
+		t.Parallel()
+
+		match, ambiguous := resolveCandidates([]*models.LookupCandidate{
+			{ID: "john", Score: 0.6},
+			{ID: "jon", Score: 0.6},
+		}, nameAcceptScore)
+		assert.Nil(t, match)
+		assert.True(t, ambiguous)
+	})
+
+	t.Run("weak candidates are only suggestions", func(t *testing.T) {
+		t.Parallel()
+
+		match, ambiguous := resolveCandidates([]*models.LookupCandidate{{ID: "505", Score: 0.5}}, roomAcceptScore)
+		assert.Nil(t, match)
+		assert.False(t, ambiguous)
+	})
+}
+
+type recordingGuestLookupRepository struct {
+	name       string
+	roomID     *string
+	candidates []*models.LookupCandidate
+}
+
+func (m *recordingGuestLookupRepository) FindGuestCandidates(ctx context.Context, hotelID, name string, roomID *string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	m.name, m.roomID = name, roomID
+	return m.candidates, nil
+}
+
+func TestLookupGuest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("normalises the name and restricts to the room", func(t *testing.T) {
+		t.Parallel()
+
+		roomID := "room-uuid-504"
+		repo := &recordingGuestLookupRepository{candidates: []*models.LookupCandidate{{ID: "guest-uuid-lopez", Label: "Maria Lopez", Score: 1}}}
+
+		result, err := LookupGuest(context.Background(), repo, GuestLookupInput{HotelID: "org_1", GuestName: "the Lopez family", RoomID: &roomID})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Lopez", repo.name)
+		assert.Equal(t, &roomID, repo.roomID)
+		require.NotNil(t, result.ID)
+		assert.Equal(t, "guest-uuid-lopez", *result.ID)
+		assert.Nil(t, result.Message)
+	})
+
+	t.Run("offers did-you-mean choices when several guests match", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &recordingGuestLookupRepository{candidates: []*models.LookupCandidate{
+			{ID: "guest-uuid-maria", Label: "Maria Lopez", Score: 1},
+			{ID: "guest-uuid-carlos", Label: "Carlos Lopez", Score: 1},
+		}}
+
+		result, err := LookupGuest(context.Background(), repo, GuestLookupInput{HotelID: "org_1", GuestName: "Lopez"})
+		require.NoError(t, err)
+
+		assert.Nil(t, result.ID)
+		assert.True(t, result.Ambiguous)
+		require.NotNil(t, result.Message)
+		assert.Equal(t, `Guest name "Lopez" matched multiple guests. Did you mean "Maria Lopez" or "Carlos Lopez"?`, *result.Message)
+		assert.Len(t, result.Candidates, 2)
+	})
+
+	t.Run("reports a guest missing from the room", func(t *testing.T) {
+		t.Parallel()
+
+		roomID := "room-uuid-504"
+		result, err := LookupGuest(context.Background(), &recordingGuestLookupRepository{}, GuestLookupInput{HotelID: "org_1", GuestName: "Maria", RoomID: &roomID})
+		require.NoError(t, err)
+
+		assert.Nil(t, result.ID)
+		require.NotNil(t, result.Message)
+		assert.Equal(t, `Guest "Maria" could not be found among the guests staying in this room.`, *result.Message)
+	})
+}
+
+func TestEnrichWithUserLookup_Ambiguous(t *testing.T) {
+	t.Parallel()
+
+	johnny := "Johnny"
+	repo := userCandidates{
+		{ID: "user_john", Label: "John Smith", Score: 0.6},
+		{ID: "user_jon", Label: "Jon Park", Score: 0.6},
+	}
+
+	output, err := enrichWithUserLookup(context.Background(), repo, "org_1", EnrichedGenerateRequestOutput{
+		GenerateRequestOutput: GenerateRequestOutput{Name: "AC Repair", RequestType: "one-time", Status: "pending", Priority: "high", UserName: &johnny},
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, output.UserID)
+	require.NotNil(t, output.Warning)
+	assert.Equal(t, "user_ambiguous", output.Warning.Code)
+	assert.Equal(t, "user_jon", output.Warning.Candidates[1].ID)
+}
+
+type userCandidates []*models.LookupCandidate
+
+func (c userCandidates) FindUserCandidates(ctx context.Context, hotelID, name string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	return c, nil
+}
@@ -0,0 +1,72 @@
+package aiflows
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+var (
+	floorFirstPattern  = regexp.MustCompile(`\b(?:floor|fl|level)\s*(\d{1,3})\b\D*?(\d{1,4})\b`)
+	floorSecondPattern = regexp.MustCompile(`\b(\d{1,3})(?:st|nd|rd|th)?\s*(?:floor|fl|level)\b\D*?(\d{1,4})\b`)
+	roomNumberPattern  = regexp.MustCompile(`\d{1,5}`)
+	referencePunct     = regexp.MustCompile(`[#.,:;()\-_/'"]+`)
+
+	roomFillerWords = map[string]bool{"the": true, "room": true, "rm": true, "no": true, "number": true, "in": true}
+	nameFillerWords = map[string]bool{
+		"the": true, "family": true, "party": true, "group": true, "guest": true, "guests": true,
+		"mr": true, "mrs": true, "ms": true, "miss": true, "mx": true, "dr": true, "sir": true, "madam": true,
+	}
+)
+
+// ParseRoomReference normalises a room reference written by hand. Prefixes
+// such as "room", "rm" and "#" are dropped, "floor 5 room 4" and "5th floor,
+// 04" become a floor and unit, and references without digits are kept as a
+// suite name.
+func ParseRoomReference(ref string) models.RoomReference {
+	text := strings.ToLower(strings.TrimSpace(ref))
+	text = referencePunct.ReplaceAllString(text, " ")
+
+	for _, pattern := range []*regexp.Regexp{floorFirstPattern, floorSecondPattern} {
+		if m := pattern.FindStringSubmatch(text); m != nil {
+			floor, _ := strconv.Atoi(m[1])
+			room, _ := strconv.Atoi(m[2])
+			if room >= 100 {
+				// "5th floor, room 504" names the full room number.
+				return models.RoomReference{Number: &room, Floor: &floor}
+			}
+			return models.RoomReference{Floor: &floor, Unit: &room}
+		}
+	}
+
+	if m := roomNumberPattern.FindString(text); m != "" {
+		number, _ := strconv.Atoi(m)
+		return models.RoomReference{Number: &number}
+	}
+
+	words := make([]string, 0)
+	for _, word := range strings.Fields(text) {
+		if !roomFillerWords[word] {
+			words = append(words, word)
+		}
+	}
+	return models.RoomReference{Suite: strings.Join(words, " ")}
+}
+
+// NormalizePersonName strips honorifics, possessives and group words from a
+// name written by hand, so "the Lopez family" becomes "Lopez" and "Mrs.
+// Maria Lopez's" becomes "Maria Lopez".
+func NormalizePersonName(name string) string {
+	words := make([]string, 0)
+	for _, word := range strings.Fields(name) {
+		word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+		word = strings.Trim(word, `.,;:!?"'()`)
+		if word == "" || nameFillerWords[strings.ToLower(word)] {
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
@@ -1,30 +1,37 @@
 package prompts
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
-// GenerateRequestPrompt builds the LLM prompt for request generation.
-// departments is the list of valid department names for the hotel; pass nil or
-// empty to omit the department constraint.
-func GenerateRequestPrompt(rawText string, departments []string) string {
-	departmentRule := "- If a department is clearly relevant, include it as the department field."
-	if len(departments) > 0 {
-		list := ""
-		for i, d := range departments {
-			if i > 0 {
-				list += ", "
-			}
-			list += fmt.Sprintf("%q", d)
-		}
-		departmentRule = fmt.Sprintf(
-			"- If a department is clearly relevant, set department to one of the following exact names: %s. If none fit, omit the field.",
-			list,
-		)
+const (
+	messageOpen  = "<message>"
+	messageClose = "</message>"
+)
+
+// ExtractMessage returns the raw text a prompt built by this package was
+// asked about, or "" if the prompt has no message block.
+func ExtractMessage(prompt string) string {
+	start := strings.Index(prompt, messageOpen)
+	end := strings.LastIndex(prompt, messageClose)
+	if start < 0 || end < start {
+		return ""
 	}
+	return strings.TrimSpace(prompt[start+len(messageOpen) : end])
+}
 
+// GenerateRequestPrompt builds the LLM prompt for request generation.
+// departments is the list of valid department names for the hotel; pass nil or
+// empty to omit the department constraint. staffLanguage is the BCP 47 tag of
+// the language the request is written in, whatever the message's language.
+func GenerateRequestPrompt(rawText string, departments []string, staffLanguage string) string {
 	return fmt.Sprintf(`
 	Generate a hotel service request from this description:
 
+	<message>
 	%s
+	</message>
 
 	Return a concrete JSON object instance only.
 	Do not return a JSON schema.
@@ -34,11 +41,12 @@ func GenerateRequestPrompt(rawText string, departments []string) string {
 
 	Allowed fields (use no others):
 	name, description, request_type, request_category, department, status, priority,
-	estimated_completion_time, notes, room_mentioned, room_reference, guest_name, user_name.
+	estimated_completion_time, notes, room_mentioned, room_reference, guest_name, user_name, field_confidence,
+	language, english_text.
 
 	Rules:
 	- Include only concrete request data, not schema metadata.
-	- Required fields: name, request_type, status, priority.
+	- Required fields: name, request_type, status, priority, language.
 	- status must be exactly one of: "pending", "assigned", "in progress", "completed".
 	- priority must be exactly one of: "low", "medium", "high".
 	- If a room is clearly mentioned, include room_mentioned=true and room_reference as the literal room identifier text.
@@ -48,6 +56,8 @@ func GenerateRequestPrompt(rawText string, departments []string) string {
 	- If a staff member or employee name is clearly mentioned as the person to assign the task to, include user_name as the literal name text.
 	- If no staff member is mentioned, omit user_name.
 	- %s
+	- %s
+	- %s
 	- Only include fields when you have real information from the description.
 	- Never set a field to null. If you have no value for a field, omit it entirely.
 
@@ -58,9 +68,158 @@ func GenerateRequestPrompt(rawText string, departments []string) string {
 	{"name":"Extra Towels Request","request_type":"one-time","status":"pending","priority":"medium","room_mentioned":true,"room_reference":"204","guest_name":"Maria Lopez"}
 
 	Valid example with staff member mention:
-	{"name":"AC Repair","request_type":"one-time","status":"pending","priority":"high","room_mentioned":true,"room_reference":"512","user_name":"John Smith"}
+	{"name":"AC Repair","request_type":"one-time","status":"pending","priority":"high","room_mentioned":true,"room_reference":"512","user_name":"John Smith","field_confidence":{"name":0.9,"priority":0.8,"room":0.95,"user":0.9}}
 
 	Valid example without room mention:
-	{"name":"Extra Towels Request","request_type":"one-time","status":"pending","priority":"medium"}
-`, rawText, departmentRule)
+	{"name":"Extra Towels Request","request_type":"one-time","status":"pending","priority":"medium","language":"en"}
+
+	Valid example for "Necesito toallas en la habitación 204, soy María López" with staff language "en":
+	{"name":"Extra Towels Request","request_type":"one-time","status":"pending","priority":"medium","room_mentioned":true,"room_reference":"204","guest_name":"María López","language":"es","english_text":"I need towels in room 204, I am María López"}
+`, rawText, departmentRule(departments), confidenceRule, languageRule(staffLanguage))
+}
+
+// GenerateRequestsPrompt builds the LLM prompt for extracting every request in
+// a message that may describe several unrelated tasks. departments and
+// staffLanguage work as in GenerateRequestPrompt.
+func GenerateRequestsPrompt(rawText string, departments []string, staffLanguage string) string {
+	return fmt.Sprintf(`
+	Split this hotel message into separate service requests, one per distinct task:
+
+	<message>
+	%s
+	</message>
+
+	Return a concrete JSON object instance only, of the form {"language":"...","english_text":"...","requests":[...]}.
+	Do not return a JSON schema.
+	Do not return markdown.
+	Do not return code fences.
+	Do not return keys such as "properties" or "additionalProperties".
+
+	Allowed fields for each request (use no others):
+	name, description, request_type, request_category, department, status, priority,
+	estimated_completion_time, notes, room_mentioned, room_reference, guest_name, user_name, field_confidence.
+
+	Rules:
+	- Create one request per task. Tasks for different rooms, guests or departments are always separate requests.
+	- Do not split a single task into several requests, and do not invent tasks that are not in the message.
+	- Each request only carries the room, guest and staff member that the message ties to that task.
+	- language and english_text describe the whole message and go next to requests, not inside each request.
+	- Include only concrete request data, not schema metadata.
+	- Required fields for each request: name, request_type, status, priority.
+	- status must be exactly one of: "pending", "in progress", "completed".
+	- priority must be exactly one of: "low", "medium", "high".
+	- If a room is clearly mentioned for a task, include room_mentioned=true and room_reference as the literal room identifier text.
+	- If a guest name is clearly mentioned for a task, include guest_name as the literal name text.
+	- If a staff member is clearly named as the person to assign a task to, include user_name as the literal name text.
+	- %s
+	- %s
+	- %s
+	- Only include fields when you have real information from the message.
+	- Never set a field to null. If you have no value for a field, omit it entirely.
+	- If the message contains no actionable task, return {"language":"...","requests":[]}.
+
+	Valid example for "need towels in 504, and the AC in 312 is loud, also late checkout for Maria":
+	{"language":"en","requests":[
+	{"name":"Extra Towels","request_type":"one-time","status":"pending","priority":"medium","room_mentioned":true,"room_reference":"504"},
+	{"name":"Noisy AC","request_type":"one-time","status":"pending","priority":"medium","room_mentioned":true,"room_reference":"312","field_confidence":{"name":0.8,"priority":0.5,"room":0.95}},
+	{"name":"Late Checkout","request_type":"one-time","status":"pending","priority":"low","guest_name":"Maria"}
+	]}
+`, rawText, departmentRule(departments), confidenceRule, languageRule(staffLanguage))
+}
+
+// GeneratePhotoRequestPrompt builds the LLM prompt for generating a request
+// from the photos sent with it. text is whatever the sender wrote alongside
+// the photos and may be empty. departments and staffLanguage work as in
+// GenerateRequestPrompt.
+func GeneratePhotoRequestPrompt(text string, departments []string, staffLanguage string) string {
+	return fmt.Sprintf(`
+	Generate a hotel service request from the attached photos, taken by hotel staff or a guest to report a problem, and this accompanying message (it may be empty):
+
+	<message>
+	%s
+	</message>
+
+	Return a concrete JSON object instance only.
+	Do not return a JSON schema.
+	Do not return markdown.
+	Do not return code fences.
+	Do not return keys such as "properties" or "additionalProperties".
+
+	Allowed fields (use no others):
+	hotel_related, name, description, request_type, request_category, department, status, priority,
+	estimated_completion_time, notes, room_mentioned, room_reference, guest_name, user_name, field_confidence,
+	language, english_text.
+
+	Rules:
+	- Required fields: hotel_related, name, request_type, status, priority, language.
+	- Infer name, request_category, department and priority from what the photos show; use the message to add detail or to settle what the photos leave open.
+	- Describe the problem you see in description, such as "water pooling under the bathroom sink".
+	- priority is "high" for anything that risks damage or safety (leaks, flooding, exposed wiring, broken glass), "low" for cosmetic issues, and "medium" otherwise.
+	- Set hotel_related=false when the photos show nothing a hotel's staff could act on, such as a selfie, a screenshot or a landscape; still fill in the other required fields as best you can. Otherwise set hotel_related=true.
+	- status must be exactly one of: "pending", "assigned", "in progress", "completed".
+	- priority must be exactly one of: "low", "medium", "high".
+	- Only set room_mentioned, room_reference, guest_name and user_name from the message or from text clearly legible in a photo, such as a room number on a door.
+	- %s
+	- %s
+	- %s
+	- If the message is empty, set language to "en" and omit english_text.
+	- Never set a field to null. If you have no value for a field, omit it entirely.
+
+	Valid example for a photo of a dripping bathroom sink and the message "room 204":
+	{"hotel_related":true,"name":"Leaking Sink","description":"Water dripping from the pipe under the bathroom sink","request_type":"one-time","request_category":"Plumbing","department":"Maintenance","status":"pending","priority":"high","room_mentioned":true,"room_reference":"204","language":"en","field_confidence":{"name":0.9,"priority":0.8,"department":0.9,"room":0.95}}
+
+	Valid example for a photo of a beach at sunset and no message:
+	{"hotel_related":false,"name":"Unclear Photo Report","request_type":"one-time","status":"pending","priority":"low","language":"en","field_confidence":{"name":0.2,"priority":0.3}}
+`, text, departmentRule(departments), confidenceRule, languageRule(staffLanguage))
+}
+
+// TranscribeAudioPrompt asks for a verbatim transcript of the voice note sent
+// with it.
+const TranscribeAudioPrompt = `
+	Transcribe the attached voice note, left by hotel staff or a guest to report a task.
+
+	Return a concrete JSON object instance only, of the form {"transcript":"..."}.
+	Do not return markdown.
+	Do not return code fences.
+
+	Rules:
+	- Write down exactly what is said, in the language it is spoken. Do not translate, summarise or answer it.
+	- Write room numbers as digits, and names as they are spoken.
+	- Leave out filler words and false starts.
+	- If nothing intelligible is said, return {"transcript":""}.
+`
+
+// confidenceRule asks the model to rate the fields it extracted; unrated
+// fields are scored as uncertain.
+const confidenceRule = `Include field_confidence, an object rating from 0 to 1 how sure you are of each field you set, keyed by name, request_type, status, priority, department, room, guest and user. Rate a field low when the message is vague or you had to guess it.`
+
+// languageRule asks for the message's language and an English rendering of
+// it, with the request itself written for staff in staffLanguage. Names are
+// kept as written so the room, guest and staff lookups still match.
+func languageRule(staffLanguage string) string {
+	return fmt.Sprintf(
+		`Set language to the ISO 639-1 code of the language the message is written in, such as "en", "es", "pt" or "zh". `+
+			`If the message is not in English, set english_text to a faithful English translation of the whole message; otherwise omit english_text. `+
+			`Write name, description and notes in the staff language %q, whatever language the message is in. `+
+			`Copy room_reference, guest_name and user_name exactly as written in the message; never translate or transliterate them.`,
+		staffLanguage,
+	)
+}
+
+func departmentRule(departments []string) string {
+	if len(departments) == 0 {
+		return "If a department is clearly relevant, include it as the department field."
+	}
+
+	list := ""
+	for i, d := range departments {
+		if i > 0 {
+			list += ", "
+		}
+		list += fmt.Sprintf("%q", d)
+	}
+	return fmt.Sprintf(
+		"If a department is clearly relevant, set department to one of the following exact names: %s. If none fit, omit the field.",
+		list,
+	)
 }
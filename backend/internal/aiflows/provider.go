@@ -0,0 +1,101 @@
+package aiflows
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/generate/selfserve/config"
+)
+
+// ErrNoLLMProvider is returned by InitGenkit when no LLM provider is
+// configured. The server still starts; request generation is unavailable.
+var ErrNoLLMProvider = errors.New("no LLM provider is configured")
+
+// Provider is an LLM backend the generate flows can run against.
+type Provider interface {
+	// Plugins returns the Genkit plugins to initialise before Model is called.
+	Plugins() []api.Plugin
+	// Model defines the model the flows generate with, and returns the config
+	// passed with every generate call.
+	Model(g *genkit.Genkit) (ai.Model, any, error)
+}
+
+// ProviderFactory builds a provider from the LLM config. It returns
+// ErrNoLLMProvider when the config lacks what the provider needs to run.
+type ProviderFactory func(cfg *config.LLM) (Provider, error)
+
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderStub   = "stub"
+	ProviderNone   = "none"
+)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		ProviderGemini: newGeminiProvider,
+		ProviderOpenAI: newOpenAIProvider,
+		ProviderStub:   newStubProvider,
+	}
+)
+
+// RegisterProvider makes a provider selectable through LLM_PROVIDER.
+// Registering an existing name replaces it.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// NewProvider builds the provider selected by cfg.Provider. An empty provider
+// selects Gemini when a Gemini API key is available, and none otherwise.
+func NewProvider(cfg *config.LLM) (Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" {
+		if geminiAPIKey(cfg) == "" {
+			return nil, ErrNoLLMProvider
+		}
+		name = ProviderGemini
+	}
+	if name == ProviderNone {
+		return nil, ErrNoLLMProvider
+	}
+
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q (registered: %s)", name, strings.Join(providerNames(), ", "))
+	}
+	return factory(cfg)
+}
+
+func providerNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// geminiAPIKey mirrors the googlegenai plugin's lookup, which panics when no
+// key is found.
+func geminiAPIKey(cfg *config.LLM) string {
+	for _, key := range []string{cfg.APIKey, os.Getenv("GEMINI_API_KEY"), os.Getenv("GOOGLE_API_KEY")} {
+		if key != "" {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package aiflows
+
+import (
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/plugins/googlegenai"
+	"github.com/generate/selfserve/config"
+	"google.golang.org/genai"
+)
+
+const defaultGeminiModel = "gemini-3-flash-preview"
+
+type geminiProvider struct {
+	plugin          *googlegenai.GoogleAI
+	model           string
+	maxOutputTokens int
+}
+
+func newGeminiProvider(cfg *config.LLM) (Provider, error) {
+	apiKey := geminiAPIKey(cfg)
+	if apiKey == "" {
+		return nil, fmt.Errorf("gemini: LLM_API_KEY is not set: %w", ErrNoLLMProvider)
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = defaultGeminiModel
+	}
+
+	return &geminiProvider{
+		plugin:          &googlegenai.GoogleAI{APIKey: apiKey},
+		model:           model,
+		maxOutputTokens: cfg.MaxOutputTokens,
+	}, nil
+}
+
+func (p *geminiProvider) Plugins() []api.Plugin {
+	return []api.Plugin{p.plugin}
+}
+
+func (p *geminiProvider) Model(g *genkit.Genkit) (ai.Model, any, error) {
+	// gemini-3-flash-preview is not yet in the plugin's known-models list,
+	// so pass explicit capabilities to avoid "unknown model" error.
+	model, err := p.plugin.DefineModel(g, p.model, &ai.ModelOptions{
+		Supports: &ai.ModelSupports{
+			Multiturn:   true,
+			Tools:       true,
+			ToolChoice:  true,
+			SystemRole:  true,
+			Media:       true,
+			Constrained: ai.ConstrainedSupportNoTools,
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("gemini: define model %q: %w", p.model, err)
+	}
+
+	return model, &genai.GenerateContentConfig{
+		MaxOutputTokens: int32(p.maxOutputTokens),
+	}, nil
+}
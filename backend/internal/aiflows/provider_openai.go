@@ -0,0 +1,232 @@
+package aiflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/generate/selfserve/config"
+)
+
+const openAIRequestTimeout = 2 * time.Minute
+
+// openAIProvider talks to any server implementing the OpenAI chat completions
+// API (OpenAI, Ollama, vLLM, LM Studio, ...).
+type openAIProvider struct {
+	baseURL         string
+	apiKey          string
+	model           string
+	maxOutputTokens int
+	client          *http.Client
+}
+
+func newOpenAIProvider(cfg *config.LLM) (Provider, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai: LLM_BASE_URL is not set: %w", ErrNoLLMProvider)
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("openai: LLM_MODEL is not set: %w", ErrNoLLMProvider)
+	}
+
+	return &openAIProvider{
+		baseURL:         strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:          cfg.APIKey,
+		model:           cfg.Model,
+		maxOutputTokens: cfg.MaxOutputTokens,
+		client:          &http.Client{Timeout: openAIRequestTimeout},
+	}, nil
+}
+
+func (p *openAIProvider) Plugins() []api.Plugin {
+	return nil
+}
+
+func (p *openAIProvider) Model(g *genkit.Genkit) (ai.Model, any, error) {
+	model := genkit.DefineModel(g, "openai/"+p.model, &ai.ModelOptions{
+		Label: "OpenAI-compatible " + p.model,
+		Supports: &ai.ModelSupports{
+			Multiturn:  true,
+			SystemRole: true,
+			Media:      true,
+		},
+	}, p.generate)
+
+	return model, &ai.GenerationCommonConfig{MaxOutputTokens: p.maxOutputTokens}, nil
+}
+
+type openAIChatRequest struct {
+	Model          string              `json:"model"`
+	Messages       []openAIChatMessage `json:"messages"`
+	MaxTokens      int                 `json:"max_tokens,omitempty"`
+	Temperature    *float64            `json:"temperature,omitempty"`
+	ResponseFormat *openAIFormat       `json:"response_format,omitempty"`
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"` // string, or []openAIContentPart when the message has media
+}
+
+type openAIContentPart struct {
+	Type       string            `json:"type"`
+	Text       string            `json:"text,omitempty"`
+	ImageURL   *openAIImageURL   `json:"image_url,omitempty"`
+	InputAudio *openAIInputAudio `json:"input_audio,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIInputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+type openAIFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (p *openAIProvider) generate(ctx context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	body := openAIChatRequest{Model: p.model, MaxTokens: p.maxOutputTokens}
+	if cfg, ok := req.Config.(*ai.GenerationCommonConfig); ok && cfg != nil {
+		if cfg.MaxOutputTokens > 0 {
+			body.MaxTokens = cfg.MaxOutputTokens
+		}
+		if cfg.Temperature != 0 {
+			body.Temperature = &cfg.Temperature
+		}
+	}
+	if req.Output != nil && req.Output.Format == "json" {
+		body.ResponseFormat = &openAIFormat{Type: "json_object"}
+	}
+	for _, msg := range req.Messages {
+		body.Messages = append(body.Messages, toOpenAIMessage(msg))
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("openai: chat completion: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return nil, fmt.Errorf("openai: chat completion failed with status %d: %s", httpResp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var decoded openAIChatResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("openai: decoding chat completion: %w", err)
+	}
+	if len(decoded.Choices) == 0 {
+		return nil, fmt.Errorf("openai: chat completion returned no choices")
+	}
+
+	choice := decoded.Choices[0]
+	return &ai.ModelResponse{
+		Request:      req,
+		Message:      ai.NewModelTextMessage(choice.Message.Content),
+		FinishReason: openAIFinishReason(choice.FinishReason),
+		Usage: &ai.GenerationUsage{
+			InputTokens:  decoded.Usage.PromptTokens,
+			OutputTokens: decoded.Usage.CompletionTokens,
+			TotalTokens:  decoded.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func toOpenAIMessage(msg *ai.Message) openAIChatMessage {
+	role := string(msg.Role)
+	if msg.Role == ai.RoleModel {
+		role = "assistant"
+	}
+
+	hasMedia := false
+	for _, part := range msg.Content {
+		if part.IsMedia() {
+			hasMedia = true
+			break
+		}
+	}
+	if !hasMedia {
+		return openAIChatMessage{Role: role, Content: msg.Text()}
+	}
+
+	parts := make([]openAIContentPart, 0, len(msg.Content))
+	for _, part := range msg.Content {
+		switch {
+		case part.IsText():
+			parts = append(parts, openAIContentPart{Type: "text", Text: part.Text})
+		case part.IsMedia():
+			parts = append(parts, toOpenAIMediaPart(part))
+		}
+	}
+	return openAIChatMessage{Role: role, Content: parts}
+}
+
+// toOpenAIMediaPart sends audio as input_audio, which only takes base64 data,
+// and anything else as an image URL.
+func toOpenAIMediaPart(part *ai.Part) openAIContentPart {
+	if strings.HasPrefix(part.ContentType, "audio/") {
+		if _, data, ok := strings.Cut(part.Text, ";base64,"); ok {
+			format := strings.TrimPrefix(strings.TrimPrefix(part.ContentType, "audio/"), "x-")
+			if format == "mpeg" {
+				format = "mp3"
+			}
+			return openAIContentPart{Type: "input_audio", InputAudio: &openAIInputAudio{Data: data, Format: format}}
+		}
+	}
+	return openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: part.Text}}
+}
+
+func openAIFinishReason(reason string) ai.FinishReason {
+	switch reason {
+	case "stop":
+		return ai.FinishReasonStop
+	case "length":
+		return ai.FinishReasonLength
+	case "content_filter":
+		return ai.FinishReasonBlocked
+	case "":
+		return ai.FinishReasonUnknown
+	default:
+		return ai.FinishReasonOther
+	}
+}
@@ -0,0 +1,191 @@
+package aiflows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/generate/selfserve/internal/aiflows/prompts"
+)
+
+// ErrNoRecordedResponse is returned by a replaying model asked something it
+// has no recorded response for, typically because the prompt changed since
+// the responses were recorded.
+var ErrNoRecordedResponse = errors.New("no recorded model response for this request")
+
+// RecordedResponse is a model answer kept in a ResponseStore. Message is the
+// raw text the prompt was about, kept so the store can be reviewed by hand.
+type RecordedResponse struct {
+	Message string `json:"message"`
+	Text    string `json:"text"`
+}
+
+// ResponseStore holds model responses keyed by a hash of the request that
+// produced them. It is saved as indented JSON with sorted keys, so
+// re-recording shows up as a readable diff.
+type ResponseStore struct {
+	mu        sync.Mutex
+	Model     string                      `json:"model"`
+	Responses map[string]RecordedResponse `json:"responses"`
+}
+
+// LoadResponseStore reads a store saved with Save. A missing file yields an
+// empty store.
+func LoadResponseStore(path string) (*ResponseStore, error) {
+	store := &ResponseStore{Responses: map[string]RecordedResponse{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("parse response store %s: %w", path, err)
+	}
+	if store.Responses == nil {
+		store.Responses = map[string]RecordedResponse{}
+	}
+	return store, nil
+}
+
+// Save writes the store to path.
+func (s *ResponseStore) Save(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (s *ResponseStore) lookup(key string) (RecordedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp, ok := s.Responses[key]
+	return resp, ok
+}
+
+func (s *ResponseStore) record(key, model string, resp RecordedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Model = model
+	s.Responses[key] = resp
+}
+
+// replayProvider answers from a ResponseStore. With recordFrom set, every
+// request is sent to that provider instead and its answer recorded.
+type replayProvider struct {
+	store      *ResponseStore
+	recordFrom Provider
+}
+
+// NewReplayProvider returns a provider that replays the responses in store,
+// failing with ErrNoRecordedResponse on requests it has not seen. When
+// recordFrom is not nil it records that provider's responses into store
+// instead; call store.Save afterwards to keep them.
+func NewReplayProvider(store *ResponseStore, recordFrom Provider) Provider {
+	return &replayProvider{store: store, recordFrom: recordFrom}
+}
+
+func (p *replayProvider) Plugins() []api.Plugin {
+	if p.recordFrom == nil {
+		return nil
+	}
+	return p.recordFrom.Plugins()
+}
+
+func (p *replayProvider) Model(g *genkit.Genkit) (ai.Model, any, error) {
+	var inner ai.Model
+	var generationConfig any
+	if p.recordFrom != nil {
+		var err error
+		if inner, generationConfig, err = p.recordFrom.Model(g); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	model := genkit.DefineModel(g, "replay/recorded", &ai.ModelOptions{
+		Label: "Recorded responses",
+		Supports: &ai.ModelSupports{
+			Constrained: ai.ConstrainedSupportAll,
+			Media:       true,
+		},
+	}, func(ctx context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		key, message, err := replayKey(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if inner == nil {
+			recorded, ok := p.store.lookup(key)
+			if !ok {
+				return nil, fmt.Errorf("%w (message %q)", ErrNoRecordedResponse, message)
+			}
+			return &ai.ModelResponse{
+				Request:      req,
+				Message:      ai.NewModelTextMessage(recorded.Text),
+				FinishReason: ai.FinishReasonStop,
+			}, nil
+		}
+
+		resp, err := inner.Generate(ctx, req, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.store.record(key, inner.Name(), RecordedResponse{Message: message, Text: resp.Text()})
+		return resp, nil
+	})
+
+	return model, generationConfig, nil
+}
+
+// replayKey identifies a model request by its messages and requested output,
+// so any change to the prompt or output schema misses the recording.
+func replayKey(req *ai.ModelRequest) (key, message string, err error) {
+	type keyedPart struct {
+		Text  string `json:"text,omitempty"`
+		Media string `json:"media,omitempty"`
+	}
+	type keyedMessage struct {
+		Role  ai.Role     `json:"role"`
+		Parts []keyedPart `json:"parts"`
+	}
+	keyed := struct {
+		Messages []keyedMessage        `json:"messages"`
+		Output   *ai.ModelOutputConfig `json:"output,omitempty"`
+	}{Output: req.Output}
+
+	for _, msg := range req.Messages {
+		km := keyedMessage{Role: msg.Role}
+		for _, part := range msg.Content {
+			if part.IsMedia() {
+				sum := sha256.Sum256([]byte(part.Text))
+				km.Parts = append(km.Parts, keyedPart{Media: hex.EncodeToString(sum[:])})
+				continue
+			}
+			km.Parts = append(km.Parts, keyedPart{Text: part.Text})
+		}
+		keyed.Messages = append(keyed.Messages, km)
+		if msg.Role == ai.RoleUser {
+			message = prompts.ExtractMessage(msg.Text())
+		}
+	}
+
+	data, err := json.Marshal(keyed)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), message, nil
+}
@@ -0,0 +1,338 @@
+package aiflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/aiflows/prompts"
+)
+
+// stubProvider answers the generate prompts with deterministic, rule-based
+// output, so the flows run in CI and offline demos without an LLM.
+type stubProvider struct{}
+
+func newStubProvider(*config.LLM) (Provider, error) {
+	return stubProvider{}, nil
+}
+
+func (stubProvider) Plugins() []api.Plugin {
+	return nil
+}
+
+func (stubProvider) Model(g *genkit.Genkit) (ai.Model, any, error) {
+	model := genkit.DefineModel(g, "stub/rules", &ai.ModelOptions{
+		Label: "Rule-based stub",
+		Supports: &ai.ModelSupports{
+			Constrained: ai.ConstrainedSupportAll,
+			Media:       true,
+		},
+	}, generateStub)
+
+	return model, nil, nil
+}
+
+func generateStub(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	var prompt string
+	var media []*ai.Part
+	for _, msg := range req.Messages {
+		if msg.Role == ai.RoleUser {
+			prompt = msg.Text()
+			media = media[:0]
+			for _, part := range msg.Content {
+				if part.IsMedia() {
+					media = append(media, part)
+				}
+			}
+		}
+	}
+	if outputHasProperty(req.Output, "transcript") {
+		return stubResponse(req, TranscribeAudioOutput{Transcript: stubTranscript(media)})
+	}
+	message := prompts.ExtractMessage(prompt)
+	language, englishText := translateStub(message)
+	text := message
+	if englishText != nil {
+		text = *englishText
+	}
+
+	var out any
+	switch {
+	case outputHasProperty(req.Output, "hotel_related"):
+		described := strings.TrimSpace(strings.Join(append(stubCaptions(media), text), ". "))
+		generated := stubRequest(described)
+		generated.Language, generated.EnglishText = language, englishText
+		out = GeneratePhotoRequestOutput{HotelRelated: stubRuleFor(described) != nil, GenerateRequestOutput: generated}
+	case outputHasProperty(req.Output, "requests"):
+		requests := []GenerateRequestOutput{}
+		for _, task := range splitStubTasks(text) {
+			requests = append(requests, stubRequest(task))
+		}
+		out = GenerateRequestsOutput{Language: language, EnglishText: englishText, Requests: requests}
+	default:
+		generated := stubRequest(text)
+		generated.Language, generated.EnglishText = language, englishText
+		out = generated
+	}
+
+	return stubResponse(req, out)
+}
+
+func stubResponse(req *ai.ModelRequest, out any) (*ai.ModelResponse, error) {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return &ai.ModelResponse{
+		Request:      req,
+		Message:      ai.NewModelTextMessage(string(data)),
+		FinishReason: ai.FinishReasonStop,
+	}, nil
+}
+
+func outputHasProperty(output *ai.ModelOutputConfig, name string) bool {
+	if output == nil {
+		return false
+	}
+	properties, ok := output.Schema["properties"].(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = properties[name]
+	return ok
+}
+
+type stubRule struct {
+	match      *regexp.Regexp
+	name       string
+	department string
+}
+
+// stubRules are tried in order; the first match names the request.
+var stubRules = []stubRule{
+	{regexp.MustCompile(`(?i)\btowels?\b`), "Extra Towels", "Housekeeping"},
+	{regexp.MustCompile(`(?i)\b(leak\w*|plumb\w*|toilet|shower|sink|drain)\b`), "Plumbing Issue", "Maintenance"},
+	{regexp.MustCompile(`(?i)\b(ac|a/c|air ?con\w*|heat\w*|thermostat)\b`), "AC Repair", "Maintenance"},
+	{regexp.MustCompile(`(?i)\b(light|bulb|tv|television|wi-?fi|broken)\b`), "Maintenance Request", "Maintenance"},
+	{regexp.MustCompile(`(?i)\b(clean\w*|sheets|linens?|pillows?|housekeeping|trash)\b`), "Room Cleaning", "Housekeeping"},
+	{regexp.MustCompile(`(?i)\b(late check-?out|check-?out)\b`), "Late Checkout", "Front Desk"},
+	{regexp.MustCompile(`(?i)\b(breakfast|dinner|lunch|food|coffee|soda|drinks?|room service)\b`), "Room Service Order", "Food & Beverage"},
+	{regexp.MustCompile(`(?i)\b(taxi|cab|luggage|bags|reservation)\b`), "Concierge Request", "Concierge"},
+}
+
+var (
+	stubRoomPattern     = regexp.MustCompile(`(?i)\b(?:room|rm|suite)\s*#?\s*([a-z]?\d{2,4}[a-z]?)\b`)
+	stubBareRoomPattern = regexp.MustCompile(`\b(\d{3,4})\b`)
+	stubGuestPattern    = regexp.MustCompile(`\b(?:[Ff]or|[Gg]uest) (?:(?:Mr|Mrs|Ms|Dr)\.? )?([A-Z][a-z]+(?: [A-Z][a-z]+)?)`)
+	stubUserPattern     = regexp.MustCompile(`\b(?:[Aa]ssign(?:ed)?(?: it| this)? to|[Aa]sk) ([A-Z][a-z]+(?: [A-Z][a-z]+)?)`)
+	stubHighPriority    = regexp.MustCompile(`(?i)\b(urgent\w*|asap|immediately|emergency|leak\w*|flood\w*|fire|smoke)\b`)
+	stubLowPriority     = regexp.MustCompile(`(?i)\b(no rush|whenever|when you can|tomorrow|late check-?out)\b`)
+	stubTaskSeparator   = regexp.MustCompile(`(?i)[.;!?\n]+|,\s*(?:and\s+)?(?:also\s+)?|\s+and\s+also\s+|\s+also\s+`)
+)
+
+// splitStubTasks splits a message into task fragments, keeping only
+// fragments that match a rule or mention a room.
+func splitStubTasks(message string) []string {
+	var tasks []string
+	for _, fragment := range stubTaskSeparator.Split(message, -1) {
+		fragment = strings.TrimSpace(fragment)
+		if fragment == "" {
+			continue
+		}
+		if stubRuleFor(fragment) != nil || stubRoomReference(fragment) != "" {
+			tasks = append(tasks, fragment)
+		}
+	}
+	return tasks
+}
+
+func stubRequest(text string) GenerateRequestOutput {
+	out := GenerateRequestOutput{
+		Name:        "Service Request",
+		RequestType: "one-time",
+		Status:      "pending",
+		Priority:    "medium",
+		// A request no rule recognises keeps its generic name and guessed priority.
+		FieldConfidence: map[string]float64{"name": 0.3, "request_type": 0.9, "status": 0.9, "priority": 0.5},
+	}
+	if text == "" {
+		return out
+	}
+	out.Description = &text
+
+	if rule := stubRuleFor(text); rule != nil {
+		out.Name = rule.name
+		department := rule.department
+		out.Department = &department
+		out.FieldConfidence["name"] = 0.9
+		out.FieldConfidence["department"] = 0.8
+	}
+
+	switch {
+	case stubHighPriority.MatchString(text):
+		out.Priority = "high"
+		out.FieldConfidence["priority"] = 0.8
+	case stubLowPriority.MatchString(text):
+		out.Priority = "low"
+		out.FieldConfidence["priority"] = 0.8
+	}
+
+	if room := stubRoomReference(text); room != "" {
+		mentioned := true
+		out.RoomMentioned = &mentioned
+		out.RoomReference = &room
+		out.FieldConfidence["room"] = 0.9
+	}
+	if m := stubGuestPattern.FindStringSubmatch(text); m != nil {
+		out.GuestName = &m[1]
+		out.FieldConfidence["guest"] = 0.8
+	}
+	if m := stubUserPattern.FindStringSubmatch(text); m != nil {
+		out.UserName = &m[1]
+		out.FieldConfidence["user"] = 0.8
+	}
+
+	return out
+}
+
+func stubRuleFor(text string) *stubRule {
+	for i := range stubRules {
+		if stubRules[i].match.MatchString(text) {
+			return &stubRules[i]
+		}
+	}
+	return nil
+}
+
+func stubRoomReference(text string) string {
+	if m := stubRoomPattern.FindStringSubmatch(text); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	if m := stubBareRoomPattern.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// stubLanguage recognises a language by a few telltale words and translates
+// it word by word through a glossary, just enough for the English rules to
+// match. Names and room numbers pass through untouched.
+type stubLanguage struct {
+	code     string
+	detect   *regexp.Regexp
+	glossary map[string]string
+}
+
+var stubLanguages = []stubLanguage{
+	{
+		code:   "es",
+		detect: regexp.MustCompile(`(?i)\b(necesito|necesitamos|habitaci[oó]n|cuarto|toallas?|limpieza|urgente|gracias|fuga)\b`),
+		glossary: map[string]string{
+			"necesito": "I need", "necesitamos": "we need", "habitación": "room", "habitacion": "room",
+			"cuarto": "room", "toallas": "towels", "toalla": "towel", "más": "more", "limpieza": "cleaning",
+			"limpiar": "clean", "urgente": "urgent", "fuga": "leak", "agua": "water", "baño": "bathroom",
+			"aire": "air", "acondicionado": "conditioning", "desayuno": "breakfast", "por": "please", "favor": "",
+			"en": "in", "la": "the", "el": "the", "de": "of", "para": "for", "y": "and", "no": "not",
+			"funciona": "working", "gracias": "thanks",
+		},
+	},
+	{
+		code:   "pt",
+		detect: regexp.MustCompile(`(?i)\b(preciso|precisamos|quarto|toalhas?|limpeza|vazamento|obrigad[oa])\b`),
+		glossary: map[string]string{
+			"preciso": "I need", "precisamos": "we need", "quarto": "room", "toalhas": "towels", "toalha": "towel",
+			"mais": "more", "limpeza": "cleaning", "limpar": "clean", "urgente": "urgent", "vazamento": "leak",
+			"água": "water", "banheiro": "bathroom", "ar": "air", "condicionado": "conditioning",
+			"café": "breakfast", "da": "of the", "manhã": "", "por": "please", "favor": "", "no": "in the",
+			"na": "in the", "em": "in", "de": "of", "para": "for", "e": "and", "não": "not",
+			"funciona": "working", "obrigado": "thanks", "obrigada": "thanks", "o": "the", "a": "the",
+			"do": "of the",
+		},
+	},
+}
+
+var (
+	stubWordPattern = regexp.MustCompile(`\p{L}+`)
+	stubHanPattern  = regexp.MustCompile(`\p{Han}`)
+	stubSpaces      = regexp.MustCompile(`\s{2,}`)
+	// stubHanGlossary translates Chinese by substring, as it has no spaces
+	// between words; anything it does not know is dropped.
+	stubHanGlossary = strings.NewReplacer(
+		"房间", " room ", "客房", " room ", "毛巾", " towels ", "需要", " need ", "紧急", " urgent ",
+		"空调", " AC ", "漏水", " leak ", "打扫", " cleaning ", "清洁", " cleaning ", "早餐", " breakfast ",
+		"电视", " TV ", "坏了", " broken ", "，", ", ", "。", ". ", "！", "! ",
+	)
+	stubHanLeftover = regexp.MustCompile(`\p{Han}+`)
+	stubSpacedPunct = regexp.MustCompile(`\s+([,.!])`)
+)
+
+// translateStub returns the message's language and, unless it is English,
+// its English translation.
+func translateStub(message string) (string, *string) {
+	if stubHanPattern.MatchString(message) {
+		english := stubHanGlossary.Replace(message)
+		english = stubHanLeftover.ReplaceAllString(english, " ")
+		english = stubSpacedPunct.ReplaceAllString(english, "$1")
+		english = strings.TrimSpace(stubSpaces.ReplaceAllString(english, " "))
+		return "zh", &english
+	}
+
+	for _, lang := range stubLanguages {
+		if !lang.detect.MatchString(message) {
+			continue
+		}
+		english := stubWordPattern.ReplaceAllStringFunc(message, func(word string) string {
+			if translated, ok := lang.glossary[strings.ToLower(word)]; ok {
+				return translated
+			}
+			return word
+		})
+		english = strings.TrimSpace(stubSpaces.ReplaceAllString(english, " "))
+		return lang.code, &english
+	}
+
+	return "en", nil
+}
+
+// stubTranscript "transcribes" a clip by reading it as UTF-8 text, so tests
+// and demos can send a text file as a voice note. Real audio yields an empty
+// transcript.
+func stubTranscript(media []*ai.Part) string {
+	if len(media) == 0 {
+		return ""
+	}
+	return stubMediaText(media[0])
+}
+
+// stubCaptions "looks at" images the same way, reading each one that is
+// UTF-8 text as a caption of what it shows. Real images are ignored, so the
+// stub only recognises them from the text sent along.
+func stubCaptions(media []*ai.Part) []string {
+	var captions []string
+	for _, part := range media {
+		if caption := stubMediaText(part); caption != "" {
+			captions = append(captions, caption)
+		}
+	}
+	return captions
+}
+
+func stubMediaText(part *ai.Part) string {
+	_, encoded, ok := strings.Cut(part.Text, ";base64,")
+	if !ok {
+		return ""
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !utf8.Valid(data) || bytes.ContainsRune(data, 0) {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,302 @@
+package aiflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockHotelLookupRepository struct {
+	departments   []*models.Department
+	staffLanguage string
+}
+
+func (m *mockHotelLookupRepository) GetDepartmentsByHotelID(ctx context.Context, hotelID string) ([]*models.Department, error) {
+	return m.departments, nil
+}
+
+func (m *mockHotelLookupRepository) GetStaffLanguageByHotelID(ctx context.Context, hotelID string) (string, error) {
+	return m.staffLanguage, nil
+}
+
+func initTestGenkit(t *testing.T, cfg *config.LLM) *GenkitService {
+	t.Helper()
+
+	rooms := &mockRoomLookupRepository{
+		findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+			if ref.Number != nil && *ref.Number == 504 {
+				return []*models.LookupCandidate{{ID: "room-uuid-504", Label: "Room 504", Score: 1}}, nil
+			}
+			return nil, nil
+		},
+	}
+	hotels := &mockHotelLookupRepository{departments: []*models.Department{
+		{ID: "dept-uuid-hk", Name: "Housekeeping"},
+		{ID: "dept-uuid-mt", Name: "Maintenance"},
+	}}
+
+	svc, err := InitGenkit(context.Background(), cfg, rooms, &mockGuestLookupRepository{}, &mockUserLookupRepository{}, hotels)
+	require.NoError(t, err)
+	return svc
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "")
+
+	t.Run("no provider and no API key is not configured", func(t *testing.T) {
+		_, err := NewProvider(&config.LLM{})
+		assert.ErrorIs(t, err, ErrNoLLMProvider)
+	})
+
+	t.Run("none is not configured", func(t *testing.T) {
+		_, err := NewProvider(&config.LLM{Provider: ProviderNone, APIKey: "key"})
+		assert.ErrorIs(t, err, ErrNoLLMProvider)
+	})
+
+	t.Run("an API key without a provider selects gemini", func(t *testing.T) {
+		p, err := NewProvider(&config.LLM{APIKey: "key"})
+		require.NoError(t, err)
+		assert.IsType(t, &geminiProvider{}, p)
+	})
+
+	t.Run("gemini without an API key is not configured", func(t *testing.T) {
+		_, err := NewProvider(&config.LLM{Provider: ProviderGemini})
+		assert.ErrorIs(t, err, ErrNoLLMProvider)
+	})
+
+	t.Run("openai needs a base URL and model", func(t *testing.T) {
+		_, err := NewProvider(&config.LLM{Provider: ProviderOpenAI, Model: "llama3.1"})
+		assert.ErrorIs(t, err, ErrNoLLMProvider)
+
+		_, err = NewProvider(&config.LLM{Provider: ProviderOpenAI, BaseURL: "http://127.0.0.1:11434/v1"})
+		assert.ErrorIs(t, err, ErrNoLLMProvider)
+	})
+
+	t.Run("unknown provider is an error", func(t *testing.T) {
+		_, err := NewProvider(&config.LLM{Provider: "watson"})
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNoLLMProvider)
+	})
+
+	t.Run("InitGenkit without a provider does not panic", func(t *testing.T) {
+		svc, err := InitGenkit(context.Background(), &config.LLM{}, nil, nil, nil, nil)
+		assert.Nil(t, svc)
+		assert.ErrorIs(t, err, ErrNoLLMProvider)
+	})
+}
+
+func TestStubProvider(t *testing.T) {
+	t.Parallel()
+
+	svc := initTestGenkit(t, &config.LLM{Provider: ProviderStub})
+	ctx := context.Background()
+
+	t.Run("generates one enriched request", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunGenerateRequest(ctx, GenerateRequestInput{HotelID: "org_1", RawText: "Room 504 needs extra towels ASAP"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Extra Towels", out.Name)
+		assert.Equal(t, "high", out.Priority)
+		assert.Equal(t, "pending", out.Status)
+		require.NotNil(t, out.RoomID)
+		assert.Equal(t, "room-uuid-504", *out.RoomID)
+		require.NotNil(t, out.DepartmentID)
+		assert.Equal(t, "dept-uuid-hk", *out.DepartmentID)
+	})
+
+	t.Run("is deterministic", func(t *testing.T) {
+		t.Parallel()
+
+		input := GenerateRequestInput{HotelID: "org_1", RawText: "the AC in 312 is loud"}
+		first, err := svc.RunGenerateRequest(ctx, input)
+		require.NoError(t, err)
+		second, err := svc.RunGenerateRequest(ctx, input)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("splits a multi-task message", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunGenerateRequestBatch(ctx, GenerateRequestInput{
+			HotelID: "org_1",
+			RawText: "need towels in 504, and the AC in 312 is loud, also late checkout for Maria",
+		})
+		require.NoError(t, err)
+		require.Len(t, out.Requests, 3)
+
+		assert.Equal(t, "Extra Towels", out.Requests[0].Name)
+		assert.Equal(t, "room-uuid-504", *out.Requests[0].RoomID)
+		assert.Equal(t, "AC Repair", out.Requests[1].Name)
+		require.NotNil(t, out.Requests[1].Warning)
+		assert.Equal(t, "room_not_found", out.Requests[1].Warning.Code)
+		assert.Equal(t, "Late Checkout", out.Requests[2].Name)
+		assert.Equal(t, "low", out.Requests[2].Priority)
+		assert.Equal(t, "Maria", *out.Requests[2].GuestName)
+	})
+
+	t.Run("detects and translates the message language", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunGenerateRequest(ctx, GenerateRequestInput{HotelID: "org_1", RawText: "Necesito más toallas en la habitación 504, es urgente"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "es", out.Language)
+		require.NotNil(t, out.EnglishText)
+		assert.Contains(t, *out.EnglishText, "towels")
+		assert.Equal(t, "Extra Towels", out.Name)
+		assert.Equal(t, "high", out.Priority)
+		require.NotNil(t, out.RoomID)
+		assert.Equal(t, "room-uuid-504", *out.RoomID)
+
+		english, err := svc.RunGenerateRequest(ctx, GenerateRequestInput{HotelID: "org_1", RawText: "Room 504 needs extra towels"})
+		require.NoError(t, err)
+		assert.Equal(t, "en", english.Language)
+		assert.Nil(t, english.EnglishText)
+	})
+
+	t.Run("gives every request of a batch the message language", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunGenerateRequestBatch(ctx, GenerateRequestInput{HotelID: "org_1", RawText: "504房间需要毛巾。312房间空调坏了"})
+		require.NoError(t, err)
+		require.Len(t, out.Requests, 2)
+
+		for _, req := range out.Requests {
+			assert.Equal(t, "zh", req.Language)
+			require.NotNil(t, req.EnglishText)
+		}
+		assert.Equal(t, "Extra Towels", out.Requests[0].Name)
+		assert.Equal(t, "AC Repair", out.Requests[1].Name)
+	})
+
+	t.Run("transcribes a clip holding text and nothing else", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunTranscribeAudio(ctx, TranscribeAudioInput{Audio: Media{ContentType: "audio/wav", Data: []byte("Room 504 needs extra towels\n")}})
+		require.NoError(t, err)
+		assert.Equal(t, "Room 504 needs extra towels", out.Transcript)
+
+		silent, err := svc.RunTranscribeAudio(ctx, TranscribeAudioInput{Audio: Media{ContentType: "audio/wav", Data: []byte{'R', 'I', 'F', 'F', 0, 0, 0, 0}}})
+		require.NoError(t, err)
+		assert.Empty(t, silent.Transcript)
+	})
+
+	t.Run("generates from photos captioned in text", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunGeneratePhotoRequest(ctx, GeneratePhotoRequestInput{
+			HotelID: "org_1",
+			Text:    "room 504",
+			Images:  []Media{{ContentType: "image/jpeg", Data: []byte("water leaking under the bathroom sink")}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Plumbing Issue", out.Name)
+		assert.Equal(t, "high", out.Priority)
+		require.NotNil(t, out.DepartmentID)
+		require.NotNil(t, out.RoomID)
+		assert.Equal(t, "room-uuid-504", *out.RoomID)
+		assert.Nil(t, out.Warning)
+	})
+
+	t.Run("warns about photos unrelated to the hotel", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := svc.RunGeneratePhotoRequest(ctx, GeneratePhotoRequestInput{
+			HotelID: "org_1",
+			Images:  []Media{{ContentType: "image/jpeg", Data: []byte("a sunset over the mountains")}},
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, out.Warning)
+		assert.Equal(t, "unrelated_image", out.Warning.Code)
+	})
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	t.Parallel()
+
+	var got openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"name\":\"Extra Towels\",\"request_type\":\"one-time\",\"status\":\"pending\",\"priority\":\"medium\",\"room_mentioned\":true,\"room_reference\":\"504\"}"},"finish_reason":"stop"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := initTestGenkit(t, &config.LLM{
+		Provider:        ProviderOpenAI,
+		BaseURL:         server.URL + "/v1/",
+		APIKey:          "secret",
+		Model:           "llama3.1",
+		MaxOutputTokens: 512,
+	})
+
+	out, err := svc.RunGenerateRequest(context.Background(), GenerateRequestInput{HotelID: "org_1", RawText: "towels to 504"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Extra Towels", out.Name)
+	assert.Equal(t, "room-uuid-504", *out.RoomID)
+
+	assert.Equal(t, "llama3.1", got.Model)
+	assert.Equal(t, 512, got.MaxTokens)
+	require.NotNil(t, got.ResponseFormat)
+	assert.Equal(t, "json_object", got.ResponseFormat.Type)
+	require.NotEmpty(t, got.Messages)
+	assert.Contains(t, got.Messages[len(got.Messages)-1].Content, "towels to 504")
+}
+
+func TestReplayProvider(t *testing.T) {
+	t.Parallel()
+
+	rooms := &mockRoomLookupRepository{
+		findRoomCandidatesFunc: func(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+			return nil, nil
+		},
+	}
+	hotels := &mockHotelLookupRepository{}
+	ctx := context.Background()
+	input := GenerateRequestInput{HotelID: "org_1", RawText: "Room 504 needs extra towels ASAP"}
+
+	store := &ResponseStore{Responses: map[string]RecordedResponse{}}
+	recording, err := InitGenkitWithProvider(ctx, NewReplayProvider(store, stubProvider{}), rooms, &mockGuestLookupRepository{}, &mockUserLookupRepository{}, hotels)
+	require.NoError(t, err)
+	recorded, err := recording.RunGenerateRequest(ctx, input)
+	require.NoError(t, err)
+
+	assert.Equal(t, "stub/rules", store.Model)
+	require.Len(t, store.Responses, 1)
+	for _, resp := range store.Responses {
+		assert.Equal(t, input.RawText, resp.Message)
+	}
+
+	path := filepath.Join(t.TempDir(), "responses.json")
+	require.NoError(t, store.Save(path))
+	loaded, err := LoadResponseStore(path)
+	require.NoError(t, err)
+
+	replaying, err := InitGenkitWithProvider(ctx, NewReplayProvider(loaded, nil), rooms, &mockGuestLookupRepository{}, &mockUserLookupRepository{}, hotels)
+	require.NoError(t, err)
+
+	replayed, err := replaying.RunGenerateRequest(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+
+	_, err = replaying.RunGenerateRequest(ctx, GenerateRequestInput{HotelID: "org_1", RawText: "something new"})
+	require.ErrorIs(t, err, ErrNoRecordedResponse)
+}
@@ -0,0 +1,64 @@
+package aiflows
+
+import "github.com/generate/selfserve/internal/models"
+
+// ToResponse wraps req, the request o was turned into, with o's warning and
+// confidence.
+func (o *EnrichedGenerateRequestOutput) ToResponse(req models.Request) models.GenerateRequestResponse {
+	var warning *models.GenerateRequestWarning
+	if o.Warning != nil {
+		warning = &models.GenerateRequestWarning{
+			Code:       o.Warning.Code,
+			Message:    o.Warning.Message,
+			Candidates: o.Warning.Candidates,
+		}
+	}
+	return models.GenerateRequestResponse{
+		Request:         req,
+		Warning:         warning,
+		Confidence:      o.Confidence,
+		FieldConfidence: o.FieldConfidence,
+	}
+}
+
+// ToMakeRequest turns a generated request into request data for the hotel.
+// rawText is the message it was generated from; an English message is its own
+// English text.
+func (o *EnrichedGenerateRequestOutput) ToMakeRequest(hotelID, rawText string) models.MakeRequest {
+	notes := o.Notes
+	if notes == nil {
+		empty := ""
+		notes = &empty
+	}
+
+	var guestLanguage *string
+	if o.Language != "" {
+		guestLanguage = &o.Language
+	}
+	englishText := o.EnglishText
+	if englishText == nil && (o.Language == "" || o.Language == "en") {
+		englishText = &rawText
+	}
+
+	return models.MakeRequest{
+		HotelID:                 hotelID,
+		GuestID:                 o.GuestID,
+		UserID:                  o.UserID,
+		ReservationID:           o.ReservationID,
+		RoomID:                  o.RoomID,
+		Name:                    o.Name,
+		Description:             o.Description,
+		RequestCategory:         o.RequestCategory,
+		RequestType:             o.RequestType,
+		Department:              o.DepartmentID,
+		Status:                  o.Status,
+		Priority:                o.Priority,
+		EstimatedCompletionTime: o.EstimatedCompletionTime,
+		ScheduledTime:           nil, // TODO: Potentially add schedule time from user input / auto-scheduling
+		CompletedAt:             nil,
+		Notes:                   notes,
+		OriginalText:            &rawText,
+		EnglishText:             englishText,
+		GuestLanguage:           guestLanguage,
+	}
+}
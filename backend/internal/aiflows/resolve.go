@@ -0,0 +1,63 @@
+package aiflows
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+const (
+	// maxLookupCandidates caps the "did you mean" choices offered per warning.
+	maxLookupCandidates = 5
+	// minCandidateScore is the lowest score worth suggesting.
+	minCandidateScore = 0.3
+	// roomAcceptScore is the score a room must reach to be picked on its own;
+	// only exact numbers and floor+unit matches reach it.
+	roomAcceptScore = 0.9
+	// nameAcceptScore is the score a guest or staff name must reach to be
+	// picked on its own.
+	nameAcceptScore = 0.5
+	// acceptMargin is how far the best candidate must lead the next one.
+	acceptMargin = 0.1
+)
+
+// resolveCandidates picks the match among candidates ranked best first. A
+// match needs acceptScore and a clear lead; a tie at the top is ambiguous.
+func resolveCandidates(candidates []*models.LookupCandidate, acceptScore float64) (match *models.LookupCandidate, ambiguous bool) {
+	if len(candidates) == 0 || candidates[0].Score < acceptScore {
+		return nil, false
+	}
+	if len(candidates) > 1 && candidates[0].Score-candidates[1].Score < acceptMargin {
+		return nil, true
+	}
+	return candidates[0], false
+}
+
+// didYouMean renders candidates as a suggestion, e.g. ` Did you mean "John
+// Smith" or "Jon Park"?`, or "" when there are none.
+func didYouMean(candidates []*models.LookupCandidate) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	labels := make([]string, len(candidates))
+	for i, c := range candidates {
+		labels[i] = fmt.Sprintf("%q", c.Label)
+	}
+	if len(labels) == 1 {
+		return fmt.Sprintf(" Did you mean %s?", labels[0])
+	}
+	return fmt.Sprintf(" Did you mean %s or %s?", strings.Join(labels[:len(labels)-1], ", "), labels[len(labels)-1])
+}
+
+func candidateValues(candidates []*models.LookupCandidate) []models.LookupCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+	out := make([]models.LookupCandidate, len(candidates))
+	for i, c := range candidates {
+		out[i] = *c
+	}
+	return out
+}
@@ -2,42 +2,48 @@ package aiflows
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
-	"github.com/generate/selfserve/internal/errs"
 	"github.com/generate/selfserve/internal/models"
 )
 
 type RoomLookupRepository interface {
-	FindRoomByNumber(ctx context.Context, hotelID string, roomReference string) (*models.Room, error)
+	// FindRoomCandidates returns the hotel's rooms scoring at least minScore
+	// against ref, best first.
+	FindRoomCandidates(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error)
 }
 
 func LookupRoom(ctx context.Context, repo RoomLookupRepository, input RoomLookupInput) (RoomLookupResult, error) {
-	room, err := repo.FindRoomByNumber(ctx, input.HotelID, input.RoomReference)
-	if err != nil {
-		if errors.Is(err, errs.ErrAlreadyExistsInDB) {
-			message := "Room reference was ambiguous. Be more specific."
-			return RoomLookupResult{
-				Matched:   false,
-				Ambiguous: true,
-				Message:   &message,
-			}, nil
-		}
-		if errors.Is(err, errs.ErrNotFoundInDB) {
-			message := fmt.Sprintf("Room %s could not be resolved for this hotel.", input.RoomReference)
-			return RoomLookupResult{
-				Matched:   false,
-				Ambiguous: false,
-				Message:   &message,
-			}, nil
+	ref := ParseRoomReference(input.RoomReference)
+
+	var candidates []*models.LookupCandidate
+	if !ref.Empty() {
+		var err error
+		candidates, err = repo.FindRoomCandidates(ctx, input.HotelID, ref, minCandidateScore, maxLookupCandidates)
+		if err != nil {
+			return RoomLookupResult{}, err
 		}
-		return RoomLookupResult{}, err
 	}
 
-	return RoomLookupResult{
-		Matched:   true,
-		Ambiguous: false,
-		RoomID:    &room.ID,
-	}, nil
+	match, ambiguous := resolveCandidates(candidates, roomAcceptScore)
+	switch {
+	case match != nil:
+		return RoomLookupResult{
+			Matched: true,
+			RoomID:  &match.ID,
+		}, nil
+	case ambiguous:
+		message := fmt.Sprintf("Room reference %q matched several rooms.%s", input.RoomReference, didYouMean(candidates))
+		return RoomLookupResult{
+			Ambiguous:  true,
+			Message:    &message,
+			Candidates: candidateValues(candidates),
+		}, nil
+	default:
+		message := fmt.Sprintf("Room %s could not be resolved for this hotel.%s", input.RoomReference, didYouMean(candidates))
+		return RoomLookupResult{
+			Message:    &message,
+			Candidates: candidateValues(candidates),
+		}, nil
+	}
 }
@@ -11,13 +11,31 @@ import (
 // Handlers should depend on this interface to allow for mocking in tests.
 type GenerateRequestService interface {
 	RunGenerateRequest(ctx context.Context, input GenerateRequestInput) (EnrichedGenerateRequestOutput, error)
+	RunGenerateRequestBatch(ctx context.Context, input GenerateRequestInput) (GenerateRequestBatchOutput, error)
+	RunTranscribeAudio(ctx context.Context, input TranscribeAudioInput) (TranscribeAudioOutput, error)
+	RunGeneratePhotoRequest(ctx context.Context, input GeneratePhotoRequestInput) (EnrichedGenerateRequestOutput, error)
 }
 
 type GenkitService struct {
 	genkit              *genkit.Genkit
 	generateRequestFlow *core.Flow[GenerateRequestInput, EnrichedGenerateRequestOutput, struct{}]
+	generateBatchFlow   *core.Flow[GenerateRequestInput, GenerateRequestBatchOutput, struct{}]
+	transcribeFlow      *core.Flow[TranscribeAudioInput, TranscribeAudioOutput, struct{}]
+	generatePhotoFlow   *core.Flow[GeneratePhotoRequestInput, EnrichedGenerateRequestOutput, struct{}]
 }
 
 func (s *GenkitService) RunGenerateRequest(ctx context.Context, input GenerateRequestInput) (EnrichedGenerateRequestOutput, error) {
 	return s.generateRequestFlow.Run(ctx, input)
 }
+
+func (s *GenkitService) RunGenerateRequestBatch(ctx context.Context, input GenerateRequestInput) (GenerateRequestBatchOutput, error) {
+	return s.generateBatchFlow.Run(ctx, input)
+}
+
+func (s *GenkitService) RunTranscribeAudio(ctx context.Context, input TranscribeAudioInput) (TranscribeAudioOutput, error) {
+	return s.transcribeFlow.Run(ctx, input)
+}
+
+func (s *GenkitService) RunGeneratePhotoRequest(ctx context.Context, input GeneratePhotoRequestInput) (EnrichedGenerateRequestOutput, error) {
+	return s.generatePhotoFlow.Run(ctx, input)
+}
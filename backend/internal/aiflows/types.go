@@ -1,13 +1,16 @@
 package aiflows
 
+import "github.com/generate/selfserve/internal/models"
+
 type GenerateRequestInput struct {
 	RawText string `json:"raw_text"`
 	HotelID string `json:"hotel_id"`
 }
 
 type GenerateRequestWarning struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code       string                   `json:"code"`
+	Message    string                   `json:"message"`
+	Candidates []models.LookupCandidate `json:"candidates,omitempty"`
 }
 
 type RoomLookupInput struct {
@@ -16,15 +19,17 @@ type RoomLookupInput struct {
 }
 
 type RoomLookupResult struct {
-	Matched   bool    `json:"matched"`
-	Ambiguous bool    `json:"ambiguous"`
-	Message   *string `json:"message,omitempty"`
-	RoomID    *string `json:"room_id,omitempty"`
+	Matched    bool                     `json:"matched"`
+	Ambiguous  bool                     `json:"ambiguous"`
+	Message    *string                  `json:"message,omitempty"`
+	RoomID     *string                  `json:"room_id,omitempty"`
+	Candidates []models.LookupCandidate `json:"candidates,omitempty"`
 }
 
 type GuestLookupInput struct {
-	HotelID   string `json:"hotel_id"`
-	GuestName string `json:"guest_name"`
+	HotelID   string  `json:"hotel_id"`
+	GuestName string  `json:"guest_name"`
+	RoomID    *string `json:"room_id,omitempty"`
 }
 
 type UserLookupInput struct {
@@ -32,12 +37,24 @@ type UserLookupInput struct {
 	UserName string `json:"user_name"`
 }
 
+// LookupResult is the outcome of resolving a guest or staff name. ID is set
+// on a match; otherwise Message explains why and Candidates holds the closest
+// names, best first.
+type LookupResult struct {
+	ID         *string                  `json:"id,omitempty"`
+	Ambiguous  bool                     `json:"ambiguous"`
+	Message    *string                  `json:"message,omitempty"`
+	Candidates []models.LookupCandidate `json:"candidates,omitempty"`
+}
+
 type EnrichedGenerateRequestOutput struct {
 	GuestID       *string `json:"guest_id,omitempty" validate:"omitempty,uuid"`
 	UserID        *string `json:"user_id,omitempty" validate:"omitempty,notblank"`
 	ReservationID *string `json:"reservation_id,omitempty" validate:"omitempty,uuid"`
 	RoomID        *string `json:"room_id,omitempty" validate:"omitempty,uuid"`
 	DepartmentID  *string `json:"department_id,omitempty" validate:"omitempty,uuid"`
+	// Confidence is the lowest of FieldConfidence once lookups are applied.
+	Confidence float64 `json:"confidence"`
 	GenerateRequestOutput
 }
 
@@ -56,4 +73,57 @@ type GenerateRequestOutput struct {
 	GuestName               *string                 `json:"guest_name,omitempty"`
 	UserName                *string                 `json:"user_name,omitempty"`
 	Warning                 *GenerateRequestWarning `json:"warning,omitempty"`
+	// FieldConfidence is the model's confidence in each extracted field,
+	// between 0 and 1, keyed as in confidenceFields.
+	FieldConfidence map[string]float64 `json:"field_confidence,omitempty"`
+	// Language is the ISO 639-1 code of the message's language. EnglishText
+	// is the message translated to English, set when it is not in English.
+	Language    string  `json:"language,omitempty"`
+	EnglishText *string `json:"english_text,omitempty"`
+}
+
+// GenerateRequestsOutput is the model's answer to GenerateRequestsPrompt.
+// Language and EnglishText describe the whole message, as in
+// GenerateRequestOutput.
+type GenerateRequestsOutput struct {
+	Language    string                  `json:"language,omitempty"`
+	EnglishText *string                 `json:"english_text,omitempty"`
+	Requests    []GenerateRequestOutput `json:"requests"`
+}
+
+// Media is a file sent to the model inline with the prompt.
+type Media struct {
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// TranscribeAudioInput is a voice note to transcribe.
+type TranscribeAudioInput struct {
+	Audio Media `json:"audio"`
+}
+
+// TranscribeAudioOutput holds what was said in a voice note, in the language
+// it was spoken. Transcript is empty when nothing intelligible was said.
+type TranscribeAudioOutput struct {
+	Transcript string `json:"transcript"`
+}
+
+// GeneratePhotoRequestInput is one or more photos of a problem, with
+// whatever text was sent along with them.
+type GeneratePhotoRequestInput struct {
+	HotelID string  `json:"hotel_id"`
+	Text    string  `json:"text,omitempty"`
+	Images  []Media `json:"images"`
+}
+
+// GeneratePhotoRequestOutput is the model's answer to
+// GeneratePhotoRequestPrompt. HotelRelated is false when the photos show
+// nothing a hotel's staff could act on.
+type GeneratePhotoRequestOutput struct {
+	HotelRelated bool `json:"hotel_related"`
+	GenerateRequestOutput
+}
+
+type GenerateRequestBatchOutput struct {
+	Requests []EnrichedGenerateRequestOutput `json:"requests"`
 }
@@ -3,28 +3,38 @@ package aiflows
 import (
 	"context"
 	"fmt"
+
+	"github.com/generate/selfserve/internal/models"
 )
 
 type UserLookupRepository interface {
-	FindUserByName(ctx context.Context, hotelID, name string) ([]string, error)
+	// FindUserCandidates returns the hotel's staff whose name scores at least
+	// minScore, best first.
+	FindUserCandidates(ctx context.Context, hotelID, name string, minScore float64, limit int) ([]*models.LookupCandidate, error)
 }
 
-// LookupUser returns (userID, warningMessage, error).
-// warningMessage is non-nil when the name matched 0 or >1 users.
-func LookupUser(ctx context.Context, repo UserLookupRepository, input UserLookupInput) (userID *string, warning *string, err error) {
-	ids, err := repo.FindUserByName(ctx, input.HotelID, input.UserName)
-	if err != nil {
-		return nil, nil, err
+// LookupUser resolves the name of the staff member a request is assigned to.
+func LookupUser(ctx context.Context, repo UserLookupRepository, input UserLookupInput) (LookupResult, error) {
+	name := NormalizePersonName(input.UserName)
+
+	var candidates []*models.LookupCandidate
+	if name != "" {
+		var err error
+		candidates, err = repo.FindUserCandidates(ctx, input.HotelID, name, minCandidateScore, maxLookupCandidates)
+		if err != nil {
+			return LookupResult{}, err
+		}
 	}
 
-	switch len(ids) {
-	case 1:
-		return &ids[0], nil, nil
-	case 0:
-		msg := fmt.Sprintf("Staff member %q could not be found for this hotel.", input.UserName)
-		return nil, &msg, nil
+	match, ambiguous := resolveCandidates(candidates, nameAcceptScore)
+	switch {
+	case match != nil:
+		return LookupResult{ID: &match.ID}, nil
+	case ambiguous:
+		msg := fmt.Sprintf("Staff member name %q matched multiple users.%s", input.UserName, didYouMean(candidates))
+		return LookupResult{Ambiguous: true, Message: &msg, Candidates: candidateValues(candidates)}, nil
 	default:
-		msg := fmt.Sprintf("Staff member name %q matched multiple users. Be more specific.", input.UserName)
-		return nil, &msg, nil
+		msg := fmt.Sprintf("Staff member %q could not be found for this hotel.%s", input.UserName, didYouMean(candidates))
+		return LookupResult{Message: &msg, Candidates: candidateValues(candidates)}, nil
 	}
 }
@@ -47,6 +47,18 @@ func Conflict(title string, withKey string, withValue any) HTTPError {
 	return NewHTTPError(http.StatusConflict, fmt.Errorf("conflict: %s with %s='%s' already exists", title, withKey, withValue))
 }
 
+// StaleVersion reports a write based on an outdated version of a resource,
+// along with the version the client should refetch or retry against.
+func StaleVersion(code int, currentVersion any) HTTPError {
+	return HTTPError{
+		Code: code,
+		Message: map[string]any{
+			"error":           "resource was modified since the expected version",
+			"current_version": currentVersion,
+		},
+	}
+}
+
 func InvalidRequestData(errors map[string]string) HTTPError {
 	return HTTPError{
 		Code:    http.StatusUnprocessableEntity,
@@ -6,5 +6,11 @@ import "errors"
 var (
 	ErrNotFoundInDB              = errors.New("not found in DB")
 	ErrAlreadyExistsInDB         = errors.New("already exists in DB")
+	ErrStaleVersionInDB          = errors.New("stale version in DB")
 	ErrDefaultDepartmentInsertDB = errors.New("failed to insert default departments")
 )
+
+// Object storage errors
+var (
+	ErrNotFoundInStorage = errors.New("not found in storage")
+)
@@ -17,6 +17,7 @@ import (
 type HotelsRepository interface {
 	FindByID(ctx context.Context, id string) (*models.Hotel, error)
 	InsertHotel(ctx context.Context, hotel *models.CreateHotelRequest) (*models.Hotel, error)
+	UpdateHotelSettings(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error)
 	GetDepartmentsByHotelID(ctx context.Context, hotelID string) ([]*models.Department, error)
 	InsertDepartment(ctx context.Context, hotelID, name string) (*models.Department, error)
 	UpdateDepartment(ctx context.Context, id, hotelID, name string) (*models.Department, error)
@@ -62,6 +63,43 @@ func (h *HotelsHandler) GetHotelByID(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(hotel)
 }
 
+// UpdateHotelSettings godoc
+// @Summary      Update hotel settings
+// @Description  Sets the language hotel staff work in, and the webhooks every notification to the hotel's staff is posted to. Requests generated from guest messages are named and described in the staff language, whatever language the guest wrote in. Webhook payloads are signed with the webhook secret when one is set; the secret is kept when left out, and removed with clear_webhook_secret.
+// @Tags         hotels
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                           true  "Hotel ID"
+// @Param        request  body      models.UpdateHotelSettingsInput  true  "Hotel settings"
+// @Success      200      {object}  models.Hotel
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      404      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/settings [put]
+func (h *HotelsHandler) UpdateHotelSettings(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+
+	var req models.UpdateHotelSettingsInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	hotel, err := h.repo.UpdateHotelSettings(c.Context(), hotelID, &req)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("hotel", "id", hotelID)
+		}
+		slog.Error("failed to update hotel settings", "hotel_id", hotelID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(hotel)
+}
+
 // GetHotelUsers godoc
 // @Summary      Get users by hotel
 // @Description  Returns a paginated list of all users for a hotel
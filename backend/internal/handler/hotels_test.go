@@ -20,6 +20,7 @@ type mockHotelsRepository struct {
 	findByIDFunc                func(ctx context.Context, id string) (*models.Hotel, error)
 	insertHotelFunc             func(ctx context.Context, req *models.CreateHotelRequest) (*models.Hotel, error)
 	getDepartmentsByHotelIDFunc func(ctx context.Context, hotelID string) ([]*models.Department, error)
+	updateHotelSettingsFunc     func(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error)
 }
 
 func (m *mockHotelsRepository) FindByID(ctx context.Context, id string) (*models.Hotel, error) {
@@ -30,6 +31,10 @@ func (m *mockHotelsRepository) InsertHotel(ctx context.Context, hotel *models.Cr
 	return m.insertHotelFunc(ctx, hotel)
 }
 
+func (m *mockHotelsRepository) UpdateHotelSettings(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error) {
+	return m.updateHotelSettingsFunc(ctx, id, settings)
+}
+
 func (m *mockHotelsRepository) GetDepartmentsByHotelID(ctx context.Context, hotelID string) ([]*models.Department, error) {
 	if m.getDepartmentsByHotelIDFunc != nil {
 		return m.getDepartmentsByHotelIDFunc(ctx, hotelID)
@@ -326,3 +331,127 @@ func TestHotelsHandler_CreateHotel(t *testing.T) {
 		assert.Equal(t, 500, resp.StatusCode)
 	})
 }
+
+func TestHotelsHandler_UpdateHotelSettings(t *testing.T) {
+	t.Parallel()
+
+	newApp := func(mock *mockHotelsRepository) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewHotelsHandler(mock, nil)
+		app.Put("/hotels/:id/settings", h.UpdateHotelSettings)
+		return app
+	}
+
+	t.Run("returns 200 with the updated hotel", func(t *testing.T) {
+		t.Parallel()
+
+		var gotID string
+		var got *models.UpdateHotelSettingsInput
+		mock := &mockHotelsRepository{
+			updateHotelSettingsFunc: func(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error) {
+				gotID, got = id, settings
+				return &models.Hotel{
+					StaffLanguage:      settings.StaffLanguage,
+					WebhookURLs:        settings.WebhookURLs,
+					CreateHotelRequest: models.CreateHotelRequest{ID: id, Name: "Hotel California"},
+				}, nil
+			},
+		}
+
+		req := httptest.NewRequest("PUT", "/hotels/org_2abc123/settings", bytes.NewBufferString(
+			`{"staff_language":"es","webhook_urls":["https://pms.example.com/hooks"],"webhook_secret":"whsec_123"}`,
+		))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(mock).Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "org_2abc123", gotID)
+		assert.Equal(t, &models.UpdateHotelSettingsInput{
+			StaffLanguage: "es",
+			WebhookURLs:   []string{"https://pms.example.com/hooks"},
+			WebhookSecret: "whsec_123",
+		}, got)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), `"staff_language":"es"`)
+		assert.Contains(t, string(body), `"webhook_urls":["https://pms.example.com/hooks"]`)
+		assert.NotContains(t, string(body), "whsec_123")
+	})
+
+	t.Run("returns 400 on an invalid webhook URL", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest("PUT", "/hotels/org_2abc123/settings", bytes.NewBufferString(`{"staff_language":"es","webhook_urls":["not a url"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(&mockHotelsRepository{}).Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("passes a request to clear the webhook secret", func(t *testing.T) {
+		t.Parallel()
+
+		var got *models.UpdateHotelSettingsInput
+		mock := &mockHotelsRepository{
+			updateHotelSettingsFunc: func(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error) {
+				got = settings
+				return &models.Hotel{CreateHotelRequest: models.CreateHotelRequest{ID: id}}, nil
+			},
+		}
+
+		req := httptest.NewRequest("PUT", "/hotels/org_2abc123/settings", bytes.NewBufferString(`{"staff_language":"es","clear_webhook_secret":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(mock).Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.True(t, got.ClearWebhookSecret)
+		assert.Empty(t, got.WebhookSecret)
+	})
+
+	t.Run("returns 400 when setting and clearing the webhook secret", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest("PUT", "/hotels/org_2abc123/settings", bytes.NewBufferString(
+			`{"staff_language":"es","webhook_secret":"whsec_123","clear_webhook_secret":true}`,
+		))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(&mockHotelsRepository{}).Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "clear_webhook_secret")
+	})
+
+	t.Run("returns 400 on an invalid language tag", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest("PUT", "/hotels/org_2abc123/settings", bytes.NewBufferString(`{"staff_language":"not a language"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(&mockHotelsRepository{}).Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "staff_language")
+	})
+
+	t.Run("returns 404 for an unknown hotel", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockHotelsRepository{
+			updateHotelSettingsFunc: func(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}
+
+		req := httptest.NewRequest("PUT", "/hotels/org_missing/settings", bytes.NewBufferString(`{"staff_language":"pt-BR"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(mock).Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
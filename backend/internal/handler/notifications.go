@@ -16,6 +16,8 @@ type NotificationsRepository interface {
 	MarkRead(ctx context.Context, id, userID string) error
 	MarkAllRead(ctx context.Context, userID string) error
 	UpsertDeviceToken(ctx context.Context, userID, token, platform string) error
+	FindNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	UpsertNotificationPreferences(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error)
 }
 
 type NotificationsHandler struct {
@@ -125,3 +127,57 @@ func (h *NotificationsHandler) RegisterDeviceToken(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// GetPreferences godoc
+// @Summary      Get notification preferences
+// @Description  Returns which notification types the authenticated user receives on which channels, their quiet hours and shift, in their timezone
+// @Tags         notifications
+// @Produce      json
+// @Success      200  {object}  models.NotificationPreferences
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /notifications/preferences [get]
+func (h *NotificationsHandler) GetPreferences(c *fiber.Ctx) error {
+	userID := c.Locals("userId").(string)
+
+	prefs, err := h.repo.FindNotificationPreferences(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("user", "id", userID)
+		}
+		slog.Error("failed to get notification preferences", "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(prefs)
+}
+
+// UpdatePreferences godoc
+// @Summary      Update notification preferences
+// @Description  Replaces the authenticated user's notification preferences. In-app notifications are always written; quiet hours, and the shift when on_shift_only is set, hold the other channels back until the quiet hours end or the shift starts.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.UpdateNotificationPreferencesInput  true  "Notification preferences"
+// @Success      200      {object}  models.NotificationPreferences
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /notifications/preferences [put]
+func (h *NotificationsHandler) UpdatePreferences(c *fiber.Ctx) error {
+	var input models.UpdateNotificationPreferencesInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+
+	userID := c.Locals("userId").(string)
+
+	prefs, err := h.repo.UpsertNotificationPreferences(c.Context(), userID, &input)
+	if err != nil {
+		slog.Error("failed to update notification preferences", "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(prefs)
+}
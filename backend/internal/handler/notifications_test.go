@@ -23,6 +23,8 @@ type mockNotificationsRepository struct {
 	markReadFunc          func(ctx context.Context, id, userID string) error
 	markAllReadFunc       func(ctx context.Context, userID string) error
 	upsertDeviceTokenFunc func(ctx context.Context, userID, token, platform string) error
+	findPreferencesFunc   func(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	upsertPreferencesFunc func(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error)
 }
 
 func (m *mockNotificationsRepository) FindByUserID(ctx context.Context, userID string) ([]*models.Notification, error) {
@@ -53,6 +55,20 @@ func (m *mockNotificationsRepository) UpsertDeviceToken(ctx context.Context, use
 	return nil
 }
 
+func (m *mockNotificationsRepository) FindNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	if m.findPreferencesFunc != nil {
+		return m.findPreferencesFunc(ctx, userID)
+	}
+	return &models.NotificationPreferences{}, nil
+}
+
+func (m *mockNotificationsRepository) UpsertNotificationPreferences(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error) {
+	if m.upsertPreferencesFunc != nil {
+		return m.upsertPreferencesFunc(ctx, userID, input)
+	}
+	return &models.NotificationPreferences{UpdateNotificationPreferencesInput: *input}, nil
+}
+
 // notifApp builds a test Fiber app with the userId local pre-set (simulating
 // the Clerk auth middleware) and all notification routes registered.
 func notifApp(h *NotificationsHandler) *fiber.App {
@@ -62,6 +78,8 @@ func notifApp(h *NotificationsHandler) *fiber.App {
 		return c.Next()
 	})
 	app.Get("/notifications", h.ListNotifications)
+	app.Get("/notifications/preferences", h.GetPreferences)
+	app.Put("/notifications/preferences", h.UpdatePreferences)
 	app.Put("/notifications/read-all", h.MarkAllRead)
 	app.Put("/notifications/:id/read", h.MarkRead)
 	app.Post("/device-tokens", h.RegisterDeviceToken)
@@ -416,3 +434,115 @@ func TestNotificationsHandler_RegisterDeviceToken(t *testing.T) {
 		assert.Equal(t, 500, resp.StatusCode)
 	})
 }
+
+func TestNotificationsHandler_GetPreferences(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns 200 with the user's preferences", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockNotificationsRepository{
+			findPreferencesFunc: func(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+				assert.Equal(t, testUserID, userID)
+				return &models.NotificationPreferences{
+					UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+						QuietHours: &models.DailyWindow{Start: "22:00", End: "07:00"},
+					},
+					Timezone: "America/New_York",
+				}, nil
+			},
+		}
+
+		resp, err := notifApp(NewNotificationsHandler(mock)).Test(httptest.NewRequest("GET", "/notifications/preferences", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), `"quiet_hours":{"start":"22:00","end":"07:00"}`)
+		assert.Contains(t, string(body), `"timezone":"America/New_York"`)
+	})
+
+	t.Run("returns 404 for an unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockNotificationsRepository{
+			findPreferencesFunc: func(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}
+
+		resp, err := notifApp(NewNotificationsHandler(mock)).Test(httptest.NewRequest("GET", "/notifications/preferences", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestNotificationsHandler_UpdatePreferences(t *testing.T) {
+	t.Parallel()
+
+	put := func(t *testing.T, mock *mockNotificationsRepository, body string) int {
+		t.Helper()
+
+		req := httptest.NewRequest("PUT", "/notifications/preferences", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := notifApp(NewNotificationsHandler(mock)).Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("returns 200 and stores the preferences", func(t *testing.T) {
+		t.Parallel()
+
+		var captured *models.UpdateNotificationPreferencesInput
+		mock := &mockNotificationsRepository{
+			upsertPreferencesFunc: func(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error) {
+				assert.Equal(t, testUserID, userID)
+				captured = input
+				return &models.NotificationPreferences{UpdateNotificationPreferencesInput: *input}, nil
+			},
+		}
+
+		status := put(t, mock, `{
+			"channels": {"task_assigned": ["push", "email"], "mentioned": []},
+			"quiet_hours": {"start": "22:00", "end": "07:00"},
+			"on_shift_only": true,
+			"shift": {"start": "08:00", "end": "16:00"}
+		}`)
+		assert.Equal(t, 200, status)
+		require.NotNil(t, captured)
+		assert.Equal(t, []models.NotificationChannel{models.ChannelPush, models.ChannelEmail}, captured.Channels[models.TypeTaskAssigned])
+		assert.Empty(t, captured.Channels[models.TypeMentioned])
+		assert.Equal(t, "16:00", captured.Shift.End)
+	})
+
+	cases := map[string]string{
+		"unknown channel":             `{"channels": {"task_assigned": ["pager"]}}`,
+		"unknown notification type":   `{"channels": {"lunch_ready": ["push"]}}`,
+		"malformed quiet hours":       `{"quiet_hours": {"start": "10pm", "end": "07:00"}}`,
+		"on shift only without shift": `{"on_shift_only": true}`,
+	}
+	for name, body := range cases {
+		t.Run("returns 400 for "+name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockNotificationsRepository{
+				upsertPreferencesFunc: func(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error) {
+					t.Fatal("invalid preferences were stored")
+					return nil, nil
+				},
+			}
+			assert.Equal(t, 400, put(t, mock, body))
+		})
+	}
+
+	t.Run("returns 500 when the repository fails", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockNotificationsRepository{
+			upsertPreferencesFunc: func(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error) {
+				return nil, errors.New("db down")
+			},
+		}
+		assert.Equal(t, 500, put(t, mock, `{}`))
+	})
+}
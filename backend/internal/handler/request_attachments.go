@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxAttachmentSize caps a single request attachment at 10 MiB.
+const maxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes maps the accepted attachment content types to the
+// extension used in their S3 key.
+var allowedAttachmentTypes = map[string]string{
+	"image/jpeg":      "jpg",
+	"image/png":       "png",
+	"image/webp":      "webp",
+	"image/heic":      "heic",
+	"application/pdf": "pdf",
+}
+
+type RequestAttachmentsHandler struct {
+	repo      storage.RequestAttachmentsRepository
+	S3Storage storage.S3Storage
+}
+
+func NewRequestAttachmentsHandler(repo storage.RequestAttachmentsRepository, s3Storage storage.S3Storage) *RequestAttachmentsHandler {
+	return &RequestAttachmentsHandler{repo: repo, S3Storage: s3Storage}
+}
+
+// CreateRequestAttachment godoc
+// @Summary      Start a request attachment upload
+// @Description  Records a pending attachment and returns a presigned S3 upload URL. PUT the file to upload_url with the declared Content-Type, then call the confirm endpoint.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                               true  "Request ID (UUID)"
+// @Param        request  body      models.CreateRequestAttachmentInput  true  "File to upload"
+// @Success      201      {object}  models.RequestAttachmentUpload
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      404      {object}  errs.HTTPError
+// @Failure      422      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/attachments [post]
+func (h *RequestAttachmentsHandler) CreateRequestAttachment(c *fiber.Ctx) error {
+	requestID := c.Params("id")
+	if !validUUID(requestID) {
+		return errs.BadRequest("request id is not a valid UUID")
+	}
+
+	var req models.CreateRequestAttachmentInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if err := validateAttachment(req.ContentType, req.SizeBytes); err != nil {
+		return err
+	}
+
+	var uploadedBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		uploadedBy = &uid
+	}
+
+	id := uuid.New().String()
+	attachment, err := h.repo.InsertRequestAttachment(c.Context(), &models.RequestAttachment{
+		ID:          id,
+		RequestID:   requestID,
+		Key:         fmt.Sprintf("request-attachments/%s/%s.%s", requestID, id, allowedAttachmentTypes[req.ContentType]),
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		SizeBytes:   req.SizeBytes,
+		UploadedBy:  uploadedBy,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("Request", "id", requestID)
+		}
+		slog.Error("failed to create request attachment", "err", err, "request_id", requestID)
+		return errs.InternalServerError()
+	}
+
+	uploadURL, err := h.S3Storage.GeneratePresignedUploadURL(c.Context(), models.PresignedURLInput{
+		Key:           attachment.Key,
+		Expiration:    expirationTime,
+		ContentType:   attachment.ContentType,
+		ContentLength: attachment.SizeBytes,
+	})
+	if err != nil {
+		slog.Error("failed to generate attachment upload url", "err", err, "key", attachment.Key)
+		return errs.InternalServerError()
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&models.RequestAttachmentUpload{
+		Attachment: attachment,
+		UploadURL:  uploadURL,
+	})
+}
+
+// ConfirmRequestAttachment godoc
+// @Summary      Confirm a request attachment upload
+// @Description  Checks the uploaded object in S3 and marks the attachment as added. Uploads with a disallowed content type or size are deleted and rejected with 422.
+// @Tags         requests
+// @Produce      json
+// @Param        id            path      string  true  "Request ID (UUID)"
+// @Param        attachmentId  path      string  true  "Attachment ID (UUID)"
+// @Success      200           {object}  models.RequestAttachment
+// @Failure      400           {object}  errs.HTTPError
+// @Failure      404           {object}  errs.HTTPError
+// @Failure      422           {object}  errs.HTTPError
+// @Failure      500           {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/attachments/{attachmentId}/confirm [post]
+func (h *RequestAttachmentsHandler) ConfirmRequestAttachment(c *fiber.Ctx) error {
+	requestID, attachmentID, err := attachmentParams(c)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.repo.FindRequestAttachment(c.Context(), requestID, attachmentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("attachment", "id", attachmentID)
+		}
+		slog.Error("failed to find request attachment", "err", err, "attachment_id", attachmentID)
+		return errs.InternalServerError()
+	}
+	if attachment.Status == models.AttachmentConfirmed {
+		return c.JSON(attachment)
+	}
+
+	info, err := h.S3Storage.HeadFile(c.Context(), attachment.Key)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInStorage) {
+			return errs.BadRequest("attachment has not been uploaded yet")
+		}
+		slog.Error("failed to inspect attachment upload", "err", err, "key", attachment.Key)
+		return errs.InternalServerError()
+	}
+
+	contentType, _, _ := mime.ParseMediaType(info.ContentType)
+	if contentType != attachment.ContentType {
+		h.discard(c, attachment)
+		return errs.InvalidRequestData(map[string]string{
+			"content_type": fmt.Sprintf("uploaded file is %q, expected %q", info.ContentType, attachment.ContentType),
+		})
+	}
+	if err := validateAttachment(contentType, info.ContentLength); err != nil {
+		h.discard(c, attachment)
+		return err
+	}
+	info.ContentType = contentType
+
+	confirmed, err := h.repo.ConfirmRequestAttachment(c.Context(), requestID, attachmentID, info)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("attachment", "id", attachmentID)
+		}
+		slog.Error("failed to confirm request attachment", "err", err, "attachment_id", attachmentID)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(confirmed)
+}
+
+// GetRequestAttachments godoc
+// @Summary      List request attachments
+// @Description  Returns the request's confirmed attachments, oldest first, each with a short-lived presigned download URL
+// @Tags         requests
+// @Produce      json
+// @Param        id   path      string  true  "Request ID (UUID)"
+// @Success      200  {array}   models.RequestAttachment
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/attachments [get]
+func (h *RequestAttachmentsHandler) GetRequestAttachments(c *fiber.Ctx) error {
+	requestID := c.Params("id")
+	if !validUUID(requestID) {
+		return errs.BadRequest("request id is not a valid UUID")
+	}
+
+	attachments, err := h.repo.FindConfirmedRequestAttachments(c.Context(), requestID)
+	if err != nil {
+		slog.Error("failed to list request attachments", "err", err, "request_id", requestID)
+		return errs.InternalServerError()
+	}
+
+	for _, a := range attachments {
+		url, err := h.S3Storage.GeneratePresignedGetURL(c.Context(), models.PresignedURLInput{
+			Key:        a.Key,
+			Expiration: expirationTime,
+		})
+		if err != nil {
+			slog.Error("failed to generate attachment get url", "err", err, "key", a.Key)
+			return errs.InternalServerError()
+		}
+		a.URL = &url
+	}
+
+	return c.JSON(attachments)
+}
+
+// DeleteRequestAttachment godoc
+// @Summary      Delete a request attachment
+// @Description  Removes the attachment and its file from S3
+// @Tags         requests
+// @Param        id            path  string  true  "Request ID (UUID)"
+// @Param        attachmentId  path  string  true  "Attachment ID (UUID)"
+// @Success      204
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/attachments/{attachmentId} [delete]
+func (h *RequestAttachmentsHandler) DeleteRequestAttachment(c *fiber.Ctx) error {
+	requestID, attachmentID, err := attachmentParams(c)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.repo.FindRequestAttachment(c.Context(), requestID, attachmentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("attachment", "id", attachmentID)
+		}
+		slog.Error("failed to find request attachment", "err", err, "attachment_id", attachmentID)
+		return errs.InternalServerError()
+	}
+
+	if err := h.S3Storage.DeleteFile(c.Context(), attachment.Key); err != nil {
+		slog.Error("failed to delete attachment file", "err", err, "key", attachment.Key)
+		return errs.InternalServerError()
+	}
+
+	if err := h.repo.DeleteRequestAttachment(c.Context(), requestID, attachmentID); err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("attachment", "id", attachmentID)
+		}
+		slog.Error("failed to delete request attachment", "err", err, "attachment_id", attachmentID)
+		return errs.InternalServerError()
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// discard removes a rejected upload so it does not linger in S3 or in the
+// pending list. Failures are only logged; the client gets the validation error.
+func (h *RequestAttachmentsHandler) discard(c *fiber.Ctx, attachment *models.RequestAttachment) {
+	if err := h.S3Storage.DeleteFile(c.Context(), attachment.Key); err != nil {
+		slog.Error("failed to delete rejected attachment file", "err", err, "key", attachment.Key)
+	}
+	if err := h.repo.DeleteRequestAttachment(c.Context(), attachment.RequestID, attachment.ID); err != nil {
+		slog.Error("failed to delete rejected attachment", "err", err, "attachment_id", attachment.ID)
+	}
+}
+
+func attachmentParams(c *fiber.Ctx) (string, string, error) {
+	requestID := c.Params("id")
+	attachmentID := c.Params("attachmentId")
+	if !validUUID(requestID) {
+		return "", "", errs.BadRequest("request id is not a valid UUID")
+	}
+	if !validUUID(attachmentID) {
+		return "", "", errs.BadRequest("attachment id is not a valid UUID")
+	}
+	return requestID, attachmentID, nil
+}
+
+func validateAttachment(contentType string, size int64) error {
+	if _, ok := allowedAttachmentTypes[contentType]; !ok {
+		return errs.InvalidRequestData(map[string]string{
+			"content_type": fmt.Sprintf("%q is not an allowed attachment type", contentType),
+		})
+	}
+	if size <= 0 || size > maxAttachmentSize {
+		return errs.InvalidRequestData(map[string]string{
+			"size_bytes": fmt.Sprintf("must be between 1 and %d bytes", maxAttachmentSize),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const attachmentRequestID = "530e8400-e458-41d4-a716-446655440000"
+const attachmentID = "550e8400-e29b-41d4-a716-446655440000"
+
+type mockRequestAttachmentsRepository struct {
+	insertFunc  func(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error)
+	findFunc    func(ctx context.Context, requestID, id string) (*models.RequestAttachment, error)
+	listFunc    func(ctx context.Context, requestID string) ([]*models.RequestAttachment, error)
+	confirmFunc func(ctx context.Context, requestID, id string, info *models.S3ObjectInfo) (*models.RequestAttachment, error)
+	deleted     []string
+}
+
+func (m *mockRequestAttachmentsRepository) InsertRequestAttachment(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error) {
+	return m.insertFunc(ctx, attachment)
+}
+
+func (m *mockRequestAttachmentsRepository) FindRequestAttachment(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+	return m.findFunc(ctx, requestID, id)
+}
+
+func (m *mockRequestAttachmentsRepository) FindConfirmedRequestAttachments(ctx context.Context, requestID string) ([]*models.RequestAttachment, error) {
+	return m.listFunc(ctx, requestID)
+}
+
+func (m *mockRequestAttachmentsRepository) ConfirmRequestAttachment(ctx context.Context, requestID, id string, info *models.S3ObjectInfo) (*models.RequestAttachment, error) {
+	return m.confirmFunc(ctx, requestID, id, info)
+}
+
+func (m *mockRequestAttachmentsRepository) DeleteRequestAttachment(ctx context.Context, requestID, id string) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func requestAttachmentsApp(repo *mockRequestAttachmentsRepository, s3 *mockS3Storage) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	h := NewRequestAttachmentsHandler(repo, s3)
+	app.Get("/request/:id/attachments", h.GetRequestAttachments)
+	app.Post("/request/:id/attachments", h.CreateRequestAttachment)
+	app.Post("/request/:id/attachments/:attachmentId/confirm", h.ConfirmRequestAttachment)
+	app.Delete("/request/:id/attachments/:attachmentId", h.DeleteRequestAttachment)
+	return app
+}
+
+func pendingAttachment() *models.RequestAttachment {
+	return &models.RequestAttachment{
+		ID:          attachmentID,
+		RequestID:   attachmentRequestID,
+		Key:         "request-attachments/" + attachmentRequestID + "/" + attachmentID + ".jpg",
+		FileName:    "broken-lamp.jpg",
+		ContentType: "image/jpeg",
+		SizeBytes:   2048,
+		Status:      models.AttachmentPending,
+	}
+}
+
+func TestRequestAttachmentsHandler_CreateRequestAttachment(t *testing.T) {
+	t.Parallel()
+
+	post := func(app *fiber.App, body string) *http.Response {
+		req := httptest.NewRequest("POST", "/request/"+attachmentRequestID+"/attachments", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("returns 201 with a presigned upload url", func(t *testing.T) {
+		t.Parallel()
+
+		var presigned models.PresignedURLInput
+		app := requestAttachmentsApp(&mockRequestAttachmentsRepository{
+			insertFunc: func(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error) {
+				assert.Equal(t, attachmentRequestID, attachment.RequestID)
+				assert.Equal(t, "request-attachments/"+attachmentRequestID+"/"+attachment.ID+".png", attachment.Key)
+				attachment.Status = models.AttachmentPending
+				return attachment, nil
+			},
+		}, &mockS3Storage{
+			uploadURLFunc: func(ctx context.Context, in models.PresignedURLInput) (string, error) {
+				presigned = in
+				return "https://upload.example/" + in.Key, nil
+			},
+		})
+
+		resp := post(app, `{"file_name":"receipt.png","content_type":"image/png","size_bytes":4096}`)
+		require.Equal(t, 201, resp.StatusCode)
+
+		var upload models.RequestAttachmentUpload
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&upload))
+		assert.Equal(t, "https://upload.example/"+upload.Attachment.Key, upload.UploadURL)
+		assert.Equal(t, "image/png", presigned.ContentType)
+		assert.Equal(t, int64(4096), presigned.ContentLength)
+	})
+
+	t.Run("returns 422 on disallowed content type or size", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestAttachmentsApp(&mockRequestAttachmentsRepository{}, &mockS3Storage{})
+		assert.Equal(t, 422, post(app, `{"file_name":"run.sh","content_type":"text/x-shellscript","size_bytes":10}`).StatusCode)
+		assert.Equal(t, 422, post(app, `{"file_name":"huge.jpg","content_type":"image/jpeg","size_bytes":104857600}`).StatusCode)
+	})
+
+	t.Run("returns 404 when request does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestAttachmentsApp(&mockRequestAttachmentsRepository{
+			insertFunc: func(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}, &mockS3Storage{})
+
+		assert.Equal(t, 404, post(app, `{"file_name":"a.jpg","content_type":"image/jpeg","size_bytes":10}`).StatusCode)
+	})
+}
+
+func TestRequestAttachmentsHandler_ConfirmRequestAttachment(t *testing.T) {
+	t.Parallel()
+
+	confirmURL := "/request/" + attachmentRequestID + "/attachments/" + attachmentID + "/confirm"
+
+	t.Run("confirms with the size found in S3", func(t *testing.T) {
+		t.Parallel()
+
+		var stored *models.S3ObjectInfo
+		app := requestAttachmentsApp(&mockRequestAttachmentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+				return pendingAttachment(), nil
+			},
+			confirmFunc: func(ctx context.Context, requestID, id string, info *models.S3ObjectInfo) (*models.RequestAttachment, error) {
+				stored = info
+				a := pendingAttachment()
+				a.Status = models.AttachmentConfirmed
+				return a, nil
+			},
+		}, &mockS3Storage{
+			headFileFunc: func(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+				return &models.S3ObjectInfo{ContentType: "image/jpeg", ContentLength: 1999}, nil
+			},
+		})
+
+		resp, err := app.Test(httptest.NewRequest("POST", confirmURL, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		require.NotNil(t, stored)
+		assert.Equal(t, int64(1999), stored.ContentLength)
+	})
+
+	t.Run("returns 400 when nothing was uploaded", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestAttachmentsApp(&mockRequestAttachmentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+				return pendingAttachment(), nil
+			},
+		}, &mockS3Storage{})
+
+		resp, err := app.Test(httptest.NewRequest("POST", confirmURL, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("rejects and discards a mismatched upload", func(t *testing.T) {
+		t.Parallel()
+
+		var deletedKeys []string
+		repo := &mockRequestAttachmentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+				return pendingAttachment(), nil
+			},
+		}
+		app := requestAttachmentsApp(repo, &mockS3Storage{
+			headFileFunc: func(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+				return &models.S3ObjectInfo{ContentType: "text/html", ContentLength: 2048}, nil
+			},
+			deleteFileFunc: func(ctx context.Context, key string) error {
+				deletedKeys = append(deletedKeys, key)
+				return nil
+			},
+		})
+
+		resp, err := app.Test(httptest.NewRequest("POST", confirmURL, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 422, resp.StatusCode)
+		assert.Equal(t, []string{pendingAttachment().Key}, deletedKeys)
+		assert.Equal(t, []string{attachmentID}, repo.deleted)
+	})
+
+	t.Run("returns 404 when attachment does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestAttachmentsApp(&mockRequestAttachmentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}, &mockS3Storage{})
+
+		resp, err := app.Test(httptest.NewRequest("POST", confirmURL, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestRequestAttachmentsHandler_GetRequestAttachments(t *testing.T) {
+	t.Parallel()
+
+	app := requestAttachmentsApp(&mockRequestAttachmentsRepository{
+		listFunc: func(ctx context.Context, requestID string) ([]*models.RequestAttachment, error) {
+			return []*models.RequestAttachment{pendingAttachment()}, nil
+		},
+	}, &mockS3Storage{})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/request/"+attachmentRequestID+"/attachments", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var attachments []models.RequestAttachment
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&attachments))
+	require.Len(t, attachments, 1)
+	require.NotNil(t, attachments[0].URL)
+	assert.Equal(t, "https://bucket.s3.amazonaws.com/"+attachments[0].Key, *attachments[0].URL)
+}
+
+func TestRequestAttachmentsHandler_DeleteRequestAttachment(t *testing.T) {
+	t.Parallel()
+
+	var deletedKey string
+	repo := &mockRequestAttachmentsRepository{
+		findFunc: func(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+			return pendingAttachment(), nil
+		},
+	}
+	app := requestAttachmentsApp(repo, &mockS3Storage{
+		deleteFileFunc: func(ctx context.Context, key string) error {
+			deletedKey = key
+			return nil
+		},
+	})
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/request/"+attachmentRequestID+"/attachments/"+attachmentID, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.StatusCode)
+	assert.Equal(t, pendingAttachment().Key, deletedKey)
+	assert.Equal(t, []string{attachmentID}, repo.deleted)
+}
+
+func TestBuildAttachmentActivity_SkipsUnconfirmed(t *testing.T) {
+	t.Parallel()
+
+	confirmedAt := time.Date(2026, 4, 23, 9, 0, 0, 0, time.UTC)
+	confirmed := pendingAttachment()
+	confirmed.ConfirmedAt = &confirmedAt
+
+	items := buildAttachmentActivity([]*models.RequestAttachment{pendingAttachment(), confirmed})
+	require.Len(t, items, 1)
+	assert.Equal(t, models.ActivityAttachmentAdded, items[0].Type)
+	assert.Equal(t, "broken-lamp.jpg", *items[0].NewValue)
+	assert.True(t, confirmedAt.Equal(items[0].Timestamp))
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const msgMentioned = "You were mentioned on a request"
+
+// mentionPattern matches @handles such as @maria or @maria.lopez that are not
+// part of a larger word (so email addresses are ignored).
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}][\p{L}\p{N}._-]*)`)
+
+var mentionSeparators = strings.NewReplacer(".", " ", "_", " ", "-", " ")
+
+type RequestCommentsHandler struct {
+	repo               storage.RequestCommentsRepository
+	usersRepo          storage.UsersRepository
+	NotificationSender NotificationSender
+}
+
+func NewRequestCommentsHandler(repo storage.RequestCommentsRepository, usersRepo storage.UsersRepository, notificationSender NotificationSender) *RequestCommentsHandler {
+	return &RequestCommentsHandler{repo: repo, usersRepo: usersRepo, NotificationSender: notificationSender}
+}
+
+// GetRequestComments godoc
+// @Summary      List request comments
+// @Description  Returns a cursor-paginated list of the request's comments, oldest first. Replies carry parent_id; deleted comments are kept with an empty body.
+// @Tags         requests
+// @Produce      json
+// @Param        id      path   string  true   "Request ID (UUID)"
+// @Param        cursor  query  string  false  "Opaque cursor for the next page"
+// @Param        limit   query  int     false  "Page size (default 20, max 100)"
+// @Success      200  {object}  utils.CursorPage[models.RequestComment]
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/comments [get]
+func (h *RequestCommentsHandler) GetRequestComments(c *fiber.Ctx) error {
+	requestID := c.Params("id")
+	if !validUUID(requestID) {
+		return errs.BadRequest("request id is not a valid UUID")
+	}
+
+	limit := c.QueryInt("limit", utils.DefaultPageLimit)
+	if limit < 1 || limit > 100 {
+		limit = utils.DefaultPageLimit
+	}
+
+	cursorCreatedAt, cursorID, err := parseCommentCursor(c.Query("cursor"))
+	if err != nil {
+		return errs.BadRequest("invalid cursor")
+	}
+
+	comments, err := h.repo.FindRequestComments(c.Context(), requestID, cursorCreatedAt, cursorID, limit+1)
+	if err != nil {
+		slog.Error("failed to list request comments", "err", err, "request_id", requestID)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(utils.BuildCursorPage(comments, limit, func(comment *models.RequestComment) string {
+		return comment.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + comment.ID
+	}))
+}
+
+// CreateRequestComment godoc
+// @Summary      Comment on a request
+// @Description  Adds a comment, optionally as a reply to another comment. Users @-mentioned in the body are notified.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string                            true  "Request ID (UUID)"
+// @Param        X-Hotel-ID  header    string                            true  "Hotel ID"
+// @Param        request     body      models.CreateRequestCommentInput  true  "Comment"
+// @Success      201         {object}  models.RequestComment
+// @Failure      400         {object}  errs.HTTPError
+// @Failure      404         {object}  errs.HTTPError
+// @Failure      500         {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/comments [post]
+func (h *RequestCommentsHandler) CreateRequestComment(c *fiber.Ctx) error {
+	requestID := c.Params("id")
+	if !validUUID(requestID) {
+		return errs.BadRequest("request id is not a valid UUID")
+	}
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateRequestCommentInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if req.ParentID != nil {
+		if _, err := h.repo.FindRequestComment(c.Context(), requestID, *req.ParentID); err != nil {
+			if errors.Is(err, errs.ErrNotFoundInDB) {
+				return errs.NotFound("comment", "id", *req.ParentID)
+			}
+			slog.Error("failed to find parent comment", "err", err, "comment_id", *req.ParentID)
+			return errs.InternalServerError()
+		}
+	}
+
+	var authorID *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		authorID = &uid
+	}
+
+	comment, err := h.repo.InsertRequestComment(c.Context(), &models.RequestComment{
+		ID:        uuid.New().String(),
+		RequestID: requestID,
+		HotelID:   hotelID,
+		ParentID:  req.ParentID,
+		AuthorID:  authorID,
+		Body:      req.Body,
+		Mentions:  h.resolveMentions(c.Context(), hotelID, req.Body),
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("Request", "id", requestID)
+		}
+		slog.Error("failed to create request comment", "err", err, "request_id", requestID)
+		return errs.InternalServerError()
+	}
+
+	h.notifyMentions(c.Context(), comment, nil)
+
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
+
+// UpdateRequestComment godoc
+// @Summary      Edit a request comment
+// @Description  Replaces the body of the caller's own comment. Users newly @-mentioned by the edit are notified.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                            true  "Request ID (UUID)"
+// @Param        commentId  path      string                            true  "Comment ID (UUID)"
+// @Param        request    body      models.UpdateRequestCommentInput  true  "New comment body"
+// @Success      200        {object}  models.RequestComment
+// @Failure      400        {object}  errs.HTTPError
+// @Failure      403        {object}  errs.HTTPError
+// @Failure      404        {object}  errs.HTTPError
+// @Failure      500        {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/comments/{commentId} [put]
+func (h *RequestCommentsHandler) UpdateRequestComment(c *fiber.Ctx) error {
+	requestID, commentID, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateRequestCommentInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	existing, err := h.findOwnComment(c, requestID, commentID)
+	if err != nil {
+		return err
+	}
+
+	comment, err := h.repo.UpdateRequestComment(c.Context(), requestID, commentID, req.Body,
+		h.resolveMentions(c.Context(), existing.HotelID, req.Body))
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("comment", "id", commentID)
+		}
+		slog.Error("failed to update request comment", "err", err, "comment_id", commentID)
+		return errs.InternalServerError()
+	}
+
+	h.notifyMentions(c.Context(), comment, existing.Mentions)
+
+	return c.JSON(comment)
+}
+
+// DeleteRequestComment godoc
+// @Summary      Delete a request comment
+// @Description  Soft-deletes the caller's own comment; replies to it are kept
+// @Tags         requests
+// @Param        id         path  string  true  "Request ID (UUID)"
+// @Param        commentId  path  string  true  "Comment ID (UUID)"
+// @Success      204
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      403  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/comments/{commentId} [delete]
+func (h *RequestCommentsHandler) DeleteRequestComment(c *fiber.Ctx) error {
+	requestID, commentID, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.findOwnComment(c, requestID, commentID); err != nil {
+		return err
+	}
+
+	if err := h.repo.SoftDeleteRequestComment(c.Context(), requestID, commentID); err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("comment", "id", commentID)
+		}
+		slog.Error("failed to delete request comment", "err", err, "comment_id", commentID)
+		return errs.InternalServerError()
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// findOwnComment loads a comment and checks that the caller wrote it.
+func (h *RequestCommentsHandler) findOwnComment(c *fiber.Ctx, requestID, commentID string) (*models.RequestComment, error) {
+	comment, err := h.repo.FindRequestComment(c.Context(), requestID, commentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil, errs.NotFound("comment", "id", commentID)
+		}
+		slog.Error("failed to find request comment", "err", err, "comment_id", commentID)
+		return nil, errs.InternalServerError()
+	}
+
+	userID, _ := c.Locals("userId").(string)
+	if comment.AuthorID == nil || userID == "" || *comment.AuthorID != userID {
+		return nil, errs.Forbidden()
+	}
+	return comment, nil
+}
+
+// resolveMentions maps each @handle in body to a user of the hotel. A handle
+// is matched against the users' full names with '.', '_' and '-' read as
+// spaces, and only resolves when exactly one user matches.
+func (h *RequestCommentsHandler) resolveMentions(ctx context.Context, hotelID, body string) []string {
+	userIDs := []string{}
+	for _, handle := range parseMentions(body) {
+		users, _, err := h.usersRepo.SearchUsersByHotel(ctx, hotelID, "", mentionSeparators.Replace(handle), 2)
+		if err != nil {
+			slog.Error("failed to resolve mention", "err", err, "handle", handle, "hotel_id", hotelID)
+			continue
+		}
+		if len(users) == 1 && !slices.Contains(userIDs, users[0].ID) {
+			userIDs = append(userIDs, users[0].ID)
+		}
+	}
+	return userIDs
+}
+
+// notifyMentions notifies users mentioned in comment, skipping the author and
+// anyone in alreadyNotified.
+func (h *RequestCommentsHandler) notifyMentions(ctx context.Context, comment *models.RequestComment, alreadyNotified []string) {
+	if h.NotificationSender == nil {
+		return
+	}
+
+	for _, userID := range comment.Mentions {
+		if slices.Contains(alreadyNotified, userID) || (comment.AuthorID != nil && *comment.AuthorID == userID) {
+			continue
+		}
+		if err := h.NotificationSender.Notify(ctx, userID, models.TypeMentioned, comment.RequestID, msgMentioned, comment.Body); err != nil {
+			slog.Error("failed to send mention notification", "err", err, "user_id", userID, "comment_id", comment.ID)
+		}
+	}
+}
+
+// parseMentions returns the distinct @handles in body, in order of appearance.
+func parseMentions(body string) []string {
+	var handles []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.TrimRight(m[1], "._-")
+		if handle != "" && !slices.Contains(handles, handle) {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+func parseCommentCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+	createdAt, id, ok := strings.Cut(cursor, "|")
+	if !ok || !validUUID(id) {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return t, id, nil
+}
+
+func commentParams(c *fiber.Ctx) (string, string, error) {
+	requestID := c.Params("id")
+	commentID := c.Params("commentId")
+	if !validUUID(requestID) {
+		return "", "", errs.BadRequest("request id is not a valid UUID")
+	}
+	if !validUUID(commentID) {
+		return "", "", errs.BadRequest("comment id is not a valid UUID")
+	}
+	return requestID, commentID, nil
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const commentHotelID = "org_550e8400-e29b-41d4-a716-446655440000"
+const commentRequestID = "530e8400-e458-41d4-a716-446655440000"
+const commentID = "550e8400-e29b-41d4-a716-446655440000"
+
+type mockRequestCommentsRepository struct {
+	insertFunc func(ctx context.Context, comment *models.RequestComment) (*models.RequestComment, error)
+	findFunc   func(ctx context.Context, requestID, id string) (*models.RequestComment, error)
+	listFunc   func(ctx context.Context, requestID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestComment, error)
+	updateFunc func(ctx context.Context, requestID, id, body string, mentions []string) (*models.RequestComment, error)
+	deleted    []string
+}
+
+func (m *mockRequestCommentsRepository) InsertRequestComment(ctx context.Context, comment *models.RequestComment) (*models.RequestComment, error) {
+	return m.insertFunc(ctx, comment)
+}
+
+func (m *mockRequestCommentsRepository) FindRequestComment(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+	return m.findFunc(ctx, requestID, id)
+}
+
+func (m *mockRequestCommentsRepository) FindRequestComments(ctx context.Context, requestID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestComment, error) {
+	return m.listFunc(ctx, requestID, cursorCreatedAt, cursorID, limit)
+}
+
+func (m *mockRequestCommentsRepository) FindActiveRequestComments(ctx context.Context, requestID string) ([]*models.RequestComment, error) {
+	return nil, nil
+}
+
+func (m *mockRequestCommentsRepository) UpdateRequestComment(ctx context.Context, requestID, id, body string, mentions []string) (*models.RequestComment, error) {
+	return m.updateFunc(ctx, requestID, id, body, mentions)
+}
+
+func (m *mockRequestCommentsRepository) SoftDeleteRequestComment(ctx context.Context, requestID, id string) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	users  []string
+	titles []string
+	bodies []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.users = append(n.users, userID)
+	n.titles = append(n.titles, title)
+	n.bodies = append(n.bodies, body)
+	return nil
+}
+
+// usersByName resolves searches against a fixed list of full names.
+func usersByName(names map[string]string) *mockUsersRepository {
+	return &mockUsersRepository{
+		searchUsersByHotelFunc: func(ctx context.Context, hotelID, cursor, query string, limit int) ([]*models.User, string, error) {
+			var users []*models.User
+			for id, name := range names {
+				if strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
+					users = append(users, &models.User{CreateUser: models.CreateUser{ID: id}})
+				}
+			}
+			return users, "", nil
+		},
+	}
+}
+
+func requestCommentsApp(repo *mockRequestCommentsRepository, users *mockUsersRepository, notifier *recordingNotifier, userID string) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userId", userID)
+		return c.Next()
+	})
+	h := NewRequestCommentsHandler(repo, users, notifier)
+	app.Get("/request/:id/comments", h.GetRequestComments)
+	app.Post("/request/:id/comments", h.CreateRequestComment)
+	app.Put("/request/:id/comments/:commentId", h.UpdateRequestComment)
+	app.Delete("/request/:id/comments/:commentId", h.DeleteRequestComment)
+	return app
+}
+
+func commentBy(authorID string, mentions ...string) *models.RequestComment {
+	return &models.RequestComment{
+		ID:        commentID,
+		RequestID: commentRequestID,
+		HotelID:   commentHotelID,
+		AuthorID:  &authorID,
+		Body:      "original",
+		Mentions:  mentions,
+	}
+}
+
+func sendComment(t *testing.T, app *fiber.App, method, url, body string) *http.Response {
+	req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(hotelIDHeader, commentHotelID)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestParseMentions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"maria.lopez", "bob"}, parseMentions("@maria.lopez can you ask @bob? thanks @maria.lopez."))
+	assert.Empty(t, parseMentions("email front@desk.com or write @ someone"))
+}
+
+func TestRequestCommentsHandler_CreateRequestComment(t *testing.T) {
+	t.Parallel()
+
+	url := "/request/" + commentRequestID + "/comments"
+
+	t.Run("resolves mentions and notifies them, skipping the author and ambiguous handles", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		users := usersByName(map[string]string{
+			"user_maria": "Maria Lopez",
+			"user_bob":   "Bob Stone",
+			"user_bobby": "Bobby Tables",
+			"user_me":    "Sam Reyes",
+		})
+		app := requestCommentsApp(&mockRequestCommentsRepository{
+			insertFunc: func(ctx context.Context, comment *models.RequestComment) (*models.RequestComment, error) {
+				assert.Equal(t, commentHotelID, comment.HotelID)
+				assert.Equal(t, "user_me", *comment.AuthorID)
+				return comment, nil
+			},
+		}, users, notifier, "user_me")
+
+		resp := sendComment(t, app, "POST", url, `{"body":"@maria_lopez lamp is fixed, cc @bob @sam"}`)
+		require.Equal(t, 201, resp.StatusCode)
+
+		var comment models.RequestComment
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&comment))
+		assert.Equal(t, []string{"user_maria", "user_me"}, comment.Mentions)
+		assert.Equal(t, []string{"user_maria"}, notifier.users)
+	})
+
+	t.Run("returns 404 when replying to a missing comment", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestCommentsApp(&mockRequestCommentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}, usersByName(nil), &recordingNotifier{}, "user_me")
+
+		resp := sendComment(t, app, "POST", url, `{"body":"reply","parent_id":"`+commentID+`"}`)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("returns 404 when request is not in the hotel", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestCommentsApp(&mockRequestCommentsRepository{
+			insertFunc: func(ctx context.Context, comment *models.RequestComment) (*models.RequestComment, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}, usersByName(nil), &recordingNotifier{}, "user_me")
+
+		assert.Equal(t, 404, sendComment(t, app, "POST", url, `{"body":"hello"}`).StatusCode)
+	})
+
+	t.Run("returns 400 on blank body", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestCommentsApp(&mockRequestCommentsRepository{}, usersByName(nil), &recordingNotifier{}, "user_me")
+		assert.Equal(t, 400, sendComment(t, app, "POST", url, `{"body":"   "}`).StatusCode)
+	})
+}
+
+func TestRequestCommentsHandler_UpdateRequestComment(t *testing.T) {
+	t.Parallel()
+
+	url := "/request/" + commentRequestID + "/comments/" + commentID
+
+	t.Run("notifies only newly mentioned users", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := &recordingNotifier{}
+		app := requestCommentsApp(&mockRequestCommentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+				return commentBy("user_me", "user_maria"), nil
+			},
+			updateFunc: func(ctx context.Context, requestID, id, body string, mentions []string) (*models.RequestComment, error) {
+				c := commentBy("user_me", mentions...)
+				c.Body = body
+				return c, nil
+			},
+		}, usersByName(map[string]string{"user_maria": "Maria Lopez", "user_bob": "Bob Stone"}), notifier, "user_me")
+
+		resp := sendComment(t, app, "PUT", url, `{"body":"@maria and @bob please check"}`)
+		require.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, []string{"user_bob"}, notifier.users)
+	})
+
+	t.Run("returns 403 when editing someone else's comment", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestCommentsApp(&mockRequestCommentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+				return commentBy("user_other"), nil
+			},
+		}, usersByName(nil), &recordingNotifier{}, "user_me")
+
+		assert.Equal(t, 403, sendComment(t, app, "PUT", url, `{"body":"changed"}`).StatusCode)
+	})
+}
+
+func TestRequestCommentsHandler_DeleteRequestComment(t *testing.T) {
+	t.Parallel()
+
+	url := "/request/" + commentRequestID + "/comments/" + commentID
+
+	t.Run("soft-deletes the caller's comment", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestCommentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+				return commentBy("user_me"), nil
+			},
+		}
+		app := requestCommentsApp(repo, usersByName(nil), &recordingNotifier{}, "user_me")
+
+		assert.Equal(t, 204, sendComment(t, app, "DELETE", url, "").StatusCode)
+		assert.Equal(t, []string{commentID}, repo.deleted)
+	})
+
+	t.Run("returns 404 for an already deleted comment", func(t *testing.T) {
+		t.Parallel()
+
+		app := requestCommentsApp(&mockRequestCommentsRepository{
+			findFunc: func(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}, usersByName(nil), &recordingNotifier{}, "user_me")
+
+		assert.Equal(t, 404, sendComment(t, app, "DELETE", url, "").StatusCode)
+	})
+}
+
+func TestRequestCommentsHandler_GetRequestComments(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 4, 24, 9, 0, 0, 0, time.UTC)
+	var gotCursorAt time.Time
+	var gotCursorID string
+	app := requestCommentsApp(&mockRequestCommentsRepository{
+		listFunc: func(ctx context.Context, requestID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestComment, error) {
+			gotCursorAt, gotCursorID = cursorCreatedAt, cursorID
+			assert.Equal(t, 2, limit)
+			first := commentBy("user_me")
+			first.CreatedAt = created
+			second := commentBy("user_me")
+			second.CreatedAt = created.Add(time.Minute)
+			return []*models.RequestComment{first, second}, nil
+		},
+	}, usersByName(nil), &recordingNotifier{}, "user_me")
+
+	cursor := created.Add(-time.Hour).Format(time.RFC3339Nano) + "|" + commentID
+	resp, err := app.Test(httptest.NewRequest("GET", "/request/"+commentRequestID+"/comments?limit=1&cursor="+cursor, nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var page utils.CursorPage[models.RequestComment]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	assert.Len(t, page.Items, 1)
+	assert.True(t, page.HasMore)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, created.Format(time.RFC3339Nano)+"|"+commentID, *page.NextCursor)
+	assert.True(t, created.Add(-time.Hour).Equal(gotCursorAt))
+	assert.Equal(t, commentID, gotCursorID)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/request/"+commentRequestID+"/comments?cursor=garbage", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
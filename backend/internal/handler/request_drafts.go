@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultDraftAccuracyWindow is how far back GET /requests/drafts/accuracy
+// looks when no since is given.
+const defaultDraftAccuracyWindow = 30 * 24 * time.Hour
+
+// GetRequestDrafts godoc
+// @Summary      List drafts awaiting review
+// @Description  Returns the hotel's AI-generated requests held for review, oldest first, with the raw text, confidence and warning each was generated with.
+// @Tags         requests
+// @Produce      json
+// @Param        X-Hotel-ID  header  string  true   "Hotel ID"
+// @Param        cursor      query   string  false  "Opaque cursor for the next page"
+// @Param        limit       query   int     false  "Page size (default 20, max 100)"
+// @Success      200  {object}  utils.CursorPage[models.RequestDraft]
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/drafts [get]
+func (r *RequestsHandler) GetRequestDrafts(c *fiber.Ctx) error {
+	if r.DraftRepository == nil {
+		return errDraftsUnavailable()
+	}
+
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", utils.DefaultPageLimit)
+	if limit < 1 || limit > 100 {
+		limit = utils.DefaultPageLimit
+	}
+
+	cursorCreatedAt, cursorID, err := parseCommentCursor(c.Query("cursor"))
+	if err != nil {
+		return errs.BadRequest("invalid cursor")
+	}
+
+	drafts, err := r.DraftRepository.FindRequestDrafts(c.Context(), hotelID, cursorCreatedAt, cursorID, limit+1)
+	if err != nil {
+		slog.Error("failed to list request drafts", "err", err, "hotelID", hotelID)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(utils.BuildCursorPage(drafts, limit, func(draft *models.RequestDraft) string {
+		return draft.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + draft.Request.ID
+	}))
+}
+
+// UpdateRequestDraft godoc
+// @Summary      Edit a draft
+// @Description  Changes fields of a draft before it is approved. The status cannot be changed here; approve or reject the draft instead.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        id          path    string                     true   "Request ID (UUID)"
+// @Param        X-Hotel-ID  header  string                     true   "Hotel ID"
+// @Param        If-Match    header  string                     false  "ETag of the draft; the edit fails with 412 if it changed since"
+// @Param        request     body    models.RequestUpdateInput  true   "Fields to update"
+// @Success      200  {object}  models.Request
+// @Header       200  {string}  ETag  "Version of the updated draft"
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      409  {object}  errs.HTTPError  "expected_version is stale"
+// @Failure      412  {object}  errs.HTTPError  "If-Match is stale"
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/drafts/{id} [put]
+func (r *RequestsHandler) UpdateRequestDraft(c *fiber.Ctx) error {
+	var input models.RequestUpdateInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+	if input.Status != nil {
+		return errs.BadRequest("status: a draft's status changes only by approving or rejecting it")
+	}
+
+	draft, err := r.findHotelDraft(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.updateDraft(c, draft, &input, nil)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, requestETag(res.RequestVersion))
+	return c.JSON(res)
+}
+
+// ApproveRequestDraft godoc
+// @Summary      Approve a draft
+// @Description  Publishes a draft to the feed, as pending unless the body sets status to in progress, applying any other fields in the body on the way.
+// @Description  Fields the reviewer changed from what was generated are recorded as corrections for accuracy reporting.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        id          path    string                          true   "Request ID (UUID)"
+// @Param        X-Hotel-ID  header  string                          true   "Hotel ID"
+// @Param        If-Match    header  string                          false  "ETag of the draft; approval fails with 412 if it changed since"
+// @Param        request     body    models.ApproveRequestDraftInput false  "Last-minute changes"
+// @Success      200  {object}  models.Request
+// @Header       200  {string}  ETag  "Version of the approved request"
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      409  {object}  errs.HTTPError  "expected_version is stale"
+// @Failure      412  {object}  errs.HTTPError  "If-Match is stale"
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/drafts/{id}/approve [post]
+func (r *RequestsHandler) ApproveRequestDraft(c *fiber.Ctx) error {
+	var input models.ApproveRequestDraftInput
+	if len(c.Body()) > 0 {
+		if err := httpx.BindAndValidate(c, &input); err != nil {
+			return err
+		}
+	}
+
+	status := string(models.StatusPending)
+	if input.Status != nil {
+		status = *input.Status
+	}
+	if status != string(models.StatusPending) && status != string(models.StatusInProgress) {
+		return errs.BadRequest(fmt.Sprintf("status: an approved draft must be %q or %q", models.StatusPending, models.StatusInProgress))
+	}
+	input.Status = &status
+
+	draft, err := r.findHotelDraft(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.updateDraft(c, draft, &input.RequestUpdateInput, &models.DraftReview{Action: models.DraftApproved})
+	if err != nil {
+		return err
+	}
+
+	if r.NotificationSender != nil && res.UserID != nil {
+		if err := r.NotificationSender.Notify(c.Context(), *res.UserID, models.TypeTaskAssigned, res.ID, models.TaskAssignedTitle, res.Name); err != nil {
+			slog.Error("failed to send task assigned notification", "err", err)
+		}
+	}
+	r.publishRequestEvent(c.Context(), models.RequestEventCreated, res.HotelID, res.ID)
+
+	c.Set(fiber.HeaderETag, requestETag(res.RequestVersion))
+	return c.JSON(res)
+}
+
+// RejectRequestDraft godoc
+// @Summary      Reject a draft
+// @Description  Archives a draft without it ever reaching the feed.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        id          path    string                         true   "Request ID (UUID)"
+// @Param        X-Hotel-ID  header  string                         true   "Hotel ID"
+// @Param        request     body    models.RejectRequestDraftInput false  "Why the draft was rejected"
+// @Success      200  {object}  models.Request
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      409  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/drafts/{id}/reject [post]
+func (r *RequestsHandler) RejectRequestDraft(c *fiber.Ctx) error {
+	var input models.RejectRequestDraftInput
+	if len(c.Body()) > 0 {
+		if err := httpx.BindAndValidate(c, &input); err != nil {
+			return err
+		}
+	}
+
+	draft, err := r.findHotelDraft(c)
+	if err != nil {
+		return err
+	}
+
+	status := string(models.StatusArchived)
+	res, err := r.updateDraft(c, draft, &models.RequestUpdateInput{Status: &status}, &models.DraftReview{
+		Action: models.DraftRejected,
+		Reason: input.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(res)
+}
+
+// GetDraftAccuracy godoc
+// @Summary      Extraction accuracy of reviewed drafts
+// @Description  Reports how many drafts were approved or rejected since the given time and, per field, how often reviewers left the generated value unchanged.
+// @Tags         requests
+// @Produce      json
+// @Param        X-Hotel-ID  header  string  true   "Hotel ID"
+// @Param        since       query   string  false  "RFC 3339 start of the window (default 30 days ago)"
+// @Success      200  {object}  models.DraftAccuracy
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/drafts/accuracy [get]
+func (r *RequestsHandler) GetDraftAccuracy(c *fiber.Ctx) error {
+	if r.DraftRepository == nil {
+		return errDraftsUnavailable()
+	}
+
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-defaultDraftAccuracyWindow)
+	if raw := c.Query("since"); raw != "" {
+		if since, err = time.Parse(time.RFC3339, raw); err != nil {
+			return errs.BadRequest("since must be an RFC 3339 timestamp")
+		}
+	}
+
+	accuracy, err := r.DraftRepository.DraftAccuracy(c.Context(), hotelID, since)
+	if err != nil {
+		slog.Error("failed to compute draft accuracy", "err", err, "hotelID", hotelID)
+		return errs.InternalServerError()
+	}
+	return c.JSON(accuracy)
+}
+
+// findHotelDraft loads the draft named by the id path parameter. Drafts of
+// other hotels and requests that are no longer drafts are reported as 404.
+func (r *RequestsHandler) findHotelDraft(c *fiber.Ctx) (*models.RequestDraft, error) {
+	if r.DraftRepository == nil {
+		return nil, errDraftsUnavailable()
+	}
+
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return nil, err
+	}
+
+	id := c.Params("id")
+	if !validUUID(id) {
+		return nil, errs.BadRequest("request id is not a valid UUID")
+	}
+
+	draft, err := r.DraftRepository.FindRequestDraft(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil, errs.NotFound("Draft", "id", id)
+		}
+		slog.Error("failed to find request draft", "err", err, "requestID", id)
+		return nil, errs.InternalServerError()
+	}
+	if draft.Request.HotelID != hotelID {
+		return nil, errs.NotFound("Draft", "id", id)
+	}
+	return draft, nil
+}
+
+// updateDraft writes a new version of a draft, pinned to the version the
+// client saw (If-Match or expected_version) or else to the one just loaded,
+// together with review when it is set. The status transition is not checked:
+// leaving draft is only possible here.
+func (r *RequestsHandler) updateDraft(c *fiber.Ctx, draft *models.RequestDraft, update *models.RequestUpdateInput, review *models.DraftReview) (*models.Request, error) {
+	expectedVersion, staleStatus, err := expectedRequestVersion(c, update.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion == nil {
+		expectedVersion = &draft.Request.RequestVersion
+	}
+	update.ExpectedVersion = expectedVersion
+
+	var changedBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		changedBy = &uid
+	}
+
+	if review != nil {
+		review.ReviewedBy = changedBy
+	}
+
+	res, err := r.DraftRepository.UpdateRequestDraft(c.Context(), draft.Request.ID, update, changedBy, review)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil, errs.NotFound("Draft", "id", draft.Request.ID)
+		}
+		if errors.Is(err, errs.ErrStaleVersionInDB) {
+			return nil, r.staleDraftError(c, draft.Request.ID, staleStatus)
+		}
+		slog.Error("failed to update request draft", "err", err, "requestID", draft.Request.ID)
+		return nil, errs.InternalServerError()
+	}
+	return res, nil
+}
+
+// staleDraftError reports a write against an outdated version of a draft as
+// staleVersionError does for other requests.
+func (r *RequestsHandler) staleDraftError(c *fiber.Ctx, id string, status int) error {
+	current, err := r.DraftRepository.FindRequestDraft(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("Draft", "id", id)
+		}
+		slog.Error("failed to find request draft", "err", err, "requestID", id)
+		return errs.InternalServerError()
+	}
+	c.Set(fiber.HeaderETag, requestETag(current.Request.RequestVersion))
+	return errs.StaleVersion(status, current.Request.RequestVersion)
+}
+
+// errDraftsUnavailable is returned by the review queue endpoints when the
+// server has no draft storage.
+func errDraftsUnavailable() errs.HTTPError {
+	return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("the draft review queue is unavailable"))
+}
@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRequestDraftRepository struct {
+	mu                           sync.Mutex
+	inserted                     []*models.RequestDraft
+	insertedReady                []*models.Request
+	reviews                      []*models.DraftReview
+	findRequestDraftFunc         func(ctx context.Context, id string) (*models.RequestDraft, error)
+	findRequestDraftsFunc        func(ctx context.Context, hotelID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestDraft, error)
+	updateRequestDraftFunc       func(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string) (*models.Request, error)
+	draftAccuracyFunc            func(ctx context.Context, hotelID string, since time.Time) (*models.DraftAccuracy, error)
+	insertGeneratedRequestsError error
+}
+
+func (m *mockRequestDraftRepository) InsertGeneratedRequests(ctx context.Context, drafts []*models.RequestDraft, ready []*models.Request) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.insertGeneratedRequestsError != nil {
+		return m.insertGeneratedRequestsError
+	}
+	for _, d := range drafts {
+		d.Request.Status = string(models.StatusDraft)
+	}
+	m.inserted = append(m.inserted, drafts...)
+	m.insertedReady = append(m.insertedReady, ready...)
+	return nil
+}
+
+func (m *mockRequestDraftRepository) FindRequestDraft(ctx context.Context, id string) (*models.RequestDraft, error) {
+	return m.findRequestDraftFunc(ctx, id)
+}
+
+func (m *mockRequestDraftRepository) FindRequestDrafts(ctx context.Context, hotelID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestDraft, error) {
+	return m.findRequestDraftsFunc(ctx, hotelID, cursorCreatedAt, cursorID, limit)
+}
+
+// UpdateRequestDraft records the review, if any, once the update succeeds.
+func (m *mockRequestDraftRepository) UpdateRequestDraft(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string, review *models.DraftReview) (*models.Request, error) {
+	req, err := m.updateRequestDraftFunc(ctx, id, update, changedBy)
+	if err != nil || review == nil {
+		return req, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reviews = append(m.reviews, review)
+	return req, nil
+}
+
+func (m *mockRequestDraftRepository) DraftAccuracy(ctx context.Context, hotelID string, since time.Time) (*models.DraftAccuracy, error) {
+	return m.draftAccuracyFunc(ctx, hotelID, since)
+}
+
+const draftRequestID = "530e8400-e458-41d4-a716-446655440001"
+
+func testDraft() *models.RequestDraft {
+	roomID := "550e8400-e29b-41d4-a716-446655440504"
+	generated := models.MakeRequest{
+		HotelID: streamHotelID, Name: "AC Repair", RequestType: "one-time",
+		Status: "pending", Priority: "medium", RoomID: &roomID,
+	}
+	request := generated
+	request.Status = string(models.StatusDraft)
+	return &models.RequestDraft{
+		Request: models.Request{
+			ID:             draftRequestID,
+			RequestVersion: time.Date(2026, 4, 27, 10, 0, 0, 0, time.UTC),
+			MakeRequest:    request,
+		},
+		RawText:    "the AC in 504 is loud",
+		Confidence: 0.4,
+		CreatedAt:  time.Date(2026, 4, 27, 10, 0, 0, 0, time.UTC),
+		Generated:  generated,
+	}
+}
+
+func doDraftRequest(t *testing.T, h *RequestsHandler, method, path, body string) (int, []byte) {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userId", "user_reviewer")
+		return c.Next()
+	})
+	app.Get("/requests/drafts", h.GetRequestDrafts)
+	app.Put("/requests/drafts/:id", h.UpdateRequestDraft)
+	app.Post("/requests/drafts/:id/approve", h.ApproveRequestDraft)
+	app.Post("/requests/drafts/:id/reject", h.RejectRequestDraft)
+
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(hotelIDHeader, streamHotelID)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, buf.Bytes()
+}
+
+func TestRequestHandler_GenerateRequestBatch_Drafts(t *testing.T) {
+	t.Parallel()
+
+	batch := generatedBatch()
+	batch.Requests[0].Confidence = 0.9
+	batch.Requests[1].Confidence = 0.9
+	llm := &mockLLMService{
+		runGenerateRequestBatchFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.GenerateRequestBatchOutput, error) {
+			return batch, nil
+		},
+	}
+
+	drafts := &mockRequestDraftRepository{}
+	h := NewRequestsHandler(&mockRequestRepository{
+		makeRequestsFunc: func(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+			t.Error("drafts and requests must be inserted together")
+			return reqs, nil
+		},
+	}, llm, nil)
+	h.DraftRepository = drafts
+	h.ReviewThreshold = 0.7
+
+	status, resp := postGenerateBatch(t, h, `{"hotel_id":"`+streamHotelID+`","raw_text":"need towels in 504, and the AC in 999 is loud","persist":true}`)
+	require.Equal(t, 200, status)
+
+	require.Len(t, drafts.insertedReady, 1)
+	assert.Equal(t, "Extra Towels", drafts.insertedReady[0].Name)
+	assert.False(t, resp.Requests[0].Draft)
+
+	require.Len(t, drafts.inserted, 1)
+	assert.Equal(t, "Noisy AC", drafts.inserted[0].Request.Name)
+	assert.Equal(t, "need towels in 504, and the AC in 999 is loud", drafts.inserted[0].RawText)
+	require.NotNil(t, drafts.inserted[0].Warning)
+	assert.True(t, resp.Requests[1].Draft)
+	assert.Equal(t, string(models.StatusDraft), resp.Requests[1].Request.Status)
+}
+
+func TestRequestHandler_GenerateRequest_LowConfidenceIsDrafted(t *testing.T) {
+	t.Parallel()
+
+	llm := &mockLLMService{
+		runGenerateRequestFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			return aiflows.EnrichedGenerateRequestOutput{
+				Confidence: 0.3,
+				GenerateRequestOutput: aiflows.GenerateRequestOutput{
+					Name: "Service Request", RequestType: "one-time", Status: "pending", Priority: "medium",
+				},
+			}, nil
+		},
+	}
+	drafts := &mockRequestDraftRepository{}
+	h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+	h.DraftRepository = drafts
+	h.ReviewThreshold = 0.7
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Post("/request/generate", h.GenerateRequest)
+	req := httptest.NewRequest("POST", "/request/generate", bytes.NewBufferString(`{"hotel_id":"`+streamHotelID+`","raw_text":"something is off","persist":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var out models.GenerateRequestResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.True(t, out.Draft)
+	assert.InDelta(t, 0.3, out.Confidence, 1e-9)
+	require.Len(t, drafts.inserted, 1)
+	assert.InDelta(t, 0.3, drafts.inserted[0].Confidence, 1e-9)
+}
+
+func TestRequestHandler_GetRequestDrafts(t *testing.T) {
+	t.Parallel()
+
+	drafts := &mockRequestDraftRepository{
+		findRequestDraftsFunc: func(ctx context.Context, hotelID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestDraft, error) {
+			assert.Equal(t, streamHotelID, hotelID)
+			assert.Equal(t, 3, limit)
+			return []*models.RequestDraft{testDraft(), testDraft(), testDraft()}, nil
+		},
+	}
+	h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+	h.DraftRepository = drafts
+
+	status, body := doDraftRequest(t, h, "GET", "/requests/drafts?limit=2", "")
+	require.Equal(t, 200, status)
+
+	var page utils.CursorPage[models.RequestDraft]
+	require.NoError(t, json.Unmarshal(body, &page))
+	assert.Len(t, page.Items, 2)
+	assert.True(t, page.HasMore)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, "2026-04-27T10:00:00Z|"+draftRequestID, *page.NextCursor)
+}
+
+func TestRequestHandler_ReviewRequestDraft(t *testing.T) {
+	t.Parallel()
+
+	t.Run("approving publishes the draft and records the review", func(t *testing.T) {
+		t.Parallel()
+
+		var update *models.RequestUpdateInput
+		drafts := &mockRequestDraftRepository{
+			findRequestDraftFunc: func(ctx context.Context, id string) (*models.RequestDraft, error) {
+				return testDraft(), nil
+			},
+			updateRequestDraftFunc: func(ctx context.Context, id string, u *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+				update = u
+				req := testDraft().Request
+				req.Status = *u.Status
+				req.RoomID = u.RoomID
+				req.UserID = u.UserID
+				req.ChangedBy = changedBy
+				return &req, nil
+			},
+		}
+		notifier := &recordingNotifier{}
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, notifier)
+		h.DraftRepository = drafts
+
+		status, body := doDraftRequest(t, h, "POST", "/requests/drafts/"+draftRequestID+"/approve",
+			`{"room_id":"550e8400-e29b-41d4-a716-446655440312","user_id":"user_maria"}`)
+		require.Equal(t, 200, status, string(body))
+
+		require.NotNil(t, update)
+		assert.Equal(t, string(models.StatusPending), *update.Status)
+		require.NotNil(t, update.ExpectedVersion)
+		assert.Equal(t, testDraft().Request.RequestVersion, *update.ExpectedVersion)
+
+		require.Len(t, drafts.reviews, 1)
+		review := drafts.reviews[0]
+		assert.Equal(t, models.DraftApproved, review.Action)
+		assert.Equal(t, "user_reviewer", *review.ReviewedBy)
+
+		assert.Equal(t, []string{"user_maria"}, notifier.users)
+	})
+
+	t.Run("rejecting archives the draft with the reason", func(t *testing.T) {
+		t.Parallel()
+
+		var update *models.RequestUpdateInput
+		drafts := &mockRequestDraftRepository{
+			findRequestDraftFunc: func(ctx context.Context, id string) (*models.RequestDraft, error) {
+				return testDraft(), nil
+			},
+			updateRequestDraftFunc: func(ctx context.Context, id string, u *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+				update = u
+				req := testDraft().Request
+				req.Status = *u.Status
+				req.ChangedBy = changedBy
+				return &req, nil
+			},
+		}
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.DraftRepository = drafts
+
+		status, body := doDraftRequest(t, h, "POST", "/requests/drafts/"+draftRequestID+"/reject", `{"reason":"duplicate"}`)
+		require.Equal(t, 200, status, string(body))
+
+		assert.Equal(t, string(models.StatusArchived), *update.Status)
+		require.Len(t, drafts.reviews, 1)
+		assert.Equal(t, models.DraftRejected, drafts.reviews[0].Action)
+		assert.Equal(t, "duplicate", *drafts.reviews[0].Reason)
+	})
+
+	t.Run("a draft of another hotel is not found", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.DraftRepository = &mockRequestDraftRepository{
+			findRequestDraftFunc: func(ctx context.Context, id string) (*models.RequestDraft, error) {
+				draft := testDraft()
+				draft.Request.HotelID = "org_other"
+				return draft, nil
+			},
+		}
+
+		status, _ := doDraftRequest(t, h, "POST", "/requests/drafts/"+draftRequestID+"/approve", "")
+		assert.Equal(t, 404, status)
+	})
+
+	t.Run("a request that is no longer a draft is not found", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.DraftRepository = &mockRequestDraftRepository{
+			findRequestDraftFunc: func(ctx context.Context, id string) (*models.RequestDraft, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}
+
+		status, _ := doDraftRequest(t, h, "POST", "/requests/drafts/"+draftRequestID+"/reject", "")
+		assert.Equal(t, 404, status)
+	})
+
+	t.Run("a draft reviewed meanwhile is not found and no review is recorded", func(t *testing.T) {
+		t.Parallel()
+
+		drafts := &mockRequestDraftRepository{
+			findRequestDraftFunc: func(ctx context.Context, id string) (*models.RequestDraft, error) {
+				return testDraft(), nil
+			},
+			updateRequestDraftFunc: func(ctx context.Context, id string, u *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.DraftRepository = drafts
+
+		status, _ := doDraftRequest(t, h, "POST", "/requests/drafts/"+draftRequestID+"/approve", "")
+		assert.Equal(t, 404, status)
+		assert.Empty(t, drafts.reviews)
+	})
+
+	t.Run("approving into another status is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.DraftRepository = &mockRequestDraftRepository{}
+
+		status, _ := doDraftRequest(t, h, "POST", "/requests/drafts/"+draftRequestID+"/approve", `{"status":"completed"}`)
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("editing cannot change the status", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.DraftRepository = &mockRequestDraftRepository{}
+
+		status, _ := doDraftRequest(t, h, "PUT", "/requests/drafts/"+draftRequestID, `{"status":"pending"}`)
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("returns 503 without draft storage", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		status, _ := doDraftRequest(t, h, "GET", "/requests/drafts", "")
+		assert.Equal(t, 503, status)
+	})
+}
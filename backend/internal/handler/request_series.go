@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RequestSeriesHandler struct {
+	repo           storage.RequestSeriesRepository
+	WorkflowClient temporalclient.RequestSeriesWorkflowClient
+}
+
+func NewRequestSeriesHandler(repo storage.RequestSeriesRepository, workflowClient temporalclient.RequestSeriesWorkflowClient) *RequestSeriesHandler {
+	return &RequestSeriesHandler{repo: repo, WorkflowClient: workflowClient}
+}
+
+// CreateRequestSeries godoc
+// @Summary      Create a recurring request series
+// @Description  Stores a request template with a recurrence rule and starts the workflow that materializes each occurrence as a new request
+// @Tags         request-series
+// @Accept       json
+// @Produce      json
+// @Param        X-Hotel-ID  header  string                           true  "Hotel ID"
+// @Param        request     body    models.CreateRequestSeriesInput  true  "Template and recurrence rule"
+// @Success      201  {object}  models.RequestSeries
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request-series [post]
+func (h *RequestSeriesHandler) CreateRequestSeries(c *fiber.Ctx) error {
+	if h.WorkflowClient == nil {
+		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("temporal workflow client unavailable"))
+	}
+
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.CreateRequestSeriesInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+	if input.Template.HotelID != hotelID {
+		return errs.BadRequest("template hotel_id must match X-Hotel-ID")
+	}
+	if _, _, err := input.Rule.Next(input.Rule.StartAt); err != nil {
+		return errs.BadRequest("rule: " + err.Error())
+	}
+
+	var createdBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		createdBy = &uid
+	}
+
+	series, err := h.repo.InsertRequestSeries(c.Context(), &input, createdBy)
+	if err != nil {
+		slog.Error("failed to insert request series", "err", err)
+		return errs.InternalServerError()
+	}
+
+	if err := h.WorkflowClient.SignalRequestSeries(c.Context(), series.ID); err != nil {
+		slog.Error("failed to start request series workflow", "err", err, "seriesID", series.ID)
+		// Cancel the series so no active series is left without a workflow
+		// to run it.
+		if _, err := h.repo.UpdateRequestSeriesStatus(c.Context(), series.ID, models.SeriesStatusCancelled); err != nil {
+			slog.Error("failed to cancel unstarted request series", "err", err, "seriesID", series.ID)
+		}
+		return errs.InternalServerError()
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(series)
+}
+
+// GetRequestSeries godoc
+// @Summary      Get a recurring request series
+// @Tags         request-series
+// @Produce      json
+// @Param        id          path    string  true  "Series ID (UUID)"
+// @Param        X-Hotel-ID  header  string  true  "Hotel ID"
+// @Success      200  {object}  models.RequestSeries
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request-series/{id} [get]
+func (h *RequestSeriesHandler) GetRequestSeries(c *fiber.Ctx) error {
+	series, err := h.findScopedSeries(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(series)
+}
+
+// UpdateRequestSeries godoc
+// @Summary      Edit a recurring request series
+// @Description  Replaces the template and/or recurrence rule. Future occurrences use the new values; already created requests are untouched.
+// @Tags         request-series
+// @Accept       json
+// @Produce      json
+// @Param        id          path    string                           true  "Series ID (UUID)"
+// @Param        X-Hotel-ID  header  string                           true  "Hotel ID"
+// @Param        request     body    models.UpdateRequestSeriesInput  true  "Fields to replace"
+// @Success      200  {object}  models.RequestSeries
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request-series/{id} [put]
+func (h *RequestSeriesHandler) UpdateRequestSeries(c *fiber.Ctx) error {
+	current, err := h.findScopedSeries(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.UpdateRequestSeriesInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+	if input.Template != nil && input.Template.HotelID != current.HotelID {
+		return errs.BadRequest("template hotel_id must match the series hotel")
+	}
+	if input.Rule != nil {
+		if _, _, err := input.Rule.Next(input.Rule.StartAt); err != nil {
+			return errs.BadRequest("rule: " + err.Error())
+		}
+	}
+
+	series, err := h.repo.UpdateRequestSeries(c.Context(), current.ID, &input)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("request series", "id", current.ID)
+		}
+		slog.Error("failed to update request series", "err", err, "seriesID", current.ID)
+		return errs.InternalServerError()
+	}
+
+	_ = h.signal(c, series.ID)
+	return c.JSON(series)
+}
+
+// PauseRequestSeries godoc
+// @Summary      Pause a recurring request series
+// @Description  Stops creating occurrences until resumed. Occurrences that fall inside the pause are skipped.
+// @Tags         request-series
+// @Produce      json
+// @Param        id          path    string  true  "Series ID (UUID)"
+// @Param        X-Hotel-ID  header  string  true  "Hotel ID"
+// @Success      200  {object}  models.RequestSeries
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request-series/{id}/pause [post]
+func (h *RequestSeriesHandler) PauseRequestSeries(c *fiber.Ctx) error {
+	return h.setStatus(c, models.SeriesStatusPaused)
+}
+
+// ResumeRequestSeries godoc
+// @Summary      Resume a paused recurring request series
+// @Description  Returns 500 and leaves the series paused when its workflow cannot be woken.
+// @Tags         request-series
+// @Produce      json
+// @Param        id          path    string  true  "Series ID (UUID)"
+// @Param        X-Hotel-ID  header  string  true  "Hotel ID"
+// @Success      200  {object}  models.RequestSeries
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request-series/{id}/resume [post]
+func (h *RequestSeriesHandler) ResumeRequestSeries(c *fiber.Ctx) error {
+	return h.setStatus(c, models.SeriesStatusActive)
+}
+
+// CancelRequestSeries godoc
+// @Summary      Cancel a recurring request series
+// @Description  Permanently stops the series. Already created requests are untouched.
+// @Tags         request-series
+// @Produce      json
+// @Param        id          path    string  true  "Series ID (UUID)"
+// @Param        X-Hotel-ID  header  string  true  "Hotel ID"
+// @Success      200  {object}  models.RequestSeries
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request-series/{id} [delete]
+func (h *RequestSeriesHandler) CancelRequestSeries(c *fiber.Ctx) error {
+	return h.setStatus(c, models.SeriesStatusCancelled)
+}
+
+func (h *RequestSeriesHandler) setStatus(c *fiber.Ctx, status models.RequestSeriesStatus) error {
+	current, err := h.findScopedSeries(c)
+	if err != nil {
+		return err
+	}
+
+	series, err := h.repo.UpdateRequestSeriesStatus(c.Context(), current.ID, status)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("request series", "id", current.ID)
+		}
+		slog.Error("failed to update request series status", "err", err, "seriesID", current.ID, "status", status)
+		return errs.InternalServerError()
+	}
+
+	if err := h.signal(c, series.ID); err != nil && current.Status == models.SeriesStatusPaused && status == models.SeriesStatusActive {
+		// A paused workflow waits for the signal alone, so without it the
+		// series would read active but create nothing. Put the pause back.
+		if _, err := h.repo.UpdateRequestSeriesStatus(c.Context(), series.ID, current.Status); err != nil {
+			slog.Error("failed to restore request series status", "err", err, "seriesID", series.ID)
+		}
+		return errs.InternalServerError()
+	}
+	return c.JSON(series)
+}
+
+// findScopedSeries loads the series from the :id param and hides series that
+// belong to a different hotel than X-Hotel-ID.
+func (h *RequestSeriesHandler) findScopedSeries(c *fiber.Ctx) (*models.RequestSeries, error) {
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return nil, err
+	}
+
+	id := c.Params("id")
+	if !validUUID(id) {
+		return nil, errs.BadRequest("series id is not a valid UUID")
+	}
+
+	series, err := h.repo.FindRequestSeries(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil, errs.NotFound("request series", "id", id)
+		}
+		slog.Error("failed to find request series", "err", err, "seriesID", id)
+		return nil, errs.InternalServerError()
+	}
+	if series.HotelID != hotelID {
+		return nil, errs.NotFound("request series", "id", id)
+	}
+	return series, nil
+}
+
+// signal wakes the series workflow, logging a failure. An active workflow
+// waits on a timer and picks up the change when it next fires anyway, but a
+// paused one waits for the signal alone, so resuming needs it to succeed.
+func (h *RequestSeriesHandler) signal(c *fiber.Ctx, seriesID string) error {
+	if h.WorkflowClient == nil {
+		return nil
+	}
+	err := h.WorkflowClient.SignalRequestSeries(c.Context(), seriesID)
+	if err != nil {
+		slog.Error("failed to signal request series workflow", "err", err, "seriesID", seriesID)
+	}
+	return err
+}
@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	seriesHotelID = "org_550e8400-e29b-41d4-a716-446655440000"
+	seriesID      = "7a1c2b3d-4e5f-4a6b-8c9d-0e1f2a3b4c5d"
+)
+
+type mockRequestSeriesRepository struct {
+	insertFunc       func(ctx context.Context, input *models.CreateRequestSeriesInput, createdBy *string) (*models.RequestSeries, error)
+	findFunc         func(ctx context.Context, id string) (*models.RequestSeries, error)
+	updateFunc       func(ctx context.Context, id string, input *models.UpdateRequestSeriesInput) (*models.RequestSeries, error)
+	updateStatusFunc func(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error)
+}
+
+func (m *mockRequestSeriesRepository) InsertRequestSeries(ctx context.Context, input *models.CreateRequestSeriesInput, createdBy *string) (*models.RequestSeries, error) {
+	return m.insertFunc(ctx, input, createdBy)
+}
+
+func (m *mockRequestSeriesRepository) FindRequestSeries(ctx context.Context, id string) (*models.RequestSeries, error) {
+	return m.findFunc(ctx, id)
+}
+
+func (m *mockRequestSeriesRepository) UpdateRequestSeries(ctx context.Context, id string, input *models.UpdateRequestSeriesInput) (*models.RequestSeries, error) {
+	return m.updateFunc(ctx, id, input)
+}
+
+func (m *mockRequestSeriesRepository) UpdateRequestSeriesStatus(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error) {
+	return m.updateStatusFunc(ctx, id, status)
+}
+
+func (m *mockRequestSeriesRepository) MarkOccurrence(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+type mockSeriesWorkflowClient struct {
+	signalled []string
+	err       error
+}
+
+func (m *mockSeriesWorkflowClient) SignalRequestSeries(ctx context.Context, seriesID string) error {
+	m.signalled = append(m.signalled, seriesID)
+	return m.err
+}
+
+func existingSeries(status models.RequestSeriesStatus) *models.RequestSeries {
+	return &models.RequestSeries{ID: seriesID, HotelID: seriesHotelID, Status: status}
+}
+
+const validSeriesBody = `{
+	"template": {
+		"hotel_id": "` + seriesHotelID + `",
+		"name": "Minibar check",
+		"request_type": "recurring",
+		"status": "pending",
+		"priority": "low"
+	},
+	"rule": {
+		"frequency": "daily",
+		"times": ["09:00", "17:00"],
+		"timezone": "America/New_York",
+		"start_at": "2026-01-01T00:00:00Z"
+	}
+}`
+
+func TestRequestSeriesHandler_CreateRequestSeries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns 201 and starts the workflow", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestSeriesRepository{
+			insertFunc: func(ctx context.Context, input *models.CreateRequestSeriesInput, createdBy *string) (*models.RequestSeries, error) {
+				assert.Equal(t, models.FrequencyDaily, input.Rule.Frequency)
+				assert.Equal(t, "Minibar check", input.Template.Name)
+				return &models.RequestSeries{ID: seriesID, HotelID: input.Template.HotelID, Status: models.SeriesStatusActive}, nil
+			},
+		}
+		wf := &mockSeriesWorkflowClient{}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(repo, wf)
+		app.Post("/request-series", h.CreateRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series", bytes.NewBufferString(validSeriesBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, []string{seriesID}, wf.signalled)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), seriesID)
+	})
+
+	t.Run("cancels the series and returns 500 when the workflow cannot start", func(t *testing.T) {
+		t.Parallel()
+
+		var cancelled []models.RequestSeriesStatus
+		repo := &mockRequestSeriesRepository{
+			insertFunc: func(ctx context.Context, input *models.CreateRequestSeriesInput, createdBy *string) (*models.RequestSeries, error) {
+				return &models.RequestSeries{ID: seriesID, HotelID: input.Template.HotelID, Status: models.SeriesStatusActive}, nil
+			},
+			updateStatusFunc: func(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error) {
+				assert.Equal(t, seriesID, id)
+				cancelled = append(cancelled, status)
+				return existingSeries(status), nil
+			},
+		}
+		wf := &mockSeriesWorkflowClient{err: errors.New("temporal down")}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(repo, wf)
+		app.Post("/request-series", h.CreateRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series", bytes.NewBufferString(validSeriesBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 500, resp.StatusCode)
+		assert.Equal(t, []models.RequestSeriesStatus{models.SeriesStatusCancelled}, cancelled)
+	})
+
+	t.Run("returns 503 when temporal is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(&mockRequestSeriesRepository{}, nil)
+		app.Post("/request-series", h.CreateRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series", bytes.NewBufferString(validSeriesBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 503, resp.StatusCode)
+	})
+
+	t.Run("returns 400 on invalid rule", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(&mockRequestSeriesRepository{}, &mockSeriesWorkflowClient{})
+		app.Post("/request-series", h.CreateRequestSeries)
+
+		body := `{
+			"template": {"hotel_id": "` + seriesHotelID + `", "name": "x", "request_type": "recurring", "status": "pending", "priority": "low"},
+			"rule": {"frequency": "monthly", "start_at": "2026-01-01T00:00:00Z"}
+		}`
+		req := httptest.NewRequest("POST", "/request-series", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("returns 400 when template hotel does not match header", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(&mockRequestSeriesRepository{}, &mockSeriesWorkflowClient{})
+		app.Post("/request-series", h.CreateRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series", bytes.NewBufferString(validSeriesBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", "org_other")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestRequestSeriesHandler_StatusTransitions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		status models.RequestSeriesStatus
+	}{
+		{"pause", "POST", "/request-series/" + seriesID + "/pause", models.SeriesStatusPaused},
+		{"resume", "POST", "/request-series/" + seriesID + "/resume", models.SeriesStatusActive},
+		{"cancel", "DELETE", "/request-series/" + seriesID, models.SeriesStatusCancelled},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name+" updates status and signals", func(t *testing.T) {
+			t.Parallel()
+
+			var gotStatus models.RequestSeriesStatus
+			repo := &mockRequestSeriesRepository{
+				findFunc: func(ctx context.Context, id string) (*models.RequestSeries, error) {
+					return existingSeries(models.SeriesStatusActive), nil
+				},
+				updateStatusFunc: func(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error) {
+					gotStatus = status
+					return existingSeries(status), nil
+				},
+			}
+			wf := &mockSeriesWorkflowClient{}
+
+			app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+			h := NewRequestSeriesHandler(repo, wf)
+			app.Post("/request-series/:id/pause", h.PauseRequestSeries)
+			app.Post("/request-series/:id/resume", h.ResumeRequestSeries)
+			app.Delete("/request-series/:id", h.CancelRequestSeries)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("X-Hotel-ID", seriesHotelID)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, tc.status, gotStatus)
+			assert.Equal(t, []string{seriesID}, wf.signalled)
+		})
+	}
+
+	t.Run("returns 404 for another hotel's series", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestSeriesRepository{
+			findFunc: func(ctx context.Context, id string) (*models.RequestSeries, error) {
+				s := existingSeries(models.SeriesStatusActive)
+				s.HotelID = "org_other"
+				return s, nil
+			},
+		}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(repo, &mockSeriesWorkflowClient{})
+		app.Post("/request-series/:id/pause", h.PauseRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series/"+seriesID+"/pause", nil)
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("keeps the series paused when resuming cannot signal", func(t *testing.T) {
+		t.Parallel()
+
+		var statuses []models.RequestSeriesStatus
+		repo := &mockRequestSeriesRepository{
+			findFunc: func(ctx context.Context, id string) (*models.RequestSeries, error) {
+				return existingSeries(models.SeriesStatusPaused), nil
+			},
+			updateStatusFunc: func(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error) {
+				statuses = append(statuses, status)
+				return existingSeries(status), nil
+			},
+		}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(repo, &mockSeriesWorkflowClient{err: errors.New("temporal down")})
+		app.Post("/request-series/:id/resume", h.ResumeRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series/"+seriesID+"/resume", nil)
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 500, resp.StatusCode)
+		assert.Equal(t, []models.RequestSeriesStatus{models.SeriesStatusActive, models.SeriesStatusPaused}, statuses)
+	})
+
+	t.Run("returns 404 when series is already cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestSeriesRepository{
+			findFunc: func(ctx context.Context, id string) (*models.RequestSeries, error) {
+				return existingSeries(models.SeriesStatusCancelled), nil
+			},
+			updateStatusFunc: func(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(repo, &mockSeriesWorkflowClient{})
+		app.Post("/request-series/:id/resume", h.ResumeRequestSeries)
+
+		req := httptest.NewRequest("POST", "/request-series/"+seriesID+"/resume", nil)
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestRequestSeriesHandler_UpdateRequestSeries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("updates rule and signals even when signal fails", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestSeriesRepository{
+			findFunc: func(ctx context.Context, id string) (*models.RequestSeries, error) {
+				return existingSeries(models.SeriesStatusActive), nil
+			},
+			updateFunc: func(ctx context.Context, id string, input *models.UpdateRequestSeriesInput) (*models.RequestSeries, error) {
+				require.NotNil(t, input.Rule)
+				assert.Nil(t, input.Template)
+				assert.Equal(t, 6, input.Rule.Interval)
+				return existingSeries(models.SeriesStatusActive), nil
+			},
+		}
+		wf := &mockSeriesWorkflowClient{err: errors.New("temporal down")}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(repo, wf)
+		app.Put("/request-series/:id", h.UpdateRequestSeries)
+
+		body := `{"rule": {"frequency": "hourly", "interval": 6, "start_at": "2026-01-01T00:00:00Z"}}`
+		req := httptest.NewRequest("PUT", "/request-series/"+seriesID, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, wf.signalled, 1)
+	})
+
+	t.Run("returns 400 on invalid series id", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestSeriesHandler(&mockRequestSeriesRepository{}, nil)
+		app.Put("/request-series/:id", h.UpdateRequestSeries)
+
+		req := httptest.NewRequest("PUT", "/request-series/not-a-uuid", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", seriesHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RequestStatusesHandler struct {
+	repo storage.RequestStatusesRepository
+}
+
+func NewRequestStatusesHandler(repo storage.RequestStatusesRepository) *RequestStatusesHandler {
+	return &RequestStatusesHandler{repo: repo}
+}
+
+// GetRequestStatuses godoc
+// @Summary      List custom request statuses
+// @Description  Returns the hotel's extra request statuses, available on top of pending, in progress, completed and archived
+// @Tags         hotels
+// @Produce      json
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {array}   models.HotelRequestStatus
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/request-statuses [get]
+func (h *RequestStatusesHandler) GetRequestStatuses(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+
+	statuses, err := h.repo.FindRequestStatusesByHotelID(c.Context(), hotelID)
+	if err != nil {
+		slog.Error("failed to get request statuses", "hotel_id", hotelID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(statuses)
+}
+
+// CreateRequestStatus godoc
+// @Summary      Create custom request status
+// @Description  Adds an extra status such as "blocked" or "awaiting guest". Open requests can move into it from pending or in progress and back out to pending, in progress or archived.
+// @Tags         hotels
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                true  "Hotel ID"
+// @Param        request  body      models.CreateHotelRequestStatusInput  true  "Status name"
+// @Success      201      {object}  models.HotelRequestStatus
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      409      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/request-statuses [post]
+func (h *RequestStatusesHandler) CreateRequestStatus(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+
+	var req models.CreateHotelRequestStatusInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(req.Name)
+	if models.RequestStatus(name).IsValid() {
+		return errs.BadRequest("name: " + name + " is a built-in status")
+	}
+
+	status, err := h.repo.InsertRequestStatus(c.Context(), hotelID, name)
+	if err != nil {
+		if errors.Is(err, errs.ErrAlreadyExistsInDB) {
+			return errs.Conflict("request status", "name", name)
+		}
+		slog.Error("failed to create request status", "hotel_id", hotelID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(status)
+}
+
+// DeleteRequestStatus godoc
+// @Summary      Delete custom request status
+// @Description  Removes a custom status. Requests already in it keep it until they are moved on.
+// @Tags         hotels
+// @Produce      json
+// @Param        id    path  string  true  "Hotel ID"
+// @Param        name  path  string  true  "Status name (URL-encoded)"
+// @Success      204
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/request-statuses/{name} [delete]
+func (h *RequestStatusesHandler) DeleteRequestStatus(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil || strings.TrimSpace(name) == "" {
+		return errs.BadRequest("status name is required")
+	}
+
+	if err := h.repo.DeleteRequestStatus(c.Context(), hotelID, name); err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("request status", "name", name)
+		}
+		slog.Error("failed to delete request status", "hotel_id", hotelID, "name", name, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
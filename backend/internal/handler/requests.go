@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"sort"
 	"strconv"
@@ -15,17 +16,16 @@ import (
 	"github.com/generate/selfserve/internal/models"
 	storage "github.com/generate/selfserve/internal/service/storage/postgres"
 	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/workflows"
 	"github.com/generate/selfserve/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-const msgTaskAssigned = "New task assigned to you"
-
 // NotificationSender is implemented by the notifications service.
 // It is nilable - if nil, notification triggering is skipped.
 type NotificationSender interface {
-	Notify(ctx context.Context, userID string, notifType models.NotificationType, title, body string) error
+	Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error
 }
 
 type RequestsHandler struct {
@@ -33,6 +33,24 @@ type RequestsHandler struct {
 	GenerateRequestService aiflows.GenerateRequestService
 	WorkflowClient         temporalclient.GenerateRequestWorkflowClient
 	NotificationSender     NotificationSender
+	EventBroker            RequestEventBroker
+	// StatusRepository is nilable; without it only the built-in statuses are accepted.
+	StatusRepository storage.RequestStatusesRepository
+	// AttachmentRepository is nilable; when set, confirmed attachments show up in the activity feed.
+	AttachmentRepository storage.RequestAttachmentsRepository
+	// CommentRepository is nilable; when set, comments show up in the activity feed.
+	CommentRepository storage.RequestCommentsRepository
+	// SearchRepository is nilable; when set, the feed is served from the search
+	// index with facets, falling back to Postgres if the search fails.
+	SearchRepository storage.RequestsSearchRepository
+	// DraftRepository is nilable; when set, persisted generated requests that
+	// need review are held as drafts instead of going straight to the feed.
+	DraftRepository storage.RequestDraftsRepository
+	// ReviewThreshold is the confidence below which a generated request needs review.
+	ReviewThreshold float64
+	// S3Storage is nilable; without it, voice notes must be uploaded with the
+	// request rather than named by S3 key, and photos cannot be used at all.
+	S3Storage storage.S3Storage
 }
 
 func NewRequestsHandler(repo storage.RequestsRepository, generateRequestService aiflows.GenerateRequestService, notificationSender NotificationSender) *RequestsHandler {
@@ -73,25 +91,32 @@ func (r *RequestsHandler) CreateRequest(c *fiber.Ctx) error {
 	}
 
 	if r.NotificationSender != nil && requestBody.UserID != nil {
-		if err := r.NotificationSender.Notify(c.Context(), *requestBody.UserID, models.TypeTaskAssigned, msgTaskAssigned, res.Name); err != nil {
+		if err := r.NotificationSender.Notify(c.Context(), *requestBody.UserID, models.TypeTaskAssigned, res.ID, models.TaskAssignedTitle, res.Name); err != nil {
 			slog.Error("failed to send task assigned notification", "err", err)
 		}
 	}
 
+	r.publishRequestEvent(c.Context(), models.RequestEventCreated, res.HotelID, res.ID)
+
 	return c.JSON(res)
 }
 
 // UpdateRequest godoc
 // @Summary      Update a request
-// @Description  Partially updates a request — only fields present in the body are applied; omitted fields keep their current values
+// @Description  Partially updates a request — only fields present in the body are applied; omitted fields keep their current values. Drafts awaiting review are not found here; edit them with PUT /requests/drafts/{id}.
+// @Description  Status changes follow pending → in progress → completed → archived (plus the hotel's custom statuses); started_at and completed_at are stamped automatically.
 // @Tags         requests
 // @Accept       json
 // @Produce      json
-// @Param        id       path  string              true  "Request ID (UUID)"
-// @Param        request  body  models.RequestUpdateInput  true  "Fields to update"
+// @Param        id        path    string              true   "Request ID (UUID)"
+// @Param        If-Match  header  string              false  "ETag from GET /request/{id}; the update fails with 412 if the request changed since"
+// @Param        request   body    models.RequestUpdateInput  true  "Fields to update"
 // @Success      200  {object}  models.Request
+// @Header       200  {string}  ETag  "Version of the updated request"
 // @Failure      400  {object}  errs.HTTPError
 // @Failure      404  {object}  errs.HTTPError
+// @Failure      409  {object}  errs.HTTPError  "Illegal status transition, or expected_version is stale"
+// @Failure      412  {object}  errs.HTTPError  "If-Match is stale"
 // @Failure      500  {object}  errs.HTTPError
 // @Security     BearerAuth
 // @Router       /request/{id} [put]
@@ -106,6 +131,23 @@ func (r *RequestsHandler) UpdateRequest(c *fiber.Ctx) error {
 		return err
 	}
 
+	expectedVersion, staleStatus, err := expectedRequestVersion(c, patchInput.ExpectedVersion)
+	if err != nil {
+		return err
+	}
+	patchInput.ExpectedVersion = expectedVersion
+
+	if patchInput.Status != nil {
+		current, err := r.checkStatusTransition(c.Context(), id, *patchInput.Status)
+		if err != nil {
+			return err
+		}
+		// Pin the write to the version the transition was checked against.
+		if patchInput.ExpectedVersion == nil {
+			patchInput.ExpectedVersion = &current.RequestVersion
+		}
+	}
+
 	var changedBy *string
 	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
 		changedBy = &uid
@@ -116,10 +158,20 @@ func (r *RequestsHandler) UpdateRequest(c *fiber.Ctx) error {
 		if errors.Is(err, errs.ErrNotFoundInDB) {
 			return errs.NotFound("Request", "id", id)
 		}
+		if errors.Is(err, errs.ErrStaleVersionInDB) {
+			return r.staleVersionError(c, id, staleStatus)
+		}
 		slog.Error("failed to update request", "err", err, "requestID", id)
 		return errs.InternalServerError()
 	}
 
+	eventType := models.RequestEventUpdated
+	if patchInput.UserID != nil || patchInput.Unassign {
+		eventType = models.RequestEventAssigned
+	}
+	r.publishRequestEvent(c.Context(), eventType, res.HotelID, res.ID)
+
+	c.Set(fiber.HeaderETag, requestETag(res.RequestVersion))
 	return c.JSON(res)
 }
 
@@ -131,11 +183,15 @@ func (r *RequestsHandler) UpdateRequest(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id          path    string                    true  "Request ID (UUID)"
 // @Param        X-Hotel-ID  header  string                    true  "Hotel ID (UUID)"
+// @Param        If-Match    header  string                    false "ETag from GET /request/{id}; the assignment fails with 412 if the request changed since"
 // @Param        body        body    models.AssignRequestInput true  "Self-assign flag and optional assignee"
 // @Success      200  {object}  models.Request
+// @Header       200  {string}  ETag  "Version of the updated request"
 // @Failure      400  {object}  errs.HTTPError
 // @Failure      401  {object}  errs.HTTPError
 // @Failure      404  {object}  errs.HTTPError
+// @Failure      409  {object}  errs.HTTPError  "expected_version is stale"
+// @Failure      412  {object}  errs.HTTPError  "If-Match is stale"
 // @Failure      500  {object}  errs.HTTPError
 // @Security     BearerAuth
 // @Router       /request/{id}/assign [post]
@@ -160,6 +216,11 @@ func (r *RequestsHandler) AssignRequest(c *fiber.Ctx) error {
 		return err
 	}
 
+	expectedVersion, staleStatus, err := expectedRequestVersion(c, body.ExpectedVersion)
+	if err != nil {
+		return err
+	}
+
 	var assigneeID string
 	if body.AssignToSelf != nil && *body.AssignToSelf {
 		assigneeID = userID
@@ -181,19 +242,38 @@ func (r *RequestsHandler) AssignRequest(c *fiber.Ctx) error {
 		return errs.NotFound("request", "id", requestID)
 	}
 
-	update := models.RequestUpdateInput{UserID: &assigneeID}
+	update := models.RequestUpdateInput{UserID: &assigneeID, ExpectedVersion: expectedVersion}
 	res, err := r.RequestRepository.UpdateRequest(c.Context(), requestID, &update, &userID)
 	if err != nil {
 		if errors.Is(err, errs.ErrNotFoundInDB) {
 			return errs.NotFound("request", "id", requestID)
 		}
+		if errors.Is(err, errs.ErrStaleVersionInDB) {
+			return r.staleVersionError(c, requestID, staleStatus)
+		}
 		slog.Error("failed to assign request", "err", err, "requestID", requestID)
 		return errs.InternalServerError()
 	}
 
+	r.publishRequestEvent(c.Context(), models.RequestEventAssigned, res.HotelID, res.ID)
+
+	c.Set(fiber.HeaderETag, requestETag(res.RequestVersion))
 	return c.JSON(res)
 }
 
+// GetRequest godoc
+// @Summary      Get a request
+// @Description  Returns the latest version of a request. The ETag header can be sent back as If-Match on PUT /request/{id} and POST /request/{id}/assign. Drafts awaiting review are not found here; they are served by /requests/drafts.
+// @Tags         requests
+// @Produce      json
+// @Param        id   path      string  true  "Request ID (UUID)"
+// @Success      200  {object}  models.Request
+// @Header       200  {string}  ETag  "Version of the request"
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id} [get]
 func (r *RequestsHandler) GetRequest(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if !validUUID(id) {
@@ -208,6 +288,7 @@ func (r *RequestsHandler) GetRequest(c *fiber.Ctx) error {
 		return errs.InternalServerError()
 	}
 
+	c.Set(fiber.HeaderETag, requestETag(dev.RequestVersion))
 	return c.JSON(dev)
 }
 
@@ -247,7 +328,8 @@ func validateGenerateRequest(input *models.GenerateRequestInput) error {
 
 // GenerateRequest godoc
 // @Summary      generates a request
-// @Description  Generates a request using AI
+// @Description  Generates a request using AI, with a confidence score per extracted field.
+// @Description  With persist set, the request is created; when it carries a warning or its confidence is below the review threshold it is held as a draft in the review queue instead.
 // @Tags         requests
 // @Accept       json
 // @Produce      json
@@ -255,9 +337,14 @@ func validateGenerateRequest(input *models.GenerateRequestInput) error {
 // @Success      200   {object}  models.GenerateRequestResponse
 // @Failure      400   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
 // @Security     BearerAuth
 // @Router       /request/generate [post]
 func (r *RequestsHandler) GenerateRequest(c *fiber.Ctx) error {
+	if r.GenerateRequestService == nil {
+		return errGenerationUnavailable()
+	}
+
 	var input models.GenerateRequestInput
 	if err := c.BodyParser(&input); err != nil {
 		return errs.InvalidJSON()
@@ -284,51 +371,161 @@ func (r *RequestsHandler) GenerateRequest(c *fiber.Ctx) error {
 		return errs.InternalServerError()
 	}
 
-	notes := parsed.Notes
-	if notes == nil {
-		empty := ""
-		notes = &empty
-	}
-
-	req := models.Request{ID: uuid.New().String(), MakeRequest: models.MakeRequest{
-		HotelID:                 input.HotelID,
-		GuestID:                 parsed.GuestID,
-		UserID:                  parsed.UserID,
-		ReservationID:           parsed.ReservationID,
-		RoomID:                  parsed.RoomID,
-		Name:                    parsed.Name,
-		Description:             parsed.Description,
-		RequestCategory:         parsed.RequestCategory,
-		RequestType:             parsed.RequestType,
-		Department:              parsed.DepartmentID,
-		Status:                  parsed.Status,
-		Priority:                parsed.Priority,
-		EstimatedCompletionTime: parsed.EstimatedCompletionTime,
-		ScheduledTime:           nil, // TODO: Potentially add schedule time from user input / auto-scheduling
-		CompletedAt:             nil,
-		Notes:                   notes,
-	}}
-
-	return c.JSON(models.GenerateRequestResponse{
-		Request: req,
-		Warning: warningFromAI(parsed.Warning),
+	resps := []models.GenerateRequestResponse{responseFromGenerated(input.HotelID, input.RawText, &parsed)}
+	if input.Persist {
+		if err := r.persistGenerated(c, input.RawText, resps); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(resps[0])
+}
+
+// GenerateRequestBatch godoc
+// @Summary      generates several requests from one message
+// @Description  Splits a message describing several tasks into one request per task using AI. Each request is resolved against the hotel's rooms, guests, staff and departments on its own and carries its own warning. Requests that fail validation are left out and listed under skipped with their index and the validation error.
+// @Description  With persist set, all generated requests are created in one transaction, except those with a warning or a confidence below the review threshold, which are held as drafts in the review queue.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param  request  body  models.GenerateRequestBatchInput  true  "Message to split into requests"
+// @Success      200   {object}  models.GenerateRequestBatchResponse
+// @Failure      400   {object}  errs.HTTPError
+// @Failure      500   {object}  errs.HTTPError
+// @Failure      503   {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/generate/batch [post]
+func (r *RequestsHandler) GenerateRequestBatch(c *fiber.Ctx) error {
+	if r.GenerateRequestService == nil {
+		return errGenerationUnavailable()
+	}
+
+	var input models.GenerateRequestBatchInput
+	if err := c.BodyParser(&input); err != nil {
+		return errs.InvalidJSON()
+	}
+
+	if err := httpx.Validate(&input); err != nil {
+		return err
+	}
+
+	if err := validateGenerateRequest(&input.GenerateRequestInput); err != nil {
+		return err
+	}
+
+	parsed, err := r.GenerateRequestService.RunGenerateRequestBatch(c.Context(), aiflows.GenerateRequestInput{
+		RawText: input.RawText,
+		HotelID: input.HotelID,
 	})
+	if err != nil {
+		slog.Error("genkit failed to generate requests", "error", err)
+		return errs.InternalServerError()
+	}
+
+	resp := models.GenerateRequestBatchResponse{Requests: make([]models.GenerateRequestResponse, 0, len(parsed.Requests))}
+	for i := range parsed.Requests {
+		generated := &parsed.Requests[i]
+		if err := httpx.Validate(generated); err != nil {
+			slog.Error("generated request failed validation, skipping it", "error", err, "name", generated.Name)
+			resp.Skipped = append(resp.Skipped, models.GenerateRequestSkipped{Index: i, Name: generated.Name, Error: validationMessage(err)})
+			continue
+		}
+		resp.Requests = append(resp.Requests, responseFromGenerated(input.HotelID, input.RawText, generated))
+	}
+
+	if input.Persist && len(resp.Requests) > 0 {
+		if err := r.persistGenerated(c, input.RawText, resp.Requests); err != nil {
+			return err
+		}
+		resp.Persisted = true
+	}
+
+	return c.JSON(resp)
+}
+
+// validationMessage returns the message of an error from httpx.Validate.
+func validationMessage(err error) string {
+	var httpErr errs.HTTPError
+	if errors.As(err, &httpErr) {
+		return fmt.Sprint(httpErr.Message)
+	}
+	return err.Error()
+}
+
+// errGenerationUnavailable is returned by the generate endpoints when the
+// server started without an LLM provider.
+func errGenerationUnavailable() errs.HTTPError {
+	return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("request generation is unavailable: no LLM provider is configured"))
 }
 
-func warningFromAI(w *aiflows.GenerateRequestWarning) *models.GenerateRequestWarning {
-	if w == nil {
-		return nil
+// persistGenerated stores generated requests in place, all in one
+// transaction. With a DraftRepository, requests that need review are held as
+// drafts; the rest are created, announced on the stream and their assignees
+// notified.
+func (r *RequestsHandler) persistGenerated(c *fiber.Ctx, rawText string, resps []models.GenerateRequestResponse) error {
+	var changedBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		changedBy = &uid
 	}
 
-	return &models.GenerateRequestWarning{
-		Code:    w.Code,
-		Message: w.Message,
+	var ready []*models.Request
+	var draftIdx []int
+	var drafts []*models.RequestDraft
+	for i := range resps {
+		resp := &resps[i]
+		resp.Request.ChangedBy = changedBy
+		if r.DraftRepository != nil && resp.NeedsReview(r.ReviewThreshold) {
+			drafts = append(drafts, resp.ToDraft(rawText))
+			draftIdx = append(draftIdx, i)
+			continue
+		}
+		ready = append(ready, &resp.Request)
 	}
+
+	if len(drafts) > 0 {
+		if err := r.DraftRepository.InsertGeneratedRequests(c.Context(), drafts, ready); err != nil {
+			slog.Error("failed to persist generated requests", "error", err)
+			return errs.InternalServerError()
+		}
+		for j, draft := range drafts {
+			resps[draftIdx[j]].Request = draft.Request
+			resps[draftIdx[j]].Draft = true
+		}
+	} else if len(ready) > 0 {
+		if _, err := r.RequestRepository.InsertRequests(c.Context(), ready); err != nil {
+			slog.Error("failed to persist generated requests", "error", err)
+			return errs.InternalServerError()
+		}
+	}
+
+	// ready points into resps, which the inserts fill in.
+	for _, req := range ready {
+		if r.NotificationSender != nil && req.UserID != nil {
+			if err := r.NotificationSender.Notify(c.Context(), *req.UserID, models.TypeTaskAssigned, req.ID, models.TaskAssignedTitle, req.Name); err != nil {
+				slog.Error("failed to send task assigned notification", "err", err)
+			}
+		}
+		r.publishRequestEvent(c.Context(), models.RequestEventCreated, req.HotelID, req.ID)
+	}
+
+	return nil
+}
+
+// responseFromGenerated wraps an AI-generated request, not yet stored, with
+// its warning and confidence.
+func responseFromGenerated(hotelID, rawText string, parsed *aiflows.EnrichedGenerateRequestOutput) models.GenerateRequestResponse {
+	return parsed.ToResponse(requestFromGenerated(hotelID, rawText, parsed))
+}
+
+// requestFromGenerated turns an AI-generated request into a request of the
+// hotel with a fresh ID, ready to be inserted.
+func requestFromGenerated(hotelID, rawText string, parsed *aiflows.EnrichedGenerateRequestOutput) models.Request {
+	return models.Request{ID: uuid.New().String(), MakeRequest: parsed.ToMakeRequest(hotelID, rawText)}
 }
 
 // StartGenerateRequestAsync godoc
 // @Summary      starts request generation workflow
-// @Description  Starts async request generation via Temporal workflow
+// @Description  Starts async request generation via Temporal workflow. Once generated, the request is created, its assignee notified and it is published on the hotel's request stream, unless it has a warning or a confidence below the review threshold, in which case it is held as a draft in the review queue; poll the status endpoint for its ID.
 // @Tags         requests
 // @Accept       json
 // @Produce      json
@@ -342,6 +539,9 @@ func (r *RequestsHandler) StartGenerateRequestAsync(c *fiber.Ctx) error {
 	if r.WorkflowClient == nil {
 		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("temporal workflow client unavailable"))
 	}
+	if r.GenerateRequestService == nil {
+		return errGenerationUnavailable()
+	}
 
 	var input models.GenerateRequestInput
 	if err := c.BodyParser(&input); err != nil {
@@ -354,9 +554,17 @@ func (r *RequestsHandler) StartGenerateRequestAsync(c *fiber.Ctx) error {
 		return err
 	}
 
-	workflowID, err := r.WorkflowClient.StartGenerateRequest(c.Context(), aiflows.GenerateRequestInput{
-		RawText: input.RawText,
-		HotelID: input.HotelID,
+	var requestedBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		requestedBy = &uid
+	}
+
+	workflowID, err := r.WorkflowClient.StartGenerateRequest(c.Context(), workflows.GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{
+			RawText: input.RawText,
+			HotelID: input.HotelID,
+		},
+		RequestedBy: requestedBy,
 	})
 	if err != nil {
 		slog.Error("failed to start generate request workflow", "error", err)
@@ -492,12 +700,12 @@ func (r *RequestsHandler) GetRequestsByRoomID(c *fiber.Ctx) error {
 
 // GetRequestsFeed godoc
 // @Summary      Get requests feed
-// @Description  Returns a paginated list of requests for the hotel, optionally filtered and searched
+// @Description  Returns a paginated list of requests for the hotel, optionally filtered and searched. When the search index is available the page also carries facet counts by status, priority, department and floor.
 // @Tags         requests
 // @Accept       json
 // @Produce      json
 // @Param        request  body  models.RequestsFeedInput  true  "Feed filters"
-// @Success      200  {object}  utils.CursorPage[models.GuestRequest]
+// @Success      200  {object}  models.RequestsFeedPage
 // @Failure      400  {object}  errs.HTTPError
 // @Failure      500  {object}  errs.HTTPError
 // @Security     BearerAuth
@@ -512,29 +720,49 @@ func (r *RequestsHandler) GetRequestsFeed(c *fiber.Ctx) error {
 		input.Sort = models.SortByPriority
 	}
 
-	cursorID, cursorCreatedAt, cursorPriorityRank, err := parseFeedCursor(input.Cursor)
+	cursorID, cursorCreatedAt, cursorPriorityRank, cursorSLADueAt, err := parseFeedCursor(input.Cursor)
 	if err != nil {
 		return errs.BadRequest("invalid cursor")
 	}
 
 	resolvedLimit := utils.ResolveLimit(input.Limit)
+	if r.SearchRepository != nil {
+		requests, facets, err := r.SearchRepository.SearchRequests(
+			c.Context(), &input,
+			cursorID, cursorCreatedAt, cursorPriorityRank, cursorSLADueAt,
+			resolvedLimit+1,
+		)
+		if err == nil {
+			return c.JSON(newRequestsFeedPage(requests, resolvedLimit, facets))
+		}
+		slog.Error("request search failed, falling back to postgres", "err", err, "hotelID", input.HotelID)
+	}
+
 	requests, err := r.RequestRepository.FindRequestsPaginated(
 		c.Context(), &input,
-		cursorID, cursorCreatedAt, cursorPriorityRank,
+		cursorID, cursorCreatedAt, cursorPriorityRank, cursorSLADueAt,
 		resolvedLimit+1,
 	)
 	if err != nil {
 		return errs.InternalServerError()
 	}
 
-	page := utils.BuildCursorPage(requests, resolvedLimit, buildFeedCursor)
+	return c.JSON(newRequestsFeedPage(requests, resolvedLimit, nil))
+}
 
-	return c.JSON(page)
+func newRequestsFeedPage(requests []*models.GuestRequest, limit int, facets *models.RequestFacets) *models.RequestsFeedPage {
+	page := utils.BuildCursorPage(requests, limit, buildFeedCursor)
+	return &models.RequestsFeedPage{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+		Facets:     facets,
+	}
 }
 
 // GetRequestActivity godoc
 // @Summary      Get request activity history
-// @Description  Returns a cursor-paginated list of activity events derived from the request's version history, newest first
+// @Description  Returns a cursor-paginated list of activity events derived from the request's version history, attachments and comments, newest first
 // @Tags         requests
 // @Produce      json
 // @Param        id      path   string  true   "Request ID (UUID)"
@@ -576,6 +804,23 @@ func (r *RequestsHandler) GetRequestActivity(c *fiber.Ctx) error {
 	}
 
 	all := buildRequestActivity(versions)
+	if r.AttachmentRepository != nil {
+		attachments, err := r.AttachmentRepository.FindConfirmedRequestAttachments(c.Context(), id)
+		if err != nil {
+			slog.Error("failed to fetch request attachments", "err", err, "requestID", id)
+			return errs.InternalServerError()
+		}
+		all = append(all, buildAttachmentActivity(attachments)...)
+	}
+	if r.CommentRepository != nil {
+		comments, err := r.CommentRepository.FindActiveRequestComments(c.Context(), id)
+		if err != nil {
+			slog.Error("failed to fetch request comments", "err", err, "requestID", id)
+			return errs.InternalServerError()
+		}
+		all = append(all, buildCommentActivity(comments)...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Timestamp.Before(all[j].Timestamp) })
 	// Reverse to newest-first
 	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
 		all[i], all[j] = all[j], all[i]
@@ -606,6 +851,37 @@ func (r *RequestsHandler) GetRequestActivity(c *fiber.Ctx) error {
 	return c.JSON(&models.RequestActivityPage{Items: page, NextCursor: nextCursor})
 }
 
+func buildAttachmentActivity(attachments []*models.RequestAttachment) []*models.RequestActivityItem {
+	items := make([]*models.RequestActivityItem, 0, len(attachments))
+	for _, a := range attachments {
+		if a.ConfirmedAt == nil {
+			continue
+		}
+		name := a.FileName
+		items = append(items, &models.RequestActivityItem{
+			Type:      models.ActivityAttachmentAdded,
+			ChangedBy: a.UploadedBy,
+			NewValue:  &name,
+			Timestamp: *a.ConfirmedAt,
+		})
+	}
+	return items
+}
+
+func buildCommentActivity(comments []*models.RequestComment) []*models.RequestActivityItem {
+	items := make([]*models.RequestActivityItem, 0, len(comments))
+	for _, comment := range comments {
+		body := comment.Body
+		items = append(items, &models.RequestActivityItem{
+			Type:      models.ActivityCommented,
+			ChangedBy: comment.AuthorID,
+			NewValue:  &body,
+			Timestamp: comment.CreatedAt,
+		})
+	}
+	return items
+}
+
 func buildRequestActivity(versions []*models.Request) []*models.RequestActivityItem {
 	items := make([]*models.RequestActivityItem, 0, len(versions))
 
@@ -738,40 +1014,43 @@ func buildRequestActivity(versions []*models.Request) []*models.RequestActivityI
 	return items
 }
 
-// parseFeedCursor decodes a universal cursor: "priority_rank|created_at_nano|id".
+// parseFeedCursor decodes a universal cursor: "priority_rank|created_at_nano|id|sla_due_at_nano".
+// The trailing SLA part is empty when the request has no deadline and may be
+// absent in cursors issued before SLA sorting existed.
 // Returns zero values and nil error for an empty cursor (first page).
-func parseFeedCursor(cursor string) (id string, createdAt time.Time, priorityRank int, err error) {
+func parseFeedCursor(cursor string) (id string, createdAt time.Time, priorityRank int, slaDueAt *time.Time, err error) {
 	if cursor == "" {
-		return "", time.Time{}, 0, nil
+		return "", time.Time{}, 0, nil, nil
 	}
-	parts := strings.SplitN(cursor, "|", 3)
-	if len(parts) != 3 {
-		return "", time.Time{}, 0, errors.New("invalid cursor")
+	parts := strings.SplitN(cursor, "|", 4)
+	if len(parts) < 3 {
+		return "", time.Time{}, 0, nil, errors.New("invalid cursor")
 	}
 	rank, rankErr := strconv.Atoi(parts[0])
 	nano, nanoErr := strconv.ParseInt(parts[1], 10, 64)
 	if rankErr != nil || nanoErr != nil {
-		return "", time.Time{}, 0, errors.New("invalid cursor")
+		return "", time.Time{}, 0, nil, errors.New("invalid cursor")
+	}
+	if len(parts) == 4 && parts[3] != "" {
+		dueNano, dueErr := strconv.ParseInt(parts[3], 10, 64)
+		if dueErr != nil {
+			return "", time.Time{}, 0, nil, errors.New("invalid cursor")
+		}
+		due := time.Unix(0, dueNano).UTC()
+		slaDueAt = &due
 	}
-	return parts[2], time.Unix(0, nano).UTC(), rank, nil
+	return parts[2], time.Unix(0, nano).UTC(), rank, slaDueAt, nil
 }
 
 // buildFeedCursor encodes all sort fields into a single universal cursor.
 func buildFeedCursor(req *models.GuestRequest) string {
-	return strconv.Itoa(priorityRankOf(req.Priority)) + "|" +
-		strconv.FormatInt(req.CreatedAt.UnixNano(), 10) + "|" +
-		req.ID
-}
-
-func priorityRankOf(priority string) int {
-	switch priority {
-	case "high":
-		return 1
-	case "medium":
-		return 2
-	default:
-		return 3
+	due := ""
+	if req.SLADueAt != nil {
+		due = strconv.FormatInt(req.SLADueAt.UnixNano(), 10)
 	}
+	return strconv.Itoa(models.PriorityRank(req.Priority)) + "|" +
+		strconv.FormatInt(req.CreatedAt.UnixNano(), 10) + "|" +
+		req.ID + "|" + due
 }
 
 // parseRequestCursor splits a "id|request_version" cursor string.
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const msgTasksAssigned = "%d new tasks assigned to you"
+
+// BulkUpdateRequests godoc
+// @Summary      Apply one change to many requests
+// @Description  Assigns, unassigns, archives or sets the status, priority or department of up to 200 requests, selected by request_ids or by a feed filter.
+// @Description  All changes are written in one transaction, each as a new request version. Requests that cannot be changed (not found, illegal status transition, modified concurrently) are reported as failed without affecting the rest.
+// @Description  Assignees receive one notification per bulk operation.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        X-Hotel-ID  header    string                    true  "Hotel ID"
+// @Param        request     body      models.BulkRequestsInput  true  "Operation and the requests to apply it to"
+// @Success      200         {object}  models.BulkRequestsResponse
+// @Failure      400         {object}  errs.HTTPError
+// @Failure      401         {object}  errs.HTTPError
+// @Failure      500         {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/bulk [post]
+func (r *RequestsHandler) BulkUpdateRequests(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(string)
+	if !ok || userID == "" {
+		return errs.Unauthorized()
+	}
+
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return err
+	}
+
+	var body models.BulkRequestsInput
+	if err := httpx.BindAndValidate(c, &body); err != nil {
+		return err
+	}
+
+	template, err := bulkUpdateTemplate(&body)
+	if err != nil {
+		return err
+	}
+
+	ids, err := r.selectBulkRequests(c.Context(), hotelID, &body)
+	if err != nil {
+		return err
+	}
+
+	var custom []string
+	if template.Status != nil {
+		if custom, err = r.customStatuses(c.Context(), hotelID, *template.Status); err != nil {
+			return err
+		}
+	}
+
+	results := make(map[string]*models.BulkRequestResult, len(ids))
+	var updates []*models.RequestBulkUpdate
+	for _, id := range ids {
+		current, err := r.RequestRepository.FindLatestRequest(c.Context(), id)
+		if err != nil && !errors.Is(err, errs.ErrNotFoundInDB) {
+			slog.Error("failed to find request for bulk update", "err", err, "requestID", id)
+			return errs.InternalServerError()
+		}
+		if err != nil || current.HotelID != hotelID || current.Status == string(models.StatusDraft) {
+			results[id] = failedBulkResult(id, "request not found")
+			continue
+		}
+
+		if template.Status != nil && !models.RequestStatus(current.Status).CanTransitionTo(models.RequestStatus(*template.Status), custom) {
+			results[id] = failedBulkResult(id, fmt.Sprintf("cannot change request status from %q to %q", current.Status, *template.Status))
+			continue
+		}
+
+		update := *template
+		update.ExpectedVersion = &current.RequestVersion
+		updates = append(updates, &models.RequestBulkUpdate{ID: id, Update: &update})
+	}
+
+	var updated []*models.Request
+	if len(updates) > 0 {
+		written, err := r.RequestRepository.UpdateRequests(c.Context(), updates, &userID)
+		if err != nil {
+			slog.Error("failed to apply bulk request update", "err", err, "hotelID", hotelID, "operation", body.Operation)
+			return errs.InternalServerError()
+		}
+		for i, res := range written {
+			id := updates[i].ID
+			switch {
+			case res.Err == nil:
+				results[id] = &models.BulkRequestResult{RequestID: id, Status: models.BulkResultUpdated, RequestVersion: &res.Request.RequestVersion}
+				updated = append(updated, res.Request)
+			case errors.Is(res.Err, errs.ErrStaleVersionInDB):
+				results[id] = failedBulkResult(id, "request was modified during the bulk update")
+			default:
+				results[id] = failedBulkResult(id, "request not found")
+			}
+		}
+	}
+
+	eventType := models.RequestEventUpdated
+	if template.UserID != nil || template.Unassign {
+		eventType = models.RequestEventAssigned
+	}
+	for _, req := range updated {
+		r.publishRequestEvent(c.Context(), eventType, req.HotelID, req.ID)
+	}
+	if template.UserID != nil && *template.UserID != userID {
+		r.notifyBulkAssignee(c.Context(), *template.UserID, updated)
+	}
+
+	resp := &models.BulkRequestsResponse{Results: make([]*models.BulkRequestResult, 0, len(ids))}
+	for _, id := range ids {
+		res := results[id]
+		if res.Status == models.BulkResultUpdated {
+			resp.Updated++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	return c.JSON(resp)
+}
+
+// bulkUpdateTemplate turns a bulk operation into the update applied to each
+// request, checking that the operation's argument was given.
+func bulkUpdateTemplate(body *models.BulkRequestsInput) (*models.RequestUpdateInput, error) {
+	required := func(field string, value *string) error {
+		if value == nil {
+			return errs.BadRequest(fmt.Sprintf("%s is required for the %s operation", field, body.Operation))
+		}
+		return nil
+	}
+
+	switch body.Operation {
+	case models.BulkAssign:
+		if err := required("user_id", body.UserID); err != nil {
+			return nil, err
+		}
+		userID := strings.TrimSpace(*body.UserID)
+		return &models.RequestUpdateInput{UserID: &userID}, nil
+	case models.BulkUnassign:
+		return &models.RequestUpdateInput{Unassign: true}, nil
+	case models.BulkSetStatus:
+		if err := required("status", body.Status); err != nil {
+			return nil, err
+		}
+		return &models.RequestUpdateInput{Status: body.Status}, nil
+	case models.BulkSetPriority:
+		if err := required("priority", body.Priority); err != nil {
+			return nil, err
+		}
+		return &models.RequestUpdateInput{Priority: body.Priority}, nil
+	case models.BulkSetDepartment:
+		if err := required("department", body.Department); err != nil {
+			return nil, err
+		}
+		return &models.RequestUpdateInput{Department: body.Department}, nil
+	default: // BulkArchive
+		status := string(models.StatusArchived)
+		return &models.RequestUpdateInput{Status: &status}, nil
+	}
+}
+
+// selectBulkRequests resolves the requests a bulk operation applies to, in
+// the order given (or the feed's order for a filter), without duplicates.
+func (r *RequestsHandler) selectBulkRequests(ctx context.Context, hotelID string, body *models.BulkRequestsInput) ([]string, error) {
+	if (len(body.RequestIDs) > 0) == (body.Filter != nil) {
+		return nil, errs.BadRequest("exactly one of request_ids or filter is required")
+	}
+
+	if body.Filter == nil {
+		ids := make([]string, 0, len(body.RequestIDs))
+		for _, id := range body.RequestIDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	if body.Filter.HotelID != hotelID {
+		return nil, errs.BadRequest("filter.hotel_id must match X-Hotel-ID")
+	}
+
+	matches, err := r.RequestRepository.FindRequestsPaginated(ctx, body.Filter, "", time.Time{}, 0, nil, models.MaxBulkRequests+1)
+	if err != nil {
+		slog.Error("failed to select requests for bulk update", "err", err, "hotelID", hotelID)
+		return nil, errs.InternalServerError()
+	}
+	if len(matches) > models.MaxBulkRequests {
+		return nil, errs.BadRequest(fmt.Sprintf("filter matches more than %d requests", models.MaxBulkRequests))
+	}
+
+	ids := make([]string, 0, len(matches))
+	for _, req := range matches {
+		ids = append(ids, req.ID)
+	}
+	return ids, nil
+}
+
+// notifyBulkAssignee sends one notification covering every request assigned
+// to userID by a bulk operation.
+func (r *RequestsHandler) notifyBulkAssignee(ctx context.Context, userID string, assigned []*models.Request) {
+	if r.NotificationSender == nil || len(assigned) == 0 {
+		return
+	}
+
+	title, requestID := models.TaskAssignedTitle, assigned[0].ID
+	if len(assigned) > 1 {
+		title, requestID = fmt.Sprintf(msgTasksAssigned, len(assigned)), ""
+	}
+	names := make([]string, 0, len(assigned))
+	for _, req := range assigned {
+		names = append(names, req.Name)
+	}
+
+	if err := r.NotificationSender.Notify(ctx, userID, models.TypeTaskAssigned, requestID, title, strings.Join(names, ", ")); err != nil {
+		slog.Error("failed to send bulk task assigned notification", "err", err, "userID", userID)
+	}
+}
+
+func failedBulkResult(id, reason string) *models.BulkRequestResult {
+	return &models.BulkRequestResult{RequestID: id, Status: models.BulkResultFailed, Error: &reason}
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bulkRequestA = "730e8400-e458-41d4-a716-446655440001"
+	bulkRequestB = "730e8400-e458-41d4-a716-446655440002"
+	bulkRequestC = "730e8400-e458-41d4-a716-446655440003"
+)
+
+func bulkRequestsApp(h *RequestsHandler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userId", "user_supervisor")
+		return c.Next()
+	})
+	app.Post("/requests/bulk", h.BulkUpdateRequests)
+	return app
+}
+
+func postBulk(t *testing.T, app *fiber.App, body string) (int, *models.BulkRequestsResponse) {
+	req := httptest.NewRequest("POST", "/requests/bulk", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hotel-ID", streamHotelID)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var out models.BulkRequestsResponse
+	if resp.StatusCode == 200 {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp.StatusCode, &out
+}
+
+// bulkRequestStore serves FindLatestRequest from a fixed set of requests and
+// applies UpdateRequests to them.
+func bulkRequestStore(requests map[string]*models.Request, stale ...string) *mockRequestRepository {
+	return &mockRequestRepository{
+		findLatestRequestFunc: func(ctx context.Context, id string) (*models.Request, error) {
+			if req, ok := requests[id]; ok {
+				return req, nil
+			}
+			return nil, errs.ErrNotFoundInDB
+		},
+		updateRequestsFunc: func(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error) {
+			results := make([]*models.RequestBulkUpdateResult, 0, len(updates))
+			for _, u := range updates {
+				if slices.Contains(stale, u.ID) {
+					results = append(results, &models.RequestBulkUpdateResult{Err: errs.ErrStaleVersionInDB})
+					continue
+				}
+				next := *requests[u.ID]
+				next.RequestVersion = next.RequestVersion.Add(time.Second)
+				if u.Update.UserID != nil {
+					next.UserID = u.Update.UserID
+				}
+				if u.Update.Status != nil {
+					next.Status = *u.Update.Status
+				}
+				results = append(results, &models.RequestBulkUpdateResult{Request: &next})
+			}
+			return results, nil
+		},
+	}
+}
+
+func bulkRequest(id, hotelID, name, status string) *models.Request {
+	return &models.Request{
+		ID:             id,
+		MakeRequest:    models.MakeRequest{HotelID: hotelID, Name: name, Status: status, Priority: "low"},
+		RequestVersion: time.Date(2026, 4, 25, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRequestsHandler_BulkUpdateRequests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("assigns by id and notifies the assignee once", func(t *testing.T) {
+		t.Parallel()
+
+		repo := bulkRequestStore(map[string]*models.Request{
+			bulkRequestA: bulkRequest(bulkRequestA, streamHotelID, "extra towels", "pending"),
+			bulkRequestB: bulkRequest(bulkRequestB, streamHotelID, "fix lamp", "in progress"),
+			bulkRequestC: bulkRequest(bulkRequestC, "org_other", "late checkout", "pending"),
+		})
+		var written []*models.RequestBulkUpdate
+		update := repo.updateRequestsFunc
+		repo.updateRequestsFunc = func(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error) {
+			written = updates
+			assert.Equal(t, "user_supervisor", *changedBy)
+			return update(ctx, updates, changedBy)
+		}
+		notifier := &recordingNotifier{}
+		h := NewRequestsHandler(repo, nil, notifier)
+
+		status, resp := postBulk(t, bulkRequestsApp(h), `{
+			"operation": "assign",
+			"user_id": "user_maria",
+			"request_ids": ["`+bulkRequestA+`", "`+bulkRequestB+`", "`+bulkRequestC+`", "`+bulkRequestA+`"]
+		}`)
+		require.Equal(t, 200, status)
+
+		assert.Equal(t, 2, resp.Updated)
+		assert.Equal(t, 1, resp.Failed)
+		require.Len(t, resp.Results, 3)
+		assert.Equal(t, models.BulkResultUpdated, resp.Results[0].Status)
+		assert.Equal(t, models.BulkResultUpdated, resp.Results[1].Status)
+		assert.Equal(t, models.BulkResultFailed, resp.Results[2].Status)
+		assert.Equal(t, "request not found", *resp.Results[2].Error)
+
+		require.Len(t, written, 2)
+		for _, u := range written {
+			assert.Equal(t, "user_maria", *u.Update.UserID)
+			require.NotNil(t, u.Update.ExpectedVersion)
+		}
+
+		assert.Equal(t, []string{"user_maria"}, notifier.users)
+		assert.Equal(t, []string{"2 new tasks assigned to you"}, notifier.titles)
+		assert.Equal(t, []string{"extra towels, fix lamp"}, notifier.bodies)
+	})
+
+	t.Run("reports illegal transitions and concurrent changes per request", func(t *testing.T) {
+		t.Parallel()
+
+		repo := bulkRequestStore(map[string]*models.Request{
+			bulkRequestA: bulkRequest(bulkRequestA, streamHotelID, "extra towels", "pending"),
+			bulkRequestB: bulkRequest(bulkRequestB, streamHotelID, "fix lamp", "in progress"),
+			bulkRequestC: bulkRequest(bulkRequestC, streamHotelID, "late checkout", "in progress"),
+		}, bulkRequestC)
+		h := NewRequestsHandler(repo, nil, nil)
+
+		status, resp := postBulk(t, bulkRequestsApp(h), `{
+			"operation": "set_status",
+			"status": "completed",
+			"request_ids": ["`+bulkRequestA+`", "`+bulkRequestB+`", "`+bulkRequestC+`"]
+		}`)
+		require.Equal(t, 200, status)
+
+		assert.Equal(t, 1, resp.Updated)
+		assert.Equal(t, `cannot change request status from "pending" to "completed"`, *resp.Results[0].Error)
+		assert.Equal(t, models.BulkResultUpdated, resp.Results[1].Status)
+		assert.Equal(t, "request was modified during the bulk update", *resp.Results[2].Error)
+	})
+
+	t.Run("does not touch drafts awaiting review", func(t *testing.T) {
+		t.Parallel()
+
+		repo := bulkRequestStore(map[string]*models.Request{
+			bulkRequestA: bulkRequest(bulkRequestA, streamHotelID, "extra towels", "pending"),
+			bulkRequestB: bulkRequest(bulkRequestB, streamHotelID, "noisy AC", string(models.StatusDraft)),
+		})
+		h := NewRequestsHandler(repo, nil, nil)
+
+		status, resp := postBulk(t, bulkRequestsApp(h), `{
+			"operation": "set_priority",
+			"priority": "high",
+			"request_ids": ["`+bulkRequestA+`", "`+bulkRequestB+`"]
+		}`)
+		require.Equal(t, 200, status)
+
+		assert.Equal(t, 1, resp.Updated)
+		assert.Equal(t, models.BulkResultUpdated, resp.Results[0].Status)
+		assert.Equal(t, "request not found", *resp.Results[1].Error)
+	})
+
+	t.Run("selects requests with a feed filter", func(t *testing.T) {
+		t.Parallel()
+
+		repo := bulkRequestStore(map[string]*models.Request{
+			bulkRequestA: bulkRequest(bulkRequestA, streamHotelID, "extra towels", "pending"),
+		})
+		repo.findRequestsPaginatedFunc = func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, error) {
+			assert.Equal(t, []string{"low"}, input.Priorities)
+			assert.Equal(t, models.MaxBulkRequests+1, limit)
+			return []*models.GuestRequest{{ID: bulkRequestA}}, nil
+		}
+		h := NewRequestsHandler(repo, nil, nil)
+
+		status, resp := postBulk(t, bulkRequestsApp(h), `{
+			"operation": "archive",
+			"filter": {"hotel_id": "`+streamHotelID+`", "priorities": ["low"]}
+		}`)
+		require.Equal(t, 200, status)
+		assert.Equal(t, 1, resp.Updated)
+	})
+
+	t.Run("rejects malformed selections and missing arguments", func(t *testing.T) {
+		t.Parallel()
+
+		app := bulkRequestsApp(NewRequestsHandler(&mockRequestRepository{}, nil, nil))
+
+		for _, body := range []string{
+			`{"operation": "assign", "request_ids": ["` + bulkRequestA + `"]}`,
+			`{"operation": "unassign"}`,
+			`{"operation": "unassign", "request_ids": ["` + bulkRequestA + `"], "filter": {"hotel_id": "` + streamHotelID + `"}}`,
+			`{"operation": "unassign", "filter": {"hotel_id": "org_other"}}`,
+			`{"operation": "delete", "request_ids": ["` + bulkRequestA + `"]}`,
+		} {
+			status, _ := postBulk(t, app, body)
+			assert.Equal(t, 400, status, body)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListGenerateRequests godoc
+// @Summary      lists request generation workflows
+// @Description  Lists a hotel's async request generations, most recent first, with their status
+// @Tags         requests
+// @Produce      json
+// @Param        X-Hotel-ID    header  string  true   "Hotel ID"
+// @Param        requested_by  query   string  false  "Only generations submitted by this user"
+// @Param        cursor        query   string  false  "Cursor from the previous page"
+// @Param        limit         query   int     false  "Page size (max 100)"
+// @Success      200  {object}  utils.CursorPage[temporalclient.GenerateRequestExecution]
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Security     BearerAuth
+// @Router       /request/generate/async [get]
+func (r *RequestsHandler) ListGenerateRequests(c *fiber.Ctx) error {
+	if r.WorkflowClient == nil {
+		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("temporal workflow client unavailable"))
+	}
+
+	hotelID := c.Get("X-Hotel-ID")
+	if hotelID == "" {
+		return errs.BadRequest("X-Hotel-ID header is required")
+	}
+	input := models.ListGenerateRequestsInput{
+		HotelID:     hotelID,
+		RequestedBy: c.Query("requested_by"),
+		Cursor:      c.Query("cursor"),
+		Limit:       c.QueryInt("limit"),
+	}
+	if err := httpx.Validate(&input); err != nil {
+		return err
+	}
+
+	page, err := r.WorkflowClient.ListGenerateRequests(c.Context(), temporalclient.GenerateRequestFilter{
+		HotelID:     input.HotelID,
+		RequestedBy: input.RequestedBy,
+		Cursor:      input.Cursor,
+		Limit:       input.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, temporalclient.ErrInvalidCursor) {
+			return errs.BadRequest("invalid cursor")
+		}
+		slog.Error("failed to list generate request workflows", "error", err, "hotel_id", input.HotelID)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(page)
+}
+
+// CancelGenerateRequest godoc
+// @Summary      cancels a request generation workflow
+// @Description  Abandons an async request generation of the hotel. Once the request has been generated it is stored regardless, and cancelling fails with 409.
+// @Tags         requests
+// @Produce      json
+// @Param        X-Hotel-ID  header  string  true  "Hotel ID"
+// @Param        workflowId  path    string  true  "Workflow ID"
+// @Success      202  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Security     BearerAuth
+// @Router       /request/generate/async/{workflowId}/cancel [post]
+func (r *RequestsHandler) CancelGenerateRequest(c *fiber.Ctx) error {
+	if r.WorkflowClient == nil {
+		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("temporal workflow client unavailable"))
+	}
+
+	hotelID := c.Get("X-Hotel-ID")
+	if hotelID == "" {
+		return errs.BadRequest("X-Hotel-ID header is required")
+	}
+
+	workflowID := c.Params("workflowId")
+	if err := r.WorkflowClient.CancelGenerateRequest(c.Context(), hotelID, workflowID); err != nil {
+		return generateWorkflowError(err, workflowID, "failed to cancel generate request workflow")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"workflow_id": workflowID,
+	})
+}
+
+// AmendGenerateRequest godoc
+// @Summary      amends the text of a request generation workflow
+// @Description  Replaces the raw text of an async request generation of the hotel and starts generating again from it. Once the request has been generated, amending fails with 409.
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        X-Hotel-ID  header  string                            true  "Hotel ID"
+// @Param        workflowId  path    string                            true  "Workflow ID"
+// @Param        request     body    models.AmendGenerateRequestInput  true  "Replacement raw text"
+// @Success      202  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Security     BearerAuth
+// @Router       /request/generate/async/{workflowId}/amend [post]
+func (r *RequestsHandler) AmendGenerateRequest(c *fiber.Ctx) error {
+	if r.WorkflowClient == nil {
+		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("temporal workflow client unavailable"))
+	}
+
+	hotelID := c.Get("X-Hotel-ID")
+	if hotelID == "" {
+		return errs.BadRequest("X-Hotel-ID header is required")
+	}
+
+	var input models.AmendGenerateRequestInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+
+	workflowID := c.Params("workflowId")
+	if err := r.WorkflowClient.AmendGenerateRequest(c.Context(), hotelID, workflowID, input.RawText); err != nil {
+		return generateWorkflowError(err, workflowID, "failed to amend generate request workflow")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"workflow_id": workflowID,
+	})
+}
+
+// generateWorkflowError maps an error from changing a generate workflow to
+// its HTTP error.
+func generateWorkflowError(err error, workflowID, msg string) error {
+	switch {
+	case temporalclient.IsWorkflowNotFound(err):
+		return errs.NotFound("workflow", "id", workflowID)
+	case errors.Is(err, temporalclient.ErrGenerationFinished):
+		return errs.NewHTTPError(fiber.StatusConflict, err)
+	default:
+		slog.Error(msg, "error", err, "workflow_id", workflowID)
+		return errs.InternalServerError()
+	}
+}
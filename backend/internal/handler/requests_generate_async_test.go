@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+)
+
+func generateAsyncApp(h *RequestsHandler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Get("/request/generate/async", h.ListGenerateRequests)
+	app.Post("/request/generate/async/:workflowId/cancel", h.CancelGenerateRequest)
+	app.Post("/request/generate/async/:workflowId/amend", h.AmendGenerateRequest)
+	return app
+}
+
+func TestRequestHandler_ListGenerateRequests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the hotel's generations", func(t *testing.T) {
+		t.Parallel()
+
+		var got temporalclient.GenerateRequestFilter
+		next := "next-page"
+		requestedBy := "user_123"
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{
+			listGenerateRequestsFunc: func(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+				got = filter
+				return utils.CursorPage[temporalclient.GenerateRequestExecution]{
+					Items: []temporalclient.GenerateRequestExecution{{
+						WorkflowID:  "generate-request-123",
+						Status:      "pending",
+						HotelID:     streamHotelID,
+						RequestedBy: &requestedBy,
+						StartedAt:   time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC),
+					}},
+					NextCursor: &next,
+					HasMore:    true,
+				}, nil
+			},
+		}
+
+		req := httptest.NewRequest("GET", "/request/generate/async?requested_by=user_123&limit=10&cursor=abc", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		resp, err := generateAsyncApp(h).Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		assert.Equal(t, temporalclient.GenerateRequestFilter{HotelID: streamHotelID, RequestedBy: "user_123", Cursor: "abc", Limit: 10}, got)
+
+		var page utils.CursorPage[temporalclient.GenerateRequestExecution]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "generate-request-123", page.Items[0].WorkflowID)
+		assert.Equal(t, "pending", page.Items[0].Status)
+		assert.True(t, page.HasMore)
+	})
+
+	t.Run("returns 400 without a hotel", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{}
+
+		resp, err := generateAsyncApp(h).Test(httptest.NewRequest("GET", "/request/generate/async", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("returns 400 for an invalid cursor", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{
+			listGenerateRequestsFunc: func(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+				return utils.CursorPage[temporalclient.GenerateRequestExecution]{}, temporalclient.ErrInvalidCursor
+			},
+		}
+
+		req := httptest.NewRequest("GET", "/request/generate/async?cursor=%25", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		resp, err := generateAsyncApp(h).Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestRequestHandler_CancelGenerateRequest(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "returns 202 once cancelled", status: fiber.StatusAccepted},
+		{name: "returns 409 once generated", err: temporalclient.ErrGenerationFinished, status: fiber.StatusConflict},
+		{name: "returns 404 for an unknown workflow", err: serviceerror.NewNotFound("workflow not found"), status: fiber.StatusNotFound},
+		{name: "returns 500 when temporal fails", err: errors.New("temporal down"), status: fiber.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var hotel, cancelled string
+			h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+			h.WorkflowClient = &mockWorkflowClient{
+				cancelGenerateRequestFunc: func(ctx context.Context, hotelID, workflowID string) error {
+					hotel, cancelled = hotelID, workflowID
+					return tc.err
+				},
+			}
+
+			req := httptest.NewRequest("POST", "/request/generate/async/generate-request-123/cancel", nil)
+			req.Header.Set("X-Hotel-ID", streamHotelID)
+			resp, err := generateAsyncApp(h).Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, streamHotelID, hotel)
+			assert.Equal(t, "generate-request-123", cancelled)
+		})
+	}
+
+	t.Run("returns 400 without a hotel", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{}
+
+		resp, err := generateAsyncApp(h).Test(httptest.NewRequest("POST", "/request/generate/async/generate-request-123/cancel", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("returns 503 when workflow client is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+
+		resp, err := generateAsyncApp(h).Test(httptest.NewRequest("POST", "/request/generate/async/generate-request-123/cancel", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	})
+}
+
+func TestRequestHandler_AmendGenerateRequest(t *testing.T) {
+	t.Parallel()
+
+	amend := func(t *testing.T, h *RequestsHandler, body string) int {
+		t.Helper()
+
+		req := httptest.NewRequest("POST", "/request/generate/async/generate-request-123/amend", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		resp, err := generateAsyncApp(h).Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("returns 202 with the text sent to the workflow", func(t *testing.T) {
+		t.Parallel()
+
+		var gotHotel, gotID, gotText string
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{
+			amendGenerateRequestFunc: func(ctx context.Context, hotelID, workflowID, rawText string) error {
+				gotHotel, gotID, gotText = hotelID, workflowID, rawText
+				return nil
+			},
+		}
+
+		status := amend(t, h, `{"raw_text":"Room 505 needs extra towels"}`)
+		assert.Equal(t, fiber.StatusAccepted, status)
+		assert.Equal(t, streamHotelID, gotHotel)
+		assert.Equal(t, "generate-request-123", gotID)
+		assert.Equal(t, "Room 505 needs extra towels", gotText)
+	})
+
+	t.Run("returns 409 once generated", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{
+			amendGenerateRequestFunc: func(ctx context.Context, hotelID, workflowID, rawText string) error {
+				return temporalclient.ErrGenerationFinished
+			},
+		}
+
+		assert.Equal(t, fiber.StatusConflict, amend(t, h, `{"raw_text":"Room 505 needs extra towels"}`))
+	})
+
+	t.Run("returns 400 for blank text", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{}
+
+		assert.Equal(t, fiber.StatusBadRequest, amend(t, h, `{"raw_text":"  "}`))
+	})
+
+	t.Run("returns 404 for another hotel's generation", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
+		h.WorkflowClient = &mockWorkflowClient{
+			amendGenerateRequestFunc: func(ctx context.Context, hotelID, workflowID, rawText string) error {
+				return temporalclient.ErrWorkflowNotFound
+			},
+		}
+
+		assert.Equal(t, fiber.StatusNotFound, amend(t, h, `{"raw_text":"Room 505 needs extra towels"}`))
+	})
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"strings"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxAudioSize caps a voice note at 3 MiB, which keeps an upload under the
+// server's 4 MiB body limit.
+const maxAudioSize = 3 << 20
+
+// allowedAudioTypes are the voice note formats the models accept.
+var allowedAudioTypes = map[string]struct{}{
+	"audio/mpeg":  {},
+	"audio/mp3":   {},
+	"audio/mp4":   {},
+	"audio/x-m4a": {},
+	"audio/aac":   {},
+	"audio/wav":   {},
+	"audio/x-wav": {},
+	"audio/webm":  {},
+	"audio/ogg":   {},
+	"audio/flac":  {},
+}
+
+// GenerateRequestFromAudio godoc
+// @Summary      generates a request from a voice note
+// @Description  Transcribes a voice note and generates a request from the transcript as POST /request/generate does. The request is not stored: the transcript is returned with it so staff can confirm before saving.
+// @Description  Send the clip as multipart form data in an "audio" file field, or as JSON with the key of a clip uploaded to S3 with a presigned URL under voice-notes/{hotel_id}/. Clips are limited to 3 MiB.
+// @Tags         requests
+// @Accept       json,mpfd
+// @Produce      json
+// @Param        request  body      models.GenerateRequestAudioInput  true  "Hotel and S3 key of the voice note"
+// @Param        audio    formData  file                              false "Voice note"
+// @Success      200      {object}  models.GenerateRequestAudioResponse
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      422      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Failure      503      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/generate/audio [post]
+func (r *RequestsHandler) GenerateRequestFromAudio(c *fiber.Ctx) error {
+	if r.GenerateRequestService == nil {
+		return errGenerationUnavailable()
+	}
+
+	var input models.GenerateRequestAudioInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+
+	audio, err := r.voiceNote(c, input.HotelID, input.Key)
+	if err != nil {
+		return err
+	}
+
+	transcribed, err := r.GenerateRequestService.RunTranscribeAudio(c.Context(), aiflows.TranscribeAudioInput{Audio: *audio})
+	if err != nil {
+		slog.Error("genkit failed to transcribe a voice note", "error", err)
+		return errs.InternalServerError()
+	}
+	if transcribed.Transcript == "" {
+		return errs.InvalidRequestData(map[string]string{"audio": "no speech could be transcribed"})
+	}
+
+	parsed, err := r.GenerateRequestService.RunGenerateRequest(c.Context(), aiflows.GenerateRequestInput{
+		RawText: transcribed.Transcript,
+		HotelID: input.HotelID,
+	})
+	if err != nil {
+		slog.Error("genkit failed to generate a request", "error", err)
+		return errs.InternalServerError()
+	}
+	if err := httpx.Validate(&parsed); err != nil {
+		slog.Error("generated request failed validation", "error", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(models.GenerateRequestAudioResponse{
+		Transcript:              transcribed.Transcript,
+		GenerateRequestResponse: responseFromGenerated(input.HotelID, transcribed.Transcript, &parsed),
+	})
+}
+
+// voiceNote reads the clip uploaded in the "audio" form field or, failing
+// that, the one the hotel stored in S3 under key.
+func (r *RequestsHandler) voiceNote(c *fiber.Ctx, hotelID, key string) (*aiflows.Media, error) {
+	if file, err := c.FormFile("audio"); err == nil {
+		contentType, err := validateAudio(file.Header.Get(fiber.HeaderContentType), file.Size)
+		if err != nil {
+			return nil, err
+		}
+		f, err := file.Open()
+		if err != nil {
+			slog.Error("failed to open uploaded voice note", "err", err)
+			return nil, errs.InternalServerError()
+		}
+		defer func() { _ = f.Close() }()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			slog.Error("failed to read uploaded voice note", "err", err)
+			return nil, errs.InternalServerError()
+		}
+		return &aiflows.Media{ContentType: contentType, Data: data}, nil
+	}
+
+	if strings.TrimSpace(key) == "" {
+		return nil, errs.BadRequest("an audio file or key is required")
+	}
+	if !uploadedForHotel(voiceNoteKeyFolder, hotelID, key) {
+		return nil, errs.BadRequest(fmt.Sprintf("key: %s is not under %s/%s/", key, voiceNoteKeyFolder, hotelID))
+	}
+	if r.S3Storage == nil {
+		return nil, errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("voice notes stored in S3 are unavailable"))
+	}
+
+	info, err := r.S3Storage.HeadFile(c.Context(), key)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInStorage) {
+			return nil, errs.NotFound("audio", "key", key)
+		}
+		slog.Error("failed to inspect voice note", "err", err, "key", key)
+		return nil, errs.InternalServerError()
+	}
+	contentType, err := validateAudio(info.ContentType, info.ContentLength)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.S3Storage.GetFile(c.Context(), key, maxAudioSize)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInStorage) {
+			return nil, errs.NotFound("audio", "key", key)
+		}
+		slog.Error("failed to read voice note", "err", err, "key", key)
+		return nil, errs.InternalServerError()
+	}
+	return &aiflows.Media{ContentType: contentType, Data: data}, nil
+}
+
+// validateAudio checks a voice note's declared type and size and returns its
+// media type without parameters.
+func validateAudio(contentType string, size int64) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if _, ok := allowedAudioTypes[mediaType]; !ok {
+		return "", errs.InvalidRequestData(map[string]string{
+			"audio": fmt.Sprintf("%q is not an allowed audio type", contentType),
+		})
+	}
+	if size <= 0 || size > maxAudioSize {
+		return "", errs.InvalidRequestData(map[string]string{
+			"audio": fmt.Sprintf("must be between 1 and %d bytes", maxAudioSize),
+		})
+	}
+	return mediaType, nil
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transcribingLLM transcribes any clip to transcript and records the audio
+// and raw text it was given.
+func transcribingLLM(transcript string) (*mockLLMService, *aiflows.Media, *string) {
+	var audio aiflows.Media
+	var rawText string
+	return &mockLLMService{
+		runTranscribeAudioFunc: func(ctx context.Context, input aiflows.TranscribeAudioInput) (aiflows.TranscribeAudioOutput, error) {
+			audio = input.Audio
+			return aiflows.TranscribeAudioOutput{Transcript: transcript}, nil
+		},
+		runGenerateRequestFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			rawText = input.RawText
+			return aiflows.EnrichedGenerateRequestOutput{
+				GenerateRequestOutput: aiflows.GenerateRequestOutput{
+					Name: "Extra Towels", RequestType: "one-time", Status: "pending", Priority: "medium", Language: "en",
+				},
+			}, nil
+		},
+	}, &audio, &rawText
+}
+
+func voiceNoteForm(t *testing.T, contentType string, clip []byte) (string, *bytes.Buffer) {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("hotel_id", streamHotelID))
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="audio"; filename="note"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(clip)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+	return form.FormDataContentType(), &body
+}
+
+func postVoiceNote(t *testing.T, h *RequestsHandler, contentType string, body io.Reader) (int, *models.GenerateRequestAudioResponse) {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Post("/request/generate/audio", h.GenerateRequestFromAudio)
+
+	req := httptest.NewRequest("POST", "/request/generate/audio", body)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var out models.GenerateRequestAudioResponse
+	if resp.StatusCode == 200 {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp.StatusCode, &out
+}
+
+func TestRequestHandler_GenerateRequestFromAudio(t *testing.T) {
+	t.Parallel()
+
+	const transcript = "Room 504 needs extra towels"
+	clip := []byte("RIFF....WAVEfmt ")
+
+	t.Run("transcribes an uploaded clip and generates from the transcript", func(t *testing.T) {
+		t.Parallel()
+
+		llm, audio, rawText := transcribingLLM(transcript)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+
+		contentType, body := voiceNoteForm(t, "audio/wav", clip)
+		status, resp := postVoiceNote(t, h, contentType, body)
+		require.Equal(t, 200, status)
+
+		assert.Equal(t, "audio/wav", audio.ContentType)
+		assert.Equal(t, clip, audio.Data)
+		assert.Equal(t, transcript, *rawText)
+		assert.Equal(t, transcript, resp.Transcript)
+		assert.Equal(t, "Extra Towels", resp.Request.Name)
+		assert.Equal(t, streamHotelID, resp.Request.HotelID)
+		require.NotNil(t, resp.Request.OriginalText)
+		assert.Equal(t, transcript, *resp.Request.OriginalText)
+	})
+
+	t.Run("reads a clip uploaded to S3", func(t *testing.T) {
+		t.Parallel()
+
+		llm, audio, _ := transcribingLLM(transcript)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.S3Storage = &mockS3Storage{
+			headFileFunc: func(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+				return &models.S3ObjectInfo{ContentType: "audio/mpeg", ContentLength: int64(len(clip))}, nil
+			},
+			getFileFunc: func(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+				assert.Equal(t, "voice-notes/"+streamHotelID+"/note.mp3", key)
+				return clip, nil
+			},
+		}
+
+		status, resp := postVoiceNote(t, h, "application/json",
+			bytes.NewBufferString(`{"hotel_id":"`+streamHotelID+`","key":"voice-notes/`+streamHotelID+`/note.mp3"}`))
+		require.Equal(t, 200, status)
+
+		assert.Equal(t, "audio/mpeg", audio.ContentType)
+		assert.Equal(t, transcript, resp.Transcript)
+	})
+
+	t.Run("returns 404 for a missing S3 clip", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _, _ := transcribingLLM(transcript)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.S3Storage = &mockS3Storage{}
+
+		status, _ := postVoiceNote(t, h, "application/json",
+			bytes.NewBufferString(`{"hotel_id":"`+streamHotelID+`","key":"voice-notes/`+streamHotelID+`/missing.mp3"}`))
+		assert.Equal(t, 404, status)
+	})
+
+	t.Run("returns 400 for a key outside the hotel's voice notes", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _, _ := transcribingLLM(transcript)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.S3Storage = &mockS3Storage{}
+
+		status, _ := postVoiceNote(t, h, "application/json",
+			bytes.NewBufferString(`{"hotel_id":"`+streamHotelID+`","key":"voice-notes/org_other/note.mp3"}`))
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("returns 400 without a clip", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _, _ := transcribingLLM(transcript)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+
+		status, _ := postVoiceNote(t, h, "application/json", bytes.NewBufferString(`{"hotel_id":"`+streamHotelID+`"}`))
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("returns 422 for a non-audio upload", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _, _ := transcribingLLM(transcript)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+
+		contentType, body := voiceNoteForm(t, "image/png", clip)
+		status, _ := postVoiceNote(t, h, contentType, body)
+		assert.Equal(t, 422, status)
+	})
+
+	t.Run("returns 422 when nothing could be transcribed", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _, rawText := transcribingLLM("")
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+
+		contentType, body := voiceNoteForm(t, "audio/wav", clip)
+		status, _ := postVoiceNote(t, h, contentType, body)
+		assert.Equal(t, 422, status)
+		assert.Empty(t, *rawText)
+	})
+
+	t.Run("returns 503 when no LLM provider is configured", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+
+		contentType, body := voiceNoteForm(t, "audio/wav", clip)
+		status, _ := postVoiceNote(t, h, contentType, body)
+		assert.Equal(t, 503, status)
+	})
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generatedBatch() aiflows.GenerateRequestBatchOutput {
+	roomID := "550e8400-e29b-41d4-a716-446655440504"
+	assignee := "user_maria"
+	return aiflows.GenerateRequestBatchOutput{Requests: []aiflows.EnrichedGenerateRequestOutput{
+		{
+			RoomID: &roomID,
+			UserID: &assignee,
+			GenerateRequestOutput: aiflows.GenerateRequestOutput{
+				Name: "Extra Towels", RequestType: "one-time", Status: "pending", Priority: "medium",
+			},
+		},
+		{
+			GenerateRequestOutput: aiflows.GenerateRequestOutput{
+				Name: "Noisy AC", RequestType: "one-time", Status: "pending", Priority: "medium",
+				Warning: &aiflows.GenerateRequestWarning{Code: "room_not_found", Message: "Room 999 could not be resolved for this hotel."},
+			},
+		},
+		{
+			// Invalid: no priority.
+			GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "Late Checkout", RequestType: "one-time", Status: "pending"},
+		},
+	}}
+}
+
+func postGenerateBatch(t *testing.T, h *RequestsHandler, body string) (int, *models.GenerateRequestBatchResponse) {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Post("/request/generate/batch", h.GenerateRequestBatch)
+
+	req := httptest.NewRequest("POST", "/request/generate/batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var out models.GenerateRequestBatchResponse
+	if resp.StatusCode == 200 {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp.StatusCode, &out
+}
+
+func TestRequestHandler_GenerateRequestBatch(t *testing.T) {
+	t.Parallel()
+
+	llm := &mockLLMService{
+		runGenerateRequestBatchFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.GenerateRequestBatchOutput, error) {
+			return generatedBatch(), nil
+		},
+	}
+
+	t.Run("returns each valid request with its own warning", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		status, resp := postGenerateBatch(t, h, `{"hotel_id":"`+streamHotelID+`","raw_text":"need towels in 504, and the AC in 999 is loud"}`)
+		require.Equal(t, 200, status)
+
+		assert.False(t, resp.Persisted)
+		require.Len(t, resp.Requests, 2)
+		assert.Equal(t, "Extra Towels", resp.Requests[0].Request.Name)
+		assert.Equal(t, streamHotelID, resp.Requests[0].Request.HotelID)
+		assert.Nil(t, resp.Requests[0].Warning)
+		assert.Equal(t, "Noisy AC", resp.Requests[1].Request.Name)
+		require.NotNil(t, resp.Requests[1].Warning)
+		assert.Equal(t, "room_not_found", resp.Requests[1].Warning.Code)
+		assert.NotEqual(t, resp.Requests[0].Request.ID, resp.Requests[1].Request.ID)
+
+		require.Len(t, resp.Skipped, 1)
+		assert.Equal(t, 2, resp.Skipped[0].Index)
+		assert.Equal(t, "Late Checkout", resp.Skipped[0].Name)
+		assert.Contains(t, resp.Skipped[0].Error, "priority")
+	})
+
+	t.Run("persists all requests in one call and notifies assignees", func(t *testing.T) {
+		t.Parallel()
+
+		var inserted []*models.Request
+		notifier := &recordingNotifier{}
+		h := NewRequestsHandler(&mockRequestRepository{
+			makeRequestsFunc: func(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+				inserted = reqs
+				return reqs, nil
+			},
+		}, llm, notifier)
+
+		status, resp := postGenerateBatch(t, h, `{"hotel_id":"`+streamHotelID+`","raw_text":"need towels in 504, and the AC in 999 is loud","persist":true}`)
+		require.Equal(t, 200, status)
+
+		assert.True(t, resp.Persisted)
+		require.Len(t, inserted, 2)
+		assert.Equal(t, inserted[0].ID, resp.Requests[0].Request.ID)
+		assert.Equal(t, []string{"user_maria"}, notifier.users)
+	})
+
+	t.Run("returns 500 when persisting fails", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{
+			makeRequestsFunc: func(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+				return nil, errors.New("db down")
+			},
+		}, llm, nil)
+
+		status, _ := postGenerateBatch(t, h, `{"hotel_id":"`+streamHotelID+`","raw_text":"towels","persist":true}`)
+		assert.Equal(t, 500, status)
+	})
+
+	t.Run("returns 400 without raw_text", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		status, _ := postGenerateBatch(t, h, `{"hotel_id":"`+streamHotelID+`","raw_text":""}`)
+		assert.Equal(t, 400, status)
+	})
+	t.Run("returns 503 when no LLM provider is configured", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		status, _ := postGenerateBatch(t, h, `{"hotel_id":"`+streamHotelID+`","raw_text":"towels"}`)
+		assert.Equal(t, 503, status)
+	})
+}
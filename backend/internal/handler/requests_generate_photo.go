@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// allowedPhotoTypes are the attachment types a photo may have.
+var allowedPhotoTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/webp": {},
+	"image/heic": {},
+}
+
+// Photos and voice notes for generation are uploaded under
+// <folder>/<hotel_id>/. Only keys in the hotel's own folder are read, so a
+// request cannot pull in, or later delete as its attachment, any other object
+// in the bucket.
+const (
+	photoKeyFolder     = "request-photos"
+	voiceNoteKeyFolder = "voice-notes"
+)
+
+// uploadedForHotel reports whether key names an object in the hotel's folder.
+func uploadedForHotel(folder, hotelID, key string) bool {
+	name, ok := strings.CutPrefix(key, folder+"/"+hotelID+"/")
+	return ok && name != "" && path.Clean(key) == key
+}
+
+// photo is an image read from S3 for generation, kept with what S3 reported
+// about it so it can be attached afterwards.
+type photo struct {
+	key   string
+	info  *models.S3ObjectInfo
+	image aiflows.Media
+}
+
+// GenerateRequestFromPhoto godoc
+// @Summary      generates a request from photos
+// @Description  Generates a request from one to four photos uploaded to S3 with presigned URLs under request-photos/{hotel_id}/, plus optional text. The name, category, department and priority are inferred from the photos.
+// @Description  The request is created and the photos are attached to it. Like persisted generated requests, it is held as a draft when it needs review, which includes photos that do not appear related to hotel operations (warning code unrelated_image).
+// @Tags         requests
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.GenerateRequestPhotoInput  true  "Hotel, photo keys and optional text"
+// @Success      201      {object}  models.GenerateRequestPhotoResponse
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      404      {object}  errs.HTTPError
+// @Failure      422      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Failure      503      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/generate/photo [post]
+func (r *RequestsHandler) GenerateRequestFromPhoto(c *fiber.Ctx) error {
+	if r.GenerateRequestService == nil {
+		return errGenerationUnavailable()
+	}
+	if r.S3Storage == nil || r.AttachmentRepository == nil {
+		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("photo request generation is unavailable: attachments are not configured"))
+	}
+
+	var input models.GenerateRequestPhotoInput
+	if err := httpx.BindAndValidate(c, &input); err != nil {
+		return err
+	}
+
+	photos := make([]photo, 0, len(input.Keys))
+	images := make([]aiflows.Media, 0, len(input.Keys))
+	for _, key := range input.Keys {
+		if !uploadedForHotel(photoKeyFolder, input.HotelID, key) {
+			return errs.BadRequest(fmt.Sprintf("keys: %s is not under %s/%s/", key, photoKeyFolder, input.HotelID))
+		}
+		p, err := r.readPhoto(c, key)
+		if err != nil {
+			return err
+		}
+		photos = append(photos, *p)
+		images = append(images, p.image)
+	}
+
+	parsed, err := r.GenerateRequestService.RunGeneratePhotoRequest(c.Context(), aiflows.GeneratePhotoRequestInput{
+		HotelID: input.HotelID,
+		Text:    input.Text,
+		Images:  images,
+	})
+	if err != nil {
+		slog.Error("genkit failed to generate a request from photos", "error", err)
+		return errs.InternalServerError()
+	}
+	if err := httpx.Validate(&parsed); err != nil {
+		slog.Error("generated request failed validation", "error", err)
+		return errs.InternalServerError()
+	}
+
+	resps := []models.GenerateRequestResponse{responseFromGenerated(input.HotelID, input.Text, &parsed)}
+	if input.Text == "" {
+		// There is no message to keep, only the photos.
+		resps[0].Request.OriginalText, resps[0].Request.EnglishText = nil, nil
+	}
+	if err := r.persistGenerated(c, input.Text, resps); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.GenerateRequestPhotoResponse{
+		GenerateRequestResponse: resps[0],
+		Attachments:             r.attachPhotos(c, resps[0].Request.ID, photos),
+	})
+}
+
+// readPhoto checks the photo stored in S3 under key and reads it.
+func (r *RequestsHandler) readPhoto(c *fiber.Ctx, key string) (*photo, error) {
+	info, err := r.S3Storage.HeadFile(c.Context(), key)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInStorage) {
+			return nil, errs.NotFound("photo", "key", key)
+		}
+		slog.Error("failed to inspect photo", "err", err, "key", key)
+		return nil, errs.InternalServerError()
+	}
+
+	contentType, _, _ := mime.ParseMediaType(info.ContentType)
+	if _, ok := allowedPhotoTypes[contentType]; !ok {
+		return nil, errs.InvalidRequestData(map[string]string{
+			"keys": fmt.Sprintf("%s is %q, which is not an allowed photo type", key, info.ContentType),
+		})
+	}
+	if info.ContentLength <= 0 || info.ContentLength > maxAttachmentSize {
+		return nil, errs.InvalidRequestData(map[string]string{
+			"keys": fmt.Sprintf("%s must be between 1 and %d bytes", key, maxAttachmentSize),
+		})
+	}
+	info.ContentType = contentType
+
+	data, err := r.S3Storage.GetFile(c.Context(), key, maxAttachmentSize)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInStorage) {
+			return nil, errs.NotFound("photo", "key", key)
+		}
+		slog.Error("failed to read photo", "err", err, "key", key)
+		return nil, errs.InternalServerError()
+	}
+
+	return &photo{key: key, info: info, image: aiflows.Media{ContentType: contentType, Data: data}}, nil
+}
+
+// attachPhotos records the photos as confirmed attachments of the request. The
+// request already exists by then, so a photo that cannot be attached is only
+// logged and left out of the result.
+func (r *RequestsHandler) attachPhotos(c *fiber.Ctx, requestID string, photos []photo) []*models.RequestAttachment {
+	var uploadedBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		uploadedBy = &uid
+	}
+
+	attachments := make([]*models.RequestAttachment, 0, len(photos))
+	for _, p := range photos {
+		attachment, err := r.AttachmentRepository.InsertRequestAttachment(c.Context(), &models.RequestAttachment{
+			ID:          uuid.New().String(),
+			RequestID:   requestID,
+			Key:         p.key,
+			FileName:    path.Base(p.key),
+			ContentType: p.info.ContentType,
+			SizeBytes:   p.info.ContentLength,
+			UploadedBy:  uploadedBy,
+		})
+		if err != nil {
+			slog.Error("failed to attach photo to generated request", "err", err, "request_id", requestID, "key", p.key)
+			continue
+		}
+
+		confirmed, err := r.AttachmentRepository.ConfirmRequestAttachment(c.Context(), requestID, attachment.ID, p.info)
+		if err != nil {
+			slog.Error("failed to confirm photo attached to generated request", "err", err, "attachment_id", attachment.ID)
+			continue
+		}
+		attachments = append(attachments, confirmed)
+	}
+	return attachments
+}
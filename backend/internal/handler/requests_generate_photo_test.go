@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// photoS3 serves the given photos by key, all as JPEGs.
+func photoS3(photos map[string][]byte) *mockS3Storage {
+	return &mockS3Storage{
+		headFileFunc: func(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+			data, ok := photos[key]
+			if !ok {
+				return nil, errs.ErrNotFoundInStorage
+			}
+			return &models.S3ObjectInfo{ContentType: "image/jpeg", ContentLength: int64(len(data))}, nil
+		},
+		getFileFunc: func(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+			return photos[key], nil
+		},
+	}
+}
+
+// recordingAttachments confirms every attachment it is given and records them.
+func recordingAttachments() (*mockRequestAttachmentsRepository, *[]*models.RequestAttachment) {
+	var attached []*models.RequestAttachment
+	return &mockRequestAttachmentsRepository{
+		insertFunc: func(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error) {
+			attached = append(attached, attachment)
+			return attachment, nil
+		},
+		confirmFunc: func(ctx context.Context, requestID, id string, info *models.S3ObjectInfo) (*models.RequestAttachment, error) {
+			for _, a := range attached {
+				if a.ID == id {
+					a.Status = models.AttachmentConfirmed
+					return a, nil
+				}
+			}
+			return nil, errs.ErrNotFoundInDB
+		},
+	}, &attached
+}
+
+func photoLLM(output aiflows.EnrichedGenerateRequestOutput) (*mockLLMService, *aiflows.GeneratePhotoRequestInput) {
+	var got aiflows.GeneratePhotoRequestInput
+	return &mockLLMService{
+		runGeneratePhotoRequestFunc: func(ctx context.Context, input aiflows.GeneratePhotoRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			got = input
+			return output, nil
+		},
+	}, &got
+}
+
+func postPhotoRequest(t *testing.T, h *RequestsHandler, body string) (int, *models.GenerateRequestPhotoResponse) {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Post("/request/generate/photo", h.GenerateRequestFromPhoto)
+
+	req := httptest.NewRequest("POST", "/request/generate/photo", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var out models.GenerateRequestPhotoResponse
+	if resp.StatusCode == fiber.StatusCreated {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp.StatusCode, &out
+}
+
+func TestRequestHandler_GenerateRequestFromPhoto(t *testing.T) {
+	t.Parallel()
+
+	sinkPhoto := []byte("\xff\xd8\xff\xe0 sink")
+	leakPhoto := []byte("\xff\xd8\xff\xe0 leak")
+	sinkKey := "request-photos/" + streamHotelID + "/sink.jpg"
+	leakKey := "request-photos/" + streamHotelID + "/leak.jpg"
+	photos := map[string][]byte{sinkKey: sinkPhoto, leakKey: leakPhoto}
+	leakingSink := aiflows.EnrichedGenerateRequestOutput{
+		Confidence: 0.9,
+		GenerateRequestOutput: aiflows.GenerateRequestOutput{
+			Name: "Leaking Sink", RequestType: "one-time", Status: "pending", Priority: "high", Language: "en",
+		},
+	}
+
+	t.Run("generates a request from the photos and attaches them", func(t *testing.T) {
+		t.Parallel()
+
+		llm, got := photoLLM(leakingSink)
+		var inserted []*models.Request
+		h := NewRequestsHandler(&mockRequestRepository{
+			makeRequestsFunc: func(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+				inserted = reqs
+				return reqs, nil
+			},
+		}, llm, nil)
+		h.S3Storage = photoS3(photos)
+		attachments, attached := recordingAttachments()
+		h.AttachmentRepository = attachments
+
+		status, resp := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":["`+sinkKey+`","`+leakKey+`"],"text":"room 204"}`)
+		require.Equal(t, fiber.StatusCreated, status)
+
+		assert.Equal(t, streamHotelID, got.HotelID)
+		assert.Equal(t, "room 204", got.Text)
+		require.Len(t, got.Images, 2)
+		assert.Equal(t, aiflows.Media{ContentType: "image/jpeg", Data: sinkPhoto}, got.Images[0])
+		assert.Equal(t, leakPhoto, got.Images[1].Data)
+
+		require.Len(t, inserted, 1)
+		assert.Equal(t, "Leaking Sink", resp.Request.Name)
+		assert.False(t, resp.Draft)
+
+		require.Len(t, *attached, 2)
+		require.Len(t, resp.Attachments, 2)
+		for i, key := range []string{sinkKey, leakKey} {
+			a := resp.Attachments[i]
+			assert.Equal(t, inserted[0].ID, a.RequestID)
+			assert.Equal(t, key, a.Key)
+			assert.Equal(t, "image/jpeg", a.ContentType)
+			assert.Equal(t, models.AttachmentConfirmed, a.Status)
+		}
+		assert.Equal(t, "sink.jpg", resp.Attachments[0].FileName)
+	})
+
+	t.Run("holds a request from unrelated photos for review", func(t *testing.T) {
+		t.Parallel()
+
+		unrelated := leakingSink
+		unrelated.Warning = &aiflows.GenerateRequestWarning{Code: "unrelated_image", Message: "not hotel related"}
+		llm, _ := photoLLM(unrelated)
+		drafts := &mockRequestDraftRepository{}
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.DraftRepository = drafts
+		h.S3Storage = photoS3(photos)
+		attachments, _ := recordingAttachments()
+		h.AttachmentRepository = attachments
+
+		status, resp := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":["`+sinkKey+`"]}`)
+		require.Equal(t, fiber.StatusCreated, status)
+
+		assert.True(t, resp.Draft)
+		require.NotNil(t, resp.Warning)
+		assert.Equal(t, "unrelated_image", resp.Warning.Code)
+		require.Len(t, drafts.inserted, 1)
+		assert.Nil(t, drafts.inserted[0].Request.OriginalText)
+		require.Len(t, resp.Attachments, 1)
+		assert.Equal(t, drafts.inserted[0].Request.ID, resp.Attachments[0].RequestID)
+	})
+
+	t.Run("returns 404 for a missing photo", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _ := photoLLM(leakingSink)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.S3Storage = photoS3(photos)
+		h.AttachmentRepository, _ = recordingAttachments()
+
+		status, _ := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":["`+sinkKey+`","request-photos/`+streamHotelID+`/missing.jpg"]}`)
+		assert.Equal(t, fiber.StatusNotFound, status)
+	})
+
+	t.Run("returns 422 for a file that is not a photo", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _ := photoLLM(leakingSink)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.S3Storage = &mockS3Storage{
+			headFileFunc: func(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+				return &models.S3ObjectInfo{ContentType: "application/pdf", ContentLength: 2048}, nil
+			},
+		}
+		h.AttachmentRepository, _ = recordingAttachments()
+
+		status, _ := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":["request-photos/`+streamHotelID+`/receipt.pdf"]}`)
+		assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("returns 400 for keys outside the hotel's photo uploads", func(t *testing.T) {
+		t.Parallel()
+
+		for _, key := range []string{
+			"request-attachments/req-1/invoice.jpg",
+			"request-photos/org_other/sink.jpg",
+			"request-photos/" + streamHotelID + "/../../profile-pictures/user_1/me.jpg",
+		} {
+			llm, _ := photoLLM(leakingSink)
+			h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+			h.S3Storage = &mockS3Storage{
+				headFileFunc: func(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+					t.Errorf("read %s, which the hotel did not upload", key)
+					return nil, errs.ErrNotFoundInStorage
+				},
+			}
+			h.AttachmentRepository, _ = recordingAttachments()
+
+			status, _ := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":["`+key+`"]}`)
+			assert.Equal(t, fiber.StatusBadRequest, status, key)
+		}
+	})
+
+	t.Run("returns 400 without photos", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _ := photoLLM(leakingSink)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+		h.S3Storage = photoS3(photos)
+		h.AttachmentRepository, _ = recordingAttachments()
+
+		status, _ := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":[]}`)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("returns 503 without attachment storage", func(t *testing.T) {
+		t.Parallel()
+
+		llm, _ := photoLLM(leakingSink)
+		h := NewRequestsHandler(&mockRequestRepository{}, llm, nil)
+
+		status, _ := postPhotoRequest(t, h, `{"hotel_id":"`+streamHotelID+`","keys":["`+sinkKey+`"]}`)
+		assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	})
+}
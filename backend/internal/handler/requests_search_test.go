@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRequestsSearchRepository struct {
+	searchRequestsFunc func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, *models.RequestFacets, error)
+}
+
+func (m *mockRequestsSearchRepository) IndexRequest(ctx context.Context, doc *models.RequestDocument) error {
+	return nil
+}
+
+func (m *mockRequestsSearchRepository) SearchRequests(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, *models.RequestFacets, error) {
+	return m.searchRequestsFunc(ctx, input, cursorID, cursorCreatedAt, cursorPriorityRank, cursorSLADueAt, limit)
+}
+
+func TestRequestHandler_GetRequestsFeed_Search(t *testing.T) {
+	t.Parallel()
+
+	feed := func(t *testing.T, h *RequestsHandler) *models.RequestsFeedPage {
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		app.Post("/requests/feed", h.GetRequestsFeed)
+
+		req := httptest.NewRequest("POST", "/requests/feed", bytes.NewBufferString(`{"hotel_id":"`+streamHotelID+`","search":"towel","limit":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var page models.RequestsFeedPage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		return &page
+	}
+
+	requests := []*models.GuestRequest{
+		{ID: "630e8400-e458-41d4-a716-446655440000", Name: "extra towels", Priority: "high", CreatedAt: time.Now()},
+		{ID: "630e8400-e458-41d4-a716-446655440001", Name: "towel swap", Priority: "low", CreatedAt: time.Now()},
+	}
+
+	t.Run("serves the feed and facets from the search index", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.SearchRepository = &mockRequestsSearchRepository{
+			searchRequestsFunc: func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, *models.RequestFacets, error) {
+				assert.Equal(t, "towel", input.Search)
+				assert.Equal(t, 2, limit)
+				return requests, &models.RequestFacets{Priority: []models.FacetCount{{Value: "high", Count: 1}, {Value: "low", Count: 1}}}, nil
+			},
+		}
+
+		page := feed(t, h)
+		require.Len(t, page.Items, 1)
+		assert.True(t, page.HasMore)
+		require.NotNil(t, page.Facets)
+		assert.Len(t, page.Facets.Priority, 2)
+	})
+
+	t.Run("falls back to postgres without facets when search fails", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(&mockRequestRepository{
+			findRequestsPaginatedFunc: func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, error) {
+				return requests[:1], nil
+			},
+		}, nil, nil)
+		h.SearchRepository = &mockRequestsSearchRepository{
+			searchRequestsFunc: func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, *models.RequestFacets, error) {
+				return nil, nil, errors.New("connection refused")
+			},
+		}
+
+		page := feed(t, h)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "extra towels", page.Items[0].Name)
+		assert.Nil(t, page.Facets)
+	})
+}
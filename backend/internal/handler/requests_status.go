@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReopenRequest godoc
+// @Summary      Reopen a completed request
+// @Description  Moves a completed request back to in progress and clears completed_at. This is the only way out of the completed status other than archiving.
+// @Tags         requests
+// @Produce      json
+// @Param        id   path      string  true  "Request ID (UUID)"
+// @Success      200  {object}  models.Request
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      409  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /request/{id}/reopen [post]
+func (r *RequestsHandler) ReopenRequest(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if !validUUID(id) {
+		return errs.BadRequest("request id is not a valid UUID")
+	}
+
+	current, err := r.findLatestRequest(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	if models.RequestStatus(current.Status) != models.StatusCompleted {
+		return errs.NewHTTPError(fiber.StatusConflict, fmt.Errorf("only completed requests can be reopened, request is %q", current.Status))
+	}
+
+	var changedBy *string
+	if uid, ok := c.Locals("userId").(string); ok && uid != "" {
+		changedBy = &uid
+	}
+
+	status := string(models.StatusInProgress)
+	update := &models.RequestUpdateInput{Status: &status, ExpectedVersion: &current.RequestVersion}
+	res, err := r.RequestRepository.UpdateRequest(c.Context(), id, update, changedBy)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("Request", "id", id)
+		}
+		if errors.Is(err, errs.ErrStaleVersionInDB) {
+			return r.staleVersionError(c, id, fiber.StatusConflict)
+		}
+		slog.Error("failed to reopen request", "err", err, "requestID", id)
+		return errs.InternalServerError()
+	}
+
+	r.publishRequestEvent(c.Context(), models.RequestEventUpdated, res.HotelID, res.ID)
+
+	c.Set(fiber.HeaderETag, requestETag(res.RequestVersion))
+	return c.JSON(res)
+}
+
+// checkStatusTransition rejects a status change on request id that is not a
+// known status for the request's hotel (400) or not a legal transition from
+// the current status (409). It returns the version it checked against.
+func (r *RequestsHandler) checkStatusTransition(ctx context.Context, id, to string) (*models.Request, error) {
+	target := models.RequestStatus(to)
+	if !target.IsValid() && r.StatusRepository == nil {
+		return nil, unknownStatusError(to)
+	}
+
+	current, err := r.findLatestRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	custom, err := r.customStatuses(ctx, current.HotelID, to)
+	if err != nil {
+		return nil, err
+	}
+	if !models.RequestStatus(current.Status).CanTransitionTo(target, custom) {
+		return nil, errs.NewHTTPError(fiber.StatusConflict, fmt.Errorf("cannot change request status from %q to %q", current.Status, to))
+	}
+	return current, nil
+}
+
+// customStatuses loads the hotel's custom statuses and rejects status (400)
+// when it is neither built in nor one of them.
+func (r *RequestsHandler) customStatuses(ctx context.Context, hotelID, status string) ([]string, error) {
+	var custom []string
+	if r.StatusRepository != nil {
+		statuses, err := r.StatusRepository.FindRequestStatusesByHotelID(ctx, hotelID)
+		if err != nil {
+			slog.Error("failed to load custom request statuses", "err", err, "hotelID", hotelID)
+			return nil, errs.InternalServerError()
+		}
+		for _, s := range statuses {
+			custom = append(custom, s.Name)
+		}
+	}
+
+	if !models.RequestStatus(status).IsValid() && !slices.Contains(custom, status) {
+		return nil, unknownStatusError(status)
+	}
+	return custom, nil
+}
+
+// findLatestRequest returns the latest version of a request, archived or not.
+// Drafts are only reached through the review queue, so they are not found.
+func (r *RequestsHandler) findLatestRequest(ctx context.Context, id string) (*models.Request, error) {
+	current, err := r.RequestRepository.FindLatestRequest(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil, errs.NotFound("Request", "id", id)
+		}
+		slog.Error("failed to find request", "err", err, "requestID", id)
+		return nil, errs.InternalServerError()
+	}
+	if current.Status == string(models.StatusDraft) {
+		return nil, errs.NotFound("Request", "id", id)
+	}
+	return current, nil
+}
+
+func unknownStatusError(status string) error {
+	return errs.BadRequest(fmt.Sprintf("status: %q is not a known request status", status))
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statusRequestID = "530e8400-e458-41d4-a716-446655440000"
+
+type mockRequestStatusesRepository struct {
+	statuses []*models.HotelRequestStatus
+	inserted []string
+}
+
+func (m *mockRequestStatusesRepository) FindRequestStatusesByHotelID(ctx context.Context, hotelID string) ([]*models.HotelRequestStatus, error) {
+	return m.statuses, nil
+}
+
+func (m *mockRequestStatusesRepository) InsertRequestStatus(ctx context.Context, hotelID, name string) (*models.HotelRequestStatus, error) {
+	for _, s := range m.statuses {
+		if s.Name == name {
+			return nil, errs.ErrAlreadyExistsInDB
+		}
+	}
+	m.inserted = append(m.inserted, name)
+	return &models.HotelRequestStatus{HotelID: hotelID, Name: name, CreatedAt: time.Now()}, nil
+}
+
+func (m *mockRequestStatusesRepository) DeleteRequestStatus(ctx context.Context, hotelID, name string) error {
+	return errs.ErrNotFoundInDB
+}
+
+func requestInStatus(status string) *mockRequestRepository {
+	return &mockRequestRepository{
+		findLatestRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+			return &models.Request{ID: id, MakeRequest: models.MakeRequest{HotelID: streamHotelID, Status: status}}, nil
+		},
+		updateRequestFunc: func(_ context.Context, id string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
+			return &models.Request{ID: id, MakeRequest: models.MakeRequest{HotelID: streamHotelID, Status: *update.Status}}, nil
+		},
+	}
+}
+
+func putStatus(t *testing.T, h *RequestsHandler, status string) (int, string) {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	app.Put("/request/:id", h.UpdateRequest)
+
+	req := httptest.NewRequest("PUT", "/request/"+statusRequestID, bytes.NewBufferString(`{"status":"`+status+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestRequestHandler_UpdateRequestStatusTransitions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns 409 on an illegal transition", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(requestInStatus("pending"), nil, nil)
+		code, body := putStatus(t, h, "completed")
+
+		assert.Equal(t, 409, code)
+		assert.Contains(t, body, `from \"pending\" to \"completed\"`)
+	})
+
+	t.Run("returns 409 when leaving archived", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(requestInStatus("archived"), nil, nil)
+		code, _ := putStatus(t, h, "pending")
+
+		assert.Equal(t, 409, code)
+	})
+
+	t.Run("returns 404 for a draft awaiting review", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(requestInStatus(string(models.StatusDraft)), nil, nil)
+		code, _ := putStatus(t, h, "pending")
+
+		assert.Equal(t, 404, code)
+	})
+
+	t.Run("accepts a custom status configured for the hotel", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(requestInStatus("in progress"), nil, nil)
+		h.StatusRepository = &mockRequestStatusesRepository{statuses: []*models.HotelRequestStatus{{Name: "blocked"}}}
+		code, _ := putStatus(t, h, "blocked")
+
+		assert.Equal(t, 200, code)
+	})
+
+	t.Run("returns 400 on a status the hotel has not configured", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewRequestsHandler(requestInStatus("in progress"), nil, nil)
+		h.StatusRepository = &mockRequestStatusesRepository{}
+		code, body := putStatus(t, h, "blocked")
+
+		assert.Equal(t, 400, code)
+		assert.Contains(t, body, "status")
+	})
+}
+
+func TestRequestHandler_ReopenRequest(t *testing.T) {
+	t.Parallel()
+
+	reopen := func(t *testing.T, mock *mockRequestRepository) int {
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(mock, nil, nil)
+		app.Post("/request/:id/reopen", h.ReopenRequest)
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/request/"+statusRequestID+"/reopen", nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("moves a completed request back to in progress", func(t *testing.T) {
+		t.Parallel()
+
+		mock := requestInStatus("completed")
+		var gotStatus string
+		mock.updateRequestFunc = func(_ context.Context, id string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
+			gotStatus = *update.Status
+			return &models.Request{ID: id}, nil
+		}
+
+		assert.Equal(t, 200, reopen(t, mock))
+		assert.Equal(t, "in progress", gotStatus)
+	})
+
+	t.Run("returns 409 when the request is not completed", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 409, reopen(t, requestInStatus("pending")))
+	})
+
+	t.Run("returns 404 when the request does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockRequestRepository{
+			findLatestRequestFunc: func(_ context.Context, _ string) (*models.Request, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		}
+		assert.Equal(t, 404, reopen(t, mock))
+	})
+}
+
+func TestRequestStatusesHandler_CreateRequestStatus(t *testing.T) {
+	t.Parallel()
+
+	post := func(t *testing.T, repo *mockRequestStatusesRepository, body string) int {
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestStatusesHandler(repo)
+		app.Post("/hotels/:id/request-statuses", h.CreateRequestStatus)
+
+		req := httptest.NewRequest("POST", "/hotels/"+streamHotelID+"/request-statuses", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("returns 201 on success", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestStatusesRepository{}
+		assert.Equal(t, 201, post(t, repo, `{"name":" awaiting guest "}`))
+		assert.Equal(t, []string{"awaiting guest"}, repo.inserted)
+	})
+
+	t.Run("returns 400 for built-in status names", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 400, post(t, &mockRequestStatusesRepository{}, `{"name":"completed"}`))
+	})
+
+	t.Run("returns 409 when the status already exists", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestStatusesRepository{statuses: []*models.HotelRequestStatus{{Name: "blocked"}}}
+		assert.Equal(t, 409, post(t, repo, `{"name":"blocked"}`))
+	})
+}
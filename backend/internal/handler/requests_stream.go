@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	maxStreamReplay         = 500
+)
+
+// RequestEventBroker fans request changes out to stream subscribers.
+// It is nilable - if nil, events are not published and the stream is unavailable.
+type RequestEventBroker interface {
+	Publish(event *models.RequestEvent)
+	Subscribe(hotelID string) (<-chan *models.RequestEvent, func())
+}
+
+// StreamRequests godoc
+// @Summary      Stream request changes
+// @Description  Server-Sent Events stream of created, updated and assigned request events for the hotel. Accepts the same filters as the feed as query params (list filters are comma-separated). The stream ends when the client falls too far behind. Reconnect with Last-Event-ID to replay changes missed while disconnected; when more changed than the stream replays, a reset event is sent instead and the feed should be refetched.
+// @Tags         requests
+// @Produce      text/event-stream
+// @Param        X-Hotel-ID     header  string  true   "Hotel ID"
+// @Param        Last-Event-ID  header  string  false  "ID of the last event received"
+// @Param        user_id        query   string  false  "Only requests assigned to this user"
+// @Param        unassigned     query   bool    false  "Only unassigned requests"
+// @Param        status         query   string  false  "Status filter"
+// @Param        priorities     query   string  false  "Comma-separated priorities"
+// @Param        departments    query   string  false  "Comma-separated department IDs"
+// @Param        floors         query   string  false  "Comma-separated floors"
+// @Param        search         query   string  false  "Name/description search"
+// @Success      200  {object}  models.RequestEvent
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Failure      503  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /requests/stream [get]
+func (r *RequestsHandler) StreamRequests(c *fiber.Ctx) error {
+	if r.EventBroker == nil {
+		return errs.NewHTTPError(fiber.StatusServiceUnavailable, errors.New("request stream unavailable"))
+	}
+
+	hotelID, err := hotelIDFromHeader(c)
+	if err != nil {
+		return err
+	}
+
+	filters, err := feedFiltersFromQuery(c, hotelID)
+	if err != nil {
+		return err
+	}
+
+	since, err := parseLastEventID(c)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before replaying so nothing inserted in between is lost;
+	// duplicates are filtered out below.
+	subscribedAt := time.Now()
+	events, unsubscribe := r.EventBroker.Subscribe(hotelID)
+
+	var replay []*models.RequestEvent
+	var reset *models.RequestEvent
+	if !since.IsZero() {
+		changed, err := r.RequestRepository.FindRequestsChangedSince(c.Context(), hotelID, since, maxStreamReplay+1)
+		if err != nil {
+			unsubscribe()
+			slog.Error("failed to replay request events", "err", err, "hotelID", hotelID)
+			return errs.InternalServerError()
+		}
+		if len(changed) > maxStreamReplay {
+			// Too much to replay: have the client refetch the feed and resume
+			// from when it subscribed.
+			reset = &models.RequestEvent{
+				ID:      strconv.FormatInt(subscribedAt.UnixNano(), 10),
+				Type:    models.RequestEventReset,
+				HotelID: hotelID,
+			}
+			changed = nil
+		}
+		for _, req := range changed {
+			eventType := models.RequestEventUpdated
+			if req.CreatedAt.Equal(req.RequestVersion) {
+				eventType = models.RequestEventCreated
+			}
+			replay = append(replay, newRequestEvent(eventType, hotelID, req))
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		if reset != nil {
+			if err := writeRequestEvent(w, reset); err != nil {
+				return
+			}
+		}
+		replayed := make(map[string]struct{}, len(replay))
+		for _, event := range replay {
+			replayed[event.Request.ID+event.ID] = struct{}{}
+			if !matchesFeedFilters(filters, event.Request) {
+				continue
+			}
+			if err := writeRequestEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if _, dup := replayed[event.Request.ID+event.ID]; dup {
+					continue
+				}
+				if !matchesFeedFilters(filters, event.Request) {
+					continue
+				}
+				if err := writeRequestEvent(w, event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// publishRequestEvent loads the latest version of the request and pushes it to
+// stream subscribers. Failures are logged only; the write already succeeded.
+func (r *RequestsHandler) publishRequestEvent(ctx context.Context, eventType models.RequestEventType, hotelID, requestID string) {
+	if r.EventBroker == nil {
+		return
+	}
+
+	req, err := r.RequestRepository.FindGuestRequest(ctx, requestID)
+	if err != nil {
+		slog.Error("failed to load request for stream event", "err", err, "requestID", requestID)
+		return
+	}
+
+	r.EventBroker.Publish(newRequestEvent(eventType, hotelID, req))
+}
+
+func newRequestEvent(eventType models.RequestEventType, hotelID string, req *models.GuestRequest) *models.RequestEvent {
+	return &models.RequestEvent{
+		ID:      strconv.FormatInt(req.RequestVersion.UnixNano(), 10),
+		Type:    eventType,
+		HotelID: hotelID,
+		Request: req,
+	}
+}
+
+func writeRequestEvent(w *bufio.Writer, event *models.RequestEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("failed to marshal request event", "err", err, "eventID", event.ID)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header, or the
+// last_event_id query param for clients that cannot set headers.
+func parseLastEventID(c *fiber.Ctx) (time.Time, error) {
+	raw := strings.TrimSpace(c.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(c.Query("last_event_id"))
+	}
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	nano, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, errs.BadRequest("invalid Last-Event-ID")
+	}
+	return time.Unix(0, nano).UTC(), nil
+}
+
+// feedFiltersFromQuery builds a RequestsFeedInput from stream query params.
+func feedFiltersFromQuery(c *fiber.Ctx, hotelID string) (*models.RequestsFeedInput, error) {
+	input := models.RequestsFeedInput{
+		HotelID:     hotelID,
+		UserID:      c.Query("user_id"),
+		Unassigned:  c.QueryBool("unassigned"),
+		Status:      c.Query("status"),
+		Priorities:  splitQueryList(c.Query("priorities")),
+		Departments: splitQueryList(c.Query("departments")),
+		Search:      c.Query("search"),
+	}
+
+	for _, f := range splitQueryList(c.Query("floors")) {
+		floor, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, errs.BadRequest("floors must be a comma-separated list of integers")
+		}
+		input.Floors = append(input.Floors, floor)
+	}
+
+	if err := httpx.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	return &input, nil
+}
+
+func splitQueryList(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// matchesFeedFilters mirrors the filtering in RequestsRepository.FindRequestsPaginated
+// so that streamed events agree with what the feed would return. Archived requests
+// are still delivered so clients can drop them.
+func matchesFeedFilters(input *models.RequestsFeedInput, req *models.GuestRequest) bool {
+	if input.Status != "" && req.Status != input.Status {
+		return false
+	}
+	if len(input.Priorities) > 0 && !slices.Contains(input.Priorities, req.Priority) {
+		return false
+	}
+	if len(input.Departments) > 0 && (req.DepartmentID == nil || !slices.Contains(input.Departments, *req.DepartmentID)) {
+		return false
+	}
+	if len(input.Floors) > 0 && (req.Floor == nil || !slices.Contains(input.Floors, *req.Floor)) {
+		return false
+	}
+	if input.Unassigned {
+		if req.UserID != nil {
+			return false
+		}
+	} else if input.UserID != "" && (req.UserID == nil || *req.UserID != input.UserID) {
+		return false
+	}
+	if input.Search != "" {
+		search := strings.ToLower(input.Search)
+		desc := ""
+		if req.Description != nil {
+			desc = *req.Description
+		}
+		if !strings.Contains(strings.ToLower(req.Name), search) && !strings.Contains(strings.ToLower(desc), search) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/generate/selfserve/internal/service/requestevents"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const streamHotelID = "org_550e8400-e29b-41d4-a716-446655440000"
+
+// mockEventBroker hands out a pre-filled, closed channel so the stream
+// terminates once the buffered events have been written.
+type mockEventBroker struct {
+	events    []*models.RequestEvent
+	published []*models.RequestEvent
+}
+
+func (m *mockEventBroker) Publish(event *models.RequestEvent) {
+	m.published = append(m.published, event)
+}
+
+func (m *mockEventBroker) Subscribe(hotelID string) (<-chan *models.RequestEvent, func()) {
+	ch := make(chan *models.RequestEvent, len(m.events))
+	for _, e := range m.events {
+		ch <- e
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+// floodingBroker is a real broker that publishes events as soon as a
+// subscriber joins, before the stream can read any, so it falls behind.
+type floodingBroker struct {
+	*requestevents.Broker
+	events []*models.RequestEvent
+}
+
+func (b *floodingBroker) Subscribe(hotelID string) (<-chan *models.RequestEvent, func()) {
+	ch, unsubscribe := b.Broker.Subscribe(hotelID)
+	for _, e := range b.events {
+		b.Publish(e)
+	}
+	return ch, unsubscribe
+}
+
+func streamGuestRequest(id, priority string, version time.Time) *models.GuestRequest {
+	return &models.GuestRequest{
+		ID:             id,
+		Name:           "extra towels",
+		Priority:       priority,
+		Status:         "pending",
+		RequestType:    "one-time",
+		CreatedAt:      version,
+		RequestVersion: version,
+	}
+}
+
+func TestRequestHandler_StreamRequests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns 503 when broker is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 503, resp.StatusCode)
+	})
+
+	t.Run("returns 400 when hotel header is missing", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.EventBroker = &mockEventBroker{}
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("returns 400 on invalid filters", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.EventBroker = &mockEventBroker{}
+		app.Get("/requests/stream", h.StreamRequests)
+
+		for _, query := range []string{"?priorities=urgent", "?floors=abc", "?status=assigned"} {
+			req := httptest.NewRequest("GET", "/requests/stream"+query, nil)
+			req.Header.Set("X-Hotel-ID", streamHotelID)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, 400, resp.StatusCode, query)
+		}
+	})
+
+	t.Run("returns 400 on invalid Last-Event-ID", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.EventBroker = &mockEventBroker{}
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		req.Header.Set("Last-Event-ID", "yesterday")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("streams live events that match the filters", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now()
+		broker := &mockEventBroker{events: []*models.RequestEvent{
+			newRequestEvent(models.RequestEventCreated, streamHotelID, streamGuestRequest("req-high", "high", now)),
+			newRequestEvent(models.RequestEventCreated, streamHotelID, streamGuestRequest("req-low", "low", now.Add(time.Second))),
+		}}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.EventBroker = broker
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream?priorities=high,medium", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "event: created\n")
+		assert.Contains(t, string(body), "req-high")
+		assert.NotContains(t, string(body), "req-low")
+	})
+
+	t.Run("replays changes since Last-Event-ID without duplicating live events", func(t *testing.T) {
+		t.Parallel()
+
+		since := time.Unix(0, 1_700_000_000_000_000_000).UTC()
+		created := streamGuestRequest("req-created", "high", since.Add(time.Second))
+		updated := streamGuestRequest("req-updated", "high", since.Add(2*time.Second))
+		updated.CreatedAt = since.Add(-time.Hour)
+
+		var gotSince time.Time
+		mock := &mockRequestRepository{
+			findRequestsChangedSinceFunc: func(ctx context.Context, hotelID string, s time.Time, limit int) ([]*models.GuestRequest, error) {
+				assert.Equal(t, streamHotelID, hotelID)
+				gotSince = s
+				return []*models.GuestRequest{created, updated}, nil
+			},
+		}
+		broker := &mockEventBroker{events: []*models.RequestEvent{
+			newRequestEvent(models.RequestEventUpdated, streamHotelID, updated),
+		}}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(mock, nil, nil)
+		h.EventBroker = broker
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		req.Header.Set("Last-Event-ID", "1700000000000000000")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.True(t, since.Equal(gotSince))
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "event: created\n")
+		assert.Equal(t, 1, strings.Count(string(body), "event: updated\n"))
+	})
+
+	t.Run("sends a reset instead of a truncated replay", func(t *testing.T) {
+		t.Parallel()
+
+		since := time.Unix(0, 1_700_000_000_000_000_000).UTC()
+		mock := &mockRequestRepository{
+			findRequestsChangedSinceFunc: func(ctx context.Context, hotelID string, s time.Time, limit int) ([]*models.GuestRequest, error) {
+				changed := make([]*models.GuestRequest, 0, limit)
+				for i := range limit {
+					changed = append(changed, streamGuestRequest(fmt.Sprintf("req-%d", i), "high", since.Add(time.Duration(i+1)*time.Second)))
+				}
+				return changed, nil
+			},
+		}
+		live := streamGuestRequest("req-live", "high", time.Now().Add(time.Minute))
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(mock, nil, nil)
+		h.EventBroker = &mockEventBroker{events: []*models.RequestEvent{newRequestEvent(models.RequestEventCreated, streamHotelID, live)}}
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		req.Header.Set("Last-Event-ID", "1700000000000000000")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, 1, strings.Count(string(body), "event: reset\n"))
+		assert.NotContains(t, string(body), "req-0")
+		assert.Contains(t, string(body), "req-live", "live events follow the reset")
+	})
+
+	t.Run("ends the stream for a client that falls behind", func(t *testing.T) {
+		t.Parallel()
+
+		since := time.Unix(0, 1_700_000_000_000_000_000).UTC()
+		broker := &floodingBroker{Broker: requestevents.NewBroker()}
+		for i := range 200 {
+			req := streamGuestRequest(fmt.Sprintf("req-%d", i), "high", since.Add(time.Duration(i+1)*time.Second))
+			broker.events = append(broker.events, newRequestEvent(models.RequestEventCreated, streamHotelID, req))
+		}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		h.EventBroker = broker
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		sent := strings.Count(string(body), "event: created\n")
+		assert.Positive(t, sent, "what was buffered is still sent")
+		assert.Less(t, sent, 200, "the rest is left for the replay on reconnect")
+		assert.Contains(t, string(body), "req-0")
+	})
+
+	t.Run("returns 500 when replay fails", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockRequestRepository{
+			findRequestsChangedSinceFunc: func(ctx context.Context, hotelID string, since time.Time, limit int) ([]*models.GuestRequest, error) {
+				return nil, errors.New("db down")
+			},
+		}
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(mock, nil, nil)
+		h.EventBroker = &mockEventBroker{}
+		app.Get("/requests/stream", h.StreamRequests)
+
+		req := httptest.NewRequest("GET", "/requests/stream", nil)
+		req.Header.Set("X-Hotel-ID", streamHotelID)
+		req.Header.Set("Last-Event-ID", "1700000000000000000")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestRequestHandler_CreateRequestPublishesEvent(t *testing.T) {
+	t.Parallel()
+
+	version := time.Now()
+	mock := &mockRequestRepository{
+		makeRequestFunc: func(ctx context.Context, req *models.Request) (*models.Request, error) {
+			req.RequestVersion = version
+			return req, nil
+		},
+		findGuestRequestFunc: func(ctx context.Context, id string) (*models.GuestRequest, error) {
+			return streamGuestRequest(id, "high", version), nil
+		},
+	}
+	broker := &mockEventBroker{}
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	h := NewRequestsHandler(mock, nil, nil)
+	h.EventBroker = broker
+	app.Post("/request", h.CreateRequest)
+
+	body := `{"hotel_id":"` + streamHotelID + `","name":"room cleaning","request_type":"one-time","status":"pending","priority":"high"}`
+	req := httptest.NewRequest("POST", "/request", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	require.Len(t, broker.published, 1)
+	assert.Equal(t, models.RequestEventCreated, broker.published[0].Type)
+	assert.Equal(t, streamHotelID, broker.published[0].HotelID)
+}
+
+func TestMatchesFeedFilters(t *testing.T) {
+	t.Parallel()
+
+	floor := 3
+	dept := "dept-1"
+	user := "user_1"
+	desc := "Bring extra pillows"
+	req := &models.GuestRequest{
+		Name:         "Pillows",
+		Description:  &desc,
+		Priority:     "medium",
+		Status:       "pending",
+		Floor:        &floor,
+		DepartmentID: &dept,
+		UserID:       &user,
+	}
+
+	cases := []struct {
+		name   string
+		input  models.RequestsFeedInput
+		expect bool
+	}{
+		{"no filters", models.RequestsFeedInput{}, true},
+		{"status match", models.RequestsFeedInput{Status: "pending"}, true},
+		{"status mismatch", models.RequestsFeedInput{Status: "completed"}, false},
+		{"priority mismatch", models.RequestsFeedInput{Priorities: []string{"high"}}, false},
+		{"department match", models.RequestsFeedInput{Departments: []string{"dept-1"}}, true},
+		{"floor mismatch", models.RequestsFeedInput{Floors: []int{4}}, false},
+		{"user match", models.RequestsFeedInput{UserID: "user_1"}, true},
+		{"user mismatch", models.RequestsFeedInput{UserID: "user_2"}, false},
+		{"unassigned excludes assigned", models.RequestsFeedInput{Unassigned: true}, false},
+		{"search matches description", models.RequestsFeedInput{Search: "EXTRA"}, true},
+		{"search mismatch", models.RequestsFeedInput{Search: "towel"}, false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expect, matchesFeedFilters(&tc.input, req), tc.name)
+	}
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http/httptest"
@@ -13,6 +14,8 @@ import (
 	"github.com/generate/selfserve/internal/errs"
 	"github.com/generate/selfserve/internal/models"
 	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/generate/selfserve/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,27 +23,44 @@ import (
 
 type mockRequestRepository struct {
 	makeRequestFunc                    func(ctx context.Context, req *models.Request) (*models.Request, error)
+	makeRequestsFunc                   func(ctx context.Context, reqs []*models.Request) ([]*models.Request, error)
 	updateRequestFunc                  func(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string) (*models.Request, error)
+	updateRequestsFunc                 func(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error)
 	findRequestFunc                    func(ctx context.Context, id string) (*models.Request, error)
+	findLatestRequestFunc              func(ctx context.Context, id string) (*models.Request, error)
 	findRequestsFunc                   func(ctx context.Context) ([]models.Request, error)
 	findRequestsByGuestIDFunc          func(ctx context.Context, guestID, hotelID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error)
 	findRequestsByRoomIDAndUserIDFunc  func(ctx context.Context, roomID, hotelID, userID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error)
 	findUnassignedRequestsByRoomIDFunc func(ctx context.Context, roomID, hotelID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error)
-	findRequestsPaginatedFunc          func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, limit int) ([]*models.GuestRequest, error)
+	findRequestsPaginatedFunc          func(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, error)
+	findGuestRequestFunc               func(ctx context.Context, id string) (*models.GuestRequest, error)
+	findRequestsChangedSinceFunc       func(ctx context.Context, hotelID string, since time.Time, limit int) ([]*models.GuestRequest, error)
 }
 
 func (m *mockRequestRepository) InsertRequest(ctx context.Context, req *models.Request) (*models.Request, error) {
 	return m.makeRequestFunc(ctx, req)
 }
 
+func (m *mockRequestRepository) InsertRequests(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+	return m.makeRequestsFunc(ctx, reqs)
+}
+
 func (m *mockRequestRepository) UpdateRequest(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
 	return m.updateRequestFunc(ctx, id, update, changedBy)
 }
 
+func (m *mockRequestRepository) UpdateRequests(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error) {
+	return m.updateRequestsFunc(ctx, updates, changedBy)
+}
+
 func (m *mockRequestRepository) FindRequest(ctx context.Context, id string) (*models.Request, error) {
 	return m.findRequestFunc(ctx, id)
 }
 
+func (m *mockRequestRepository) FindLatestRequest(ctx context.Context, id string) (*models.Request, error) {
+	return m.findLatestRequestFunc(ctx, id)
+}
+
 func (m *mockRequestRepository) FindRequests(ctx context.Context) ([]models.Request, error) {
 	return m.findRequestsFunc(ctx)
 }
@@ -57,28 +77,54 @@ func (m *mockRequestRepository) FindUnassignedRequestsByRoomIDAndUserID(ctx cont
 	return m.findUnassignedRequestsByRoomIDFunc(ctx, roomID, hotelID, cursorID, cursorVersion, limit)
 }
 
-func (m *mockRequestRepository) FindRequestsPaginated(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, limit int) ([]*models.GuestRequest, error) {
-	return m.findRequestsPaginatedFunc(ctx, input, cursorID, cursorCreatedAt, cursorPriorityRank, limit)
+func (m *mockRequestRepository) FindRequestsPaginated(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, error) {
+	return m.findRequestsPaginatedFunc(ctx, input, cursorID, cursorCreatedAt, cursorPriorityRank, cursorSLADueAt, limit)
 }
 
 func (m *mockRequestRepository) FindRequestVersions(ctx context.Context, id string) ([]*models.Request, error) {
 	return nil, nil
 }
 
+func (m *mockRequestRepository) FindGuestRequest(ctx context.Context, id string) (*models.GuestRequest, error) {
+	return m.findGuestRequestFunc(ctx, id)
+}
+
+func (m *mockRequestRepository) FindRequestsChangedSince(ctx context.Context, hotelID string, since time.Time, limit int) ([]*models.GuestRequest, error) {
+	return m.findRequestsChangedSinceFunc(ctx, hotelID, since, limit)
+}
+
 type mockLLMService struct {
-	runGenerateRequestFunc func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error)
+	runGenerateRequestFunc      func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error)
+	runGenerateRequestBatchFunc func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.GenerateRequestBatchOutput, error)
+	runTranscribeAudioFunc      func(ctx context.Context, input aiflows.TranscribeAudioInput) (aiflows.TranscribeAudioOutput, error)
+	runGeneratePhotoRequestFunc func(ctx context.Context, input aiflows.GeneratePhotoRequestInput) (aiflows.EnrichedGenerateRequestOutput, error)
 }
 
 func (m *mockLLMService) RunGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
 	return m.runGenerateRequestFunc(ctx, input)
 }
 
+func (m *mockLLMService) RunGenerateRequestBatch(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.GenerateRequestBatchOutput, error) {
+	return m.runGenerateRequestBatchFunc(ctx, input)
+}
+
+func (m *mockLLMService) RunTranscribeAudio(ctx context.Context, input aiflows.TranscribeAudioInput) (aiflows.TranscribeAudioOutput, error) {
+	return m.runTranscribeAudioFunc(ctx, input)
+}
+
+func (m *mockLLMService) RunGeneratePhotoRequest(ctx context.Context, input aiflows.GeneratePhotoRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+	return m.runGeneratePhotoRequestFunc(ctx, input)
+}
+
 type mockWorkflowClient struct {
-	startGenerateRequestFunc   func(ctx context.Context, input aiflows.GenerateRequestInput) (workflowID string, err error)
+	startGenerateRequestFunc   func(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (workflowID string, err error)
 	getGenerateRequestResultFn func(ctx context.Context, workflowID string) (temporalclient.GenerateRequestResult, error)
+	cancelGenerateRequestFunc  func(ctx context.Context, hotelID, workflowID string) error
+	amendGenerateRequestFunc   func(ctx context.Context, hotelID, workflowID, rawText string) error
+	listGenerateRequestsFunc   func(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error)
 }
 
-func (m *mockWorkflowClient) StartGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (string, error) {
+func (m *mockWorkflowClient) StartGenerateRequest(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (string, error) {
 	return m.startGenerateRequestFunc(ctx, input)
 }
 
@@ -86,6 +132,18 @@ func (m *mockWorkflowClient) GetGenerateRequestResult(ctx context.Context, workf
 	return m.getGenerateRequestResultFn(ctx, workflowID)
 }
 
+func (m *mockWorkflowClient) CancelGenerateRequest(ctx context.Context, hotelID, workflowID string) error {
+	return m.cancelGenerateRequestFunc(ctx, hotelID, workflowID)
+}
+
+func (m *mockWorkflowClient) AmendGenerateRequest(ctx context.Context, hotelID, workflowID, rawText string) error {
+	return m.amendGenerateRequestFunc(ctx, hotelID, workflowID, rawText)
+}
+
+func (m *mockWorkflowClient) ListGenerateRequests(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+	return m.listGenerateRequestsFunc(ctx, filter)
+}
+
 func TestRequestHandler_GetRequest(t *testing.T) {
 	t.Parallel()
 
@@ -735,6 +793,23 @@ func TestRequestHandler_Generate_Request(t *testing.T) {
 		assert.Equal(t, 500, resp.StatusCode)
 	})
 
+	t.Run("returns 503 when no LLM provider is configured", func(t *testing.T) {
+		t.Parallel()
+
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(&mockRequestRepository{}, nil, nil)
+		app.Post("/request/generate", h.GenerateRequest)
+
+		req := httptest.NewRequest("POST", "/request/generate", bytes.NewBufferString(validBody))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 503, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "no LLM provider is configured")
+	})
+
 	t.Run("returns 500 when LLM output fails validation", func(t *testing.T) {
 		t.Parallel()
 
@@ -900,6 +975,80 @@ func TestRequestHandler_Generate_Request(t *testing.T) {
 		assert.Contains(t, string(body), warningMessage)
 	})
 
+	t.Run("keeps the guest message and its language", func(t *testing.T) {
+		t.Parallel()
+
+		rawText := "Necesito toallas en la habitación 302"
+		english := "I need towels in room 302"
+		llmMock := &mockLLMService{
+			runGenerateRequestFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+				return aiflows.EnrichedGenerateRequestOutput{
+					GenerateRequestOutput: aiflows.GenerateRequestOutput{
+						Name:        "Extra Towels",
+						RequestType: "one-time",
+						Status:      "pending",
+						Priority:    "medium",
+						Language:    "es",
+						EnglishText: &english,
+					},
+				}, nil
+			},
+		}
+
+		app := fiber.New()
+		h := NewRequestsHandler(&mockRequestRepository{}, llmMock, nil)
+		app.Post("/request/generate", h.GenerateRequest)
+
+		body := `{"hotel_id": "org_550e8400-e29b-41d4-a716-446655440000", "raw_text": "` + rawText + `"}`
+		req := httptest.NewRequest("POST", "/request/generate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var got models.GenerateRequestResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		require.NotNil(t, got.Request.OriginalText)
+		assert.Equal(t, rawText, *got.Request.OriginalText)
+		require.NotNil(t, got.Request.EnglishText)
+		assert.Equal(t, english, *got.Request.EnglishText)
+		require.NotNil(t, got.Request.GuestLanguage)
+		assert.Equal(t, "es", *got.Request.GuestLanguage)
+	})
+
+	t.Run("uses an English message as its own English text", func(t *testing.T) {
+		t.Parallel()
+
+		llmMock := &mockLLMService{
+			runGenerateRequestFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+				return aiflows.EnrichedGenerateRequestOutput{
+					GenerateRequestOutput: aiflows.GenerateRequestOutput{
+						Name:        "Extra Towels",
+						RequestType: "one-time",
+						Status:      "pending",
+						Priority:    "high",
+						Language:    "en",
+					},
+				}, nil
+			},
+		}
+
+		app := fiber.New()
+		h := NewRequestsHandler(&mockRequestRepository{}, llmMock, nil)
+		app.Post("/request/generate", h.GenerateRequest)
+
+		req := httptest.NewRequest("POST", "/request/generate", bytes.NewBufferString(validBody))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var got models.GenerateRequestResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		require.NotNil(t, got.Request.EnglishText)
+		assert.Equal(t, "Room 302 needs extra towels urgently", *got.Request.EnglishText)
+		assert.Equal(t, got.Request.OriginalText, got.Request.EnglishText)
+	})
 }
 
 func TestRequestHandler_GetRequestsByGuest(t *testing.T) {
@@ -1113,6 +1262,9 @@ func TestRequestHandler_UpdateRequest(t *testing.T) {
 		updated := "completed"
 		var gotUpdate *models.RequestUpdateInput
 		mock := &mockRequestRepository{
+			findLatestRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+				return &models.Request{ID: id, MakeRequest: models.MakeRequest{Status: "in progress"}}, nil
+			},
 			updateRequestFunc: func(_ context.Context, id string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
 				gotUpdate = update
 				return &models.Request{
@@ -1238,6 +1390,9 @@ func TestRequestHandler_UpdateRequest(t *testing.T) {
 		t.Parallel()
 
 		mock := &mockRequestRepository{
+			findLatestRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+				return &models.Request{ID: id, MakeRequest: models.MakeRequest{Status: "pending"}}, nil
+			},
 			updateRequestFunc: func(_ context.Context, id string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
 				require.NotNil(t, update.Status)
 				require.Equal(t, "in progress", *update.Status)
@@ -1322,7 +1477,7 @@ func TestRequestHandler_UpdateRequest(t *testing.T) {
 		t.Parallel()
 
 		mock := &mockRequestRepository{
-			updateRequestFunc: func(_ context.Context, _ string, _ *models.RequestUpdateInput, _ *string) (*models.Request, error) {
+			findLatestRequestFunc: func(_ context.Context, _ string) (*models.Request, error) {
 				return nil, errs.ErrNotFoundInDB
 			},
 		}
@@ -1342,6 +1497,9 @@ func TestRequestHandler_UpdateRequest(t *testing.T) {
 		t.Parallel()
 
 		mock := &mockRequestRepository{
+			findLatestRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+				return &models.Request{ID: id, MakeRequest: models.MakeRequest{Status: "pending"}}, nil
+			},
 			updateRequestFunc: func(_ context.Context, _ string, _ *models.RequestUpdateInput, _ *string) (*models.Request, error) {
 				return nil, errors.New("db connection failed")
 			},
@@ -1876,13 +2034,19 @@ func TestRequestHandler_StartGenerateRequestAsync(t *testing.T) {
 		t.Parallel()
 
 		workflowClient := &mockWorkflowClient{
-			startGenerateRequestFunc: func(ctx context.Context, input aiflows.GenerateRequestInput) (string, error) {
+			startGenerateRequestFunc: func(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (string, error) {
 				assert.Equal(t, "Room 302 needs extra towels urgently", input.RawText)
+				require.NotNil(t, input.RequestedBy)
+				assert.Equal(t, "user_123", *input.RequestedBy)
 				return "generate-request-123", nil
 			},
 		}
 
 		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userId", "user_123")
+			return c.Next()
+		})
 		h := NewRequestsHandler(&mockRequestRepository{}, &mockLLMService{}, nil)
 		h.WorkflowClient = workflowClient
 		app.Post("/request/generate/async", h.StartGenerateRequestAsync)
@@ -1942,8 +2106,10 @@ func TestRequestHandler_GetGenerateRequestStatus(t *testing.T) {
 		t.Parallel()
 		workflowClient := &mockWorkflowClient{
 			getGenerateRequestResultFn: func(ctx context.Context, workflowID string) (temporalclient.GenerateRequestResult, error) {
+				requestID := "530e8400-e458-41d4-a716-446655440000"
 				return temporalclient.GenerateRequestResult{
-					Status: "completed",
+					Status:    "completed",
+					RequestID: &requestID,
 					Output: &aiflows.EnrichedGenerateRequestOutput{
 						GenerateRequestOutput: aiflows.GenerateRequestOutput{
 							Name:        "Extra Towels Request",
@@ -1969,5 +2135,6 @@ func TestRequestHandler_GetGenerateRequestStatus(t *testing.T) {
 		body, _ := io.ReadAll(resp.Body)
 		assert.Contains(t, string(body), "completed")
 		assert.Contains(t, string(body), "Extra Towels Request")
+		assert.Contains(t, string(body), `"request_id":"530e8400-e458-41d4-a716-446655440000"`)
 	})
 }
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestETag is the strong ETag of a request version: its request_version
+// in Unix nanoseconds.
+func requestETag(version time.Time) string {
+	return `"` + strconv.FormatInt(version.UnixNano(), 10) + `"`
+}
+
+// expectedRequestVersion resolves the version a write is conditioned on: the
+// If-Match header when present, otherwise the body's expected_version. It
+// also returns the status to fail with when that version is stale: 412 for
+// If-Match, 409 for expected_version.
+func expectedRequestVersion(c *fiber.Ctx, fromBody *time.Time) (*time.Time, int, error) {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" {
+		return fromBody, fiber.StatusConflict, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	nano, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return nil, 0, errs.BadRequest("If-Match must be an ETag returned by GET /request/:id")
+	}
+	version := time.Unix(0, nano).UTC()
+	return &version, fiber.StatusPreconditionFailed, nil
+}
+
+// staleVersionError reports a write against an outdated request version with
+// the current version in the body and its ETag in the header.
+func (r *RequestsHandler) staleVersionError(c *fiber.Ctx, id string, status int) error {
+	current, err := r.findLatestRequest(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderETag, requestETag(current.RequestVersion))
+	return errs.StaleVersion(status, current.RequestVersion)
+}
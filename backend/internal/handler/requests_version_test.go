@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandler_GetRequestSetsETag(t *testing.T) {
+	t.Parallel()
+
+	version := time.Unix(0, 1_700_000_000_123_456_000).UTC()
+	mock := &mockRequestRepository{
+		findRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+			return &models.Request{ID: id, RequestVersion: version}, nil
+		},
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	h := NewRequestsHandler(mock, nil, nil)
+	app.Get("/request/:id", h.GetRequest)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/request/"+statusRequestID, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, `"1700000000123456000"`, resp.Header.Get("ETag"))
+}
+
+func TestRequestHandler_UpdateRequestOptimisticConcurrency(t *testing.T) {
+	t.Parallel()
+
+	seen := time.Unix(0, 1_700_000_000_000_000_000).UTC()
+	current := seen.Add(time.Minute)
+
+	staleRepo := func(gotExpected **time.Time) *mockRequestRepository {
+		return &mockRequestRepository{
+			updateRequestFunc: func(_ context.Context, _ string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
+				*gotExpected = update.ExpectedVersion
+				return nil, errs.ErrStaleVersionInDB
+			},
+			findLatestRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+				return &models.Request{ID: id, RequestVersion: current}, nil
+			},
+		}
+	}
+
+	put := func(t *testing.T, mock *mockRequestRepository, body, ifMatch string) (int, string, map[string]any) {
+		app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+		h := NewRequestsHandler(mock, nil, nil)
+		app.Put("/request/:id", h.UpdateRequest)
+
+		req := httptest.NewRequest("PUT", "/request/"+statusRequestID, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, resp.Header.Get("ETag"), out
+	}
+
+	t.Run("returns 412 with the current version when If-Match is stale", func(t *testing.T) {
+		t.Parallel()
+
+		var gotExpected *time.Time
+		code, etag, body := put(t, staleRepo(&gotExpected), `{"name":"towels"}`, `"1700000000000000000"`)
+
+		assert.Equal(t, 412, code)
+		assert.Equal(t, requestETag(current), etag)
+		require.NotNil(t, gotExpected)
+		assert.True(t, seen.Equal(*gotExpected))
+		message, ok := body["message"].(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, current.Format(time.RFC3339Nano), message["current_version"])
+	})
+
+	t.Run("returns 409 when expected_version is stale", func(t *testing.T) {
+		t.Parallel()
+
+		var gotExpected *time.Time
+		code, _, _ := put(t, staleRepo(&gotExpected), `{"name":"towels","expected_version":"`+seen.Format(time.RFC3339Nano)+`"}`, "")
+
+		assert.Equal(t, 409, code)
+		require.NotNil(t, gotExpected)
+		assert.True(t, seen.Equal(*gotExpected))
+	})
+
+	t.Run("returns 400 on a malformed If-Match", func(t *testing.T) {
+		t.Parallel()
+
+		var gotExpected *time.Time
+		code, _, _ := put(t, staleRepo(&gotExpected), `{"name":"towels"}`, `"yesterday"`)
+
+		assert.Equal(t, 400, code)
+		assert.Nil(t, gotExpected)
+	})
+
+	t.Run("pins status changes to the checked version", func(t *testing.T) {
+		t.Parallel()
+
+		var gotExpected *time.Time
+		mock := requestInStatus("pending")
+		mock.findLatestRequestFunc = func(_ context.Context, id string) (*models.Request, error) {
+			return &models.Request{ID: id, RequestVersion: current, MakeRequest: models.MakeRequest{Status: "pending"}}, nil
+		}
+		mock.updateRequestFunc = func(_ context.Context, id string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
+			gotExpected = update.ExpectedVersion
+			return &models.Request{ID: id, RequestVersion: current.Add(time.Second)}, nil
+		}
+
+		code, etag, _ := put(t, mock, `{"status":"in progress"}`, "")
+
+		assert.Equal(t, 200, code)
+		assert.Equal(t, requestETag(current.Add(time.Second)), etag)
+		require.NotNil(t, gotExpected)
+		assert.True(t, current.Equal(*gotExpected))
+	})
+}
+
+func TestRequestHandler_AssignRequestStaleVersion(t *testing.T) {
+	t.Parallel()
+
+	current := time.Unix(0, 1_700_000_060_000_000_000).UTC()
+	mock := &mockRequestRepository{
+		findRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+			return &models.Request{ID: id, MakeRequest: models.MakeRequest{HotelID: streamHotelID}}, nil
+		},
+		findLatestRequestFunc: func(_ context.Context, id string) (*models.Request, error) {
+			return &models.Request{ID: id, RequestVersion: current}, nil
+		},
+		updateRequestFunc: func(_ context.Context, _ string, update *models.RequestUpdateInput, _ *string) (*models.Request, error) {
+			require.NotNil(t, update.ExpectedVersion)
+			return nil, errs.ErrStaleVersionInDB
+		},
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	h := NewRequestsHandler(mock, nil, nil)
+	app.Post("/request/:id/assign", func(c *fiber.Ctx) error {
+		c.Locals("userId", "user_1")
+		return h.AssignRequest(c)
+	})
+
+	req := httptest.NewRequest("POST", "/request/"+statusRequestID+"/assign", bytes.NewBufferString(`{"assign_to_self":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hotel-ID", streamHotelID)
+	req.Header.Set("If-Match", `"1700000000000000000"`)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 412, resp.StatusCode)
+	assert.Equal(t, requestETag(current), resp.Header.Get("ETag"))
+}
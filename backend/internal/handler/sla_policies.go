@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/httpx"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SLAPoliciesHandler struct {
+	repo storage.SLARepository
+}
+
+func NewSLAPoliciesHandler(repo storage.SLARepository) *SLAPoliciesHandler {
+	return &SLAPoliciesHandler{repo: repo}
+}
+
+// GetSLAPolicies godoc
+// @Summary      List SLA policies
+// @Description  Returns the hotel's SLA policies. The most specific policy (department and priority) applies to a request.
+// @Tags         hotels
+// @Produce      json
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {array}   models.SLAPolicy
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/sla-policies [get]
+func (h *SLAPoliciesHandler) GetSLAPolicies(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+
+	policies, err := h.repo.FindSLAPoliciesByHotelID(c.Context(), hotelID)
+	if err != nil {
+		slog.Error("failed to get sla policies", "hotel_id", hotelID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(policies)
+}
+
+// CreateSLAPolicy godoc
+// @Summary      Create SLA policy
+// @Description  Adds an SLA policy, optionally scoped to a department and/or priority
+// @Tags         hotels
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true  "Hotel ID"
+// @Param        request  body      models.SLAPolicyInput  true  "SLA policy"
+// @Success      201      {object}  models.SLAPolicy
+// @Failure      400      {object}  errs.HTTPError
+// @Failure      409      {object}  errs.HTTPError
+// @Failure      500      {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/sla-policies [post]
+func (h *SLAPoliciesHandler) CreateSLAPolicy(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+
+	var req models.SLAPolicyInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if err := validateSLAPolicyInput(&req); err != nil {
+		return err
+	}
+
+	policy, err := h.repo.InsertSLAPolicy(c.Context(), hotelID, &req)
+	if err != nil {
+		if errors.Is(err, errs.ErrAlreadyExistsInDB) {
+			return errs.Conflict("sla policy", "scope", slaPolicyScope(&req))
+		}
+		slog.Error("failed to create sla policy", "hotel_id", hotelID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(policy)
+}
+
+// UpdateSLAPolicy godoc
+// @Summary      Update SLA policy
+// @Description  Replaces an SLA policy's scope, targets and escalation user
+// @Tags         hotels
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                 true  "Hotel ID"
+// @Param        policyId  path      string                 true  "SLA policy ID"
+// @Param        request   body      models.SLAPolicyInput  true  "SLA policy"
+// @Success      200       {object}  models.SLAPolicy
+// @Failure      400       {object}  errs.HTTPError
+// @Failure      404       {object}  errs.HTTPError
+// @Failure      409       {object}  errs.HTTPError
+// @Failure      500       {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/sla-policies/{policyId} [put]
+func (h *SLAPoliciesHandler) UpdateSLAPolicy(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	policyID := c.Params("policyId")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+	if !validUUID(policyID) {
+		return errs.BadRequest("sla policy id is not a valid UUID")
+	}
+
+	var req models.SLAPolicyInput
+	if err := httpx.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if err := validateSLAPolicyInput(&req); err != nil {
+		return err
+	}
+
+	policy, err := h.repo.UpdateSLAPolicy(c.Context(), policyID, hotelID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrNotFoundInDB):
+			return errs.NotFound("sla policy", "id", policyID)
+		case errors.Is(err, errs.ErrAlreadyExistsInDB):
+			return errs.Conflict("sla policy", "scope", slaPolicyScope(&req))
+		}
+		slog.Error("failed to update sla policy", "policy_id", policyID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.JSON(policy)
+}
+
+// DeleteSLAPolicy godoc
+// @Summary      Delete SLA policy
+// @Tags         hotels
+// @Produce      json
+// @Param        id        path  string  true  "Hotel ID"
+// @Param        policyId  path  string  true  "SLA policy ID"
+// @Success      204
+// @Failure      400  {object}  errs.HTTPError
+// @Failure      404  {object}  errs.HTTPError
+// @Failure      500  {object}  errs.HTTPError
+// @Security     BearerAuth
+// @Router       /hotels/{id}/sla-policies/{policyId} [delete]
+func (h *SLAPoliciesHandler) DeleteSLAPolicy(c *fiber.Ctx) error {
+	hotelID := c.Params("id")
+	policyID := c.Params("policyId")
+	if strings.TrimSpace(hotelID) == "" {
+		return errs.BadRequest("hotel id is required")
+	}
+	if !validUUID(policyID) {
+		return errs.BadRequest("sla policy id is not a valid UUID")
+	}
+
+	if err := h.repo.DeleteSLAPolicy(c.Context(), policyID, hotelID); err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return errs.NotFound("sla policy", "id", policyID)
+		}
+		slog.Error("failed to delete sla policy", "policy_id", policyID, "err", err)
+		return errs.InternalServerError()
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// validateSLAPolicyInput rejects policies that set no target at all.
+func validateSLAPolicyInput(req *models.SLAPolicyInput) error {
+	if req.AcknowledgeWithinMinutes == nil && req.CompleteWithinMinutes == nil {
+		return errs.BadRequest("at least one of acknowledge_within_minutes or complete_within_minutes is required")
+	}
+	return nil
+}
+
+func slaPolicyScope(req *models.SLAPolicyInput) string {
+	department, priority := "any", "any"
+	if req.DepartmentID != nil {
+		department = *req.DepartmentID
+	}
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	return "department=" + department + ", priority=" + priority
+}
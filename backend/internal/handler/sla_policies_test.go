@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slaHotelID = "org_550e8400-e29b-41d4-a716-446655440000"
+const slaPolicyID = "550e8400-e29b-41d4-a716-446655440000"
+
+type mockSLARepository struct {
+	findSLAPoliciesByHotelIDFunc func(ctx context.Context, hotelID string) ([]*models.SLAPolicy, error)
+	insertSLAPolicyFunc          func(ctx context.Context, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error)
+	updateSLAPolicyFunc          func(ctx context.Context, id, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error)
+	deleteSLAPolicyFunc          func(ctx context.Context, id, hotelID string) error
+}
+
+func (m *mockSLARepository) FindSLAPoliciesByHotelID(ctx context.Context, hotelID string) ([]*models.SLAPolicy, error) {
+	return m.findSLAPoliciesByHotelIDFunc(ctx, hotelID)
+}
+
+func (m *mockSLARepository) InsertSLAPolicy(ctx context.Context, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+	return m.insertSLAPolicyFunc(ctx, hotelID, input)
+}
+
+func (m *mockSLARepository) UpdateSLAPolicy(ctx context.Context, id, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+	return m.updateSLAPolicyFunc(ctx, id, hotelID, input)
+}
+
+func (m *mockSLARepository) DeleteSLAPolicy(ctx context.Context, id, hotelID string) error {
+	return m.deleteSLAPolicyFunc(ctx, id, hotelID)
+}
+
+func (m *mockSLARepository) FindSLABreaches(ctx context.Context, now time.Time, limit int) ([]*models.SLABreach, error) {
+	return nil, nil
+}
+
+func (m *mockSLARepository) RecordSLABreach(ctx context.Context, breach *models.SLABreach) (bool, error) {
+	return false, nil
+}
+
+func slaPoliciesApp(repo *mockSLARepository) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errs.ErrorHandler})
+	h := NewSLAPoliciesHandler(repo)
+	app.Get("/hotels/:id/sla-policies", h.GetSLAPolicies)
+	app.Post("/hotels/:id/sla-policies", h.CreateSLAPolicy)
+	app.Put("/hotels/:id/sla-policies/:policyId", h.UpdateSLAPolicy)
+	app.Delete("/hotels/:id/sla-policies/:policyId", h.DeleteSLAPolicy)
+	return app
+}
+
+func TestSLAPoliciesHandler_GetSLAPolicies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns 200 with policies", func(t *testing.T) {
+		t.Parallel()
+
+		complete := 60
+		app := slaPoliciesApp(&mockSLARepository{
+			findSLAPoliciesByHotelIDFunc: func(ctx context.Context, hotelID string) ([]*models.SLAPolicy, error) {
+				assert.Equal(t, slaHotelID, hotelID)
+				return []*models.SLAPolicy{{ID: slaPolicyID, HotelID: hotelID, CompleteWithinMinutes: &complete}}, nil
+			},
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/hotels/"+slaHotelID+"/sla-policies", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var policies []models.SLAPolicy
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&policies))
+		require.Len(t, policies, 1)
+		assert.Equal(t, 60, *policies[0].CompleteWithinMinutes)
+	})
+
+	t.Run("returns 500 on db error", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			findSLAPoliciesByHotelIDFunc: func(ctx context.Context, hotelID string) ([]*models.SLAPolicy, error) {
+				return nil, errors.New("db down")
+			},
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/hotels/"+slaHotelID+"/sla-policies", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestSLAPoliciesHandler_CreateSLAPolicy(t *testing.T) {
+	t.Parallel()
+
+	post := func(app *fiber.App, body string) int {
+		req := httptest.NewRequest("POST", "/hotels/"+slaHotelID+"/sla-policies", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("returns 201 on success", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			insertSLAPolicyFunc: func(ctx context.Context, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+				assert.Equal(t, "high", *input.Priority)
+				assert.Equal(t, 10, *input.AcknowledgeWithinMinutes)
+				return &models.SLAPolicy{ID: slaPolicyID, HotelID: hotelID, Priority: input.Priority}, nil
+			},
+		})
+
+		assert.Equal(t, 201, post(app, `{"priority":"high","acknowledge_within_minutes":10,"escalate_to_user_id":"user_lead"}`))
+	})
+
+	t.Run("returns 400 when no target is set", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{})
+		assert.Equal(t, 400, post(app, `{"priority":"high"}`))
+	})
+
+	t.Run("returns 400 on invalid fields", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{})
+		assert.Equal(t, 400, post(app, `{"priority":"urgent","complete_within_minutes":30}`))
+		assert.Equal(t, 400, post(app, `{"department_id":"kitchen","complete_within_minutes":30}`))
+		assert.Equal(t, 400, post(app, `{"complete_within_minutes":0}`))
+	})
+
+	t.Run("returns 409 when scope already has a policy", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			insertSLAPolicyFunc: func(ctx context.Context, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+				return nil, errs.ErrAlreadyExistsInDB
+			},
+		})
+
+		assert.Equal(t, 409, post(app, `{"complete_within_minutes":30}`))
+	})
+}
+
+func TestSLAPoliciesHandler_UpdateSLAPolicy(t *testing.T) {
+	t.Parallel()
+
+	put := func(app *fiber.App, policyID, body string) int {
+		req := httptest.NewRequest("PUT", "/hotels/"+slaHotelID+"/sla-policies/"+policyID, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("returns 200 on success", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			updateSLAPolicyFunc: func(ctx context.Context, id, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+				assert.Equal(t, slaPolicyID, id)
+				assert.Equal(t, slaHotelID, hotelID)
+				return &models.SLAPolicy{ID: id, HotelID: hotelID, CompleteWithinMinutes: input.CompleteWithinMinutes}, nil
+			},
+		})
+
+		assert.Equal(t, 200, put(app, slaPolicyID, `{"complete_within_minutes":45}`))
+	})
+
+	t.Run("returns 400 on invalid policy id", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{})
+		assert.Equal(t, 400, put(app, "not-a-uuid", `{"complete_within_minutes":45}`))
+	})
+
+	t.Run("returns 404 when policy does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			updateSLAPolicyFunc: func(ctx context.Context, id, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+				return nil, errs.ErrNotFoundInDB
+			},
+		})
+
+		assert.Equal(t, 404, put(app, slaPolicyID, `{"complete_within_minutes":45}`))
+	})
+}
+
+func TestSLAPoliciesHandler_DeleteSLAPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns 204 on success", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			deleteSLAPolicyFunc: func(ctx context.Context, id, hotelID string) error {
+				return nil
+			},
+		})
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/hotels/"+slaHotelID+"/sla-policies/"+slaPolicyID, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 204, resp.StatusCode)
+	})
+
+	t.Run("returns 404 when policy does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		app := slaPoliciesApp(&mockSLARepository{
+			deleteSLAPolicyFunc: func(ctx context.Context, id, hotelID string) error {
+				return errs.ErrNotFoundInDB
+			},
+		})
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/hotels/"+slaHotelID+"/sla-policies/"+slaPolicyID, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestFeedCursor_RoundTripsSLADueAt(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2026, 4, 21, 12, 0, 0, 0, time.UTC)
+	req := &models.GuestRequest{ID: slaPolicyID, Priority: "medium", CreatedAt: due.Add(-time.Hour), SLADueAt: &due}
+
+	id, createdAt, rank, slaDueAt, err := parseFeedCursor(buildFeedCursor(req))
+	require.NoError(t, err)
+	assert.Equal(t, slaPolicyID, id)
+	assert.True(t, req.CreatedAt.Equal(createdAt))
+	assert.Equal(t, 2, rank)
+	require.NotNil(t, slaDueAt)
+	assert.True(t, due.Equal(*slaDueAt))
+
+	req.SLADueAt = nil
+	_, _, _, slaDueAt, err = parseFeedCursor(buildFeedCursor(req))
+	require.NoError(t, err)
+	assert.Nil(t, slaDueAt)
+
+	// cursors issued before SLA sorting existed still parse
+	_, _, _, slaDueAt, err = parseFeedCursor("1|1700000000000000000|" + slaPolicyID)
+	require.NoError(t, err)
+	assert.Nil(t, slaDueAt)
+}
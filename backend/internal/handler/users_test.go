@@ -118,14 +118,34 @@ var _ storage.UsersRepository = (*mockUsersRepository)(nil)
 // Mock S3 Storage for testing
 type mockS3Storage struct {
 	deleteFileFunc func(ctx context.Context, key string) error
+	headFileFunc   func(ctx context.Context, key string) (*models.S3ObjectInfo, error)
+	getFileFunc    func(ctx context.Context, key string, maxSize int64) ([]byte, error)
+	uploadURLFunc  func(ctx context.Context, in models.PresignedURLInput) (string, error)
 }
 
 func (m *mockS3Storage) GeneratePresignedUploadURL(ctx context.Context, in models.PresignedURLInput) (string, error) {
+	if m.uploadURLFunc != nil {
+		return m.uploadURLFunc(ctx, in)
+	}
 	return "", nil
 }
 
 func (m *mockS3Storage) GeneratePresignedGetURL(ctx context.Context, in models.PresignedURLInput) (string, error) {
-	return "", nil
+	return "https://bucket.s3.amazonaws.com/" + in.Key, nil
+}
+
+func (m *mockS3Storage) HeadFile(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+	if m.headFileFunc != nil {
+		return m.headFileFunc(ctx, key)
+	}
+	return nil, errs.ErrNotFoundInStorage
+}
+
+func (m *mockS3Storage) GetFile(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	if m.getFileFunc != nil {
+		return m.getFileFunc(ctx, key, maxSize)
+	}
+	return nil, errs.ErrNotFoundInStorage
 }
 
 func (m *mockS3Storage) DeleteFile(ctx context.Context, key string) error {
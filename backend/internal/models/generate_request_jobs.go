@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type GenerateRequestJobStatus string
+
+const (
+	JobPending    GenerateRequestJobStatus = "pending"
+	JobGenerating GenerateRequestJobStatus = "generating"
+	JobStoring    GenerateRequestJobStatus = "storing"
+	JobCompleted  GenerateRequestJobStatus = "completed"
+	JobFailed     GenerateRequestJobStatus = "failed"
+	JobCancelled  GenerateRequestJobStatus = "cancelled"
+)
+
+// GenerateRequestJob is an async request generation run in-process, used
+// while Temporal is unavailable. Output is the generated request once the job
+// reaches JobStoring; RequestID is the stored request once it completes.
+type GenerateRequestJob struct {
+	ID          string
+	HotelID     string
+	RequestedBy *string
+	RawText     string
+	Revision    int
+	Status      GenerateRequestJobStatus
+	Attempts    int
+	Output      json.RawMessage
+	RequestID   *string
+	Error       *string
+	CreatedAt   time.Time
+	FinishedAt  *time.Time
+}
+
+// Open reports whether the job is still to run.
+func (j *GenerateRequestJob) Open() bool {
+	return j.Status == JobPending || j.Status == JobGenerating || j.Status == JobStoring
+}
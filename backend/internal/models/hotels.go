@@ -11,5 +11,25 @@ type CreateHotelRequest struct {
 type Hotel struct {
 	CreatedAt time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
 	UpdatedAt time.Time `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+	// StaffLanguage is the language staff work in; generated requests are
+	// named and described in it.
+	StaffLanguage string `json:"staff_language" example:"en"`
+	// WebhookURLs receive every notification sent to the hotel's staff.
+	WebhookURLs []string `json:"webhook_urls" example:"https://pms.example.com/hooks/selfserve"`
 	CreateHotelRequest
 } //@name Hotel
+
+// DefaultStaffLanguage is the staff language of a hotel that has not set one.
+const DefaultStaffLanguage = "en"
+
+// UpdateHotelSettingsInput is the body for PUT /hotels/:id/settings.
+type UpdateHotelSettingsInput struct {
+	StaffLanguage string `json:"staff_language" validate:"notblank,bcp47_language_tag" example:"es"`
+	// WebhookURLs replace the hotel's webhooks; none turns them off.
+	WebhookURLs []string `json:"webhook_urls" validate:"omitempty,dive,http_url" example:"https://pms.example.com/hooks/selfserve"`
+	// WebhookSecret replaces the secret webhook payloads are signed with. It
+	// is never returned, so leaving it out keeps the current secret.
+	WebhookSecret string `json:"webhook_secret,omitempty" example:"whsec_123"`
+	// ClearWebhookSecret removes the secret so payloads are sent unsigned.
+	ClearWebhookSecret bool `json:"clear_webhook_secret,omitempty" validate:"excluded_with=WebhookSecret" example:"false"`
+} //@name UpdateHotelSettingsInput
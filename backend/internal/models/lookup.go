@@ -0,0 +1,24 @@
+package models
+
+// RoomReference is a room mentioned in free text, normalised for lookup.
+// "rm 504" sets Number; "floor 5 room 4" sets Floor and Unit; "the
+// presidential suite" sets Suite.
+type RoomReference struct {
+	Number *int
+	Floor  *int
+	Unit   *int
+	Suite  string
+}
+
+// Empty reports whether the reference has nothing to look up.
+func (r RoomReference) Empty() bool {
+	return r.Number == nil && r.Unit == nil && r.Suite == ""
+}
+
+// LookupCandidate is a ranked match for a free-text room, guest or staff
+// reference. Score is between 0 and 1; 1 is an exact match.
+type LookupCandidate struct {
+	ID    string  `json:"id"`
+	Label string  `json:"label" example:"Room 504 (Deluxe)"`
+	Score float64 `json:"score" example:"0.92"`
+} //@name LookupCandidate
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type NotificationOutboxStatus string
+
+const (
+	OutboxPending   NotificationOutboxStatus = "pending"
+	OutboxDelivered NotificationOutboxStatus = "delivered"
+	OutboxFailed    NotificationOutboxStatus = "failed"
+)
+
+// NotificationOutboxEntry is a notification to deliver on one channel, named
+// as the channel names itself.
+type NotificationOutboxEntry struct {
+	ID             string
+	NotificationID string
+	Channel        string
+	Attempts       int
+	UserID         string
+	Type           NotificationType
+	Title          string
+	Body           string
+	CreatedAt      time.Time
+}
+
+type NotificationDeliveryStatus string
+
+const (
+	// DeliverySent is accepted by a provider that is yet to report delivery.
+	DeliverySent      NotificationDeliveryStatus = "sent"
+	DeliveryDelivered NotificationDeliveryStatus = "delivered"
+	// DeliveryRetrying failed and is attempted again with its outbox entry.
+	DeliveryRetrying NotificationDeliveryStatus = "retrying"
+	DeliveryFailed   NotificationDeliveryStatus = "failed"
+)
+
+// NotificationDelivery is the receipt for delivering an outbox entry to one
+// address.
+type NotificationDelivery struct {
+	OutboxID string
+	Address  string
+	Status   NotificationDeliveryStatus
+	TicketID *string
+	Error    *string
+}
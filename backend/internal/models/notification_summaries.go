@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DailySummaryRecipient is a user due their daily summary.
+type DailySummaryRecipient struct {
+	UserID string
+	// SentOn is the date in the user's timezone the summary is for.
+	SentOn time.Time
+}
+
+// DepartmentWorkload counts the open requests in a department and how many of
+// them are past their SLA.
+type DepartmentWorkload struct {
+	DepartmentID string
+	Name         string
+	Open         int
+	Overdue      int
+}
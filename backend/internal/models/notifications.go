@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"slices"
 	"time"
 )
 
@@ -10,8 +11,16 @@ type NotificationType string
 const (
 	TypeTaskAssigned     NotificationType = "task_assigned"
 	TypeHighPriorityTask NotificationType = "high_priority_task"
+	TypeSLABreached      NotificationType = "sla_breached"
+	TypeMentioned        NotificationType = "mentioned"
+	// TypeDailySummary is the daily count of open and overdue requests in the
+	// user's departments.
+	TypeDailySummary NotificationType = "daily_summary"
 )
 
+// TaskAssignedTitle is the title of a TypeTaskAssigned notification.
+const TaskAssignedTitle = "New task assigned to you"
+
 type Notification struct {
 	ID        string           `json:"id"`
 	UserID    string           `json:"user_id"`
@@ -23,7 +32,182 @@ type Notification struct {
 	CreatedAt time.Time        `json:"created_at"`
 } //@name Notification
 
+// NotificationInput is a notification to write and queue for delivery.
+type NotificationInput struct {
+	UserID string
+	Type   NotificationType
+	// RequestID is the request the notification is about, if any.
+	RequestID string
+	Title     string
+	Body      string
+	// Channels names the channels to deliver it on besides in-app.
+	Channels []string
+	// DigestWindow holds back its delivery on a channel the user was notified
+	// on less than the window ago, to go out together with any others that
+	// arrive meanwhile. Zero delivers it straight away.
+	DigestWindow time.Duration
+	// DedupeWindow drops it when the user was sent one of the same type and
+	// title, such as the same kind of SLA breach, about the same request less
+	// than the window ago. Zero keeps every one.
+	DedupeWindow time.Duration
+	// Held names the channels, out of Channels, to hold its delivery back on
+	// until HeldUntil, such as the end of the user's quiet hours.
+	Held      []string
+	HeldUntil time.Time
+}
+
+// DeviceToken is a push token registered by one of a user's devices.
+type DeviceToken struct {
+	Token    string
+	Platform string
+}
+
+// NotificationRecipient is a user with the addresses notifications can be
+// delivered to.
+type NotificationRecipient struct {
+	UserID  string
+	HotelID string
+	Email   *string
+	Phone   *string
+	Devices []DeviceToken
+	// WebhookURLs are the webhooks of the user's hotel, signed with
+	// WebhookSecret when it is set.
+	WebhookURLs   []string
+	WebhookSecret string
+}
+
 type RegisterDeviceTokenInput struct {
 	Token    string `json:"token" validate:"notblank"`
 	Platform string `json:"platform" validate:"oneof=ios android"`
 } //@name RegisterDeviceTokenInput
+
+type NotificationChannel string
+
+const (
+	ChannelInApp NotificationChannel = "in_app"
+	ChannelPush  NotificationChannel = "push"
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+	// ChannelWebhook reaches the hotel's own systems rather than the user, so
+	// it is not subject to the user's preferences.
+	ChannelWebhook NotificationChannel = "webhook"
+)
+
+// DefaultNotificationChannels are the channels of a notification type a user
+// has not chosen channels for.
+var DefaultNotificationChannels = []NotificationChannel{ChannelInApp, ChannelPush}
+
+// DailyWindow is a time of day range in a user's timezone, such as quiet
+// hours. It wraps past midnight when End is before Start, and covers the whole
+// day when they are equal.
+type DailyWindow struct {
+	Start string `json:"start" validate:"required,datetime=15:04" example:"22:00"`
+	End   string `json:"end" validate:"required,datetime=15:04" example:"07:00"`
+} //@name DailyWindow
+
+// Contains reports whether the time of day of t falls in the window.
+func (w *DailyWindow) Contains(t time.Time) bool {
+	start, errStart := time.Parse("15:04", w.Start)
+	end, errEnd := time.Parse("15:04", w.End)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	switch {
+	case from == to:
+		return true
+	case from < to:
+		return minute >= from && minute < to
+	default:
+		return minute >= from || minute < to
+	}
+}
+
+// next returns the first time after t, in t's location, whose time of day is
+// clock.
+func next(clock string, t time.Time) (time.Time, bool) {
+	at, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), at.Hour(), at.Minute(), 0, 0, t.Location())
+	if !day.After(t) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, true
+}
+
+// UpdateNotificationPreferencesInput is the body for PUT
+// /notifications/preferences. It replaces the user's preferences.
+type UpdateNotificationPreferencesInput struct {
+	// Channels maps notification types to the channels they are delivered on;
+	// types left out are delivered on DefaultNotificationChannels, and an
+	// empty list keeps a type in-app only.
+	Channels map[NotificationType][]NotificationChannel `json:"channels" validate:"omitempty,dive,keys,oneof=task_assigned high_priority_task sla_breached mentioned daily_summary,endkeys,dive,oneof=in_app push email sms"`
+	// QuietHours hold back everything but in-app notifications until they end.
+	QuietHours *DailyWindow `json:"quiet_hours,omitempty" validate:"omitempty"`
+	// OnShiftOnly holds back everything but in-app notifications outside Shift
+	// until it starts.
+	OnShiftOnly bool         `json:"on_shift_only" example:"false"`
+	Shift       *DailyWindow `json:"shift,omitempty" validate:"required_if=OnShiftOnly true,omitempty"`
+} //@name UpdateNotificationPreferencesInput
+
+// NotificationPreferences are how a user is notified, in their timezone (UTC
+// when they have none). The in-app record is written whatever they say.
+type NotificationPreferences struct {
+	UpdateNotificationPreferencesInput
+	Timezone  string     `json:"timezone" example:"America/New_York"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+} //@name NotificationPreferences
+
+// DeliverAt returns when a notification arriving at t may go out on channels
+// other than in-app: t itself, or the end of the quiet hours or the start of
+// the shift holding it back. ok is false when it never may, as when quiet
+// hours cover the whole day or the shift falls inside them.
+func (p *NotificationPreferences) DeliverAt(t time.Time) (at time.Time, ok bool) {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil || p.Timezone == "" {
+		loc = time.UTC
+	}
+	at = t.In(loc)
+	// Each step moves past a window, so a few cover every way the two can
+	// overlap; more means they leave no time free.
+	for range 4 {
+		var until time.Time
+		switch {
+		case p.QuietHours != nil && p.QuietHours.Contains(at):
+			if p.QuietHours.Start == p.QuietHours.End {
+				return time.Time{}, false
+			}
+			until, ok = next(p.QuietHours.End, at)
+		case p.OnShiftOnly && p.Shift != nil && !p.Shift.Contains(at):
+			until, ok = next(p.Shift.Start, at)
+		default:
+			return at, true
+		}
+		if !ok {
+			return time.Time{}, false
+		}
+		at = until
+	}
+	return time.Time{}, false
+}
+
+// ChannelsFor returns the channels other than in-app to deliver a notification
+// of notifType on. DeliverAt says when.
+func (p *NotificationPreferences) ChannelsFor(notifType NotificationType) []NotificationChannel {
+	channels, ok := p.Channels[notifType]
+	if !ok {
+		channels = DefaultNotificationChannels
+	}
+	external := make([]NotificationChannel, 0, len(channels))
+	for _, channel := range channels {
+		if channel != ChannelInApp && !slices.Contains(external, channel) {
+			external = append(external, channel)
+		}
+	}
+	return external
+}
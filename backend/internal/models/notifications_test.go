@@ -0,0 +1,151 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyWindow_Contains(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour, minute int) time.Time { return time.Date(2026, 5, 1, hour, minute, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name   string
+		window DailyWindow
+		at     time.Time
+		expect bool
+	}{
+		{name: "inside a daytime window", window: DailyWindow{Start: "08:00", End: "16:00"}, at: at(12, 0), expect: true},
+		{name: "start is inclusive", window: DailyWindow{Start: "08:00", End: "16:00"}, at: at(8, 0), expect: true},
+		{name: "end is exclusive", window: DailyWindow{Start: "08:00", End: "16:00"}, at: at(16, 0), expect: false},
+		{name: "late in a window past midnight", window: DailyWindow{Start: "22:00", End: "07:00"}, at: at(23, 30), expect: true},
+		{name: "early in a window past midnight", window: DailyWindow{Start: "22:00", End: "07:00"}, at: at(6, 59), expect: true},
+		{name: "outside a window past midnight", window: DailyWindow{Start: "22:00", End: "07:00"}, at: at(12, 0), expect: false},
+		{name: "equal bounds cover the day", window: DailyWindow{Start: "00:00", End: "00:00"}, at: at(15, 0), expect: true},
+		{name: "malformed bounds cover nothing", window: DailyWindow{Start: "10pm", End: "07:00"}, at: at(23, 0), expect: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expect, tc.window.Contains(tc.at))
+		})
+	}
+}
+
+func TestNotificationPreferences_ChannelsFor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults to push", func(t *testing.T) {
+		t.Parallel()
+
+		prefs := &NotificationPreferences{}
+		assert.Equal(t, []NotificationChannel{ChannelPush}, prefs.ChannelsFor(TypeTaskAssigned))
+	})
+
+	t.Run("uses the channels chosen for the type", func(t *testing.T) {
+		t.Parallel()
+
+		prefs := &NotificationPreferences{UpdateNotificationPreferencesInput: UpdateNotificationPreferencesInput{
+			Channels: map[NotificationType][]NotificationChannel{
+				TypeSLABreached: {ChannelInApp, ChannelSMS, ChannelEmail, ChannelSMS},
+				TypeMentioned:   {},
+			},
+		}}
+		assert.Equal(t, []NotificationChannel{ChannelSMS, ChannelEmail}, prefs.ChannelsFor(TypeSLABreached))
+		assert.Empty(t, prefs.ChannelsFor(TypeMentioned))
+		assert.Equal(t, []NotificationChannel{ChannelPush}, prefs.ChannelsFor(TypeTaskAssigned))
+	})
+}
+
+func TestNotificationPreferences_DeliverAt(t *testing.T) {
+	t.Parallel()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// 03:00 UTC is 23:00 the evening before in New York.
+	lateEvening := time.Date(2026, 5, 1, 3, 0, 0, 0, time.UTC)
+	afternoon := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+
+	t.Run("delivers straight away by default", func(t *testing.T) {
+		t.Parallel()
+
+		at, ok := (&NotificationPreferences{}).DeliverAt(afternoon)
+		require.True(t, ok)
+		assert.True(t, at.Equal(afternoon))
+	})
+
+	t.Run("holds back until quiet hours end in the user's timezone", func(t *testing.T) {
+		t.Parallel()
+
+		prefs := &NotificationPreferences{
+			UpdateNotificationPreferencesInput: UpdateNotificationPreferencesInput{
+				QuietHours: &DailyWindow{Start: "22:00", End: "07:00"},
+			},
+			Timezone: "America/New_York",
+		}
+		at, ok := prefs.DeliverAt(lateEvening)
+		require.True(t, ok)
+		assert.True(t, at.Equal(time.Date(2026, 5, 1, 7, 0, 0, 0, newYork)), at)
+
+		at, ok = prefs.DeliverAt(afternoon)
+		require.True(t, ok)
+		assert.True(t, at.Equal(afternoon))
+	})
+
+	t.Run("holds back until the shift starts when on shift only", func(t *testing.T) {
+		t.Parallel()
+
+		prefs := &NotificationPreferences{
+			UpdateNotificationPreferencesInput: UpdateNotificationPreferencesInput{
+				OnShiftOnly: true,
+				Shift:       &DailyWindow{Start: "08:00", End: "16:00"},
+			},
+			Timezone: "America/New_York",
+		}
+		// 18:00 UTC is 14:00 in New York.
+		at, ok := prefs.DeliverAt(afternoon)
+		require.True(t, ok)
+		assert.True(t, at.Equal(afternoon))
+
+		at, ok = prefs.DeliverAt(lateEvening)
+		require.True(t, ok)
+		assert.True(t, at.Equal(time.Date(2026, 5, 1, 8, 0, 0, 0, newYork)), at)
+	})
+
+	t.Run("waits for a shift that starts in quiet hours to leave them", func(t *testing.T) {
+		t.Parallel()
+
+		prefs := &NotificationPreferences{
+			UpdateNotificationPreferencesInput: UpdateNotificationPreferencesInput{
+				QuietHours:  &DailyWindow{Start: "22:00", End: "07:00"},
+				OnShiftOnly: true,
+				Shift:       &DailyWindow{Start: "06:00", End: "14:00"},
+			},
+		}
+		at, ok := prefs.DeliverAt(time.Date(2026, 5, 1, 15, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		assert.True(t, at.Equal(time.Date(2026, 5, 2, 7, 0, 0, 0, time.UTC)), at)
+	})
+
+	t.Run("never delivers when no time is free", func(t *testing.T) {
+		t.Parallel()
+
+		allDay := &NotificationPreferences{UpdateNotificationPreferencesInput: UpdateNotificationPreferencesInput{
+			QuietHours: &DailyWindow{Start: "00:00", End: "00:00"},
+		}}
+		_, ok := allDay.DeliverAt(afternoon)
+		assert.False(t, ok)
+
+		shiftInQuietHours := &NotificationPreferences{UpdateNotificationPreferencesInput: UpdateNotificationPreferencesInput{
+			QuietHours:  &DailyWindow{Start: "20:00", End: "08:00"},
+			OnShiftOnly: true,
+			Shift:       &DailyWindow{Start: "22:00", End: "02:00"},
+		}}
+		_, ok = shiftInQuietHours.DeliverAt(afternoon)
+		assert.False(t, ok)
+	})
+}
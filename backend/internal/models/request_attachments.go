@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+type AttachmentStatus string
+
+const (
+	AttachmentPending   AttachmentStatus = "pending"
+	AttachmentConfirmed AttachmentStatus = "confirmed"
+)
+
+// RequestAttachment is a file stored in S3 under Key and linked to a request.
+// URL is a short-lived presigned GET URL, filled in when attachments are listed.
+type RequestAttachment struct {
+	ID          string           `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RequestID   string           `json:"request_id" example:"530e8400-e458-41d4-a716-446655440000"`
+	HotelID     string           `json:"hotel_id" example:"org_2abc123"`
+	Key         string           `json:"key" example:"request-attachments/530e8400-e458-41d4-a716-446655440000/550e8400-e29b-41d4-a716-446655440000.jpg"`
+	FileName    string           `json:"file_name" example:"broken-lamp.jpg"`
+	ContentType string           `json:"content_type" example:"image/jpeg"`
+	SizeBytes   int64            `json:"size_bytes" example:"204800"`
+	Status      AttachmentStatus `json:"status" example:"confirmed"`
+	UploadedBy  *string          `json:"uploaded_by,omitempty" example:"user_2abc123"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ConfirmedAt *time.Time       `json:"confirmed_at,omitempty"`
+	URL         *string          `json:"url,omitempty"`
+} //@name RequestAttachment
+
+// CreateRequestAttachmentInput declares the file the client is about to upload.
+type CreateRequestAttachmentInput struct {
+	FileName    string `json:"file_name" validate:"notblank,max=255" example:"broken-lamp.jpg"`
+	ContentType string `json:"content_type" validate:"notblank" example:"image/jpeg"`
+	SizeBytes   int64  `json:"size_bytes" validate:"gt=0" example:"204800"`
+} //@name CreateRequestAttachmentInput
+
+// RequestAttachmentUpload is returned when an attachment is created. The file
+// must be PUT to UploadURL with the declared Content-Type before confirming.
+type RequestAttachmentUpload struct {
+	Attachment *RequestAttachment `json:"attachment"`
+	UploadURL  string             `json:"upload_url"`
+} //@name RequestAttachmentUpload
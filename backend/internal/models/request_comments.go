@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RequestComment is a comment on a request. Replies set ParentID to the
+// comment they answer. Deleted comments keep their place in the thread with
+// an empty Body.
+type RequestComment struct {
+	ID        string     `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RequestID string     `json:"request_id" example:"530e8400-e458-41d4-a716-446655440000"`
+	HotelID   string     `json:"hotel_id" example:"org_2abc123"`
+	ParentID  *string    `json:"parent_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+	AuthorID  *string    `json:"author_id,omitempty" example:"user_2abc123"`
+	Body      string     `json:"body" example:"@maria the part arrives tomorrow"`
+	Mentions  []string   `json:"mentions"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+} //@name RequestComment
+
+type CreateRequestCommentInput struct {
+	Body     string  `json:"body" validate:"notblank,max=5000" example:"@maria the part arrives tomorrow"`
+	ParentID *string `json:"parent_id" validate:"omitempty,uuid" example:"550e8400-e29b-41d4-a716-446655440001"`
+} //@name CreateRequestCommentInput
+
+type UpdateRequestCommentInput struct {
+	Body string `json:"body" validate:"notblank,max=5000" example:"@maria the part arrives on Friday"`
+} //@name UpdateRequestCommentInput
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+type DraftReviewAction string
+
+const (
+	DraftApproved DraftReviewAction = "approved"
+	DraftRejected DraftReviewAction = "rejected"
+)
+
+// RequestDraft is an AI-generated request stored with status draft: hidden
+// from the feed until a reviewer approves or rejects it.
+type RequestDraft struct {
+	Request         Request                 `json:"request"`
+	RawText         string                  `json:"raw_text" example:"rm 504 needs something for the AC"`
+	Confidence      float64                 `json:"confidence" example:"0.42"`
+	FieldConfidence map[string]float64      `json:"field_confidence"`
+	Warning         *GenerateRequestWarning `json:"warning,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	// Generated is the request as extracted, before any reviewer edits;
+	// corrections are measured against it.
+	Generated MakeRequest `json:"-"`
+} //@name RequestDraft
+
+// ApproveRequestDraftInput is the body for POST /requests/drafts/:id/approve.
+// Changes are applied as the draft is published; status may be pending (the
+// default) or in progress.
+type ApproveRequestDraftInput struct {
+	RequestUpdateInput
+} //@name ApproveRequestDraftInput
+
+// RejectRequestDraftInput is the body for POST /requests/drafts/:id/reject.
+type RejectRequestDraftInput struct {
+	Reason *string `json:"reason" validate:"omitempty,max=500" example:"Duplicate of an open request"`
+} //@name RejectRequestDraftInput
+
+// DraftCorrection is one field a reviewer changed before approving a draft.
+type DraftCorrection struct {
+	Field     string  `json:"field" example:"room_id"`
+	Generated *string `json:"generated"`
+	Corrected *string `json:"corrected"`
+} //@name DraftCorrection
+
+// DraftReview records the outcome of reviewing a draft. Corrections are
+// worked out as an approval is written.
+type DraftReview struct {
+	Action      DraftReviewAction
+	ReviewedBy  *string
+	Reason      *string
+	Corrections []DraftCorrection
+}
+
+// DraftAccuracy summarises reviewed drafts of a hotel: how often each field
+// was extracted correctly in approved drafts.
+type DraftAccuracy struct {
+	Since    time.Time            `json:"since"`
+	Reviewed int                  `json:"reviewed" example:"120"`
+	Approved int                  `json:"approved" example:"104"`
+	Rejected int                  `json:"rejected" example:"16"`
+	Fields   []DraftFieldAccuracy `json:"fields"`
+} //@name DraftAccuracy
+
+type DraftFieldAccuracy struct {
+	Field     string  `json:"field" example:"room_id"`
+	Corrected int     `json:"corrected" example:"9"`
+	Accuracy  float64 `json:"accuracy" example:"0.91"`
+} //@name DraftFieldAccuracy
+
+// DraftReviewedFields are the fields compared between the generated and the
+// approved request, in reporting order.
+var DraftReviewedFields = []string{
+	"name", "priority", "request_type", "request_category", "department",
+	"room_id", "guest_id", "user_id", "estimated_completion_time",
+}
+
+// DraftCorrections lists the reviewed fields that differ between the request
+// as generated and as approved.
+func DraftCorrections(generated, approved MakeRequest) []DraftCorrection {
+	str := func(s string) *string { return &s }
+	values := func(r MakeRequest) map[string]*string {
+		var eta *string
+		if r.EstimatedCompletionTime != nil {
+			eta = str(strconv.Itoa(*r.EstimatedCompletionTime))
+		}
+		return map[string]*string{
+			"name":                      str(r.Name),
+			"priority":                  str(r.Priority),
+			"request_type":              str(r.RequestType),
+			"request_category":          r.RequestCategory,
+			"department":                r.Department,
+			"room_id":                   r.RoomID,
+			"guest_id":                  r.GuestID,
+			"user_id":                   r.UserID,
+			"estimated_completion_time": eta,
+		}
+	}
+
+	before, after := values(generated), values(approved)
+	var corrections []DraftCorrection
+	for _, field := range DraftReviewedFields {
+		if !sameOptional(before[field], after[field]) {
+			corrections = append(corrections, DraftCorrection{Field: field, Generated: before[field], Corrected: after[field]})
+		}
+	}
+	return corrections
+}
+
+func sameOptional(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftCorrections(t *testing.T) {
+	t.Parallel()
+
+	room, otherRoom := "room-504", "room-312"
+	eta := 30
+	generated := MakeRequest{Name: "AC Repair", Priority: "medium", RequestType: "one-time", RoomID: &room, EstimatedCompletionTime: &eta}
+
+	t.Run("no changes", func(t *testing.T) {
+		t.Parallel()
+
+		approved := generated
+		approved.Status = "pending"
+		notes := "reviewed"
+		approved.Notes = &notes
+		assert.Empty(t, DraftCorrections(generated, approved))
+	})
+
+	t.Run("lists changed fields in reporting order", func(t *testing.T) {
+		t.Parallel()
+
+		approved := generated
+		approved.Priority = "high"
+		approved.RoomID = &otherRoom
+		approved.EstimatedCompletionTime = nil
+		user := "user_maria"
+		approved.UserID = &user
+
+		corrections := DraftCorrections(generated, approved)
+		require.Len(t, corrections, 4)
+		assert.Equal(t, DraftCorrection{Field: "priority", Generated: ptr("medium"), Corrected: ptr("high")}, corrections[0])
+		assert.Equal(t, DraftCorrection{Field: "room_id", Generated: &room, Corrected: &otherRoom}, corrections[1])
+		assert.Equal(t, DraftCorrection{Field: "user_id", Corrected: &user}, corrections[2])
+		assert.Equal(t, DraftCorrection{Field: "estimated_completion_time", Generated: ptr("30")}, corrections[3])
+	})
+}
+
+func ptr(s string) *string { return &s }
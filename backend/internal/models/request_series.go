@@ -0,0 +1,171 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type RecurrenceFrequency string
+
+const (
+	FrequencyHourly   RecurrenceFrequency = "hourly"
+	FrequencyDaily    RecurrenceFrequency = "daily"
+	FrequencyWeekdays RecurrenceFrequency = "weekdays"
+)
+
+type RequestSeriesStatus string
+
+const (
+	SeriesStatusActive    RequestSeriesStatus = "active"
+	SeriesStatusPaused    RequestSeriesStatus = "paused"
+	SeriesStatusCancelled RequestSeriesStatus = "cancelled"
+)
+
+// maxRecurrenceSearchDays bounds how far ahead Next scans for a matching day.
+const maxRecurrenceSearchDays = 366 * 7
+
+// RecurrenceRule describes when a series produces its next request.
+//
+//   - hourly:   every Interval hours counted from StartAt.
+//   - daily:    every Interval days at each of Times (defaults to StartAt's clock time).
+//   - weekdays: Monday to Friday at each of Times.
+//
+// Times are "HH:MM" in Timezone (UTC when empty).
+type RecurrenceRule struct {
+	Frequency RecurrenceFrequency `json:"frequency" validate:"oneof=hourly daily weekdays" example:"daily"`
+	Interval  int                 `json:"interval"  validate:"omitempty,min=1,max=365" example:"1"`
+	Times     []string            `json:"times"     validate:"omitempty,dive,datetime=15:04" example:"09:00,17:30"`
+	Timezone  string              `json:"timezone"  validate:"omitempty,timezone" example:"America/New_York"`
+	StartAt   time.Time           `json:"start_at"  validate:"required" example:"2024-01-01T00:00:00Z"`
+	EndAt     *time.Time          `json:"end_at,omitempty" example:"2024-12-31T00:00:00Z"`
+} //@name RecurrenceRule
+
+// Next returns the first occurrence strictly after the given time. ok is
+// false when the rule has ended or never matches.
+func (r RecurrenceRule) Next(after time.Time) (next time.Time, ok bool, err error) {
+	loc := time.UTC
+	if r.Timezone != "" {
+		loc, err = time.LoadLocation(r.Timezone)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch r.Frequency {
+	case FrequencyHourly:
+		next = r.StartAt
+		if !after.Before(r.StartAt) {
+			step := time.Duration(interval) * time.Hour
+			next = r.StartAt.Add((after.Sub(r.StartAt)/step + 1) * step)
+		}
+	case FrequencyDaily, FrequencyWeekdays:
+		next, ok, err = r.nextDaily(after, loc, interval)
+		if err != nil || !ok {
+			return time.Time{}, false, err
+		}
+	default:
+		return time.Time{}, false, errors.New("unknown recurrence frequency")
+	}
+
+	if r.EndAt != nil && next.After(*r.EndAt) {
+		return time.Time{}, false, nil
+	}
+	return next, true, nil
+}
+
+func (r RecurrenceRule) nextDaily(after time.Time, loc *time.Location, interval int) (time.Time, bool, error) {
+	clocks, err := r.clockTimes(loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	start := r.StartAt.In(loc)
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	local := after.In(loc)
+	if after.Before(r.StartAt) {
+		local = start
+	}
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	for i := 0; i < maxRecurrenceSearchDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		if r.Frequency == FrequencyWeekdays {
+			if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+				continue
+			}
+		} else if daysBetween(startDay, day)%interval != 0 {
+			continue
+		}
+
+		for _, clock := range clocks {
+			candidate := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+			if candidate.After(after) && !candidate.Before(r.StartAt) {
+				return candidate, true, nil
+			}
+		}
+	}
+
+	return time.Time{}, false, nil
+}
+
+// clockTimes parses Times into sorted clock values, defaulting to StartAt.
+func (r RecurrenceRule) clockTimes(loc *time.Location) ([]time.Time, error) {
+	if len(r.Times) == 0 {
+		return []time.Time{r.StartAt.In(loc)}, nil
+	}
+	clocks := make([]time.Time, 0, len(r.Times))
+	for _, t := range r.Times {
+		clock, err := time.Parse("15:04", t)
+		if err != nil {
+			return nil, err
+		}
+		clocks = append(clocks, clock)
+	}
+	sort.Slice(clocks, func(i, j int) bool { return clocks[i].Before(clocks[j]) })
+	return clocks, nil
+}
+
+func daysBetween(from, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	a := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	b := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+type RequestSeries struct {
+	ID               string              `json:"id" example:"530e8400-e458-41d4-a716-446655440000"`
+	HotelID          string              `json:"hotel_id" example:"org_521e8400-e458-41d4-a716-446655440000"`
+	Template         MakeRequest         `json:"template"`
+	Rule             RecurrenceRule      `json:"rule"`
+	Status           RequestSeriesStatus `json:"status" example:"active"`
+	LastOccurrenceAt *time.Time          `json:"last_occurrence_at,omitempty"`
+	CreatedBy        *string             `json:"created_by,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+} //@name RequestSeries
+
+// CreateRequestSeriesInput is the body for POST /request-series.
+type CreateRequestSeriesInput struct {
+	Template MakeRequest    `json:"template" validate:"required"`
+	Rule     RecurrenceRule `json:"rule"     validate:"required"`
+} //@name CreateRequestSeriesInput
+
+// UpdateRequestSeriesInput is the body for PUT /request-series/:id. Omitted
+// fields keep their current values.
+type UpdateRequestSeriesInput struct {
+	Template *MakeRequest    `json:"template" validate:"omitempty"`
+	Rule     *RecurrenceRule `json:"rule"     validate:"omitempty"`
+} //@name UpdateRequestSeriesInput
+
+// RequestSeriesPlan is what the recurring workflow needs to decide its next
+// step: whether the series is still running and when it next fires.
+type RequestSeriesPlan struct {
+	Status    RequestSeriesStatus `json:"status"`
+	NextRunAt *time.Time          `json:"next_run_at,omitempty"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceRule_Next(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC) // Monday
+
+	cases := []struct {
+		name   string
+		rule   RecurrenceRule
+		after  time.Time
+		expect time.Time
+	}{
+		{
+			name:   "hourly before start returns start",
+			rule:   RecurrenceRule{Frequency: FrequencyHourly, Interval: 4, StartAt: start},
+			after:  start.Add(-time.Hour),
+			expect: start,
+		},
+		{
+			name:   "hourly steps by interval",
+			rule:   RecurrenceRule{Frequency: FrequencyHourly, Interval: 4, StartAt: start},
+			after:  start.Add(5 * time.Hour),
+			expect: start.Add(8 * time.Hour),
+		},
+		{
+			name:   "daily defaults to start clock time",
+			rule:   RecurrenceRule{Frequency: FrequencyDaily, StartAt: start},
+			after:  start,
+			expect: start.AddDate(0, 0, 1),
+		},
+		{
+			name:   "daily picks the next listed time",
+			rule:   RecurrenceRule{Frequency: FrequencyDaily, Times: []string{"18:00", "07:30"}, StartAt: start},
+			after:  start,
+			expect: time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "daily honours interval",
+			rule:   RecurrenceRule{Frequency: FrequencyDaily, Interval: 3, StartAt: start},
+			after:  start,
+			expect: start.AddDate(0, 0, 3),
+		},
+		{
+			name:   "weekdays skip the weekend",
+			rule:   RecurrenceRule{Frequency: FrequencyWeekdays, Times: []string{"09:00"}, StartAt: start},
+			after:  time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC), // Friday
+			expect: time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "times are interpreted in the rule timezone",
+			rule:   RecurrenceRule{Frequency: FrequencyDaily, Times: []string{"08:00"}, Timezone: "America/New_York", StartAt: start},
+			after:  start,
+			expect: time.Date(2026, 3, 2, 13, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range cases {
+		next, ok, err := tc.rule.Next(tc.after)
+		require.NoError(t, err, tc.name)
+		require.True(t, ok, tc.name)
+		assert.True(t, tc.expect.Equal(next), "%s: expected %s, got %s", tc.name, tc.expect, next)
+	}
+}
+
+func TestRecurrenceRule_NextAfterEnd(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	rule := RecurrenceRule{Frequency: FrequencyHourly, StartAt: start, EndAt: &end}
+
+	_, ok, err := rule.Next(end)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRecurrenceRule_NextInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := RecurrenceRule{Frequency: "monthly", StartAt: time.Now()}.Next(time.Now())
+	assert.Error(t, err)
+
+	_, _, err = RecurrenceRule{Frequency: FrequencyDaily, Timezone: "Mars/Olympus", StartAt: time.Now()}.Next(time.Now())
+	assert.Error(t, err)
+}
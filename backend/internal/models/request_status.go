@@ -0,0 +1,52 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// requestStatusTransitions is the built-in request lifecycle. Reopening a
+// completed request is deliberately absent; it goes through the explicit
+// reopen endpoint instead of a plain status update. Drafts likewise only
+// leave review through the approve and reject endpoints.
+var requestStatusTransitions = map[RequestStatus][]RequestStatus{
+	StatusPending:    {StatusInProgress, StatusArchived},
+	StatusInProgress: {StatusPending, StatusCompleted, StatusArchived},
+	StatusCompleted:  {StatusArchived},
+}
+
+// CanTransitionTo reports whether a request in status s may move to status
+// to. custom holds the hotel's extra statuses (see HotelRequestStatus): open
+// requests can be parked in a custom status and later resume as pending or
+// in progress, or be archived. Staying in the same status is always allowed.
+func (s RequestStatus) CanTransitionTo(to RequestStatus, custom []string) bool {
+	if s == to {
+		return true
+	}
+
+	if !to.IsValid() {
+		if !slices.Contains(custom, string(to)) {
+			return false
+		}
+		return s == StatusPending || s == StatusInProgress || !s.IsValid()
+	}
+
+	// Any unknown current status is a custom one, possibly since removed by the hotel.
+	if !s.IsValid() {
+		return to == StatusPending || to == StatusInProgress || to == StatusArchived
+	}
+
+	return slices.Contains(requestStatusTransitions[s], to)
+}
+
+// HotelRequestStatus is an extra, hotel-defined request status such as
+// "blocked" or "awaiting guest".
+type HotelRequestStatus struct {
+	HotelID   string    `json:"hotel_id" example:"org_521e8400-e458-41d4-a716-446655440000"`
+	Name      string    `json:"name" example:"awaiting guest"`
+	CreatedAt time.Time `json:"created_at"`
+} //@name HotelRequestStatus
+
+type CreateHotelRequestStatusInput struct {
+	Name string `json:"name" validate:"notblank,max=50" example:"awaiting guest"`
+} //@name CreateHotelRequestStatusInput
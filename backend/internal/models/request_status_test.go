@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestStatus_CanTransitionTo(t *testing.T) {
+	t.Parallel()
+
+	custom := []string{"blocked", "awaiting guest"}
+
+	cases := []struct {
+		from, to RequestStatus
+		expect   bool
+	}{
+		{StatusPending, StatusInProgress, true},
+		{StatusPending, StatusCompleted, false},
+		{StatusPending, StatusArchived, true},
+		{StatusInProgress, StatusCompleted, true},
+		{StatusInProgress, StatusPending, true},
+		{StatusCompleted, StatusArchived, true},
+		{StatusCompleted, StatusInProgress, false},
+		{StatusCompleted, StatusPending, false},
+		{StatusArchived, StatusPending, false},
+		{StatusArchived, StatusArchived, true},
+		{StatusDraft, StatusPending, false},
+		{StatusDraft, StatusArchived, false},
+		{StatusPending, StatusDraft, false},
+		{StatusDraft, "blocked", false},
+		{StatusInProgress, "blocked", true},
+		{StatusCompleted, "blocked", false},
+		{"blocked", StatusInProgress, true},
+		{"blocked", StatusCompleted, false},
+		{"blocked", "awaiting guest", true},
+		{StatusPending, "on hold", false},
+		{"on hold", StatusPending, true},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expect, tc.from.CanTransitionTo(tc.to, custom), "%s -> %s", tc.from, tc.to)
+	}
+}
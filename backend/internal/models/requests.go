@@ -8,11 +8,12 @@ const (
 	SortByPriority RequestFeedSort = "priority"
 	SortByNewest   RequestFeedSort = "newest"
 	SortByOldest   RequestFeedSort = "oldest"
+	SortBySLA      RequestFeedSort = "sla"
 )
 
 func (s RequestFeedSort) IsValid() bool {
 	switch s {
-	case SortByPriority, SortByNewest, SortByOldest:
+	case SortByPriority, SortByNewest, SortByOldest, SortBySLA:
 		return true
 	}
 	return false
@@ -25,11 +26,14 @@ const (
 	StatusInProgress RequestStatus = "in progress"
 	StatusCompleted  RequestStatus = "completed"
 	StatusArchived   RequestStatus = "archived"
+	// StatusDraft marks an AI-generated request awaiting human review. Drafts
+	// are stored but left out of the feed until approved.
+	StatusDraft RequestStatus = "draft"
 )
 
 func (s RequestStatus) IsValid() bool {
 	switch s {
-	case StatusPending, StatusInProgress, StatusCompleted, StatusArchived:
+	case StatusPending, StatusInProgress, StatusCompleted, StatusArchived, StatusDraft:
 		return true
 	}
 	return false
@@ -71,11 +75,23 @@ type MakeRequest struct {
 	ScheduledTime           *time.Time `json:"scheduled_time" example:"2024-01-01T00:00:00Z"`
 	CompletedAt             *time.Time `json:"completed_at" example:"2024-01-01T00:30:00Z"`
 	Notes                   *string    `json:"notes" example:"No special requests"`
+	// OriginalText and EnglishText keep the guest message a request was
+	// generated from, as written and in English. GuestLanguage is the
+	// message's language (ISO 639-1), for replying to the guest in it.
+	OriginalText  *string `json:"original_text,omitempty" example:"Necesito toallas en la habitación 504"`
+	EnglishText   *string `json:"english_text,omitempty" example:"I need towels in room 504"`
+	GuestLanguage *string `json:"guest_language,omitempty" example:"es"`
 } //@name MakeRequest
 
 // RequestUpdateInput is the body for PUT /request/:id — all fields are optional.
 // Only non-nil fields are applied; the rest are copied from the current version.
+// Status may also be one of the hotel's custom statuses and must be a legal
+// transition from the current status (see RequestStatus.CanTransitionTo).
+// CompletedAt only overrides the stamp when the request is completed.
 type RequestUpdateInput struct {
+	// ExpectedVersion is the request_version the client last saw; the update
+	// fails when the request has changed since. The If-Match header takes precedence.
+	ExpectedVersion         *time.Time `json:"expected_version,omitempty" example:"2024-01-02T00:00:00Z"`
 	Unassign                bool       `json:"unassign"`
 	UserID                  *string    `json:"user_id"`
 	GuestID                 *string    `json:"guest_id"`
@@ -86,7 +102,7 @@ type RequestUpdateInput struct {
 	RequestCategory         *string    `json:"request_category"`
 	RequestType             *string    `json:"request_type" validate:"omitempty,notblank"`
 	Department              *string    `json:"department"`
-	Status                  *string    `json:"status" validate:"omitempty,oneof='pending' 'in progress' 'completed' 'archived'"`
+	Status                  *string    `json:"status" validate:"omitempty,notblank,max=50"`
 	Priority                *string    `json:"priority" validate:"omitempty,oneof=low medium high"`
 	EstimatedCompletionTime *int       `json:"estimated_completion_time"`
 	ScheduledTime           *time.Time `json:"scheduled_time"`
@@ -97,9 +113,11 @@ type RequestUpdateInput struct {
 // AssignRequestInput is the body for POST /request/:id/assign.
 // Set assign_to_self to true to assign to the authenticated caller.
 // Omit assign_to_self (or set to false) and provide user_id to assign to another user.
+// expected_version works as in RequestUpdateInput.
 type AssignRequestInput struct {
-	AssignToSelf *bool   `json:"assign_to_self"`
-	UserID       *string `json:"user_id"`
+	AssignToSelf    *bool      `json:"assign_to_self"`
+	UserID          *string    `json:"user_id"`
+	ExpectedVersion *time.Time `json:"expected_version,omitempty" example:"2024-01-02T00:00:00Z"`
 } //@name AssignRequestInput
 
 type GetRequestsByStatusInput struct {
@@ -109,26 +127,130 @@ type GetRequestsByStatusInput struct {
 	CursorID   *string `json:"cursor_id"`
 } //@name GetRequestsByStatusInput
 
+// GenerateRequestInput is the body for POST /request/generate. Set persist to
+// store the generated request; requests that need review are stored as drafts.
 type GenerateRequestInput struct {
 	RawText string `json:"raw_text" example:"Guest in room 504 needs extra towels urgently"`
 	HotelID string `json:"hotel_id" validate:"notblank,startswith=org_" example:"org_521e8400-e458-41d4-a716-446655440000"`
+	Persist bool   `json:"persist" example:"false"`
 } //@name GenerateRequestInput
 
+// GenerateRequestWarning flags a room, guest or staff member named in the
+// text that could not be resolved. Candidates lists the closest matches, best
+// first, for a "did you mean" choice.
 type GenerateRequestWarning struct {
-	Code    string `json:"code" example:"room_not_found"`
-	Message string `json:"message" example:"Room 301 could not be resolved for this hotel."`
+	Code       string            `json:"code" example:"room_not_found"`
+	Message    string            `json:"message" example:"Room 301 could not be resolved for this hotel."`
+	Candidates []LookupCandidate `json:"candidates,omitempty"`
 } //@name GenerateRequestWarning
 
+// GenerateRequestResponse is one generated request. Confidence is the lowest
+// of FieldConfidence, each between 0 and 1. Draft is set when the request was
+// persisted as a draft for review rather than published to the feed.
 type GenerateRequestResponse struct {
-	Request Request                 `json:"request"`
-	Warning *GenerateRequestWarning `json:"warning,omitempty"`
+	Request         Request                 `json:"request"`
+	Warning         *GenerateRequestWarning `json:"warning,omitempty"`
+	Confidence      float64                 `json:"confidence" example:"0.82"`
+	FieldConfidence map[string]float64      `json:"field_confidence,omitempty"`
+	Draft           bool                    `json:"draft,omitempty"`
 } //@name GenerateRequestResponse
 
+// NeedsReview reports whether the generated request should be checked by a
+// person before it reaches the feed: it came with a warning, or with a
+// confidence below threshold.
+func (r *GenerateRequestResponse) NeedsReview(threshold float64) bool {
+	return r.Warning != nil || r.Confidence < threshold
+}
+
+// ToDraft holds the generated request for review. rawText is the message it
+// was generated from.
+func (r *GenerateRequestResponse) ToDraft(rawText string) *RequestDraft {
+	return &RequestDraft{
+		Request:         r.Request,
+		RawText:         rawText,
+		Confidence:      r.Confidence,
+		FieldConfidence: r.FieldConfidence,
+		Warning:         r.Warning,
+		Generated:       r.Request.MakeRequest,
+	}
+}
+
+// GenerateRequestBatchInput is the body for POST /request/generate/batch. Set
+// persist to create every generated request in one transaction.
+type GenerateRequestBatchInput struct {
+	GenerateRequestInput
+} //@name GenerateRequestBatchInput
+
+// GenerateRequestBatchResponse is the requests generated from a message.
+// Skipped lists those that failed validation and were left out.
+type GenerateRequestBatchResponse struct {
+	Requests  []GenerateRequestResponse `json:"requests"`
+	Skipped   []GenerateRequestSkipped  `json:"skipped,omitempty"`
+	Persisted bool                      `json:"persisted"`
+} //@name GenerateRequestBatchResponse
+
+// GenerateRequestSkipped is a generated request left out of a batch. Index is
+// its position among the requests generated, and Error why it is invalid.
+type GenerateRequestSkipped struct {
+	Index int    `json:"index" example:"2"`
+	Name  string `json:"name" example:"Late Checkout"`
+	Error string `json:"error" example:"priority: must be one of: low, medium, high"`
+} //@name GenerateRequestSkipped
+
+// GenerateRequestAudioInput is the body for POST /request/generate/audio:
+// multipart form data with the voice note in an "audio" file field, or JSON
+// with the key of a voice note already uploaded to S3 under
+// voice-notes/<hotel_id>/.
+type GenerateRequestAudioInput struct {
+	HotelID string `json:"hotel_id" form:"hotel_id" validate:"notblank,startswith=org_" example:"org_521e8400-e458-41d4-a716-446655440000"`
+	Key     string `json:"key" form:"key" example:"voice-notes/org_521e8400-e458-41d4-a716-446655440000/1706540000.m4a"`
+} //@name GenerateRequestAudioInput
+
+// GenerateRequestAudioResponse is a request generated from a voice note, not
+// yet stored, with the transcript it was generated from so staff can check it
+// before saving.
+type GenerateRequestAudioResponse struct {
+	Transcript string `json:"transcript" example:"Room 504 needs extra towels, it's urgent"`
+	GenerateRequestResponse
+} //@name GenerateRequestAudioResponse
+
+// GenerateRequestPhotoInput is the body for POST /request/generate/photo:
+// the keys of one to four photos uploaded to S3 under request-photos/<hotel_id>/,
+// with optional text.
+type GenerateRequestPhotoInput struct {
+	HotelID string   `json:"hotel_id" validate:"notblank,startswith=org_" example:"org_521e8400-e458-41d4-a716-446655440000"`
+	Keys    []string `json:"keys" validate:"min=1,max=4,dive,notblank" example:"request-photos/org_521e8400-e458-41d4-a716-446655440000/1706540000.jpg"`
+	Text    string   `json:"text" validate:"max=2000" example:"Room 204, under the sink"`
+} //@name GenerateRequestPhotoInput
+
+// GenerateRequestPhotoResponse is a request generated and stored from photos,
+// with the photos attached to it.
+type GenerateRequestPhotoResponse struct {
+	GenerateRequestResponse
+	Attachments []*RequestAttachment `json:"attachments"`
+} //@name GenerateRequestPhotoResponse
+
+// AmendGenerateRequestInput is the body for POST
+// /request/generate/async/{workflowId}/amend.
+type AmendGenerateRequestInput struct {
+	RawText string `json:"raw_text" validate:"notblank" example:"Guest in room 505, not 504, needs extra towels"`
+} //@name AmendGenerateRequestInput
+
+// ListGenerateRequestsInput selects the async generations of a hotel,
+// optionally only those submitted by one user.
+type ListGenerateRequestsInput struct {
+	HotelID     string `json:"hotel_id" validate:"required"`
+	RequestedBy string `json:"requested_by"`
+	Cursor      string `json:"cursor"`
+	Limit       int    `json:"limit" validate:"omitempty,min=1,max=100"`
+} //@name ListGenerateRequestsInput
+
 type Request struct {
-	ID             string    `json:"id" example:"530e8400-e458-41d4-a716-446655440000"`
-	CreatedAt      time.Time `json:"created_at" example:"2024-01-02T00:00:00Z"`
-	RequestVersion time.Time `json:"request_version" example:"2024-01-02T00:00:00Z"`
-	ChangedBy      *string   `json:"changed_by,omitempty"`
+	ID             string     `json:"id" example:"530e8400-e458-41d4-a716-446655440000"`
+	CreatedAt      time.Time  `json:"created_at" example:"2024-01-02T00:00:00Z"`
+	RequestVersion time.Time  `json:"request_version" example:"2024-01-02T00:00:00Z"`
+	ChangedBy      *string    `json:"changed_by,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty" example:"2024-01-02T00:10:00Z"`
 	MakeRequest
 } //@name Request
 
@@ -144,6 +266,8 @@ const (
 	ActivityDepartmentChanged  RequestActivityType = "department_changed"
 	ActivityRoomChanged        RequestActivityType = "room_changed"
 	ActivityDescriptionChanged RequestActivityType = "description_changed"
+	ActivityAttachmentAdded    RequestActivityType = "attachment_added"
+	ActivityCommented          RequestActivityType = "commented"
 )
 
 type RequestActivityItem struct {
@@ -170,7 +294,7 @@ type RequestsFeedInput struct {
 	Priorities  []string        `json:"priorities"  validate:"omitempty,dive,oneof=low medium high"`
 	Departments []string        `json:"departments"`
 	Floors      []int           `json:"floors"`
-	Sort        RequestFeedSort `json:"sort"        validate:"omitempty,oneof=priority newest oldest"`
+	Sort        RequestFeedSort `json:"sort"        validate:"omitempty,oneof=priority newest oldest sla"`
 	Search      string          `json:"search"`
 } //@name RequestsFeedInput
 
@@ -212,4 +336,83 @@ type GuestRequest struct {
 	UserID          *string   `json:"user_id,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 	RequestVersion  time.Time `json:"request_version"`
+	// SLADueAt is when the request must be completed under the matching SLA
+	// policy, falling back to estimated_completion_time, counted from when it
+	// was last opened: created, or reopened after it was completed or archived.
+	SLADueAt  *time.Time `json:"sla_due_at,omitempty"`
+	IsOverdue bool       `json:"is_overdue"`
 } //@name GuestRequest
+
+// RequestDocument is the OpenSearch representation of a request's latest
+// version. IsOverdue is only a snapshot; readers recompute it from SLADueAt.
+type RequestDocument struct {
+	GuestRequest
+	HotelID      string `json:"hotel_id"`
+	PriorityRank int    `json:"priority_rank"`
+} //@name RequestDocument
+
+func NewRequestDocument(hotelID string, req *GuestRequest) *RequestDocument {
+	return &RequestDocument{GuestRequest: *req, HotelID: hotelID, PriorityRank: PriorityRank(req.Priority)}
+}
+
+// PriorityRank orders priorities for the feed, most urgent first. It matches
+// the priority_rank column of the Postgres feed query.
+func PriorityRank(priority string) int {
+	switch priority {
+	case "high":
+		return 1
+	case "medium":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// FacetCount is the number of feed requests sharing one value of a facet.
+// Label carries a display name where the value is an id (departments).
+type FacetCount struct {
+	Value string  `json:"value" example:"high"`
+	Label *string `json:"label,omitempty" example:"Housekeeping"`
+	Count int     `json:"count" example:"12"`
+} //@name FacetCount
+
+// RequestFacets counts the feed's matching requests per status, priority,
+// department and floor. Each facet ignores the feed's own filter on that field
+// so clients can show how many requests every other option would match.
+type RequestFacets struct {
+	Status     []FacetCount `json:"status"`
+	Priority   []FacetCount `json:"priority"`
+	Department []FacetCount `json:"department"`
+	Floor      []FacetCount `json:"floor"`
+} //@name RequestFacets
+
+// RequestsFeedPage is a page of the requests feed. Facets are only present
+// when the feed is served from the search index.
+type RequestsFeedPage struct {
+	Items      []*GuestRequest `json:"items"`
+	NextCursor *string         `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+	Facets     *RequestFacets  `json:"facets,omitempty"`
+} //@name RequestsFeedPage
+
+type RequestEventType string
+
+const (
+	RequestEventCreated  RequestEventType = "created"
+	RequestEventUpdated  RequestEventType = "updated"
+	RequestEventAssigned RequestEventType = "assigned"
+	// RequestEventReset tells a resuming client that more changed while it was
+	// disconnected than the stream replays, so it must refetch the feed. It
+	// carries no request.
+	RequestEventReset RequestEventType = "reset"
+)
+
+// RequestEvent is pushed to GET /requests/stream subscribers whenever a new
+// request version is inserted. ID is the request_version in Unix nanoseconds
+// so clients can resume with Last-Event-ID.
+type RequestEvent struct {
+	ID      string           `json:"id"`
+	Type    RequestEventType `json:"type"`
+	HotelID string           `json:"hotel_id"`
+	Request *GuestRequest    `json:"request"`
+} //@name RequestEvent
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// BulkRequestOperation is the change POST /requests/bulk applies to every
+// selected request.
+type BulkRequestOperation string
+
+const (
+	BulkAssign        BulkRequestOperation = "assign"
+	BulkUnassign      BulkRequestOperation = "unassign"
+	BulkSetStatus     BulkRequestOperation = "set_status"
+	BulkSetPriority   BulkRequestOperation = "set_priority"
+	BulkSetDepartment BulkRequestOperation = "set_department"
+	BulkArchive       BulkRequestOperation = "archive"
+)
+
+// MaxBulkRequests caps the number of requests one bulk operation may change.
+const MaxBulkRequests = 200
+
+// BulkRequestsInput is the body for POST /requests/bulk. Requests are
+// selected either by request_ids or by filter, which takes the feed's filters
+// (cursor, limit and sort are ignored) and must name the same hotel as the
+// X-Hotel-ID header. user_id, status, priority and department carry the
+// argument of the assign, set_status, set_priority and set_department
+// operations respectively.
+type BulkRequestsInput struct {
+	Operation  BulkRequestOperation `json:"operation" validate:"required,oneof=assign unassign set_status set_priority set_department archive" example:"assign"`
+	RequestIDs []string             `json:"request_ids,omitempty" validate:"omitempty,max=200,dive,uuid"`
+	Filter     *RequestsFeedInput   `json:"filter,omitempty"`
+	UserID     *string              `json:"user_id,omitempty" validate:"omitempty,notblank" example:"user_2abc"`
+	Status     *string              `json:"status,omitempty" validate:"omitempty,notblank,max=50" example:"completed"`
+	Priority   *string              `json:"priority,omitempty" validate:"omitempty,oneof=low medium high" example:"high"`
+	Department *string              `json:"department,omitempty" validate:"omitempty,notblank" example:"521e8400-e458-41d4-a716-446655440000"`
+} //@name BulkRequestsInput
+
+type BulkRequestResultStatus string
+
+const (
+	BulkResultUpdated BulkRequestResultStatus = "updated"
+	BulkResultFailed  BulkRequestResultStatus = "failed"
+)
+
+// BulkRequestResult reports what happened to one request of a bulk operation.
+type BulkRequestResult struct {
+	RequestID      string                  `json:"request_id" example:"530e8400-e458-41d4-a716-446655440000"`
+	Status         BulkRequestResultStatus `json:"status" example:"updated"`
+	RequestVersion *time.Time              `json:"request_version,omitempty"`
+	Error          *string                 `json:"error,omitempty" example:"cannot change request status from \"completed\" to \"pending\""`
+} //@name BulkRequestResult
+
+type BulkRequestsResponse struct {
+	Updated int                  `json:"updated" example:"12"`
+	Failed  int                  `json:"failed" example:"1"`
+	Results []*BulkRequestResult `json:"results"`
+} //@name BulkRequestsResponse
+
+// RequestBulkUpdate is one request's change within a bulk update.
+type RequestBulkUpdate struct {
+	ID     string
+	Update *RequestUpdateInput
+}
+
+// RequestBulkUpdateResult is the outcome of one RequestBulkUpdate: the new
+// latest version, or the error that kept it from being written.
+type RequestBulkUpdateResult struct {
+	Request *Request
+	Err     error
+}
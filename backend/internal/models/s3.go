@@ -6,4 +6,14 @@ import "time"
 type PresignedURLInput struct {
 	Key        string        `json:"key" validate:"notblank" example:"profile-pictures/user123/1706540000.jpg"`
 	Expiration time.Duration `json:"expiration" validate:"gt=0" swaggertype:"integer" example:"300000000000"`
+	// ContentType and ContentLength are optional; when set on an upload URL the
+	// client must send the same Content-Type and Content-Length headers.
+	ContentType   string `json:"content_type,omitempty" example:"image/jpeg"`
+	ContentLength int64  `json:"content_length,omitempty" validate:"gte=0" example:"204800"`
 } //@name PresignedURLInput
+
+// S3ObjectInfo describes an object that has been uploaded to S3.
+type S3ObjectInfo struct {
+	ContentType   string `json:"content_type"`
+	ContentLength int64  `json:"content_length"`
+} //@name S3ObjectInfo
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+type SLABreachKind string
+
+const (
+	SLABreachAcknowledge SLABreachKind = "acknowledge"
+	SLABreachComplete    SLABreachKind = "complete"
+)
+
+// SLAPolicy sets response targets for a hotel. DepartmentID and Priority are
+// optional scopes; the most specific matching policy applies to a request.
+type SLAPolicy struct {
+	ID                       string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	HotelID                  string    `json:"hotel_id" example:"org_2abc123"`
+	DepartmentID             *string   `json:"department_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Priority                 *string   `json:"priority,omitempty" example:"high"`
+	AcknowledgeWithinMinutes *int      `json:"acknowledge_within_minutes,omitempty" example:"10"`
+	CompleteWithinMinutes    *int      `json:"complete_within_minutes,omitempty" example:"60"`
+	EscalateToUserID         *string   `json:"escalate_to_user_id,omitempty" example:"user_2abc123"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+} //@name SLAPolicy
+
+// SLAPolicyInput is the body for creating or replacing an SLA policy.
+// EscalateToUserID is the department lead breached requests are reassigned to.
+type SLAPolicyInput struct {
+	DepartmentID             *string `json:"department_id"              validate:"omitempty,uuid"`
+	Priority                 *string `json:"priority"                   validate:"omitempty,oneof=low medium high"`
+	AcknowledgeWithinMinutes *int    `json:"acknowledge_within_minutes" validate:"omitempty,min=1"`
+	CompleteWithinMinutes    *int    `json:"complete_within_minutes"    validate:"omitempty,min=1"`
+	EscalateToUserID         *string `json:"escalate_to_user_id"        validate:"omitempty,notblank"`
+} //@name SLAPolicyInput
+
+// SLABreach is an open request that has passed one of its SLA deadlines and
+// has not been escalated for that deadline yet since it was last opened, at
+// OpenedAt: a request reopened after it was closed can breach again.
+type SLABreach struct {
+	RequestID        string
+	HotelID          string
+	Name             string
+	Priority         string
+	UserID           *string
+	Kind             SLABreachKind
+	DueAt            time.Time
+	OpenedAt         time.Time
+	EscalateToUserID *string
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GenerateRequestJobsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGenerateRequestJobsRepository(db *pgxpool.Pool) *GenerateRequestJobsRepository {
+	return &GenerateRequestJobsRepository{db: db}
+}
+
+const generateRequestJobColumns = `id, hotel_id, requested_by, raw_text, revision, status, attempts,
+	output, request_id::text, error, created_at, finished_at`
+
+func (r *GenerateRequestJobsRepository) InsertGenerateRequestJob(ctx context.Context, job *models.GenerateRequestJob) (*models.GenerateRequestJob, error) {
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO public.generate_request_jobs (id, hotel_id, requested_by, raw_text)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+generateRequestJobColumns,
+		job.ID, job.HotelID, job.RequestedBy, job.RawText)
+
+	return scanGenerateRequestJob(row)
+}
+
+func (r *GenerateRequestJobsRepository) FindGenerateRequestJob(ctx context.Context, id string) (*models.GenerateRequestJob, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+generateRequestJobColumns+`
+		FROM public.generate_request_jobs
+		WHERE id = $1
+	`, id)
+
+	return scanGenerateRequestJob(row)
+}
+
+// FindGenerateRequestJobs returns one page of a hotel's jobs, most recent
+// first, starting after the (cursorCreatedAt, cursorID) cursor. requestedBy
+// narrows them to one user's when set.
+func (r *GenerateRequestJobsRepository) FindGenerateRequestJobs(ctx context.Context, hotelID, requestedBy string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.GenerateRequestJob, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+generateRequestJobColumns+`
+		FROM public.generate_request_jobs
+		WHERE hotel_id = $1
+		  AND ($2::text = '' OR requested_by = $2)
+		  AND ($4::text = '' OR (created_at, id) < ($3, $4))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`, hotelID, requestedBy, cursorCreatedAt, cursorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*models.GenerateRequestJob{}
+	for rows.Next() {
+		job, err := scanGenerateRequestJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimGenerateRequestJob locks the next job due at now for lease and counts
+// the attempt. Jobs locked by a worker that did not finish them within its
+// lease are claimed again. It returns errs.ErrNotFoundInDB when no job is due.
+func (r *GenerateRequestJobsRepository) ClaimGenerateRequestJob(ctx context.Context, now time.Time, lease time.Duration) (*models.GenerateRequestJob, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE public.generate_request_jobs
+		SET status = CASE WHEN status = 'storing' THEN 'storing' ELSE 'generating' END,
+		    attempts = attempts + 1,
+		    locked_until = $2,
+		    updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM public.generate_request_jobs
+			WHERE status IN ('pending', 'generating', 'storing')
+			  AND run_after <= $1
+			  AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY run_after ASC, created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+generateRequestJobColumns,
+		now, now.Add(lease))
+
+	return scanGenerateRequestJob(row)
+}
+
+// MarkGenerateRequestJobGenerated records the generated request, moving the
+// job on to storing it. It returns false when the job was cancelled, or
+// amended since revision was generated.
+func (r *GenerateRequestJobsRepository) MarkGenerateRequestJobGenerated(ctx context.Context, id string, revision int, output json.RawMessage) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE public.generate_request_jobs
+		SET status = 'storing', output = $3::jsonb, updated_at = NOW()
+		WHERE id = $1 AND revision = $2 AND status = 'generating'
+	`, id, revision, string(output))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *GenerateRequestJobsRepository) CompleteGenerateRequestJob(ctx context.Context, id, requestID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE public.generate_request_jobs
+		SET status = 'completed', request_id = $2::uuid, error = NULL,
+		    locked_until = NULL, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`, id, requestID)
+	return err
+}
+
+// RetryGenerateRequestJob releases the job to be claimed again at runAfter,
+// recording why the attempt failed.
+func (r *GenerateRequestJobsRepository) RetryGenerateRequestJob(ctx context.Context, id, reason string, runAfter time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE public.generate_request_jobs
+		SET error = NULLIF($2, ''), run_after = $3, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id, reason, runAfter)
+	return err
+}
+
+func (r *GenerateRequestJobsRepository) FailGenerateRequestJob(ctx context.Context, id, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE public.generate_request_jobs
+		SET status = 'failed', error = $2, locked_until = NULL,
+		    updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'generating', 'storing')
+	`, id, reason)
+	return err
+}
+
+// CancelGenerateRequestJob cancels a job that has not generated its request
+// yet. It returns false when the job has.
+func (r *GenerateRequestJobsRepository) CancelGenerateRequestJob(ctx context.Context, id string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE public.generate_request_jobs
+		SET status = 'cancelled', locked_until = NULL, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'generating')
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// AmendGenerateRequestJob replaces the raw text of a job that has not
+// generated its request yet, so it is generated again from the new text with
+// a fresh set of attempts. It returns false when the job has.
+func (r *GenerateRequestJobsRepository) AmendGenerateRequestJob(ctx context.Context, id, rawText string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE public.generate_request_jobs
+		SET raw_text = $2, revision = revision + 1, attempts = 0, error = NULL,
+		    run_after = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'generating')
+	`, id, rawText)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanGenerateRequestJob(row pgx.Row) (*models.GenerateRequestJob, error) {
+	var job models.GenerateRequestJob
+	var output []byte
+	err := row.Scan(&job.ID, &job.HotelID, &job.RequestedBy, &job.RawText, &job.Revision, &job.Status,
+		&job.Attempts, &output, &job.RequestID, &job.Error, &job.CreatedAt, &job.FinishedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	job.Output = output
+	return &job, nil
+}
@@ -311,7 +311,8 @@ func (r *GuestsRepository) AllGuestDocuments(ctx context.Context) iter.Seq2[*mod
 				JOIN rooms r ON r.id = gb.room_id
 				LEFT JOIN (
 					SELECT guest_id, hotel_id, COUNT(*) AS request_count, BOOL_OR(priority = 'high') AS has_urgent
-					FROM requests
+					FROM requests_current
+					WHERE status != 'draft'
 					GROUP BY guest_id, hotel_id
 				) ra ON ra.guest_id = g.id AND ra.hotel_id = gb.hotel_id
 				WHERE (
@@ -391,29 +392,44 @@ func buildNextCursor(guests []*models.GuestWithBooking, limit int) ([]*models.Gu
 	return guests, &encoded
 }
 
-func (r *GuestsRepository) FindGuestByName(ctx context.Context, hotelID, name string) ([]string, error) {
+// FindGuestCandidates scores guests with an active booking at the hotel (in
+// roomID, when set) by trigram similarity of their full name, word similarity
+// of name within it (so a surname alone matches), and double metaphone of the
+// first and last name.
+func (r *GuestsRepository) FindGuestCandidates(ctx context.Context, hotelID, name string, roomID *string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	first, last := nameTokens(name)
 	rows, err := r.db.Query(ctx, `
-		SELECT DISTINCT g.id
-		FROM guests g
-		JOIN guest_bookings gb ON gb.guest_id = g.id
-		WHERE gb.hotel_id = $1
-		  AND gb.status = 'active'
-		  AND CONCAT_WS(' ', g.first_name, g.last_name) ILIKE '%' || $2 || '%'
-	`, hotelID, name)
+		SELECT id, label, score
+		FROM (
+			SELECT
+				g.id,
+				CONCAT_WS(' ', g.first_name, g.last_name) AS label,
+				GREATEST(
+					similarity(CONCAT_WS(' ', g.first_name, g.last_name), $2),
+					word_similarity($2, CONCAT_WS(' ', g.first_name, g.last_name)),
+					CASE WHEN dmetaphone(g.first_name) = dmetaphone($3)
+						OR dmetaphone(g.last_name) = dmetaphone($4) THEN 0.6 END
+				)::float8 AS score
+			FROM guests g
+			WHERE EXISTS (
+				SELECT 1
+				FROM guest_bookings gb
+				WHERE gb.guest_id = g.id
+				  AND gb.hotel_id = $1
+				  AND gb.status = 'active'
+				  AND ($5::uuid IS NULL OR gb.room_id = $5::uuid)
+			)
+		) c
+		WHERE score >= $6
+		ORDER BY score DESC, label
+		LIMIT $7
+	`, hotelID, name, first, last, roomID, minScore, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var ids []string
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		ids = append(ids, id)
-	}
-	return ids, rows.Err()
+	return scanLookupCandidates(rows)
 }
 
 func (r *GuestsRepository) FindGuestsWithActiveBooking(ctx context.Context, filters *models.GuestFilters) (*models.GuestPage, error) {
@@ -425,8 +441,8 @@ func (r *GuestsRepository) FindGuestsWithActiveBooking(ctx context.Context, filt
 			guest_id,
 			COUNT(*) AS request_count,
 			BOOL_OR(priority = 'high') AS has_urgent
-		FROM requests
-		WHERE hotel_id = $1
+		FROM requests_current
+		WHERE hotel_id = $1 AND status != 'draft'
 		GROUP BY guest_id
 	),
 	guest_data AS (
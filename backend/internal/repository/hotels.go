@@ -22,13 +22,13 @@ func NewHotelsRepository(db *pgxpool.Pool) *HotelsRepository {
 
 func (r *HotelsRepository) FindByID(ctx context.Context, id string) (*models.Hotel, error) {
 	row := r.db.QueryRow(ctx, `
-		SELECT id, name, floors, created_at, updated_at
+		SELECT id, name, floors, staff_language, webhook_urls, created_at, updated_at
 		FROM hotels
 		WHERE id = $1
 	`, id)
 
 	var hotel models.Hotel
-	err := row.Scan(&hotel.ID, &hotel.Name, &hotel.Floors, &hotel.CreatedAt, &hotel.UpdatedAt)
+	err := row.Scan(&hotel.ID, &hotel.Name, &hotel.Floors, &hotel.StaffLanguage, &hotel.WebhookURLs, &hotel.CreatedAt, &hotel.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errs.ErrNotFoundInDB
@@ -51,9 +51,9 @@ func (r *HotelsRepository) InsertHotel(ctx context.Context, hotel *models.Create
         INSERT INTO hotels (id, name, floors)
         VALUES ($1, $2, $3)
         ON CONFLICT (id) DO NOTHING
-        RETURNING created_at, updated_at
+        RETURNING staff_language, webhook_urls, created_at, updated_at
     `, hotel.ID, hotel.Name, hotel.Floors).Scan(
-		&createdHotel.CreatedAt, &createdHotel.UpdatedAt,
+		&createdHotel.StaffLanguage, &createdHotel.WebhookURLs, &createdHotel.CreatedAt, &createdHotel.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -77,6 +77,49 @@ func (r *HotelsRepository) InsertHotel(ctx context.Context, hotel *models.Create
 	return createdHotel, nil
 }
 
+// UpdateHotelSettings applies settings to the hotel and returns it. The webhook
+// secret is kept unless a new one is given or it is cleared.
+func (r *HotelsRepository) UpdateHotelSettings(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error) {
+	urls := settings.WebhookURLs
+	if urls == nil {
+		urls = []string{}
+	}
+	var hotel models.Hotel
+	err := r.db.QueryRow(ctx, `
+		UPDATE hotels
+		SET staff_language = $2, webhook_urls = $3,
+			webhook_secret = CASE WHEN $5 THEN NULL ELSE COALESCE(NULLIF($4, ''), webhook_secret) END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, name, floors, staff_language, webhook_urls, created_at, updated_at
+	`, id, settings.StaffLanguage, urls, settings.WebhookSecret, settings.ClearWebhookSecret).Scan(
+		&hotel.ID, &hotel.Name, &hotel.Floors, &hotel.StaffLanguage, &hotel.WebhookURLs, &hotel.CreatedAt, &hotel.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &hotel, nil
+}
+
+// GetStaffLanguageByHotelID returns the language the hotel's staff work in,
+// or models.DefaultStaffLanguage for an unknown hotel.
+func (r *HotelsRepository) GetStaffLanguageByHotelID(ctx context.Context, hotelID string) (string, error) {
+	var language string
+	err := r.db.QueryRow(ctx, `
+		SELECT staff_language FROM hotels WHERE id = $1
+	`, hotelID).Scan(&language)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.DefaultStaffLanguage, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return language, nil
+}
+
 const allHotelsWithoutDepartmentsPageSize = 100
 
 // AllHotelsWithoutDepartments returns a paginated iterator over hotels that have
@@ -88,7 +131,7 @@ func (r *HotelsRepository) AllHotelsWithoutDepartments(ctx context.Context) iter
 
 		for {
 			rows, err := r.db.Query(ctx, `
-				SELECT h.id, h.name, h.floors, h.created_at, h.updated_at
+				SELECT h.id, h.name, h.floors, h.staff_language, h.webhook_urls, h.created_at, h.updated_at
 				FROM hotels h
 				LEFT JOIN departments d ON d.hotel_id = h.id
 				WHERE d.id IS NULL
@@ -104,7 +147,7 @@ func (r *HotelsRepository) AllHotelsWithoutDepartments(ctx context.Context) iter
 			var page []*models.Hotel
 			for rows.Next() {
 				var h models.Hotel
-				if err := rows.Scan(&h.ID, &h.Name, &h.Floors, &h.CreatedAt, &h.UpdatedAt); err != nil {
+				if err := rows.Scan(&h.ID, &h.Name, &h.Floors, &h.StaffLanguage, &h.WebhookURLs, &h.CreatedAt, &h.UpdatedAt); err != nil {
 					rows.Close()
 					yield(nil, err)
 					return
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// scanLookupCandidates reads (id, label, score) rows.
+func scanLookupCandidates(rows pgx.Rows) ([]*models.LookupCandidate, error) {
+	var candidates []*models.LookupCandidate
+	for rows.Next() {
+		var c models.LookupCandidate
+		if err := rows.Scan(&c.ID, &c.Label, &c.Score); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, &c)
+	}
+	return candidates, rows.Err()
+}
+
+// nameTokens returns the first and last word of a name for phonetic
+// matching; a single word is both. A lone surname ("Lopez") is compared
+// against first names too, which only ever adds candidates.
+func nameTokens(name string) (first, last string) {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "", ""
+	}
+	return words[0], words[len(words)-1]
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ClaimNotificationOutbox locks the next entry due at now for lease, along
+// with every other entry due for the same user on the same channel so they
+// can go out as one digest, and counts the attempt. Entries locked by a worker
+// that did not finish them within its lease are claimed again. Entries are
+// returned oldest first; it returns errs.ErrNotFoundInDB when none is due.
+func (r *NotificationsRepository) ClaimNotificationOutbox(ctx context.Context, now time.Time, lease time.Duration) ([]*models.NotificationOutboxEntry, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH next AS (
+			SELECT o.channel, n.user_id
+			FROM public.notification_outbox o
+			JOIN public.notifications n ON n.id = o.notification_id
+			WHERE o.status = 'pending'
+			  AND o.run_after <= $1
+			  AND (o.locked_until IS NULL OR o.locked_until <= $1)
+			ORDER BY o.run_after ASC, o.created_at ASC
+			LIMIT 1
+			FOR UPDATE OF o SKIP LOCKED
+		), batch AS (
+			SELECT o.id
+			FROM public.notification_outbox o
+			JOIN public.notifications n ON n.id = o.notification_id
+			JOIN next ON next.channel = o.channel AND next.user_id = n.user_id
+			WHERE o.status = 'pending'
+			  AND o.run_after <= $1
+			  AND (o.locked_until IS NULL OR o.locked_until <= $1)
+			FOR UPDATE OF o SKIP LOCKED
+		)
+		UPDATE public.notification_outbox o
+		SET attempts = o.attempts + 1, locked_until = $2, updated_at = NOW()
+		FROM public.notifications n
+		WHERE n.id = o.notification_id AND o.id IN (SELECT id FROM batch)
+		RETURNING o.id, o.notification_id, o.channel, o.attempts,
+		          n.user_id, n.type, n.title, n.body, COALESCE(n.created_at, o.created_at)
+	`, now, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.NotificationOutboxEntry
+	for rows.Next() {
+		var e models.NotificationOutboxEntry
+		if err := rows.Scan(&e.ID, &e.NotificationID, &e.Channel, &e.Attempts,
+			&e.UserID, &e.Type, &e.Title, &e.Body, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errs.ErrNotFoundInDB
+	}
+	slices.SortFunc(entries, func(a, b *models.NotificationOutboxEntry) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return entries, nil
+}
+
+func (r *NotificationsRepository) CompleteNotificationOutboxEntry(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE public.notification_outbox
+		SET status = 'delivered', error = NULL, locked_until = NULL,
+		    updated_at = NOW(), delivered_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// RetryNotificationOutboxEntry releases the entry to be claimed again at
+// runAfter, recording why the attempt failed.
+func (r *NotificationsRepository) RetryNotificationOutboxEntry(ctx context.Context, id, reason string, runAfter time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE public.notification_outbox
+		SET error = $2, run_after = $3, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id, reason, runAfter)
+	return err
+}
+
+// FailNotificationOutboxEntry gives up on the entry, failing the deliveries
+// that were still to be retried.
+func (r *NotificationsRepository) FailNotificationOutboxEntry(ctx context.Context, id, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx, `
+		UPDATE public.notification_outbox
+		SET status = 'failed', error = $2, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id, reason)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE public.notification_deliveries
+		SET status = 'failed', updated_at = NOW()
+		WHERE outbox_id = $1 AND status = 'retrying'
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *NotificationsRepository) FindNotificationDeliveries(ctx context.Context, outboxID string) ([]*models.NotificationDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT outbox_id, address, status, ticket_id, error
+		FROM public.notification_deliveries
+		WHERE outbox_id = $1
+	`, outboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationDeliveries(rows)
+}
+
+// UpsertNotificationDeliveries records the receipts, replacing any earlier
+// receipt for the same address. A receipt without a ticket keeps the ticket
+// recorded before it.
+func (r *NotificationsRepository) UpsertNotificationDeliveries(ctx context.Context, deliveries []*models.NotificationDelivery) error {
+	batch := &pgx.Batch{}
+
+	for _, d := range deliveries {
+		batch.Queue(`
+			INSERT INTO public.notification_deliveries (outbox_id, address, status, ticket_id, error)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (outbox_id, address) DO UPDATE
+			SET status = EXCLUDED.status,
+			    ticket_id = COALESCE(EXCLUDED.ticket_id, notification_deliveries.ticket_id),
+			    error = EXCLUDED.error,
+			    updated_at = NOW()
+		`, d.OutboxID, d.Address, d.Status, d.TicketID, d.Error)
+	}
+
+	return r.db.SendBatch(ctx, batch).Close()
+}
+
+// FindNotificationTickets returns up to limit deliveries on channel that are
+// awaiting a receipt and were sent between sentAfter and sentBefore, oldest
+// first.
+func (r *NotificationsRepository) FindNotificationTickets(ctx context.Context, channel string, sentAfter, sentBefore time.Time, limit int) ([]*models.NotificationDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT d.outbox_id, d.address, d.status, d.ticket_id, d.error
+		FROM public.notification_deliveries d
+		JOIN public.notification_outbox o ON o.id = d.outbox_id
+		WHERE d.status = 'sent' AND d.ticket_id IS NOT NULL
+		  AND o.channel = $1
+		  AND d.updated_at > $2 AND d.updated_at <= $3
+		ORDER BY d.updated_at ASC
+		LIMIT $4
+	`, channel, sentAfter, sentBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationDeliveries(rows)
+}
+
+// DeleteDeviceToken forgets a push token that can no longer be delivered to,
+// for whichever user registered it.
+func (r *NotificationsRepository) DeleteDeviceToken(ctx context.Context, token string) error {
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM public.device_tokens WHERE token = $1
+	`, token)
+	return err
+}
+
+func scanNotificationDeliveries(rows pgx.Rows) ([]*models.NotificationDelivery, error) {
+	deliveries := []*models.NotificationDelivery{}
+	for rows.Next() {
+		var d models.NotificationDelivery
+		if err := rows.Scan(&d.OutboxID, &d.Address, &d.Status, &d.TicketID, &d.Error); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
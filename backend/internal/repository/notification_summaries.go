@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+// userTimezone is the timezone of the user row aliased u, falling back to UTC
+// when it is unset or not one Postgres knows.
+const userTimezone = `COALESCE((SELECT tz.name FROM pg_timezone_names tz WHERE tz.name = u.timezone), 'UTC')`
+
+// ClaimDailySummaries records the daily summary as sent for up to limit
+// department members whose local time at now is less than window past at
+// ("HH:MM") and who have not had one for that day yet, and returns them. A day
+// whose window passed without a sweep, as while the server was down, is
+// skipped. A summary that then fails to send is not retried, so none is sent
+// twice.
+func (r *NotificationsRepository) ClaimDailySummaries(ctx context.Context, at string, window time.Duration, now time.Time, limit int) ([]*models.DailySummaryRecipient, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH local AS (
+			SELECT u.id, ($2::timestamptz AT TIME ZONE `+userTimezone+`) AS local_now
+			FROM public.users u
+			WHERE EXISTS (SELECT 1 FROM public.employee_departments ed WHERE ed.employee_id = u.id)
+		), due AS (
+			-- since is how long ago the time of day was last at, so a window
+			-- wrapping past midnight counts toward the day it opened on.
+			SELECT l.id, l.local_now, make_interval(secs =>
+				mod(EXTRACT(EPOCH FROM l.local_now::time - $1::time)::numeric + 86400, 86400)::float8) AS since
+			FROM local l
+		), claimed AS (
+			INSERT INTO public.notification_daily_summaries (user_id, sent_on)
+			SELECT d.id, (d.local_now - d.since)::date
+			FROM due d
+			WHERE d.since < make_interval(secs => $4)
+			  AND NOT EXISTS (
+			    SELECT 1 FROM public.notification_daily_summaries s
+			    WHERE s.user_id = d.id AND s.sent_on = (d.local_now - d.since)::date
+			  )
+			LIMIT $3
+			ON CONFLICT DO NOTHING
+			RETURNING user_id, sent_on
+		)
+		SELECT user_id, sent_on FROM claimed
+	`, at, now, limit, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*models.DailySummaryRecipient
+	for rows.Next() {
+		var rc models.DailySummaryRecipient
+		if err := rows.Scan(&rc.UserID, &rc.SentOn); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, &rc)
+	}
+	return recipients, rows.Err()
+}
+
+// FindDepartmentWorkloads counts the open and overdue requests at now in each
+// of the user's departments, by department name.
+func (r *NotificationsRepository) FindDepartmentWorkloads(ctx context.Context, userID string, now time.Time) ([]*models.DepartmentWorkload, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH latest AS (
+			SELECT r.department, r.status, `+slaDueAtColumn+`
+			FROM public.requests_current r
+			`+slaPolicyJoin+`
+			WHERE r.department IN (
+				SELECT ed.department_id::text FROM public.employee_departments ed WHERE ed.employee_id = $1
+			)
+		)
+		SELECT d.id, d.name,
+		       COUNT(l.status) AS open,
+		       COUNT(l.status) FILTER (WHERE l.sla_due_at < $2) AS overdue
+		FROM public.employee_departments ed
+		JOIN public.departments d ON d.id = ed.department_id
+		LEFT JOIN latest l ON l.department = d.id::text
+		  AND l.status NOT IN ('completed', 'archived', 'draft')
+		WHERE ed.employee_id = $1
+		GROUP BY d.id, d.name
+		ORDER BY d.name
+	`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workloads []*models.DepartmentWorkload
+	for rows.Next() {
+		var w models.DepartmentWorkload
+		if err := rows.Scan(&w.DepartmentID, &w.Name, &w.Open, &w.Overdue); err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, &w)
+	}
+	return workloads, rows.Err()
+}
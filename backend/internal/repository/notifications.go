@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/generate/selfserve/internal/errs"
 	"github.com/generate/selfserve/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,22 +20,96 @@ func NewNotificationsRepository(db *pgxpool.Pool) *NotificationsRepository {
 	return &NotificationsRepository{db: db}
 }
 
-func (r *NotificationsRepository) InsertNotification(ctx context.Context, userID string, notifType models.NotificationType, title, body string) (*models.Notification, error) {
+// InsertNotification writes the in-app notification together with an outbox
+// entry for each channel it is to be delivered on, so it is delivered even if
+// the process stops straight after. It returns errs.ErrAlreadyExistsInDB when
+// the notification is dropped as a duplicate.
+func (r *NotificationsRepository) InsertNotification(ctx context.Context, input *models.NotificationInput) (*models.Notification, error) {
 	n := &models.Notification{
 		ID:     uuid.New().String(),
-		UserID: userID,
-		Type:   notifType,
-		Title:  title,
-		Body:   body,
+		UserID: input.UserID,
+		Type:   input.Type,
+		Title:  input.Title,
+		Body:   input.Body,
 	}
-	err := r.db.QueryRow(ctx, `
-		INSERT INTO public.notifications (id, user_id, type, title, body)
-		VALUES ($1, $2, $3, $4, $5)
+	var data *string
+	if input.RequestID != "" {
+		encoded, err := json.Marshal(map[string]string{"request_id": input.RequestID})
+		if err != nil {
+			return nil, err
+		}
+		n.Data = encoded
+		str := string(encoded)
+		data = &str
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if input.RequestID != "" && input.DedupeWindow > 0 {
+		// Serialise notifications to the user about the request so two sent
+		// at once cannot both miss each other.
+		_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, n.UserID+"|"+input.RequestID)
+		if err != nil {
+			return nil, err
+		}
+		var duplicate bool
+		err = tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM public.notifications
+				WHERE user_id = $1 AND type = $2 AND data->>'request_id' = $3 AND title = $4
+				  AND created_at > NOW() - make_interval(secs => $5)
+			)
+		`, n.UserID, n.Type, input.RequestID, n.Title, input.DedupeWindow.Seconds()).Scan(&duplicate)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate {
+			return nil, errs.ErrAlreadyExistsInDB
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO public.notifications (id, user_id, type, title, body, data)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb)
 		RETURNING created_at
-	`, n.ID, n.UserID, n.Type, n.Title, n.Body).Scan(&n.CreatedAt)
+	`, n.ID, n.UserID, n.Type, n.Title, n.Body, data).Scan(&n.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
+
+	if len(input.Channels) > 0 {
+		// Within a digest window an entry joins the user's entry on the channel
+		// that is already held back, or is held until the window since their
+		// last one closes. Held channels wait for HeldUntil besides.
+		_, err = tx.Exec(ctx, `
+			INSERT INTO public.notification_outbox (notification_id, channel, run_after)
+			SELECT $1, c.channel, GREATEST(CASE WHEN $4::float8 <= 0 THEN NOW() ELSE COALESCE(
+				(SELECT MIN(o.run_after)
+				 FROM public.notification_outbox o
+				 JOIN public.notifications n ON n.id = o.notification_id
+				 WHERE n.user_id = $3 AND o.channel = c.channel
+				   AND o.status = 'pending' AND o.attempts = 0 AND o.run_after > NOW()),
+				GREATEST(NOW(),
+					(SELECT MAX(o.created_at)
+					 FROM public.notification_outbox o
+					 JOIN public.notifications n ON n.id = o.notification_id
+					 WHERE n.user_id = $3 AND o.channel = c.channel
+					   AND o.created_at > NOW() - make_interval(secs => $4)) + make_interval(secs => $4))
+			) END, CASE WHEN c.channel = ANY($5::text[]) THEN $6::timestamptz END)
+			FROM unnest($2::text[]) AS c(channel)
+		`, n.ID, input.Channels, n.UserID, input.DigestWindow.Seconds(), input.Held, input.HeldUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return n, nil
 }
 
@@ -94,22 +170,119 @@ func (r *NotificationsRepository) UpsertDeviceToken(ctx context.Context, userID,
 	return err
 }
 
-func (r *NotificationsRepository) FindDeviceTokensByUserID(ctx context.Context, userID string) ([]string, error) {
+// FindNotificationRecipient returns the user with their email, phone number,
+// device tokens and their hotel's webhooks. It returns errs.ErrNotFoundInDB for
+// an unknown user.
+func (r *NotificationsRepository) FindNotificationRecipient(ctx context.Context, userID string) (*models.NotificationRecipient, error) {
+	recipient := &models.NotificationRecipient{UserID: userID}
+	err := r.db.QueryRow(ctx, `
+		SELECT u.hotel_id, u.primary_email, u.phone_number,
+		       COALESCE(h.webhook_urls, '{}'), COALESCE(h.webhook_secret, '')
+		FROM public.users u
+		LEFT JOIN public.hotels h ON h.id = u.hotel_id
+		WHERE u.id = $1
+	`, userID).Scan(&recipient.HotelID, &recipient.Email, &recipient.Phone, &recipient.WebhookURLs, &recipient.WebhookSecret)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+
 	rows, err := r.db.Query(ctx, `
-		SELECT token FROM public.device_tokens WHERE user_id = $1
+		SELECT token, platform FROM public.device_tokens WHERE user_id = $1
 	`, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var tokens []string
 	for rows.Next() {
-		var token string
-		if err := rows.Scan(&token); err != nil {
+		var device models.DeviceToken
+		if err := rows.Scan(&device.Token, &device.Platform); err != nil {
+			return nil, err
+		}
+		recipient.Devices = append(recipient.Devices, device)
+	}
+	return recipient, rows.Err()
+}
+
+// FindNotificationPreferences returns the user's preferences, or the defaults
+// when they have not set any. It returns errs.ErrNotFoundInDB for an unknown
+// user.
+func (r *NotificationsRepository) FindNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT COALESCE(u.timezone, ''), p.channels, p.quiet_start, p.quiet_end,
+		       COALESCE(p.on_shift_only, false), p.shift_start, p.shift_end, p.updated_at
+		FROM public.users u
+		LEFT JOIN public.notification_preferences p ON p.user_id = u.id
+		WHERE u.id = $1
+	`, userID)
+
+	return scanNotificationPreferences(row)
+}
+
+// UpsertNotificationPreferences replaces the user's preferences.
+func (r *NotificationsRepository) UpsertNotificationPreferences(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error) {
+	channels := input.Channels
+	if channels == nil {
+		channels = map[models.NotificationType][]models.NotificationChannel{}
+	}
+	var quietStart, quietEnd, shiftStart, shiftEnd *string
+	if input.QuietHours != nil {
+		quietStart, quietEnd = &input.QuietHours.Start, &input.QuietHours.End
+	}
+	if input.Shift != nil {
+		shiftStart, shiftEnd = &input.Shift.Start, &input.Shift.End
+	}
+
+	row := r.db.QueryRow(ctx, `
+		WITH upserted AS (
+			INSERT INTO public.notification_preferences (
+				user_id, channels, quiet_start, quiet_end, on_shift_only, shift_start, shift_end
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (user_id) DO UPDATE
+			SET channels = EXCLUDED.channels,
+			    quiet_start = EXCLUDED.quiet_start,
+			    quiet_end = EXCLUDED.quiet_end,
+			    on_shift_only = EXCLUDED.on_shift_only,
+			    shift_start = EXCLUDED.shift_start,
+			    shift_end = EXCLUDED.shift_end,
+			    updated_at = NOW()
+			RETURNING *
+		)
+		SELECT COALESCE(u.timezone, ''), p.channels, p.quiet_start, p.quiet_end,
+		       p.on_shift_only, p.shift_start, p.shift_end, p.updated_at
+		FROM upserted p
+		JOIN public.users u ON u.id = p.user_id
+	`, userID, channels, quietStart, quietEnd, input.OnShiftOnly, shiftStart, shiftEnd)
+
+	return scanNotificationPreferences(row)
+}
+
+func scanNotificationPreferences(row pgx.Row) (*models.NotificationPreferences, error) {
+	var p models.NotificationPreferences
+	var channels []byte
+	var quietStart, quietEnd, shiftStart, shiftEnd *string
+	err := row.Scan(&p.Timezone, &channels, &quietStart, &quietEnd, &p.OnShiftOnly, &shiftStart, &shiftEnd, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+
+	p.Channels = map[models.NotificationType][]models.NotificationChannel{}
+	if channels != nil {
+		if err := json.Unmarshal(channels, &p.Channels); err != nil {
 			return nil, err
 		}
-		tokens = append(tokens, token)
 	}
-	return tokens, rows.Err()
+	if quietStart != nil && quietEnd != nil {
+		p.QuietHours = &models.DailyWindow{Start: *quietStart, End: *quietEnd}
+	}
+	if shiftStart != nil && shiftEnd != nil {
+		p.Shift = &models.DailyWindow{Start: *shiftStart, End: *shiftEnd}
+	}
+	return &p, nil
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// bulkIndex writes docs to index in a single bulk request, using idOf as the
+// document id. A partial failure is reported with the ids that failed.
+func bulkIndex[T any](ctx context.Context, client *opensearch.Client, index string, docs []T, idOf func(T) string) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, doc := range docs {
+		meta := fmt.Sprintf(`{"index":{"_index":%q,"_id":%q}}`, index, idOf(doc))
+		body.WriteString(meta)
+		body.WriteByte('\n')
+		serialized, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("marshaling %s document %s: %w", index, idOf(doc), err)
+		}
+		body.Write(serialized)
+		body.WriteByte('\n')
+	}
+
+	res, err := opensearchapi.BulkRequest{
+		Body: &body,
+	}.Do(ctx, client)
+	if err != nil {
+		return fmt.Errorf("bulk indexing %s: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("bulk index failed: %s", res.String())
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Reason string `json:"reason"`
+			} `json:"error,omitempty"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding bulk response: %w", err)
+	}
+
+	if result.Errors {
+		var errs []string
+		for _, item := range result.Items {
+			for _, op := range item {
+				if op.Error != nil {
+					errs = append(errs, fmt.Sprintf("id=%s: %s", op.ID, op.Error.Reason))
+				}
+			}
+		}
+		return fmt.Errorf("bulk index partial failure: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
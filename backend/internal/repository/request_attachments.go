@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RequestAttachmentsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRequestAttachmentsRepository(db *pgxpool.Pool) *RequestAttachmentsRepository {
+	return &RequestAttachmentsRepository{db: db}
+}
+
+const requestAttachmentColumns = `id, request_id, hotel_id, s3_key, file_name, content_type, size_bytes, status, uploaded_by, created_at, confirmed_at`
+
+// InsertRequestAttachment records a pending attachment. The hotel is taken from
+// the request; errs.ErrNotFoundInDB is returned when the request does not exist.
+func (r *RequestAttachmentsRepository) InsertRequestAttachment(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error) {
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO public.request_attachments (id, request_id, hotel_id, s3_key, file_name, content_type, size_bytes, status, uploaded_by)
+		SELECT $1, req.id, req.hotel_id, $3, $4, $5, $6, $7, $8
+		FROM (
+			SELECT id, hotel_id FROM public.requests_current
+			WHERE id = $2
+		) req
+		RETURNING `+requestAttachmentColumns,
+		attachment.ID, attachment.RequestID, attachment.Key, attachment.FileName, attachment.ContentType,
+		attachment.SizeBytes, models.AttachmentPending, attachment.UploadedBy)
+
+	return scanRequestAttachment(row)
+}
+
+func (r *RequestAttachmentsRepository) FindRequestAttachment(ctx context.Context, requestID, id string) (*models.RequestAttachment, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+requestAttachmentColumns+`
+		FROM public.request_attachments
+		WHERE request_id = $1 AND id = $2
+	`, requestID, id)
+
+	return scanRequestAttachment(row)
+}
+
+// FindConfirmedRequestAttachments returns a request's confirmed attachments,
+// oldest first.
+func (r *RequestAttachmentsRepository) FindConfirmedRequestAttachments(ctx context.Context, requestID string) ([]*models.RequestAttachment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+requestAttachmentColumns+`
+		FROM public.request_attachments
+		WHERE request_id = $1 AND status = $2
+		ORDER BY confirmed_at ASC, id ASC
+	`, requestID, models.AttachmentConfirmed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*models.RequestAttachment{}
+	for rows.Next() {
+		a, err := scanRequestAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// ConfirmRequestAttachment marks an attachment as uploaded, storing the content
+// type and size that were actually found in S3. Confirming twice is a no-op.
+func (r *RequestAttachmentsRepository) ConfirmRequestAttachment(ctx context.Context, requestID, id string, info *models.S3ObjectInfo) (*models.RequestAttachment, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE public.request_attachments
+		SET status = $3,
+		    content_type = $4,
+		    size_bytes = $5,
+		    confirmed_at = COALESCE(confirmed_at, now())
+		WHERE request_id = $1 AND id = $2
+		RETURNING `+requestAttachmentColumns,
+		requestID, id, models.AttachmentConfirmed, info.ContentType, info.ContentLength)
+
+	return scanRequestAttachment(row)
+}
+
+func (r *RequestAttachmentsRepository) DeleteRequestAttachment(ctx context.Context, requestID, id string) error {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM public.request_attachments
+		WHERE request_id = $1 AND id = $2
+	`, requestID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFoundInDB
+	}
+	return nil
+}
+
+func scanRequestAttachment(row pgx.Row) (*models.RequestAttachment, error) {
+	var a models.RequestAttachment
+	err := row.Scan(&a.ID, &a.RequestID, &a.HotelID, &a.Key, &a.FileName, &a.ContentType,
+		&a.SizeBytes, &a.Status, &a.UploadedBy, &a.CreatedAt, &a.ConfirmedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &a, nil
+}
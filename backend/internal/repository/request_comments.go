@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RequestCommentsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRequestCommentsRepository(db *pgxpool.Pool) *RequestCommentsRepository {
+	return &RequestCommentsRepository{db: db}
+}
+
+// The body of a deleted comment is never returned.
+const requestCommentColumns = `id, request_id, hotel_id, parent_id, author_id,
+	CASE WHEN deleted_at IS NULL THEN body ELSE '' END, mentions, created_at, edited_at, deleted_at`
+
+// InsertRequestComment adds a comment to a request of comment.HotelID;
+// errs.ErrNotFoundInDB is returned when no such request exists in that hotel.
+func (r *RequestCommentsRepository) InsertRequestComment(ctx context.Context, comment *models.RequestComment) (*models.RequestComment, error) {
+	mentions := comment.Mentions
+	if mentions == nil {
+		mentions = []string{}
+	}
+
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO public.request_comments (id, request_id, hotel_id, parent_id, author_id, body, mentions)
+		SELECT $1, req.id, req.hotel_id, $4, $5, $6, $7
+		FROM (
+			SELECT id, hotel_id FROM public.requests_current
+			WHERE id = $2
+		) req
+		WHERE req.hotel_id = $3
+		RETURNING `+requestCommentColumns,
+		comment.ID, comment.RequestID, comment.HotelID, comment.ParentID, comment.AuthorID, comment.Body, mentions)
+
+	return scanRequestComment(row)
+}
+
+// FindRequestComment returns a comment that has not been deleted.
+func (r *RequestCommentsRepository) FindRequestComment(ctx context.Context, requestID, id string) (*models.RequestComment, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+requestCommentColumns+`
+		FROM public.request_comments
+		WHERE request_id = $1 AND id = $2 AND deleted_at IS NULL
+	`, requestID, id)
+
+	return scanRequestComment(row)
+}
+
+// FindRequestComments returns one page of a request's comments, oldest first,
+// starting after the (cursorCreatedAt, cursorID) cursor. Deleted comments are
+// included so replies to them keep their place in the thread.
+func (r *RequestCommentsRepository) FindRequestComments(ctx context.Context, requestID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestComment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+requestCommentColumns+`
+		FROM public.request_comments
+		WHERE request_id = $1
+		  AND ($3::text = '' OR (created_at, id::text) > ($2, $3))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $4
+	`, requestID, cursorCreatedAt, cursorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectRequestComments(rows)
+}
+
+// FindActiveRequestComments returns every comment on a request that has not
+// been deleted, oldest first.
+func (r *RequestCommentsRepository) FindActiveRequestComments(ctx context.Context, requestID string) ([]*models.RequestComment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+requestCommentColumns+`
+		FROM public.request_comments
+		WHERE request_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC
+	`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	return collectRequestComments(rows)
+}
+
+func (r *RequestCommentsRepository) UpdateRequestComment(ctx context.Context, requestID, id, body string, mentions []string) (*models.RequestComment, error) {
+	if mentions == nil {
+		mentions = []string{}
+	}
+
+	row := r.db.QueryRow(ctx, `
+		UPDATE public.request_comments
+		SET body = $3, mentions = $4, edited_at = now()
+		WHERE request_id = $1 AND id = $2 AND deleted_at IS NULL
+		RETURNING `+requestCommentColumns,
+		requestID, id, body, mentions)
+
+	return scanRequestComment(row)
+}
+
+func (r *RequestCommentsRepository) SoftDeleteRequestComment(ctx context.Context, requestID, id string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE public.request_comments
+		SET deleted_at = now()
+		WHERE request_id = $1 AND id = $2 AND deleted_at IS NULL
+	`, requestID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFoundInDB
+	}
+	return nil
+}
+
+func collectRequestComments(rows pgx.Rows) ([]*models.RequestComment, error) {
+	defer rows.Close()
+
+	comments := []*models.RequestComment{}
+	for rows.Next() {
+		c, err := scanRequestComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func scanRequestComment(row pgx.Row) (*models.RequestComment, error) {
+	var c models.RequestComment
+	err := row.Scan(&c.ID, &c.RequestID, &c.HotelID, &c.ParentID, &c.AuthorID,
+		&c.Body, &c.Mentions, &c.CreatedAt, &c.EditedAt, &c.DeletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &c, nil
+}
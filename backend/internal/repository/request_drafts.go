@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RequestDraftsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRequestDraftsRepository(db *pgxpool.Pool) *RequestDraftsRepository {
+	return &RequestDraftsRepository{db: db}
+}
+
+// requestDraftSelect joins the latest version of each draft request with what
+// the model generated for it, in the order scanRequestDraft reads them. Only
+// requests still in status draft are returned.
+const requestDraftSelect = `
+	SELECT ` + requestColumns + `,
+		d.raw_text, d.generated, d.confidence, d.field_confidence, d.warning, d.drafted_at
+	FROM requests_current
+	JOIN (
+		SELECT request_id AS id, raw_text, generated, confidence, field_confidence, warning,
+			created_at AS drafted_at
+		FROM public.request_drafts
+	) d USING (id)
+	WHERE status = 'draft'`
+
+// InsertGeneratedRequests stores each draft's request as its first version and
+// records what was generated for it, and inserts the requests that need no
+// review, all in one transaction.
+func (r *RequestDraftsRepository) InsertGeneratedRequests(ctx context.Context, drafts []*models.RequestDraft, ready []*models.Request) error {
+	for _, draft := range drafts {
+		if draft.Request.ID == "" {
+			return errors.New("request ID must be provided by the caller")
+		}
+	}
+	for _, req := range ready {
+		if req.ID == "" {
+			return errors.New("request ID must be provided by the caller")
+		}
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, draft := range drafts {
+		draft.Request.Status = string(models.StatusDraft)
+		if err := insertRequestVersion(ctx, tx, &draft.Request); err != nil {
+			return err
+		}
+
+		fieldConfidence := draft.FieldConfidence
+		if fieldConfidence == nil {
+			fieldConfidence = map[string]float64{}
+		}
+		err := tx.QueryRow(ctx, `
+			INSERT INTO public.request_drafts (
+				request_id, hotel_id, raw_text, generated, confidence, field_confidence, warning
+			) VALUES ($1, $2, $3, $4::jsonb, $5, $6::jsonb, $7::jsonb)
+			RETURNING created_at
+		`, draft.Request.ID, draft.Request.HotelID, draft.RawText, draft.Generated,
+			draft.Confidence, fieldConfidence, draft.Warning).Scan(&draft.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	for _, req := range ready {
+		if err := insertRequestVersion(ctx, tx, req); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindRequestDraft returns a request awaiting review; errs.ErrNotFoundInDB is
+// returned once it has been approved or rejected.
+func (r *RequestDraftsRepository) FindRequestDraft(ctx context.Context, id string) (*models.RequestDraft, error) {
+	row := r.db.QueryRow(ctx, requestDraftSelect+` AND id = $1`, id)
+
+	return scanRequestDraft(row)
+}
+
+// FindRequestDrafts returns one page of a hotel's review queue, oldest draft
+// first, starting after the (cursorCreatedAt, cursorID) cursor.
+func (r *RequestDraftsRepository) FindRequestDrafts(ctx context.Context, hotelID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestDraft, error) {
+	rows, err := r.db.Query(ctx, requestDraftSelect+`
+		  AND hotel_id = $1
+		  AND ($3::text = '' OR (drafted_at, id::text) > ($2, $3))
+		ORDER BY drafted_at ASC, id ASC
+		LIMIT $4
+	`, hotelID, cursorCreatedAt, cursorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []*models.RequestDraft{}
+	for rows.Next() {
+		draft, err := scanRequestDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, rows.Err()
+}
+
+// UpdateRequestDraft writes the next version of a draft as
+// RequestsRepository.UpdateRequest does. With a review, the outcome is
+// recorded in the same transaction, and an approval records the fields the
+// reviewer changed from what was generated. A draft is only reviewed once;
+// errs.ErrNotFoundInDB is returned once it has been.
+func (r *RequestDraftsRepository) UpdateRequestDraft(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string, review *models.DraftReview) (*models.Request, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var generated models.MakeRequest
+	err = tx.QueryRow(ctx, `
+		SELECT generated FROM public.request_drafts
+		WHERE request_id = $1 AND reviewed_at IS NULL
+		FOR UPDATE
+	`, id).Scan(&generated)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+
+	if err := appendRequestVersion(ctx, tx, id, update, changedBy, true); err != nil {
+		return nil, err
+	}
+	req, err := scanRequest(tx.QueryRow(ctx, `
+		SELECT `+requestColumns+` FROM requests_current WHERE id = $1
+	`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if review != nil {
+		corrections := []models.DraftCorrection{}
+		if review.Action == models.DraftApproved {
+			corrections = append(corrections, models.DraftCorrections(generated, req.MakeRequest)...)
+		}
+		review.Corrections = corrections
+
+		_, err := tx.Exec(ctx, `
+			UPDATE public.request_drafts
+			SET review_action = $2, reviewed_by = $3, review_reason = $4,
+			    corrections = $5::jsonb, reviewed_at = NOW()
+			WHERE request_id = $1
+		`, id, review.Action, review.ReviewedBy, review.Reason, corrections)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// DraftAccuracy reports how the hotel's drafts reviewed since since fared:
+// for every reviewed field, the share of approved drafts in which the
+// reviewer left it as generated.
+func (r *RequestDraftsRepository) DraftAccuracy(ctx context.Context, hotelID string, since time.Time) (*models.DraftAccuracy, error) {
+	accuracy := models.DraftAccuracy{Since: since}
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE review_action = 'approved'),
+		       COUNT(*) FILTER (WHERE review_action = 'rejected')
+		FROM public.request_drafts
+		WHERE hotel_id = $1 AND reviewed_at >= $2
+	`, hotelID, since).Scan(&accuracy.Reviewed, &accuracy.Approved, &accuracy.Rejected)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT c->>'field', COUNT(*)
+		FROM public.request_drafts d, jsonb_array_elements(d.corrections) c
+		WHERE d.hotel_id = $1 AND d.reviewed_at >= $2 AND d.review_action = 'approved'
+		GROUP BY 1
+	`, hotelID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrected := map[string]int{}
+	for rows.Next() {
+		var field string
+		var count int
+		if err := rows.Scan(&field, &count); err != nil {
+			return nil, err
+		}
+		corrected[field] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, field := range models.DraftReviewedFields {
+		fieldAccuracy := models.DraftFieldAccuracy{Field: field, Corrected: corrected[field]}
+		if accuracy.Approved > 0 {
+			fieldAccuracy.Accuracy = 1 - float64(fieldAccuracy.Corrected)/float64(accuracy.Approved)
+		}
+		accuracy.Fields = append(accuracy.Fields, fieldAccuracy)
+	}
+	return &accuracy, nil
+}
+
+func scanRequestDraft(row pgx.Row) (*models.RequestDraft, error) {
+	var d models.RequestDraft
+	err := row.Scan(requestScanTargets(&d.Request,
+		&d.RawText, &d.Generated, &d.Confidence, &d.FieldConfidence, &d.Warning, &d.CreatedAt)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &d, nil
+}
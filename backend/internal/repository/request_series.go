@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RequestSeriesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRequestSeriesRepository(db *pgxpool.Pool) *RequestSeriesRepository {
+	return &RequestSeriesRepository{db: db}
+}
+
+const requestSeriesColumns = `id, hotel_id, template, rule, status, last_occurrence_at, created_by, created_at, updated_at`
+
+func (r *RequestSeriesRepository) InsertRequestSeries(ctx context.Context, input *models.CreateRequestSeriesInput, createdBy *string) (*models.RequestSeries, error) {
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO public.request_series (id, hotel_id, template, rule, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+requestSeriesColumns,
+		uuid.New().String(), input.Template.HotelID, input.Template, input.Rule, models.SeriesStatusActive, createdBy)
+
+	return scanRequestSeries(row)
+}
+
+func (r *RequestSeriesRepository) FindRequestSeries(ctx context.Context, id string) (*models.RequestSeries, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+requestSeriesColumns+`
+		FROM public.request_series
+		WHERE id = $1
+	`, id)
+
+	return scanRequestSeries(row)
+}
+
+// UpdateRequestSeries replaces the template and/or rule. Cancelled series
+// cannot be edited.
+func (r *RequestSeriesRepository) UpdateRequestSeries(ctx context.Context, id string, input *models.UpdateRequestSeriesInput) (*models.RequestSeries, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE public.request_series
+		SET template = COALESCE($2, template),
+		    rule = COALESCE($3, rule),
+		    updated_at = NOW()
+		WHERE id = $1 AND status != 'cancelled'
+		RETURNING `+requestSeriesColumns,
+		id, input.Template, input.Rule)
+
+	return scanRequestSeries(row)
+}
+
+// UpdateRequestSeriesStatus moves a series to the given status. Cancelled is
+// terminal, so cancelled series are never updated.
+func (r *RequestSeriesRepository) UpdateRequestSeriesStatus(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE public.request_series
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status != 'cancelled'
+		RETURNING `+requestSeriesColumns,
+		id, status)
+
+	return scanRequestSeries(row)
+}
+
+func (r *RequestSeriesRepository) MarkOccurrence(ctx context.Context, id string, at time.Time) error {
+	result, err := r.db.Exec(ctx, `
+		UPDATE public.request_series
+		SET last_occurrence_at = GREATEST(COALESCE(last_occurrence_at, $2), $2)
+		WHERE id = $1
+	`, id, at)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errs.ErrNotFoundInDB
+	}
+	return nil
+}
+
+func scanRequestSeries(row pgx.Row) (*models.RequestSeries, error) {
+	var s models.RequestSeries
+	if err := row.Scan(&s.ID, &s.HotelID, &s.Template, &s.Rule, &s.Status,
+		&s.LastOccurrenceAt, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RequestStatusesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRequestStatusesRepository(db *pgxpool.Pool) *RequestStatusesRepository {
+	return &RequestStatusesRepository{db: db}
+}
+
+// FindRequestStatusesByHotelID returns the hotel's custom request statuses.
+func (r *RequestStatusesRepository) FindRequestStatusesByHotelID(ctx context.Context, hotelID string) ([]*models.HotelRequestStatus, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT hotel_id, name, created_at
+		FROM public.hotel_request_statuses
+		WHERE hotel_id = $1
+		ORDER BY name ASC
+	`, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []*models.HotelRequestStatus{}
+	for rows.Next() {
+		var s models.HotelRequestStatus
+		if err := rows.Scan(&s.HotelID, &s.Name, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, &s)
+	}
+	return statuses, rows.Err()
+}
+
+func (r *RequestStatusesRepository) InsertRequestStatus(ctx context.Context, hotelID, name string) (*models.HotelRequestStatus, error) {
+	var s models.HotelRequestStatus
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO public.hotel_request_statuses (hotel_id, name)
+		VALUES ($1, $2)
+		RETURNING hotel_id, name, created_at
+	`, hotelID, name).Scan(&s.HotelID, &s.Name, &s.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, errs.ErrAlreadyExistsInDB
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteRequestStatus removes a custom status. Requests currently in it keep
+// the status and can still move back to pending, in progress or archived.
+func (r *RequestStatusesRepository) DeleteRequestStatus(ctx context.Context, hotelID, name string) error {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM public.hotel_request_statuses
+		WHERE hotel_id = $1 AND name = $2
+	`, hotelID, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFoundInDB
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"iter"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/generate/selfserve/internal/errs"
@@ -11,6 +14,108 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// slaPolicyJoin attaches the most specific SLA policy matching the request row
+// aliased r: department and priority, then department, then priority, then hotel-wide.
+const slaPolicyJoin = `LEFT JOIN LATERAL (
+				SELECT p.acknowledge_within_minutes, p.complete_within_minutes, p.escalate_to_user_id
+				FROM public.sla_policies p
+				WHERE p.hotel_id = r.hotel_id
+				  AND (p.department_id IS NULL OR p.department_id::text = r.department)
+				  AND (p.priority IS NULL OR p.priority = r.priority)
+				ORDER BY (p.department_id IS NOT NULL) DESC, (p.priority IS NOT NULL) DESC
+				LIMIT 1
+			) sla ON true`
+
+// slaDueAtColumn is the completion deadline of row r joined with slaPolicyJoin,
+// falling back to the request's own estimated_completion_time. It counts from
+// when the request was last opened, so reopening restarts it.
+const slaDueAtColumn = `r.opened_at + make_interval(mins => COALESCE(sla.complete_within_minutes, r.estimated_completion_time)) AS sla_due_at`
+
+// isOverdueColumn derives is_overdue from sla_due_at in a select over the latest versions.
+const isOverdueColumn = `(sla_due_at IS NOT NULL AND sla_due_at < NOW() AND status NOT IN ('completed', 'archived', 'draft')) AS is_overdue`
+
+// requestColumns lists the requests columns in the order scanRequest reads them.
+const requestColumns = `id, hotel_id, guest_id, reservation_id, name, description,
+	room_id, request_category, request_type, department, status,
+	priority, estimated_completion_time, scheduled_time, completed_at, notes,
+	created_at, user_id, request_version, changed_by, started_at,
+	original_text, english_text, guest_language`
+
+// openedAtColumn is when the request of the requests row was last opened, as
+// openedAt works it out, from the version history: its first version after
+// the one that last completed or archived it, before this one.
+const openedAtColumn = `(
+		SELECT MIN(v.request_version)
+		FROM requests v
+		WHERE v.id = requests.id AND v.request_version <= requests.request_version
+		  AND v.request_version > COALESCE((
+		    SELECT MAX(c.request_version)
+		    FROM requests c
+		    WHERE c.id = requests.id AND c.request_version < requests.request_version
+		      AND c.status IN ('completed', 'archived')
+		  ), '-infinity')
+	)`
+
+// upsertCurrentRequestConflict overwrites the requests_current row of a
+// request with a newer version. The guard on request_version keeps an older
+// version (e.g. one read by a concurrent backfill) from replacing it.
+const upsertCurrentRequestConflict = `
+	ON CONFLICT (id) DO UPDATE SET
+		hotel_id = EXCLUDED.hotel_id,
+		guest_id = EXCLUDED.guest_id,
+		reservation_id = EXCLUDED.reservation_id,
+		name = EXCLUDED.name,
+		description = EXCLUDED.description,
+		room_id = EXCLUDED.room_id,
+		request_category = EXCLUDED.request_category,
+		request_type = EXCLUDED.request_type,
+		department = EXCLUDED.department,
+		status = EXCLUDED.status,
+		priority = EXCLUDED.priority,
+		estimated_completion_time = EXCLUDED.estimated_completion_time,
+		scheduled_time = EXCLUDED.scheduled_time,
+		completed_at = EXCLUDED.completed_at,
+		notes = EXCLUDED.notes,
+		created_at = EXCLUDED.created_at,
+		user_id = EXCLUDED.user_id,
+		request_version = EXCLUDED.request_version,
+		changed_by = EXCLUDED.changed_by,
+		started_at = EXCLUDED.started_at,
+		original_text = EXCLUDED.original_text,
+		english_text = EXCLUDED.english_text,
+		guest_language = EXCLUDED.guest_language,
+		opened_at = EXCLUDED.opened_at
+	WHERE requests_current.request_version < EXCLUDED.request_version`
+
+// upsertCurrentRequest copies version $2 of request $1 into requests_current,
+// last opened at $3. It runs in the transaction that inserted the version.
+const upsertCurrentRequest = `
+	INSERT INTO requests_current (` + requestColumns + `, opened_at)
+	SELECT ` + requestColumns + `, $3::timestamptz FROM requests WHERE id = $1 AND request_version = $2
+` + upsertCurrentRequestConflict
+
+func scanRequest(row pgx.Row) (*models.Request, error) {
+	var req models.Request
+	if err := row.Scan(requestScanTargets(&req)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// requestScanTargets returns the fields of req in requestColumns order, for
+// scanning a row that starts with requestColumns.
+func requestScanTargets(req *models.Request, extra ...any) []any {
+	return append([]any{&req.ID, &req.HotelID, &req.GuestID,
+		&req.ReservationID, &req.Name, &req.Description,
+		&req.RoomID, &req.RequestCategory, &req.RequestType, &req.Department, &req.Status,
+		&req.Priority, &req.EstimatedCompletionTime, &req.ScheduledTime, &req.CompletedAt, &req.Notes,
+		&req.CreatedAt, &req.UserID, &req.RequestVersion, &req.ChangedBy, &req.StartedAt,
+		&req.OriginalText, &req.EnglishText, &req.GuestLanguage}, extra...)
+}
+
 type RequestsRepository struct {
 	db *pgxpool.Pool
 }
@@ -24,69 +129,236 @@ func (r *RequestsRepository) InsertRequest(ctx context.Context, req *models.Requ
 		return nil, errors.New("request ID must be provided by the caller")
 	}
 
-	err := r.db.QueryRow(ctx, `
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := insertRequestVersion(ctx, tx, req); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// InsertRequests inserts every request in one transaction: either all of them
+// are created or none are.
+func (r *RequestsRepository) InsertRequests(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+	for _, req := range reqs {
+		if req.ID == "" {
+			return nil, errors.New("request ID must be provided by the caller")
+		}
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, req := range reqs {
+		if err := insertRequestVersion(ctx, tx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return reqs, nil
+}
+
+// insertRequestVersion inserts req as a new version within tx, mirrors it into
+// requests_current and fills in the server-assigned columns of req.
+func insertRequestVersion(ctx context.Context, tx pgx.Tx, req *models.Request) error {
+	err := tx.QueryRow(ctx, `
 		INSERT INTO requests (
 			id, hotel_id, guest_id, user_id, reservation_id, name, description,
 			room_id, request_category, request_type, department, status,
 			priority, estimated_completion_time, scheduled_time, notes,
-			request_version, created_at, changed_by
+			request_version, created_at, changed_by, started_at, completed_at,
+			original_text, english_text, guest_language
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
 			NOW(),
-			COALESCE((SELECT MIN(created_at) FROM requests WHERE id = $1), NOW()),
-			$17
+			COALESCE((SELECT created_at FROM requests_current WHERE id = $1), NOW()),
+			$17,
+			CASE WHEN $12 = 'in progress' THEN NOW() END,
+			CASE WHEN $12 = 'completed' THEN NOW() END,
+			$18, $19, $20
 		)
 		RETURNING id, created_at, request_version
 	`, req.ID, req.HotelID, req.GuestID, req.UserID, req.ReservationID, req.Name,
 		req.Description, req.RoomID, req.RequestCategory, req.RequestType, req.Department,
 		req.Status, req.Priority, req.EstimatedCompletionTime,
-		req.ScheduledTime, req.Notes, req.ChangedBy).Scan(&req.ID, &req.CreatedAt, &req.RequestVersion)
+		req.ScheduledTime, req.Notes, req.ChangedBy,
+		req.OriginalText, req.EnglishText, req.GuestLanguage).Scan(&req.ID, &req.CreatedAt, &req.RequestVersion)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, upsertCurrentRequest, req.ID, req.RequestVersion, req.RequestVersion)
+	return err
+}
+
+// openedAt returns when a request was last opened once a version moved it
+// from prevStatus to status: at that version when it reopens a completed or
+// archived request, or still at prevOpenedAt. SLA deadlines count from it.
+func openedAt(prevStatus string, prevOpenedAt time.Time, status string, version time.Time) time.Time {
+	closed := func(status string) bool {
+		return status == string(models.StatusCompleted) || status == string(models.StatusArchived)
+	}
+	if closed(prevStatus) && !closed(status) {
+		return version
+	}
+	return prevOpenedAt
+}
 
+// UpdateRequest appends a new version of the request. When
+// update.ExpectedVersion is set and is no longer the latest version, nothing
+// is written and errs.ErrStaleVersionInDB is returned. Drafts are only
+// written through RequestDraftsRepository and are reported as
+// errs.ErrNotFoundInDB.
+func (r *RequestsRepository) UpdateRequest(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	return req, nil
+	if err := appendRequestVersion(ctx, tx, id, update, changedBy, false); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.FindLatestRequest(ctx, id)
 }
 
-func (r *RequestsRepository) UpdateRequest(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
-	row := r.db.QueryRow(ctx, `
+// UpdateRequests applies each update as UpdateRequest would, all in one
+// transaction. An update failing with errs.ErrNotFoundInDB or
+// errs.ErrStaleVersionInDB is rolled back on its own and reported in its
+// result; any other error rolls back the whole batch. Results are in the
+// order of updates.
+func (r *RequestsRepository) UpdateRequests(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error) {
+	// Take the per-request locks in a fixed order so overlapping batches
+	// cannot deadlock.
+	order := make([]int, len(updates))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return strings.Compare(updates[a].ID, updates[b].ID) })
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	results := make([]*models.RequestBulkUpdateResult, len(updates))
+	for _, i := range order {
+		u := updates[i]
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := appendRequestVersion(ctx, savepoint, u.ID, u.Update, changedBy, false); err != nil {
+			_ = savepoint.Rollback(ctx)
+			if errors.Is(err, errs.ErrNotFoundInDB) || errors.Is(err, errs.ErrStaleVersionInDB) {
+				results[i] = &models.RequestBulkUpdateResult{Err: err}
+				continue
+			}
+			return nil, err
+		}
+		req, err := scanRequest(savepoint.QueryRow(ctx, `
+			SELECT `+requestColumns+` FROM requests_current WHERE id = $1
+		`, u.ID))
+		if err != nil {
+			return nil, err
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, err
+		}
+		results[i] = &models.RequestBulkUpdateResult{Request: req}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// appendRequestVersion inserts the next version of request id within tx and
+// mirrors it into requests_current. draft says whether the request is one
+// under review; a request of the other kind is reported as errs.ErrNotFoundInDB.
+func appendRequestVersion(ctx context.Context, tx pgx.Tx, id string, update *models.RequestUpdateInput, changedBy *string, draft bool) error {
+	// Serialize writers of the same request so the version check and the
+	// insert of the next version are atomic.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, id); err != nil {
+		return err
+	}
+
+	row := tx.QueryRow(ctx, `
 		WITH current AS (
 			SELECT *
-			FROM requests
-			WHERE id = $1
-			ORDER BY request_version DESC
-			LIMIT 1
+			FROM requests_current
+			WHERE id = $1 AND (status = 'draft') = $20
+		), inserted AS (
+			INSERT INTO requests (
+				id, hotel_id, guest_id, user_id, reservation_id, name, description,
+				room_id, request_category, request_type, department, status,
+				priority, estimated_completion_time, scheduled_time, completed_at, notes,
+				request_version, created_at, changed_by, started_at,
+				original_text, english_text, guest_language
+			)
+			SELECT
+				current.id,
+				current.hotel_id,
+				COALESCE($2, current.guest_id),
+				CASE WHEN $17 THEN NULL ELSE COALESCE($3, current.user_id) END,
+				COALESCE($4, current.reservation_id),
+				COALESCE($5, current.name),
+				COALESCE($6, current.description),
+				COALESCE($7, current.room_id),
+				COALESCE($8, current.request_category),
+				COALESCE($9, current.request_type),
+				COALESCE($10, current.department),
+				COALESCE($11, current.status),
+				COALESCE($12, current.priority),
+				COALESCE($13, current.estimated_completion_time),
+				COALESCE($14, current.scheduled_time),
+				-- completed_at is stamped on completion, kept once archived and
+				-- cleared when a request is reopened or moved back.
+				CASE COALESCE($11, current.status)
+					WHEN 'completed' THEN COALESCE($15, current.completed_at, NOW())
+					WHEN 'archived' THEN current.completed_at
+				END,
+				COALESCE($16, current.notes),
+				-- clock_timestamp, not NOW(): the transaction may have waited on the lock.
+				GREATEST(clock_timestamp(), current.request_version + INTERVAL '1 microsecond'),
+				current.created_at,
+				$18,
+				-- started_at records when work first started and survives reopening.
+				COALESCE(current.started_at, CASE WHEN COALESCE($11, current.status) = 'in progress' THEN NOW() END),
+				-- The message a request was generated from never changes.
+				current.original_text,
+				current.english_text,
+				current.guest_language
+			FROM current
+			WHERE $19::timestamptz IS NULL OR current.request_version = $19
+			RETURNING request_version, status
 		)
-		INSERT INTO requests (
-			id, hotel_id, guest_id, user_id, reservation_id, name, description,
-			room_id, request_category, request_type, department, status,
-			priority, estimated_completion_time, scheduled_time, completed_at, notes,
-			request_version, created_at, changed_by
-		)
-		SELECT
-			current.id,
-			current.hotel_id,
-			COALESCE($2, current.guest_id),
-			CASE WHEN $17 THEN NULL ELSE COALESCE($3, current.user_id) END,
-			COALESCE($4, current.reservation_id),
-			COALESCE($5, current.name),
-			COALESCE($6, current.description),
-			COALESCE($7, current.room_id),
-			COALESCE($8, current.request_category),
-			COALESCE($9, current.request_type),
-			COALESCE($10, current.department),
-			COALESCE($11, current.status),
-			COALESCE($12, current.priority),
-			COALESCE($13, current.estimated_completion_time),
-			COALESCE($14, current.scheduled_time),
-			COALESCE($15, current.completed_at),
-			COALESCE($16, current.notes),
-			NOW(),
-			current.created_at,
-			$18
-		FROM current
-		RETURNING id, created_at, request_version
+		SELECT inserted.request_version, inserted.status, current.status, current.opened_at
+		FROM inserted, current
 	`, id,
 		update.GuestID,
 		update.UserID,
@@ -105,52 +377,60 @@ func (r *RequestsRepository) UpdateRequest(ctx context.Context, id string, updat
 		update.Notes,
 		update.Unassign,
 		changedBy,
+		update.ExpectedVersion,
+		draft,
 	)
 
-	var req models.Request
-	if err := row.Scan(&req.ID, &req.CreatedAt, &req.RequestVersion); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errs.ErrNotFoundInDB
+	var version, prevOpenedAt time.Time
+	var status, prevStatus string
+	if err := row.Scan(&version, &status, &prevStatus, &prevOpenedAt); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
 		}
-		return nil, err
+		if update.ExpectedVersion == nil {
+			return errs.ErrNotFoundInDB
+		}
+		var exists bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM requests_current WHERE id = $1 AND (status = 'draft') = $2)
+		`, id, draft).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errs.ErrStaleVersionInDB
+		}
+		return errs.ErrNotFoundInDB
 	}
 
-	return r.FindRequest(ctx, id)
+	_, err := tx.Exec(ctx, upsertCurrentRequest, id, version, openedAt(prevStatus, prevOpenedAt, status, version))
+	return err
 }
 
+// FindRequest returns the latest version of a request, hiding archived ones
+// and drafts.
 func (r *RequestsRepository) FindRequest(ctx context.Context, id string) (*models.Request, error) {
-
 	row := r.db.QueryRow(ctx, `
-		WITH latest AS (
-			SELECT * FROM requests WHERE id = $1 ORDER BY request_version DESC LIMIT 1
-		)
-		SELECT * FROM latest WHERE status != 'archived'
+		SELECT `+requestColumns+` FROM requests_current WHERE id = $1 AND status NOT IN ('archived', 'draft')
 	`, id)
 
-	var request models.Request
-
-	err := row.Scan(&request.ID, &request.HotelID, &request.GuestID,
-		&request.ReservationID, &request.Name, &request.Description,
-		&request.RoomID, &request.RequestCategory, &request.RequestType, &request.Department, &request.Status,
-		&request.Priority, &request.EstimatedCompletionTime, &request.ScheduledTime, &request.CompletedAt, &request.Notes,
-		&request.CreatedAt, &request.UserID, &request.RequestVersion, &request.ChangedBy)
+	return scanRequest(row)
+}
 
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errs.ErrNotFoundInDB
-		}
-		return nil, err
-	}
+// FindLatestRequest returns the latest version of a request, including
+// archived ones and drafts.
+func (r *RequestsRepository) FindLatestRequest(ctx context.Context, id string) (*models.Request, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+requestColumns+` FROM requests_current WHERE id = $1
+	`, id)
 
-	return &request, nil
+	return scanRequest(row)
 }
 
 func (r *RequestsRepository) FindRequests(ctx context.Context) ([]models.Request, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT * FROM (
-			SELECT DISTINCT ON (id) * FROM requests ORDER BY id, request_version DESC
-		) latest
-		WHERE status != 'archived'
+		SELECT `+requestColumns+` FROM requests_current
+		WHERE status NOT IN ('archived', 'draft')
 		ORDER BY created_at DESC
 	`)
 	if err != nil {
@@ -160,16 +440,11 @@ func (r *RequestsRepository) FindRequests(ctx context.Context) ([]models.Request
 
 	var requests []models.Request
 	for rows.Next() {
-		var request models.Request
-		err := rows.Scan(&request.ID, &request.HotelID, &request.GuestID,
-			&request.ReservationID, &request.Name, &request.Description,
-			&request.RoomID, &request.RequestCategory, &request.RequestType, &request.Department, &request.Status,
-			&request.Priority, &request.EstimatedCompletionTime, &request.ScheduledTime, &request.CompletedAt, &request.Notes,
-			&request.CreatedAt, &request.UserID, &request.RequestVersion, &request.ChangedBy)
+		request, err := scanRequest(rows)
 		if err != nil {
 			return nil, err
 		}
-		requests = append(requests, request)
+		requests = append(requests, *request)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -182,18 +457,24 @@ func (r *RequestsRepository) FindRequests(ctx context.Context) ([]models.Request
 func (r *RequestsRepository) FindRequestsByGuestID(ctx context.Context, guestID, hotelID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error) {
 	rows, err := r.db.Query(ctx, `
 		WITH latest AS (
-			SELECT DISTINCT ON (r.id)
+			SELECT
 				r.id, r.name, r.priority, r.status, r.description, r.notes,
 				rm.room_number, r.request_type, r.request_category, r.created_at,
-				r.request_version, r.department, r.user_id, rm.floor
-			FROM public.requests r
+				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
+				`+slaDueAtColumn+`
+			FROM public.requests_current r
 			LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
+			LEFT JOIN public.departments d ON d.id::text = r.department
+			`+slaPolicyJoin+`
 			WHERE r.guest_id = $1
 			  AND r.hotel_id = $2
-			ORDER BY r.id ASC, r.request_version DESC
 		)
-		SELECT * FROM latest
-		WHERE status != 'archived'
+		SELECT id, name, priority, status, description, notes, room_number,
+		       request_type, request_category, created_at, request_version,
+		       department_id, department_name, user_id, floor,
+		       sla_due_at, `+isOverdueColumn+`
+		FROM latest
+		WHERE status NOT IN ('archived', 'draft')
 		  AND ($3::text = '' OR (id::text, request_version) > ($3, $4))
 		ORDER BY id ASC
 		LIMIT $5
@@ -209,22 +490,24 @@ func (r *RequestsRepository) FindRequestsByGuestID(ctx context.Context, guestID,
 func (r *RequestsRepository) FindRequestsByRoomIDAndUserID(ctx context.Context, roomID, hotelID, userID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error) {
 	rows, err := r.db.Query(ctx, `
 		WITH latest AS (
-			SELECT DISTINCT ON (r.id)
+			SELECT
 				r.id, r.name, r.priority, r.status, r.description, r.notes,
 				rm.room_number, r.request_type, r.request_category, r.created_at,
-				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor
-			FROM public.requests r
+				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
+				`+slaDueAtColumn+`
+			FROM public.requests_current r
 			LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
 			LEFT JOIN public.departments d ON d.id::text = r.department
+			`+slaPolicyJoin+`
 			WHERE r.room_id = $1
 			  AND r.hotel_id = $2
-			ORDER BY r.id ASC, r.request_version DESC
 		)
 		SELECT id, name, priority, status, description, notes, room_number,
 		       request_type, request_category, created_at, request_version,
-		       department_id, department_name, user_id, floor
+		       department_id, department_name, user_id, floor,
+		       sla_due_at, `+isOverdueColumn+`
 		FROM latest
-		WHERE status != 'archived'
+		WHERE status NOT IN ('archived', 'draft')
 		  AND user_id = $3
 		  AND ($4::text = '' OR (id::text, request_version) > ($4, $5))
 		ORDER BY id ASC
@@ -241,22 +524,24 @@ func (r *RequestsRepository) FindRequestsByRoomIDAndUserID(ctx context.Context,
 func (r *RequestsRepository) FindUnassignedRequestsByRoomIDAndUserID(ctx context.Context, roomID, hotelID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error) {
 	rows, err := r.db.Query(ctx, `
 		WITH latest AS (
-			SELECT DISTINCT ON (r.id)
+			SELECT
 				r.id, r.name, r.priority, r.status, r.description, r.notes,
 				rm.room_number, r.request_type, r.request_category, r.created_at,
-				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor
-			FROM public.requests r
+				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
+				`+slaDueAtColumn+`
+			FROM public.requests_current r
 			LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
 			LEFT JOIN public.departments d ON d.id::text = r.department
+			`+slaPolicyJoin+`
 			WHERE r.room_id = $1
 			  AND r.hotel_id = $2
-			ORDER BY r.id ASC, r.request_version DESC
 		)
 		SELECT id, name, priority, status, description, notes, room_number,
 		       request_type, request_category, created_at, request_version,
-		       department_id, department_name, user_id, floor
+		       department_id, department_name, user_id, floor,
+		       sla_due_at, `+isOverdueColumn+`
 		FROM latest
-		WHERE status != 'archived'
+		WHERE status NOT IN ('archived', 'draft')
 		  AND user_id IS NULL
 		  AND ($3::text = '' OR (id::text, request_version) > ($3, $4))
 		ORDER BY id ASC
@@ -276,6 +561,7 @@ func (r *RequestsRepository) FindRequestsPaginated(
 	cursorID string,
 	cursorCreatedAt time.Time,
 	cursorPriorityRank int,
+	cursorSLADueAt *time.Time,
 	limit int,
 ) ([]*models.GuestRequest, error) {
 	priorities := input.Priorities
@@ -292,26 +578,28 @@ func (r *RequestsRepository) FindRequestsPaginated(
 	}
 	const baseFilter = `
 		WITH latest AS (
-			SELECT DISTINCT ON (r.id)
+			SELECT
 				r.id, r.name, r.priority, r.status, r.description, r.notes,
 				rm.room_number, r.request_type, r.request_category, r.created_at,
 				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
-				CASE r.priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END AS priority_rank
-			FROM public.requests r
+				CASE r.priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END AS priority_rank,
+				` + slaDueAtColumn + `
+			FROM public.requests_current r
 			LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
 			LEFT JOIN public.departments d ON d.id::text = r.department
+			` + slaPolicyJoin + `
 			WHERE r.hotel_id = $1
 			  AND ($4::text = '' OR r.status = $4)
 			  AND (cardinality($5::text[]) = 0 OR r.priority = ANY($5))
 			  AND (cardinality($7::int[]) = 0 OR rm.floor = ANY($7))
 			  AND ($8::text = '' OR r.name ILIKE '%' || $8 || '%' OR r.description ILIKE '%' || $8 || '%')
-			ORDER BY r.id ASC, r.request_version DESC
 		)
 		SELECT id, name, priority, status, description, notes, room_number,
 		       request_type, request_category, created_at, request_version,
-		       department_id, department_name, user_id, floor
+		       department_id, department_name, user_id, floor,
+		       sla_due_at, ` + isOverdueColumn + `
 		FROM latest
-		WHERE status != 'archived'
+		WHERE status NOT IN ('archived', 'draft')
 		  AND (
 		    ($3::bool AND user_id IS NULL)
 		    OR (NOT $3::bool AND ($2::text = '' OR user_id = $2))
@@ -339,6 +627,14 @@ func (r *RequestsRepository) FindRequestsPaginated(
 			LIMIT $11
 		`, input.HotelID, input.UserID, input.Unassigned, input.Status, priorities, departments, floors, input.Search, cursorID, cursorCreatedAt, limit)
 
+	case models.SortBySLA:
+		// Requests without a deadline sort last.
+		rows, err = r.db.Query(ctx, baseFilter+`
+			AND ($9::text = '' OR (COALESCE(sla_due_at, 'infinity'), id::text) > (COALESCE($10::timestamptz, 'infinity'), $9))
+			ORDER BY COALESCE(sla_due_at, 'infinity') ASC, id ASC
+			LIMIT $11
+		`, input.HotelID, input.UserID, input.Unassigned, input.Status, priorities, departments, floors, input.Search, cursorID, cursorSLADueAt, limit)
+
 	default: // SortByPriority
 		rows, err = r.db.Query(ctx, baseFilter+`
 			AND ($9::text = '' OR (priority_rank, id::text) > ($10::int, $9))
@@ -357,10 +653,7 @@ func (r *RequestsRepository) FindRequestsPaginated(
 
 func (r *RequestsRepository) FindRequestVersions(ctx context.Context, id string) ([]*models.Request, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, hotel_id, guest_id, reservation_id, name, description,
-		       room_id, request_category, request_type, department, status,
-		       priority, estimated_completion_time, scheduled_time, completed_at, notes,
-		       created_at, user_id, request_version, changed_by
+		SELECT `+requestColumns+`
 		FROM requests
 		WHERE id = $1
 		ORDER BY request_version ASC
@@ -372,17 +665,11 @@ func (r *RequestsRepository) FindRequestVersions(ctx context.Context, id string)
 
 	var versions []*models.Request
 	for rows.Next() {
-		var req models.Request
-		if err := rows.Scan(
-			&req.ID, &req.HotelID, &req.GuestID,
-			&req.ReservationID, &req.Name, &req.Description,
-			&req.RoomID, &req.RequestCategory, &req.RequestType, &req.Department, &req.Status,
-			&req.Priority, &req.EstimatedCompletionTime, &req.ScheduledTime, &req.CompletedAt, &req.Notes,
-			&req.CreatedAt, &req.UserID, &req.RequestVersion, &req.ChangedBy,
-		); err != nil {
+		req, err := scanRequest(rows)
+		if err != nil {
 			return nil, err
 		}
-		versions = append(versions, &req)
+		versions = append(versions, req)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -393,6 +680,187 @@ func (r *RequestsRepository) FindRequestVersions(ctx context.Context, id string)
 	return versions, nil
 }
 
+// FindGuestRequest returns the latest version of a request in the denormalized
+// GuestRequest shape used by the feed, including archived requests.
+func (r *RequestsRepository) FindGuestRequest(ctx context.Context, id string) (*models.GuestRequest, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH latest AS (
+			SELECT r.id, r.name, r.priority, r.status, r.description, r.notes,
+				rm.room_number, r.request_type, r.request_category, r.created_at,
+				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
+				`+slaDueAtColumn+`
+			FROM public.requests_current r
+			LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
+			LEFT JOIN public.departments d ON d.id::text = r.department
+			`+slaPolicyJoin+`
+			WHERE r.id = $1
+		)
+		SELECT id, name, priority, status, description, notes, room_number,
+		       request_type, request_category, created_at, request_version,
+		       department_id, department_name, user_id, floor,
+		       sla_due_at, `+isOverdueColumn+`
+		FROM latest
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests, err := scanGuestRequests(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, errs.ErrNotFoundInDB
+	}
+	return requests[0], nil
+}
+
+// FindRequestsChangedSince returns the latest version of every request in the
+// hotel whose newest version was inserted after since, oldest change first.
+func (r *RequestsRepository) FindRequestsChangedSince(ctx context.Context, hotelID string, since time.Time, limit int) ([]*models.GuestRequest, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH latest AS (
+			SELECT
+				r.id, r.name, r.priority, r.status, r.description, r.notes,
+				rm.room_number, r.request_type, r.request_category, r.created_at,
+				r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
+				`+slaDueAtColumn+`
+			FROM public.requests_current r
+			LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
+			LEFT JOIN public.departments d ON d.id::text = r.department
+			`+slaPolicyJoin+`
+			WHERE r.hotel_id = $1
+		)
+		SELECT id, name, priority, status, description, notes, room_number,
+		       request_type, request_category, created_at, request_version,
+		       department_id, department_name, user_id, floor,
+		       sla_due_at, `+isOverdueColumn+`
+		FROM latest
+		WHERE request_version > $2 AND status != 'draft'
+		ORDER BY request_version ASC
+		LIMIT $3
+	`, hotelID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanGuestRequests(rows)
+}
+
+// RequestHotelIDs returns every hotel that has at least one request version.
+func (r *RequestsRepository) RequestHotelIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT DISTINCT hotel_id FROM requests ORDER BY hotel_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hotelIDs []string
+	for rows.Next() {
+		var hotelID string
+		if err := rows.Scan(&hotelID); err != nil {
+			return nil, err
+		}
+		hotelIDs = append(hotelIDs, hotelID)
+	}
+	return hotelIDs, rows.Err()
+}
+
+// BackfillCurrentRequests copies the latest version of each of the hotel's
+// requests from the version history into requests_current and returns how
+// many rows were inserted or brought up to date.
+func (r *RequestsRepository) BackfillCurrentRequests(ctx context.Context, hotelID string) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO requests_current (`+requestColumns+`, opened_at)
+		SELECT DISTINCT ON (id) `+requestColumns+`, `+openedAtColumn+`
+		FROM requests
+		WHERE hotel_id = $1
+		ORDER BY id, request_version DESC
+	`+upsertCurrentRequestConflict, hotelID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+const fetchAllRequestDocumentsPageSize = 100
+
+// AllRequestDocuments returns a paginated iterator over the latest version of
+// every request, archived ones included, in the shape stored in the search
+// index. The first non-nil error stops iteration and is yielded as the second
+// value.
+func (r *RequestsRepository) AllRequestDocuments(ctx context.Context) iter.Seq2[*models.RequestDocument, error] {
+	return func(yield func(*models.RequestDocument, error) bool) {
+		var cursorID string
+
+		for {
+			rows, err := r.db.Query(ctx, `
+				WITH latest AS (
+					SELECT
+						r.id, r.name, r.priority, r.status, r.description, r.notes,
+						rm.room_number, r.request_type, r.request_category, r.created_at,
+						r.request_version, r.department AS department_id, d.name AS department_name, r.user_id, rm.floor,
+						`+slaDueAtColumn+`, r.hotel_id
+					FROM public.requests_current r
+					LEFT JOIN public.rooms rm ON rm.id::text = r.room_id
+					LEFT JOIN public.departments d ON d.id::text = r.department
+					`+slaPolicyJoin+`
+					WHERE ($1::text = '' OR r.id::text > $1)
+				)
+				SELECT id, name, priority, status, description, notes, room_number,
+				       request_type, request_category, created_at, request_version,
+				       department_id, department_name, user_id, floor,
+				       sla_due_at, `+isOverdueColumn+`, hotel_id
+				FROM latest
+				ORDER BY id ASC
+				LIMIT $2
+			`, cursorID, fetchAllRequestDocumentsPageSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			var page []*models.RequestDocument
+			for rows.Next() {
+				var doc models.RequestDocument
+				if err := rows.Scan(
+					&doc.ID, &doc.Name, &doc.Priority, &doc.Status,
+					&doc.Description, &doc.Notes, &doc.RoomNumber,
+					&doc.RequestType, &doc.RequestCategory, &doc.CreatedAt,
+					&doc.RequestVersion, &doc.DepartmentID, &doc.DepartmentName, &doc.UserID, &doc.Floor,
+					&doc.SLADueAt, &doc.IsOverdue, &doc.HotelID,
+				); err != nil {
+					rows.Close()
+					yield(nil, err)
+					return
+				}
+				doc.PriorityRank = models.PriorityRank(doc.Priority)
+				page = append(page, &doc)
+			}
+			rows.Close()
+
+			if err := rows.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, doc := range page {
+				if !yield(doc, nil) {
+					return
+				}
+			}
+
+			if len(page) < fetchAllRequestDocumentsPageSize {
+				return // last page
+			}
+
+			cursorID = page[len(page)-1].ID
+		}
+	}
+}
+
 func scanGuestRequests(rows pgx.Rows) ([]*models.GuestRequest, error) {
 	requests := make([]*models.GuestRequest, 0)
 	for rows.Next() {
@@ -402,6 +870,7 @@ func scanGuestRequests(rows pgx.Rows) ([]*models.GuestRequest, error) {
 			&req.Description, &req.Notes, &req.RoomNumber,
 			&req.RequestType, &req.RequestCategory, &req.CreatedAt,
 			&req.RequestVersion, &req.DepartmentID, &req.DepartmentName, &req.UserID, &req.Floor,
+			&req.SLADueAt, &req.IsOverdue,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	opensearchstorage "github.com/generate/selfserve/internal/service/storage/opensearch"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// requestFacetSize caps the number of buckets returned per facet.
+const requestFacetSize = 50
+
+// requestFacetFields maps each facet to the document field it counts.
+var requestFacetFields = map[string]string{
+	"status":     "status",
+	"priority":   "priority",
+	"department": "department_id",
+	"floor":      "floor",
+}
+
+type OpenSearchRequestsRepository struct {
+	client *opensearch.Client
+	now    func() time.Time
+}
+
+func NewOpenSearchRequestsRepository(client *opensearch.Client) *OpenSearchRequestsRepository {
+	return &OpenSearchRequestsRepository{client: client, now: time.Now}
+}
+
+func (r *OpenSearchRequestsRepository) IndexRequest(ctx context.Context, doc *models.RequestDocument) error {
+	serializedDoc, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	indexResponse, err := opensearchapi.IndexRequest{
+		Index:      opensearchstorage.RequestsIndex,
+		DocumentID: doc.ID,
+		Body:       bytes.NewReader(serializedDoc),
+	}.Do(ctx, r.client)
+	if err != nil {
+		return fmt.Errorf("indexing request %s: %w", doc.ID, err)
+	}
+	defer indexResponse.Body.Close()
+
+	if indexResponse.IsError() {
+		return fmt.Errorf("indexing request %s failed: %s", doc.ID, indexResponse.String())
+	}
+	return nil
+}
+
+func (r *OpenSearchRequestsRepository) BulkIndexRequests(ctx context.Context, docs []*models.RequestDocument) error {
+	return bulkIndex(ctx, r.client, opensearchstorage.RequestsIndex, docs, func(doc *models.RequestDocument) string { return doc.ID })
+}
+
+// SearchRequests serves the requests feed from the index. Filters, sort order
+// and cursor semantics match RequestsRepository.FindRequestsPaginated.
+func (r *OpenSearchRequestsRepository) SearchRequests(
+	ctx context.Context,
+	input *models.RequestsFeedInput,
+	cursorID string,
+	cursorCreatedAt time.Time,
+	cursorPriorityRank int,
+	cursorSLADueAt *time.Time,
+	limit int,
+) ([]*models.GuestRequest, *models.RequestFacets, error) {
+	searchQuery := buildRequestSearchQuery(input, cursorID, cursorCreatedAt, cursorPriorityRank, cursorSLADueAt, limit)
+	serializedQuery, err := json.Marshal(searchQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	searchResponse, err := opensearchapi.SearchRequest{
+		Index: []string{opensearchstorage.RequestsIndex},
+		Body:  bytes.NewReader(serializedQuery),
+	}.Do(ctx, r.client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("searching requests: %w", err)
+	}
+	defer searchResponse.Body.Close()
+
+	if searchResponse.IsError() {
+		return nil, nil, fmt.Errorf("request search failed: %s", searchResponse.String())
+	}
+
+	var decoded requestSearchResponse
+	if err := json.NewDecoder(searchResponse.Body).Decode(&decoded); err != nil {
+		return nil, nil, fmt.Errorf("decoding search response: %w", err)
+	}
+
+	now := r.now()
+	requests := make([]*models.GuestRequest, 0, len(decoded.Hits.Hits))
+	for _, hit := range decoded.Hits.Hits {
+		req := hit.Source.GuestRequest
+		req.IsOverdue = req.SLADueAt != nil && req.SLADueAt.Before(now) &&
+			req.Status != string(models.StatusCompleted) && req.Status != string(models.StatusArchived)
+		requests = append(requests, &req)
+	}
+
+	return requests, decoded.facets(), nil
+}
+
+type requestSearchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source models.RequestDocument `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]struct {
+		Values struct {
+			Buckets []struct {
+				Key      any `json:"key"`
+				DocCount int `json:"doc_count"`
+				Label    *struct {
+					Buckets []struct {
+						Key string `json:"key"`
+					} `json:"buckets"`
+				} `json:"label,omitempty"`
+			} `json:"buckets"`
+		} `json:"values"`
+	} `json:"aggregations"`
+}
+
+func (res *requestSearchResponse) facets() *models.RequestFacets {
+	counts := func(name string) []models.FacetCount {
+		agg := res.Aggregations[name]
+		out := make([]models.FacetCount, 0, len(agg.Values.Buckets))
+		for _, b := range agg.Values.Buckets {
+			fc := models.FacetCount{Value: facetKey(b.Key), Count: b.DocCount}
+			if b.Label != nil && len(b.Label.Buckets) > 0 {
+				label := b.Label.Buckets[0].Key
+				fc.Label = &label
+			}
+			out = append(out, fc)
+		}
+		return out
+	}
+
+	return &models.RequestFacets{
+		Status:     counts("status"),
+		Priority:   counts("priority"),
+		Department: counts("department"),
+		Floor:      counts("floor"),
+	}
+}
+
+// facetKey renders a terms bucket key; numeric keys (floors) decode as float64.
+func facetKey(key any) string {
+	if f, ok := key.(float64); ok && f == math.Trunc(f) {
+		return fmt.Sprintf("%d", int64(f))
+	}
+	return fmt.Sprint(key)
+}
+
+// buildRequestSearchQuery constructs the feed query. Hotel, assignment and
+// search narrow both hits and facets. The facetable filters (status, priority,
+// department, floor) go in post_filter, and each facet aggregation applies
+// every facetable filter except its own.
+func buildRequestSearchQuery(
+	input *models.RequestsFeedInput,
+	cursorID string,
+	cursorCreatedAt time.Time,
+	cursorPriorityRank int,
+	cursorSLADueAt *time.Time,
+	limit int,
+) map[string]any {
+	filterClauses := []any{
+		map[string]any{"term": map[string]any{"hotel_id": input.HotelID}},
+	}
+	switch {
+	case input.Unassigned:
+		filterClauses = append(filterClauses, map[string]any{
+			"bool": map[string]any{"must_not": map[string]any{"exists": map[string]any{"field": "user_id"}}},
+		})
+	case input.UserID != "":
+		filterClauses = append(filterClauses, map[string]any{"term": map[string]any{"user_id": input.UserID}})
+	}
+
+	boolQuery := map[string]any{
+		"filter":   filterClauses,
+		"must_not": []any{map[string]any{"terms": map[string]any{"status": []string{string(models.StatusArchived), string(models.StatusDraft)}}}},
+	}
+	if input.Search != "" {
+		boolQuery["must"] = []any{map[string]any{
+			"multi_match": map[string]any{
+				"query":     input.Search,
+				"fields":    []string{"name^3", "description", "notes"},
+				"fuzziness": "AUTO",
+			},
+		}}
+	}
+
+	facetFilters := map[string]any{}
+	if input.Status != "" {
+		facetFilters["status"] = map[string]any{"term": map[string]any{"status": input.Status}}
+	}
+	if len(input.Priorities) > 0 {
+		facetFilters["priority"] = map[string]any{"terms": map[string]any{"priority": input.Priorities}}
+	}
+	if len(input.Departments) > 0 {
+		facetFilters["department"] = map[string]any{"terms": map[string]any{"department_id": input.Departments}}
+	}
+	if len(input.Floors) > 0 {
+		facetFilters["floor"] = map[string]any{"terms": map[string]any{"floor": input.Floors}}
+	}
+
+	filtersExcept := func(skip string) []any {
+		out := []any{}
+		for _, name := range []string{"status", "priority", "department", "floor"} {
+			if f, ok := facetFilters[name]; ok && name != skip {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+
+	aggs := map[string]any{}
+	for name, field := range requestFacetFields {
+		terms := map[string]any{"terms": map[string]any{"field": field, "size": requestFacetSize}}
+		if name == "department" {
+			terms["aggs"] = map[string]any{
+				"label": map[string]any{"terms": map[string]any{"field": "department_name", "size": 1}},
+			}
+		}
+		aggs[name] = map[string]any{
+			"filter": map[string]any{"bool": map[string]any{"filter": filtersExcept(name)}},
+			"aggs":   map[string]any{"values": terms},
+		}
+	}
+
+	openSearchQuery := map[string]any{
+		"query":       map[string]any{"bool": boolQuery},
+		"post_filter": map[string]any{"bool": map[string]any{"filter": filtersExcept("")}},
+		"aggs":        aggs,
+		"size":        limit,
+	}
+
+	// Date sort values are epoch milliseconds; ties within a millisecond are
+	// broken by id, so the cursor's truncated timestamp still resumes correctly.
+	switch input.Sort {
+	case models.SortByNewest:
+		openSearchQuery["sort"] = []any{
+			map[string]any{"created_at": "desc"},
+			map[string]any{"id": "desc"},
+		}
+		if cursorID != "" {
+			openSearchQuery["search_after"] = []any{cursorCreatedAt.UnixMilli(), cursorID}
+		}
+	case models.SortByOldest:
+		openSearchQuery["sort"] = []any{
+			map[string]any{"created_at": "asc"},
+			map[string]any{"id": "asc"},
+		}
+		if cursorID != "" {
+			openSearchQuery["search_after"] = []any{cursorCreatedAt.UnixMilli(), cursorID}
+		}
+	case models.SortBySLA:
+		// Requests without a deadline sort last, as they do in Postgres.
+		openSearchQuery["sort"] = []any{
+			map[string]any{"sla_due_at": map[string]any{"order": "asc", "missing": "_last"}},
+			map[string]any{"id": "asc"},
+		}
+		if cursorID != "" {
+			due := int64(math.MaxInt64)
+			if cursorSLADueAt != nil {
+				due = cursorSLADueAt.UnixMilli()
+			}
+			openSearchQuery["search_after"] = []any{due, cursorID}
+		}
+	default: // SortByPriority
+		openSearchQuery["sort"] = []any{
+			map[string]any{"priority_rank": "asc"},
+			map[string]any{"id": "asc"},
+		}
+		if cursorID != "" {
+			openSearchQuery["search_after"] = []any{cursorPriorityRank, cursorID}
+		}
+	}
+
+	return openSearchQuery
+}
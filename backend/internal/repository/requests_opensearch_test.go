@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRequestSearchQuery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("facet aggregations skip their own filter", func(t *testing.T) {
+		t.Parallel()
+
+		query := buildRequestSearchQuery(&models.RequestsFeedInput{
+			HotelID:    "org_1",
+			Priorities: []string{"high"},
+			Floors:     []int{3},
+		}, "", time.Time{}, 0, nil, 21)
+
+		aggs := query["aggs"].(map[string]any)
+		priorityFilters := aggs["priority"].(map[string]any)["filter"].(map[string]any)["bool"].(map[string]any)["filter"].([]any)
+		floorFilters := aggs["floor"].(map[string]any)["filter"].(map[string]any)["bool"].(map[string]any)["filter"].([]any)
+		statusFilters := aggs["status"].(map[string]any)["filter"].(map[string]any)["bool"].(map[string]any)["filter"].([]any)
+
+		assert.Equal(t, []any{map[string]any{"terms": map[string]any{"floor": []int{3}}}}, priorityFilters)
+		assert.Equal(t, []any{map[string]any{"terms": map[string]any{"priority": []string{"high"}}}}, floorFilters)
+		assert.Len(t, statusFilters, 2)
+		assert.Len(t, query["post_filter"].(map[string]any)["bool"].(map[string]any)["filter"], 2)
+		assert.Equal(t, 21, query["size"])
+	})
+
+	t.Run("sla sort resumes after requests without a deadline", func(t *testing.T) {
+		t.Parallel()
+
+		query := buildRequestSearchQuery(&models.RequestsFeedInput{HotelID: "org_1", Sort: models.SortBySLA}, "req-1", time.Time{}, 0, nil, 21)
+		searchAfter := query["search_after"].([]any)
+		assert.Equal(t, int64(1<<63-1), searchAfter[0])
+		assert.Equal(t, "req-1", searchAfter[1])
+	})
+
+	t.Run("priority sort resumes from the cursor rank", func(t *testing.T) {
+		t.Parallel()
+
+		query := buildRequestSearchQuery(&models.RequestsFeedInput{HotelID: "org_1"}, "req-1", time.Time{}, 2, nil, 21)
+		assert.Equal(t, []any{2, "req-1"}, query["search_after"])
+	})
+}
+
+func TestRequestSearchResponse_Facets(t *testing.T) {
+	t.Parallel()
+
+	var res requestSearchResponse
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hits": {"hits": []},
+		"aggregations": {
+			"floor": {"doc_count": 3, "values": {"buckets": [{"key": 3, "doc_count": 2}, {"key": 5, "doc_count": 1}]}},
+			"department": {"doc_count": 2, "values": {"buckets": [
+				{"key": "dept-1", "doc_count": 2, "label": {"buckets": [{"key": "Housekeeping", "doc_count": 2}]}}
+			]}}
+		}
+	}`), &res))
+
+	facets := res.facets()
+	assert.Equal(t, []models.FacetCount{{Value: "3", Count: 2}, {Value: "5", Count: 1}}, facets.Floor)
+	require.Len(t, facets.Department, 1)
+	assert.Equal(t, "Housekeeping", *facets.Department[0].Label)
+	assert.Empty(t, facets.Status)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenedAt(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	// Long past any deadline counted from created.
+	later := created.Add(48 * time.Hour)
+
+	cases := []struct {
+		name   string
+		prev   string
+		status string
+		expect time.Time
+	}{
+		{"reopening a completed request past its deadline restarts it", "completed", "pending", later},
+		{"restoring an archived request restarts it", "archived", "in progress", later},
+		{"working on an open request keeps it", "pending", "in progress", created},
+		{"completing a request keeps it", "in progress", "completed", created},
+		{"archiving a completed request keeps it", "completed", "archived", created},
+		{"approving a draft keeps it", "draft", "pending", created},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expect, openedAt(tc.prev, created, tc.status, later))
+		})
+	}
+}
@@ -51,13 +51,10 @@ func (r *RoomsRepository) FindRoomsWithOptionalGuestBookingsByFloor(ctx context.
 					'low'
 				) AS priority,
 				BOOL_OR(user_id IS NULL) AS has_unassigned_tasks
-			FROM (
-				SELECT DISTINCT ON (id) id, room_id, status, priority, user_id
-				FROM requests
-				WHERE hotel_id = $1 AND room_id IS NOT NULL
-				ORDER BY id, request_version DESC
-			) latest
-			WHERE status NOT IN ('completed', 'archived')
+			FROM requests_current
+			WHERE hotel_id = $1
+			  AND room_id IS NOT NULL
+			  AND status NOT IN ('completed', 'archived', 'draft')
 			GROUP BY room_id
 		),
 		room_enriched AS (
@@ -204,15 +201,14 @@ func (r *RoomsRepository) FindAllFloors(ctx context.Context, hotelID string) ([]
 func (r *RoomsRepository) FindRoomByID(ctx context.Context, hotelID string, id string) (*models.RoomWithOptionalGuestBooking, error) {
 	row := r.db.QueryRow(ctx, `
 		WITH latest_requests AS (
-			SELECT DISTINCT ON (r.id)
+			SELECT
 				r.id,
 				r.room_id,
 				r.user_id,
 				r.priority,
 				r.status
-			FROM public.requests r
+			FROM public.requests_current r
 			WHERE r.hotel_id = $2
-			ORDER BY r.id ASC, r.request_version DESC
 		)
 		SELECT
 			r.id, r.room_number, r.floor, r.suite_type, r.room_status, r.is_accessible,
@@ -276,36 +272,35 @@ func (r *RoomsRepository) InsertRoom(ctx context.Context, hotelID string, roomNu
 	return &room, nil
 }
 
-func (r *RoomsRepository) FindRoomByNumber(ctx context.Context, hotelID string, roomReference string) (*models.Room, error) {
+// FindRoomCandidates scores the hotel's rooms against a normalised room
+// reference: 1 for the exact number, 0.95 for a floor+unit match, 0.5 for a
+// number one digit off, and trigram word similarity for suite names.
+func (r *RoomsRepository) FindRoomCandidates(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, room_number, floor, suite_type, room_status, is_accessible
-		FROM rooms
-		WHERE hotel_id = $1
-			AND room_number::text = $2
-		LIMIT 2`,
-		hotelID, roomReference)
+		SELECT id, label, score
+		FROM (
+			SELECT
+				id,
+				room_number,
+				CASE WHEN suite_type <> '' THEN 'Room ' || room_number || ' (' || suite_type || ')'
+					ELSE 'Room ' || room_number END AS label,
+				GREATEST(
+					CASE WHEN room_number = $2::int THEN 1.0 END,
+					CASE WHEN floor = $3::int AND room_number % 100 = $4::int THEN 0.95 END,
+					CASE WHEN levenshtein(room_number::text, $2::int::text) = 1 THEN 0.5 END,
+					CASE WHEN $5 <> '' THEN word_similarity($5, suite_type) END
+				)::float8 AS score
+			FROM rooms
+			WHERE hotel_id = $1
+		) c
+		WHERE score >= $6
+		ORDER BY score DESC, room_number
+		LIMIT $7`,
+		hotelID, ref.Number, ref.Floor, ref.Unit, ref.Suite, minScore, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var rooms []*models.Room
-	for rows.Next() {
-		var room models.Room
-		if err := rows.Scan(&room.ID, &room.RoomNumber, &room.Floor, &room.SuiteType, &room.RoomStatus, &room.IsAccessible); err != nil {
-			return nil, err
-		}
-		rooms = append(rooms, &room)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	if len(rooms) == 0 {
-		return nil, errs.ErrNotFoundInDB
-	}
-	if len(rooms) > 1 {
-		return nil, errs.ErrAlreadyExistsInDB
-	}
-	return rooms[0], nil
+	return scanLookupCandidates(rows)
 }
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SLARepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSLARepository(db *pgxpool.Pool) *SLARepository {
+	return &SLARepository{db: db}
+}
+
+const slaPolicyColumns = `id, hotel_id, department_id, priority, acknowledge_within_minutes,
+	complete_within_minutes, escalate_to_user_id, created_at, updated_at`
+
+func (r *SLARepository) FindSLAPoliciesByHotelID(ctx context.Context, hotelID string) ([]*models.SLAPolicy, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+slaPolicyColumns+`
+		FROM public.sla_policies
+		WHERE hotel_id = $1
+		ORDER BY department_id NULLS FIRST, priority NULLS FIRST
+	`, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*models.SLAPolicy{}
+	for rows.Next() {
+		p, err := scanSLAPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// InsertSLAPolicy returns errs.ErrAlreadyExistsInDB when the hotel already has
+// a policy for the same department and priority scope.
+func (r *SLARepository) InsertSLAPolicy(ctx context.Context, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO public.sla_policies (
+			hotel_id, department_id, priority, acknowledge_within_minutes,
+			complete_within_minutes, escalate_to_user_id
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+slaPolicyColumns,
+		hotelID, input.DepartmentID, input.Priority, input.AcknowledgeWithinMinutes,
+		input.CompleteWithinMinutes, input.EscalateToUserID)
+
+	return mapSLAPolicyWriteErr(scanSLAPolicy(row))
+}
+
+func (r *SLARepository) UpdateSLAPolicy(ctx context.Context, id, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE public.sla_policies
+		SET department_id = $3,
+		    priority = $4,
+		    acknowledge_within_minutes = $5,
+		    complete_within_minutes = $6,
+		    escalate_to_user_id = $7,
+		    updated_at = NOW()
+		WHERE id = $1 AND hotel_id = $2
+		RETURNING `+slaPolicyColumns,
+		id, hotelID, input.DepartmentID, input.Priority, input.AcknowledgeWithinMinutes,
+		input.CompleteWithinMinutes, input.EscalateToUserID)
+
+	return mapSLAPolicyWriteErr(scanSLAPolicy(row))
+}
+
+func (r *SLARepository) DeleteSLAPolicy(ctx context.Context, id, hotelID string) error {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM public.sla_policies
+		WHERE id = $1 AND hotel_id = $2
+	`, id, hotelID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFoundInDB
+	}
+	return nil
+}
+
+// FindSLABreaches returns open requests whose acknowledge or completion
+// deadline passed at or before now and that have not been escalated for that
+// deadline since they were last opened, oldest deadline first.
+func (r *SLARepository) FindSLABreaches(ctx context.Context, now time.Time, limit int) ([]*models.SLABreach, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH latest AS (
+			SELECT
+				r.id, r.hotel_id, r.name, r.priority, r.status, r.user_id, r.opened_at,
+				sla.acknowledge_within_minutes, sla.escalate_to_user_id,
+				`+slaDueAtColumn+`
+			FROM public.requests_current r
+			`+slaPolicyJoin+`
+		), due AS (
+			SELECT id, hotel_id, name, priority, user_id, opened_at, 'acknowledge' AS kind,
+			       opened_at + make_interval(mins => acknowledge_within_minutes) AS due_at,
+			       escalate_to_user_id
+			FROM latest
+			WHERE status = 'pending' AND acknowledge_within_minutes IS NOT NULL
+			UNION ALL
+			SELECT id, hotel_id, name, priority, user_id, opened_at, 'complete' AS kind,
+			       sla_due_at AS due_at, escalate_to_user_id
+			FROM latest
+			WHERE status NOT IN ('completed', 'archived', 'draft') AND sla_due_at IS NOT NULL
+		)
+		SELECT d.id, d.hotel_id, d.name, d.priority, d.user_id, d.kind, d.due_at, d.opened_at, d.escalate_to_user_id
+		FROM due d
+		WHERE d.due_at <= $1
+		  AND NOT EXISTS (
+		    SELECT 1 FROM public.request_sla_breaches b
+		    WHERE b.request_id = d.id AND b.kind = d.kind AND b.opened_at = d.opened_at
+		  )
+		ORDER BY d.due_at ASC
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breaches []*models.SLABreach
+	for rows.Next() {
+		var b models.SLABreach
+		if err := rows.Scan(&b.RequestID, &b.HotelID, &b.Name, &b.Priority, &b.UserID,
+			&b.Kind, &b.DueAt, &b.OpenedAt, &b.EscalateToUserID); err != nil {
+			return nil, err
+		}
+		breaches = append(breaches, &b)
+	}
+	return breaches, rows.Err()
+}
+
+// RecordSLABreach marks the breach as escalated. It returns false when another
+// evaluator already recorded it, in which case the caller must not escalate.
+func (r *SLARepository) RecordSLABreach(ctx context.Context, breach *models.SLABreach) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO public.request_sla_breaches (request_id, kind, due_at, opened_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (request_id, kind, opened_at) DO NOTHING
+	`, breach.RequestID, breach.Kind, breach.DueAt, breach.OpenedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func scanSLAPolicy(row pgx.Row) (*models.SLAPolicy, error) {
+	var p models.SLAPolicy
+	if err := row.Scan(&p.ID, &p.HotelID, &p.DepartmentID, &p.Priority, &p.AcknowledgeWithinMinutes,
+		&p.CompleteWithinMinutes, &p.EscalateToUserID, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFoundInDB
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func mapSLAPolicyWriteErr(p *models.SLAPolicy, err error) (*models.SLAPolicy, error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, errs.ErrAlreadyExistsInDB
+	}
+	return p, err
+}
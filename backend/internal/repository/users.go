@@ -328,27 +328,35 @@ func (r *UsersRepository) CompleteOnboarding(ctx context.Context, id string, dat
 	return &user, nil
 }
 
-func (r *UsersRepository) FindUserByName(ctx context.Context, hotelID, name string) ([]string, error) {
+// FindUserCandidates scores the hotel's staff the same way as
+// GuestsRepository.FindGuestCandidates, so "Johnny" still finds John Smith.
+func (r *UsersRepository) FindUserCandidates(ctx context.Context, hotelID, name string, minScore float64, limit int) ([]*models.LookupCandidate, error) {
+	first, last := nameTokens(name)
 	rows, err := r.db.Query(ctx, `
-		SELECT DISTINCT u.id
-		FROM users u
-		WHERE u.hotel_id = $1
-		  AND CONCAT_WS(' ', u.first_name, u.last_name) ILIKE '%' || $2 || '%'
-	`, hotelID, name)
+		SELECT id, label, score
+		FROM (
+			SELECT
+				u.id,
+				CONCAT_WS(' ', u.first_name, u.last_name) AS label,
+				GREATEST(
+					similarity(CONCAT_WS(' ', u.first_name, u.last_name), $2),
+					word_similarity($2, CONCAT_WS(' ', u.first_name, u.last_name)),
+					CASE WHEN dmetaphone(u.first_name) = dmetaphone($3)
+						OR dmetaphone(u.last_name) = dmetaphone($4) THEN 0.6 END
+				)::float8 AS score
+			FROM users u
+			WHERE u.hotel_id = $1
+		) c
+		WHERE score >= $5
+		ORDER BY score DESC, label
+		LIMIT $6
+	`, hotelID, name, first, last, minScore, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var ids []string
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		ids = append(ids, id)
-	}
-	return ids, rows.Err()
+	return scanLookupCandidates(rows)
 }
 
 func (r *UsersRepository) BulkInsertUsers(ctx context.Context, users []*models.CreateUser) error {
@@ -0,0 +1,127 @@
+package generatequeue
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/generate/selfserve/internal/utils"
+)
+
+// queueCursorPrefix marks a listing cursor as one of the queue's, once the
+// generations run on Temporal have all been listed.
+const queueCursorPrefix = "queue:"
+
+// Fallback runs generations on Temporal, queueing those started while
+// Temporal cannot be reached. Jobs are told apart by their ID, so they can be
+// looked up, cancelled and amended once Temporal is back.
+type Fallback struct {
+	temporal temporalclient.GenerateRequestWorkflowClient
+	queue    *Queue
+}
+
+var _ temporalclient.GenerateRequestWorkflowClient = (*Fallback)(nil)
+
+func NewFallback(temporal temporalclient.GenerateRequestWorkflowClient, queue *Queue) *Fallback {
+	return &Fallback{temporal: temporal, queue: queue}
+}
+
+func (f *Fallback) StartGenerateRequest(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (string, error) {
+	workflowID, err := f.temporal.StartGenerateRequest(ctx, input)
+	if temporalclient.IsUnavailable(err) {
+		slog.Warn("generatequeue: Temporal unavailable, queueing generation", "err", err, "hotel_id", input.HotelID)
+		return f.queue.StartGenerateRequest(ctx, input)
+	}
+	return workflowID, err
+}
+
+func (f *Fallback) GetGenerateRequestResult(ctx context.Context, workflowID string) (temporalclient.GenerateRequestResult, error) {
+	if isJobID(workflowID) {
+		return f.queue.GetGenerateRequestResult(ctx, workflowID)
+	}
+	result, err := f.temporal.GetGenerateRequestResult(ctx, workflowID)
+	if temporalclient.IsWorkflowNotFound(err) {
+		return f.queue.GetGenerateRequestResult(ctx, workflowID)
+	}
+	return result, err
+}
+
+func (f *Fallback) CancelGenerateRequest(ctx context.Context, hotelID, workflowID string) error {
+	if isJobID(workflowID) {
+		return f.queue.CancelGenerateRequest(ctx, hotelID, workflowID)
+	}
+	err := f.temporal.CancelGenerateRequest(ctx, hotelID, workflowID)
+	if temporalclient.IsWorkflowNotFound(err) {
+		return f.queue.CancelGenerateRequest(ctx, hotelID, workflowID)
+	}
+	return err
+}
+
+func (f *Fallback) AmendGenerateRequest(ctx context.Context, hotelID, workflowID, rawText string) error {
+	if isJobID(workflowID) {
+		return f.queue.AmendGenerateRequest(ctx, hotelID, workflowID, rawText)
+	}
+	err := f.temporal.AmendGenerateRequest(ctx, hotelID, workflowID, rawText)
+	if temporalclient.IsWorkflowNotFound(err) {
+		return f.queue.AmendGenerateRequest(ctx, hotelID, workflowID, rawText)
+	}
+	return err
+}
+
+// ListGenerateRequests lists the generations run on Temporal, most recent
+// first, followed by the queued ones, most recent first. While Temporal cannot
+// be reached, only the queued ones are listed.
+func (f *Fallback) ListGenerateRequests(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+	if cursor, ok := strings.CutPrefix(filter.Cursor, queueCursorPrefix); ok {
+		filter.Cursor = cursor
+		return f.listQueue(ctx, filter)
+	}
+
+	page, err := f.temporal.ListGenerateRequests(ctx, filter)
+	if temporalclient.IsUnavailable(err) {
+		slog.Warn("generatequeue: Temporal unavailable, listing queued generations only", "err", err, "hotel_id", filter.HotelID)
+		// A Temporal cursor means nothing to the queue, so start from its top.
+		filter.Cursor = ""
+		return f.listQueue(ctx, filter)
+	}
+	if err != nil || page.HasMore {
+		return page, err
+	}
+
+	// Fill the rest of the last page with the first of the queued ones.
+	limit := utils.ResolveLimit(filter.Limit)
+	filter.Cursor = ""
+	filter.Limit = max(limit-len(page.Items), 1)
+	queued, err := f.listQueue(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+	if len(page.Items) == limit {
+		if len(queued.Items) > 0 {
+			cursor := queueCursorPrefix
+			page.NextCursor, page.HasMore = &cursor, true
+		}
+		return page, nil
+	}
+	page.Items = append(page.Items, queued.Items...)
+	page.NextCursor, page.HasMore = queued.NextCursor, queued.HasMore
+	return page, nil
+}
+
+func (f *Fallback) listQueue(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+	page, err := f.queue.ListGenerateRequests(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+	if page.NextCursor != nil {
+		cursor := queueCursorPrefix + *page.NextCursor
+		page.NextCursor = &cursor
+	}
+	return page, nil
+}
+
+func isJobID(workflowID string) bool {
+	return strings.HasPrefix(workflowID, JobIDPrefix)
+}
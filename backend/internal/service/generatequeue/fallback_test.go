@@ -0,0 +1,183 @@
+package generatequeue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/generate/selfserve/internal/models"
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+)
+
+// fakeTemporal keeps workflows by ID, failing every call with err when set.
+type fakeTemporal struct {
+	workflows []string
+	cancelled []string
+	err       error
+}
+
+func (f *fakeTemporal) StartGenerateRequest(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	id := fmt.Sprintf("generate-request-%d", len(f.workflows))
+	f.workflows = append(f.workflows, id)
+	return id, nil
+}
+
+func (f *fakeTemporal) find(workflowID string) error {
+	for _, id := range f.workflows {
+		if id == workflowID {
+			return nil
+		}
+	}
+	return serviceerror.NewNotFound("workflow not found")
+}
+
+func (f *fakeTemporal) GetGenerateRequestResult(ctx context.Context, workflowID string) (temporalclient.GenerateRequestResult, error) {
+	if err := f.find(workflowID); err != nil {
+		return temporalclient.GenerateRequestResult{}, err
+	}
+	return temporalclient.GenerateRequestResult{Status: "pending"}, nil
+}
+
+func (f *fakeTemporal) CancelGenerateRequest(ctx context.Context, hotelID, workflowID string) error {
+	if err := f.find(workflowID); err != nil {
+		return err
+	}
+	f.cancelled = append(f.cancelled, workflowID)
+	return nil
+}
+
+func (f *fakeTemporal) AmendGenerateRequest(ctx context.Context, hotelID, workflowID, rawText string) error {
+	return f.find(workflowID)
+}
+
+// ListGenerateRequests pages through the workflows, most recent first, with
+// the index to continue from as the cursor.
+func (f *fakeTemporal) ListGenerateRequests(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+	if f.err != nil {
+		return utils.CursorPage[temporalclient.GenerateRequestExecution]{}, f.err
+	}
+	var from int
+	if filter.Cursor != "" {
+		_, _ = fmt.Sscan(filter.Cursor, &from)
+	}
+	var page utils.CursorPage[temporalclient.GenerateRequestExecution]
+	for i := len(f.workflows) - 1 - from; i >= 0 && len(page.Items) < filter.Limit; i-- {
+		page.Items = append(page.Items, temporalclient.GenerateRequestExecution{WorkflowID: f.workflows[i], Status: "pending", HotelID: hotelID})
+	}
+	if next := from + len(page.Items); next < len(f.workflows) {
+		cursor := fmt.Sprint(next)
+		page.NextCursor, page.HasMore = &cursor, true
+	}
+	return page, nil
+}
+
+func newTestFallback() (*Fallback, *fakeTemporal, *Queue) {
+	temporal := &fakeTemporal{}
+	q, _, _ := newTestQueue(&fakeSteps{})
+	return NewFallback(temporal, q), temporal, q
+}
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	input := workflows.GenerateRequestWorkflowInput{}
+	input.RawText, input.HotelID = "need towels", hotelID
+
+	t.Run("queues generations while Temporal is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		f, temporal, q := newTestFallback()
+		onTemporal, err := f.StartGenerateRequest(ctx, input)
+		require.NoError(t, err)
+
+		temporal.err = serviceerror.NewUnavailable("connection refused")
+		queued, err := f.StartGenerateRequest(ctx, input)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(queued, JobIDPrefix))
+
+		temporal.err = serviceerror.NewInvalidArgument("bad input")
+		_, err = f.StartGenerateRequest(ctx, input)
+		assert.Error(t, err, "only an unavailable Temporal is fallen back from")
+
+		temporal.err = nil
+		res, err := f.GetGenerateRequestResult(ctx, queued)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", res.Status)
+		require.NoError(t, f.CancelGenerateRequest(ctx, hotelID, queued))
+		assert.Equal(t, "cancelled", result(t, q, queued).Status)
+		assert.Empty(t, temporal.cancelled)
+
+		require.NoError(t, f.CancelGenerateRequest(ctx, hotelID, onTemporal))
+		assert.Equal(t, []string{onTemporal}, temporal.cancelled)
+	})
+
+	t.Run("finds jobs queued under workflow IDs", func(t *testing.T) {
+		t.Parallel()
+
+		f, _, q := newTestFallback()
+		_, err := q.repo.InsertGenerateRequestJob(ctx, &models.GenerateRequestJob{ID: "generate-request-legacy", HotelID: hotelID, RawText: "need towels"})
+		require.NoError(t, err)
+
+		require.NoError(t, f.AmendGenerateRequest(ctx, hotelID, "generate-request-legacy", "need soap"))
+		assert.True(t, temporalclient.IsWorkflowNotFound(f.AmendGenerateRequest(ctx, hotelID, "generate-request-unknown", "need soap")))
+	})
+
+	t.Run("lists queued generations after Temporal's", func(t *testing.T) {
+		t.Parallel()
+
+		f, temporal, q := newTestFallback()
+		for range 3 {
+			_, err := f.StartGenerateRequest(ctx, input)
+			require.NoError(t, err)
+		}
+		first := start(t, q, "need soap")
+		second := start(t, q, "need pillows")
+		assert.Len(t, temporal.workflows, 3)
+
+		var listed []string
+		filter := temporalclient.GenerateRequestFilter{HotelID: hotelID, Limit: 2}
+		for range 5 {
+			page, err := f.ListGenerateRequests(ctx, filter)
+			require.NoError(t, err)
+			for _, item := range page.Items {
+				listed = append(listed, item.WorkflowID)
+			}
+			if !page.HasMore {
+				break
+			}
+			filter.Cursor = *page.NextCursor
+		}
+
+		assert.Equal(t, []string{"generate-request-2", "generate-request-1", "generate-request-0", second, first}, listed)
+	})
+
+	t.Run("lists queued generations while Temporal is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		f, temporal, q := newTestFallback()
+		_, err := f.StartGenerateRequest(ctx, input)
+		require.NoError(t, err)
+		queued := start(t, q, "need soap")
+
+		temporal.err = serviceerror.NewUnavailable("connection refused")
+		page, err := f.ListGenerateRequests(ctx, temporalclient.GenerateRequestFilter{HotelID: hotelID, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, queued, page.Items[0].WorkflowID)
+		assert.False(t, page.HasMore)
+
+		temporal.err = serviceerror.NewInvalidArgument("bad query")
+		_, err = f.ListGenerateRequests(ctx, temporalclient.GenerateRequestFilter{HotelID: hotelID, Limit: 2})
+		assert.Error(t, err, "only an unavailable Temporal is fallen back from")
+	})
+}
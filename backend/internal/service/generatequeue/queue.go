@@ -0,0 +1,361 @@
+// Package generatequeue runs async request generation in-process, for when
+// Temporal cannot be reached. Jobs are kept in Postgres, so a restart picks up
+// where the previous process stopped.
+package generatequeue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/generate/selfserve/internal/utils"
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	// DefaultInterval is how often Run polls for due jobs when it is not
+	// woken by a new one.
+	DefaultInterval = 2 * time.Second
+	// lease is how long a claimed job stays locked. A job whose worker has not
+	// finished it by then is claimed again, so it must outlast a generation.
+	lease = 5 * time.Minute
+	// maxAttempts and retryInterval mirror the generate workflow's retry
+	// policy: three attempts, two seconds apart and doubling.
+	maxAttempts   = 3
+	retryInterval = 2 * time.Second
+)
+
+// JobIDPrefix starts the IDs of jobs, telling them apart from the IDs of
+// generate workflows run on Temporal. Jobs queued before it was introduced
+// have workflow IDs.
+const JobIDPrefix = "generate-job-"
+
+// Steps are the steps of a generation; *activities.Activities implements
+// them, so a job does what the generate workflow does.
+type Steps interface {
+	RunGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error)
+	PersistGeneratedRequest(ctx context.Context, input workflows.PersistGeneratedRequestInput) (*models.Request, error)
+	NotifyRequestAssignee(ctx context.Context, req models.Request) error
+	PublishRequestCreated(ctx context.Context, hotelID, requestID string) error
+}
+
+// Queue implements temporal.GenerateRequestWorkflowClient with jobs run by
+// Run, with the same statuses as the generate workflow. Jobs can be cancelled
+// or amended until their request is generated; a generation already under way
+// when a job is amended finishes and is discarded.
+type Queue struct {
+	repo     storage.GenerateRequestJobsRepository
+	steps    Steps
+	Interval time.Duration
+	wake     chan struct{}
+	now      func() time.Time
+}
+
+var _ temporalclient.GenerateRequestWorkflowClient = (*Queue)(nil)
+
+func NewQueue(repo storage.GenerateRequestJobsRepository, steps Steps) *Queue {
+	return &Queue{
+		repo:     repo,
+		steps:    steps,
+		Interval: DefaultInterval,
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+func (q *Queue) StartGenerateRequest(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (string, error) {
+	job, err := q.repo.InsertGenerateRequestJob(ctx, &models.GenerateRequestJob{
+		ID:          JobIDPrefix + uuid.NewString(),
+		HotelID:     input.HotelID,
+		RequestedBy: input.RequestedBy,
+		RawText:     input.RawText,
+	})
+	if err != nil {
+		return "", err
+	}
+	q.notify()
+	return job.ID, nil
+}
+
+func (q *Queue) GetGenerateRequestResult(ctx context.Context, workflowID string) (temporalclient.GenerateRequestResult, error) {
+	job, err := q.findJob(ctx, workflowID)
+	if err != nil {
+		return temporalclient.GenerateRequestResult{}, err
+	}
+
+	switch job.Status {
+	case models.JobCompleted:
+		var output aiflows.EnrichedGenerateRequestOutput
+		if err := json.Unmarshal(job.Output, &output); err != nil {
+			return temporalclient.GenerateRequestResult{}, fmt.Errorf("decode generated request: %w", err)
+		}
+		return temporalclient.GenerateRequestResult{Status: "completed", RequestID: job.RequestID, Output: &output}, nil
+	case models.JobCancelled:
+		return temporalclient.GenerateRequestResult{Status: "cancelled"}, nil
+	case models.JobFailed:
+		msg := "workflow failed"
+		if job.Error != nil {
+			msg = *job.Error
+		}
+		return temporalclient.GenerateRequestResult{Status: "failed", Error: &msg}, nil
+	default:
+		return temporalclient.GenerateRequestResult{Status: "pending"}, nil
+	}
+}
+
+func (q *Queue) CancelGenerateRequest(ctx context.Context, hotelID, workflowID string) error {
+	if err := q.ensureHotel(ctx, hotelID, workflowID); err != nil {
+		return err
+	}
+	cancelled, err := q.repo.CancelGenerateRequestJob(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return q.finishedOrNotFound(ctx, workflowID)
+	}
+	return nil
+}
+
+func (q *Queue) AmendGenerateRequest(ctx context.Context, hotelID, workflowID, rawText string) error {
+	if err := q.ensureHotel(ctx, hotelID, workflowID); err != nil {
+		return err
+	}
+	amended, err := q.repo.AmendGenerateRequestJob(ctx, workflowID, rawText)
+	if err != nil {
+		return err
+	}
+	if !amended {
+		return q.finishedOrNotFound(ctx, workflowID)
+	}
+	q.notify()
+	return nil
+}
+
+func (q *Queue) ListGenerateRequests(ctx context.Context, filter temporalclient.GenerateRequestFilter) (utils.CursorPage[temporalclient.GenerateRequestExecution], error) {
+	cursorID, cursorCreatedAt, err := parseCursor(filter.Cursor)
+	if err != nil {
+		return utils.CursorPage[temporalclient.GenerateRequestExecution]{}, temporalclient.ErrInvalidCursor
+	}
+
+	limit := utils.ResolveLimit(filter.Limit)
+	jobs, err := q.repo.FindGenerateRequestJobs(ctx, filter.HotelID, filter.RequestedBy, cursorCreatedAt, cursorID, limit+1)
+	if err != nil {
+		return utils.CursorPage[temporalclient.GenerateRequestExecution]{}, err
+	}
+
+	page := utils.BuildCursorPage(jobs, limit, func(job *models.GenerateRequestJob) string {
+		return job.ID + "|" + job.CreatedAt.UTC().Format(time.RFC3339Nano)
+	})
+	executions := make([]temporalclient.GenerateRequestExecution, 0, len(page.Items))
+	for _, job := range page.Items {
+		executions = append(executions, temporalclient.GenerateRequestExecution{
+			WorkflowID:  job.ID,
+			Status:      executionStatus(job.Status),
+			HotelID:     job.HotelID,
+			RequestedBy: job.RequestedBy,
+			StartedAt:   job.CreatedAt,
+			ClosedAt:    job.FinishedAt,
+		})
+	}
+	return utils.CursorPage[temporalclient.GenerateRequestExecution]{
+		Items:      executions,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}, nil
+}
+
+// Run works through due jobs every Interval, or as soon as one is started,
+// until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := q.Drain(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("generatequeue: failed to claim job", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// Drain runs jobs until none is due. A job that fails is retried or failed;
+// only a failure to claim stops it.
+func (q *Queue) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := q.repo.ClaimGenerateRequestJob(ctx, q.now(), lease)
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		q.process(ctx, job)
+	}
+	return nil
+}
+
+func (q *Queue) process(ctx context.Context, job *models.GenerateRequestJob) {
+	var output aiflows.EnrichedGenerateRequestOutput
+	if job.Status == models.JobGenerating {
+		generated, err := q.steps.RunGenerateRequest(ctx, aiflows.GenerateRequestInput{RawText: job.RawText, HotelID: job.HotelID})
+		if err != nil {
+			q.retryOrFail(ctx, job, err)
+			return
+		}
+		encoded, err := json.Marshal(generated)
+		if err != nil {
+			q.retryOrFail(ctx, job, err)
+			return
+		}
+		stored, err := q.repo.MarkGenerateRequestJobGenerated(ctx, job.ID, job.Revision, encoded)
+		if err != nil {
+			q.retryOrFail(ctx, job, err)
+			return
+		}
+		if !stored {
+			// Cancelled, or amended while generating: release the job so an
+			// amended one is generated again straight away.
+			if err := q.repo.RetryGenerateRequestJob(ctx, job.ID, "", q.now()); err != nil {
+				slog.Error("generatequeue: failed to release job", "err", err, "job_id", job.ID)
+			}
+			return
+		}
+		output = generated
+	} else if err := json.Unmarshal(job.Output, &output); err != nil {
+		q.fail(ctx, job, fmt.Errorf("decode generated request: %w", err))
+		return
+	}
+
+	req, err := q.steps.PersistGeneratedRequest(ctx, workflows.PersistGeneratedRequestInput{
+		WorkflowID:  job.ID,
+		HotelID:     job.HotelID,
+		RawText:     job.RawText,
+		RequestedBy: job.RequestedBy,
+		Output:      output,
+	})
+	if err != nil {
+		q.retryOrFail(ctx, job, err)
+		return
+	}
+
+	// A draft is announced once a reviewer approves it.
+	if req.Status != string(models.StatusDraft) {
+		if req.UserID != nil {
+			if err := q.steps.NotifyRequestAssignee(ctx, *req); err != nil {
+				slog.Warn("generatequeue: failed to notify the assignee of a generated request", "err", err, "request_id", req.ID)
+			}
+		}
+		if err := q.steps.PublishRequestCreated(ctx, req.HotelID, req.ID); err != nil {
+			slog.Warn("generatequeue: failed to publish a generated request", "err", err, "request_id", req.ID)
+		}
+	}
+
+	if err := q.repo.CompleteGenerateRequestJob(ctx, job.ID, req.ID); err != nil {
+		// The request is stored; once the lease expires the job is claimed
+		// again, finds it and completes.
+		slog.Error("generatequeue: failed to complete job", "err", err, "job_id", job.ID)
+	}
+}
+
+// retryOrFail schedules another attempt with backoff, or fails the job once it
+// is out of attempts or the error says retrying cannot help.
+func (q *Queue) retryOrFail(ctx context.Context, job *models.GenerateRequestJob, cause error) {
+	var appErr *temporal.ApplicationError
+	if job.Attempts >= maxAttempts || (errors.As(cause, &appErr) && appErr.NonRetryable()) {
+		q.fail(ctx, job, cause)
+		return
+	}
+
+	backoff := retryInterval << (job.Attempts - 1)
+	if err := q.repo.RetryGenerateRequestJob(ctx, job.ID, cause.Error(), q.now().Add(backoff)); err != nil {
+		slog.Error("generatequeue: failed to schedule retry", "err", err, "job_id", job.ID)
+	}
+}
+
+func (q *Queue) fail(ctx context.Context, job *models.GenerateRequestJob, cause error) {
+	slog.Error("generatequeue: job failed", "err", cause, "job_id", job.ID, "attempts", job.Attempts)
+	if err := q.repo.FailGenerateRequestJob(ctx, job.ID, cause.Error()); err != nil {
+		slog.Error("generatequeue: failed to record job failure", "err", err, "job_id", job.ID)
+	}
+}
+
+// ensureHotel returns ErrWorkflowNotFound unless the job generates a request
+// for the hotel.
+func (q *Queue) ensureHotel(ctx context.Context, hotelID, workflowID string) error {
+	job, err := q.findJob(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+	if job.HotelID != hotelID {
+		return temporalclient.ErrWorkflowNotFound
+	}
+	return nil
+}
+
+// finishedOrNotFound explains why a job could not be cancelled or amended.
+func (q *Queue) finishedOrNotFound(ctx context.Context, workflowID string) error {
+	if _, err := q.findJob(ctx, workflowID); err != nil {
+		return err
+	}
+	return temporalclient.ErrGenerationFinished
+}
+
+func (q *Queue) findJob(ctx context.Context, workflowID string) (*models.GenerateRequestJob, error) {
+	job, err := q.repo.FindGenerateRequestJob(ctx, workflowID)
+	if errors.Is(err, errs.ErrNotFoundInDB) {
+		return nil, temporalclient.ErrWorkflowNotFound
+	}
+	return job, err
+}
+
+// notify wakes Run without blocking when it is already due to wake.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// executionStatus names a job status as the generate workflow's statuses are
+// named.
+func executionStatus(status models.GenerateRequestJobStatus) string {
+	switch status {
+	case models.JobCompleted, models.JobFailed, models.JobCancelled:
+		return string(status)
+	default:
+		return "pending"
+	}
+}
+
+func parseCursor(cursor string) (id string, createdAt time.Time, err error) {
+	if cursor == "" {
+		return "", time.Time{}, nil
+	}
+	parts := strings.SplitN(cursor, "|", 2)
+	if len(parts) != 2 {
+		return "", time.Time{}, errors.New("invalid cursor")
+	}
+	createdAt, err = time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return "", time.Time{}, errors.New("invalid cursor")
+	}
+	return parts[0], createdAt, nil
+}
@@ -0,0 +1,417 @@
+package generatequeue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	temporalclient "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+)
+
+// memoryJobs keeps jobs in memory with the semantics of the Postgres queries.
+type memoryJobs struct {
+	jobs        map[string]*models.GenerateRequestJob
+	runAfter    map[string]time.Time
+	lockedUntil map[string]time.Time
+	created     int
+}
+
+func newMemoryJobs() *memoryJobs {
+	return &memoryJobs{
+		jobs:        map[string]*models.GenerateRequestJob{},
+		runAfter:    map[string]time.Time{},
+		lockedUntil: map[string]time.Time{},
+	}
+}
+
+func (m *memoryJobs) InsertGenerateRequestJob(ctx context.Context, job *models.GenerateRequestJob) (*models.GenerateRequestJob, error) {
+	m.created++
+	stored := *job
+	stored.Status = models.JobPending
+	stored.CreatedAt = time.Date(2026, 5, 1, 9, 0, m.created, 0, time.UTC)
+	m.jobs[job.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *memoryJobs) FindGenerateRequestJob(ctx context.Context, id string) (*models.GenerateRequestJob, error) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, errs.ErrNotFoundInDB
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *memoryJobs) FindGenerateRequestJobs(ctx context.Context, hotelID, requestedBy string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.GenerateRequestJob, error) {
+	var jobs []*models.GenerateRequestJob
+	for _, job := range m.jobs {
+		if job.HotelID != hotelID || (requestedBy != "" && (job.RequestedBy == nil || *job.RequestedBy != requestedBy)) {
+			continue
+		}
+		if cursorID != "" && !job.CreatedAt.Before(cursorCreatedAt) {
+			continue
+		}
+		copied := *job
+		jobs = append(jobs, &copied)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (m *memoryJobs) ClaimGenerateRequestJob(ctx context.Context, now time.Time, lease time.Duration) (*models.GenerateRequestJob, error) {
+	var due *models.GenerateRequestJob
+	for _, job := range m.jobs {
+		if !job.Open() || m.runAfter[job.ID].After(now) || m.lockedUntil[job.ID].After(now) {
+			continue
+		}
+		if due == nil || job.CreatedAt.Before(due.CreatedAt) {
+			due = job
+		}
+	}
+	if due == nil {
+		return nil, errs.ErrNotFoundInDB
+	}
+	if due.Status != models.JobStoring {
+		due.Status = models.JobGenerating
+	}
+	due.Attempts++
+	m.lockedUntil[due.ID] = now.Add(lease)
+	copied := *due
+	return &copied, nil
+}
+
+func (m *memoryJobs) MarkGenerateRequestJobGenerated(ctx context.Context, id string, revision int, output json.RawMessage) (bool, error) {
+	job := m.jobs[id]
+	if job.Revision != revision || job.Status != models.JobGenerating {
+		return false, nil
+	}
+	job.Status = models.JobStoring
+	job.Output = output
+	return true, nil
+}
+
+func (m *memoryJobs) CompleteGenerateRequestJob(ctx context.Context, id, requestID string) error {
+	job := m.jobs[id]
+	job.Status = models.JobCompleted
+	job.RequestID = &requestID
+	job.Error = nil
+	delete(m.lockedUntil, id)
+	return nil
+}
+
+func (m *memoryJobs) RetryGenerateRequestJob(ctx context.Context, id, reason string, runAfter time.Time) error {
+	job := m.jobs[id]
+	job.Error = nil
+	if reason != "" {
+		job.Error = &reason
+	}
+	m.runAfter[id] = runAfter
+	delete(m.lockedUntil, id)
+	return nil
+}
+
+func (m *memoryJobs) FailGenerateRequestJob(ctx context.Context, id, reason string) error {
+	job := m.jobs[id]
+	if job.Open() {
+		job.Status = models.JobFailed
+		job.Error = &reason
+	}
+	delete(m.lockedUntil, id)
+	return nil
+}
+
+func (m *memoryJobs) CancelGenerateRequestJob(ctx context.Context, id string) (bool, error) {
+	job, ok := m.jobs[id]
+	if !ok || (job.Status != models.JobPending && job.Status != models.JobGenerating) {
+		return false, nil
+	}
+	job.Status = models.JobCancelled
+	delete(m.lockedUntil, id)
+	return true, nil
+}
+
+func (m *memoryJobs) AmendGenerateRequestJob(ctx context.Context, id, rawText string) (bool, error) {
+	job, ok := m.jobs[id]
+	if !ok || (job.Status != models.JobPending && job.Status != models.JobGenerating) {
+		return false, nil
+	}
+	job.RawText = rawText
+	job.Revision++
+	job.Attempts = 0
+	job.Error = nil
+	delete(m.runAfter, id)
+	return true, nil
+}
+
+// fakeSteps generates a request named after the raw text, or fails with
+// generateErr. onGenerate runs during each generation. The request is stored
+// with status, or pending when it is empty.
+type fakeSteps struct {
+	generated   []string
+	generateErr error
+	onGenerate  func()
+	persisted   []workflows.PersistGeneratedRequestInput
+	assignee    *string
+	status      models.RequestStatus
+	notified    int
+	published   int
+}
+
+func (f *fakeSteps) RunGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+	f.generated = append(f.generated, input.RawText)
+	if f.onGenerate != nil {
+		f.onGenerate()
+	}
+	if f.generateErr != nil {
+		return aiflows.EnrichedGenerateRequestOutput{}, f.generateErr
+	}
+	return aiflows.EnrichedGenerateRequestOutput{GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: input.RawText}}, nil
+}
+
+func (f *fakeSteps) PersistGeneratedRequest(ctx context.Context, input workflows.PersistGeneratedRequestInput) (*models.Request, error) {
+	f.persisted = append(f.persisted, input)
+	status := f.status
+	if status == "" {
+		status = models.StatusPending
+	}
+	return &models.Request{ID: "request-1", MakeRequest: models.MakeRequest{
+		HotelID: input.HotelID, Name: input.Output.Name, Status: string(status), UserID: f.assignee,
+	}}, nil
+}
+
+func (f *fakeSteps) NotifyRequestAssignee(ctx context.Context, req models.Request) error {
+	f.notified++
+	return nil
+}
+
+func (f *fakeSteps) PublishRequestCreated(ctx context.Context, hotelID, requestID string) error {
+	f.published++
+	return nil
+}
+
+const hotelID = "org_1"
+
+func newTestQueue(steps *fakeSteps) (*Queue, *memoryJobs, *time.Time) {
+	repo := newMemoryJobs()
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	q := NewQueue(repo, steps)
+	q.now = func() time.Time { return now }
+	return q, repo, &now
+}
+
+func start(t *testing.T, q *Queue, rawText string) string {
+	t.Helper()
+
+	requestedBy := "user_1"
+	id, err := q.StartGenerateRequest(context.Background(), workflows.GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: rawText, HotelID: hotelID},
+		RequestedBy:          &requestedBy,
+	})
+	require.NoError(t, err)
+	return id
+}
+
+func result(t *testing.T, q *Queue, id string) temporalclient.GenerateRequestResult {
+	t.Helper()
+
+	res, err := q.GetGenerateRequestResult(context.Background(), id)
+	require.NoError(t, err)
+	return res
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("generates, stores and announces a request", func(t *testing.T) {
+		t.Parallel()
+
+		assignee := "user_2"
+		steps := &fakeSteps{assignee: &assignee}
+		q, _, _ := newTestQueue(steps)
+		id := start(t, q, "need towels")
+		assert.Equal(t, "pending", result(t, q, id).Status)
+
+		require.NoError(t, q.Drain(ctx))
+
+		res := result(t, q, id)
+		assert.Equal(t, "completed", res.Status)
+		require.NotNil(t, res.RequestID)
+		assert.Equal(t, "request-1", *res.RequestID)
+		require.NotNil(t, res.Output)
+		assert.Equal(t, "need towels", res.Output.Name)
+
+		require.Len(t, steps.persisted, 1)
+		assert.Equal(t, id, steps.persisted[0].WorkflowID)
+		assert.Equal(t, "user_1", *steps.persisted[0].RequestedBy)
+		assert.Equal(t, 1, steps.notified)
+		assert.Equal(t, 1, steps.published)
+	})
+
+	t.Run("does not announce a request held for review", func(t *testing.T) {
+		t.Parallel()
+
+		assignee := "user_2"
+		steps := &fakeSteps{assignee: &assignee, status: models.StatusDraft}
+		q, _, _ := newTestQueue(steps)
+		id := start(t, q, "need towels")
+
+		require.NoError(t, q.Drain(ctx))
+
+		assert.Equal(t, "completed", result(t, q, id).Status)
+		assert.Equal(t, 0, steps.notified)
+		assert.Equal(t, 0, steps.published)
+	})
+
+	t.Run("retries a failed generation with backoff, then fails", func(t *testing.T) {
+		t.Parallel()
+
+		steps := &fakeSteps{generateErr: errors.New("llm unavailable")}
+		q, _, now := newTestQueue(steps)
+		id := start(t, q, "need towels")
+
+		require.NoError(t, q.Drain(ctx))
+		assert.Len(t, steps.generated, 1)
+		assert.Equal(t, "pending", result(t, q, id).Status)
+
+		*now = now.Add(time.Second)
+		require.NoError(t, q.Drain(ctx))
+		assert.Len(t, steps.generated, 1, "retried before its backoff")
+
+		for range 2 {
+			*now = now.Add(time.Minute)
+			require.NoError(t, q.Drain(ctx))
+		}
+		assert.Len(t, steps.generated, 3)
+
+		res := result(t, q, id)
+		assert.Equal(t, "failed", res.Status)
+		require.NotNil(t, res.Error)
+		assert.Equal(t, "llm unavailable", *res.Error)
+		assert.Empty(t, steps.persisted)
+	})
+
+	t.Run("fails at once when retrying cannot help", func(t *testing.T) {
+		t.Parallel()
+
+		steps := &fakeSteps{generateErr: temporal.NewNonRetryableApplicationError("no provider", "NoLLMProvider", nil)}
+		q, _, _ := newTestQueue(steps)
+		id := start(t, q, "need towels")
+
+		require.NoError(t, q.Drain(ctx))
+		assert.Equal(t, "failed", result(t, q, id).Status)
+		assert.Len(t, steps.generated, 1)
+	})
+
+	t.Run("finishes storing a request generated before a restart", func(t *testing.T) {
+		t.Parallel()
+
+		steps := &fakeSteps{}
+		q, repo, _ := newTestQueue(steps)
+		id := start(t, q, "need towels")
+		repo.jobs[id].Status = models.JobStoring
+		repo.jobs[id].Output = json.RawMessage(`{"name":"Towels"}`)
+
+		require.NoError(t, q.Drain(ctx))
+		assert.Empty(t, steps.generated)
+		require.Len(t, steps.persisted, 1)
+		assert.Equal(t, "Towels", steps.persisted[0].Output.Name)
+		assert.Equal(t, "completed", result(t, q, id).Status)
+	})
+
+	t.Run("cancels a job until its request is generated", func(t *testing.T) {
+		t.Parallel()
+
+		steps := &fakeSteps{}
+		q, _, _ := newTestQueue(steps)
+		pending := start(t, q, "need towels")
+		require.NoError(t, q.CancelGenerateRequest(ctx, hotelID, pending))
+
+		require.NoError(t, q.Drain(ctx))
+		assert.Equal(t, "cancelled", result(t, q, pending).Status)
+		assert.Empty(t, steps.generated)
+
+		done := start(t, q, "need soap")
+		require.NoError(t, q.Drain(ctx))
+		assert.ErrorIs(t, q.CancelGenerateRequest(ctx, hotelID, done), temporalclient.ErrGenerationFinished)
+
+		assert.True(t, temporalclient.IsWorkflowNotFound(q.CancelGenerateRequest(ctx, hotelID, "generate-job-unknown")))
+		assert.True(t, temporalclient.IsWorkflowNotFound(q.CancelGenerateRequest(ctx, "org_2", start(t, q, "need pillows"))),
+			"another hotel's job is not found")
+	})
+
+	t.Run("discards a generation cancelled while it runs", func(t *testing.T) {
+		t.Parallel()
+
+		steps := &fakeSteps{}
+		q, _, _ := newTestQueue(steps)
+		id := start(t, q, "need towels")
+		steps.onGenerate = func() { require.NoError(t, q.CancelGenerateRequest(ctx, hotelID, id)) }
+
+		require.NoError(t, q.Drain(ctx))
+		assert.Equal(t, "cancelled", result(t, q, id).Status)
+		assert.Empty(t, steps.persisted)
+	})
+
+	t.Run("generates again from text amended while generating", func(t *testing.T) {
+		t.Parallel()
+
+		steps := &fakeSteps{}
+		q, _, _ := newTestQueue(steps)
+		id := start(t, q, "need towels")
+		steps.onGenerate = func() {
+			if len(steps.generated) == 1 {
+				require.NoError(t, q.AmendGenerateRequest(ctx, hotelID, id, "need towels in 505"))
+			}
+		}
+
+		require.NoError(t, q.Drain(ctx))
+		assert.Equal(t, []string{"need towels", "need towels in 505"}, steps.generated)
+		require.Len(t, steps.persisted, 1)
+		assert.Equal(t, "need towels in 505", steps.persisted[0].RawText)
+		assert.Equal(t, "completed", result(t, q, id).Status)
+
+		assert.ErrorIs(t, q.AmendGenerateRequest(ctx, hotelID, id, "too late"), temporalclient.ErrGenerationFinished)
+	})
+
+	t.Run("lists a hotel's jobs, most recent first", func(t *testing.T) {
+		t.Parallel()
+
+		q, _, _ := newTestQueue(&fakeSteps{})
+		first := start(t, q, "need towels")
+		require.NoError(t, q.Drain(ctx))
+		second := start(t, q, "need soap")
+
+		page, err := q.ListGenerateRequests(ctx, temporalclient.GenerateRequestFilter{HotelID: hotelID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, second, page.Items[0].WorkflowID)
+		assert.Equal(t, "pending", page.Items[0].Status)
+		require.NotNil(t, page.NextCursor)
+
+		page, err = q.ListGenerateRequests(ctx, temporalclient.GenerateRequestFilter{HotelID: hotelID, Cursor: *page.NextCursor, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, first, page.Items[0].WorkflowID)
+		assert.Equal(t, "completed", page.Items[0].Status)
+		assert.False(t, page.HasMore)
+
+		_, err = q.ListGenerateRequests(ctx, temporalclient.GenerateRequestFilter{HotelID: hotelID, Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, temporalclient.ErrInvalidCursor)
+	})
+}
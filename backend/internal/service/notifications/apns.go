@@ -0,0 +1,142 @@
+package notifications
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+)
+
+const apnsURL = "https://api.push.apple.com"
+
+// apnsTokenTTL is how long a provider token is reused. APNs rejects tokens
+// older than an hour and throttles ones refreshed more than every 20 minutes.
+const apnsTokenTTL = 50 * time.Minute
+
+var ErrAPNsMisconfigured = errors.New("apns key id, team id and topic are required")
+
+// APNsChannel pushes straight to iOS devices registered without Expo.
+type APNsChannel struct {
+	url    string
+	key    crypto.Signer
+	keyID  string
+	teamID string
+	topic  string
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNsChannel(cfg config.APNs) (*APNsChannel, error) {
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, ErrAPNsMisconfigured
+	}
+	key, err := parsePrivateKey(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+	url := cfg.URL
+	if url == "" {
+		url = apnsURL
+	}
+	return &APNsChannel{
+		url:    strings.TrimRight(url, "/"),
+		key:    key,
+		keyID:  cfg.KeyID,
+		teamID: cfg.TeamID,
+		topic:  cfg.Topic,
+		client: newHTTPClient(),
+		now:    time.Now,
+	}, nil
+}
+
+func (c *APNsChannel) Name() string { return "apns" }
+
+func (c *APNsChannel) Kind() models.NotificationChannel { return models.ChannelPush }
+
+type apnsPayload struct {
+	APS apnsAPS `json:"aps"`
+}
+
+type apnsAPS struct {
+	Alert apnsAlert `json:"alert"`
+	Sound string    `json:"sound"`
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (c *APNsChannel) Addresses(to *models.NotificationRecipient) []string {
+	var tokens []string
+	for _, device := range to.Devices {
+		if device.Platform == "ios" && !isExpoToken(device.Token) {
+			tokens = append(tokens, device.Token)
+		}
+	}
+	return tokens
+}
+
+func (c *APNsChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	jwt, err := c.providerToken()
+	if err != nil {
+		return nil, err
+	}
+	headers := http.Header{}
+	headers.Set("Authorization", "bearer "+jwt)
+	headers.Set("Apns-Topic", c.topic)
+	headers.Set("Apns-Push-Type", "alert")
+
+	payload := apnsPayload{APS: apnsAPS{Alert: apnsAlert{Title: msg.Title, Body: msg.Body}, Sound: "default"}}
+
+	receipts := make([]Receipt, len(addresses))
+	for i, token := range addresses {
+		receipts[i] = Receipt{Address: token, Err: apnsError(postJSON(ctx, c.client, c.url+"/3/device/"+token, headers, payload))}
+	}
+	return receipts, nil
+}
+
+// apnsError marks the responses APNs gives for tokens that are no longer
+// valid as ErrDeviceNotRegistered.
+func apnsError(err error) error {
+	var status *statusError
+	if errors.As(err, &status) && (status.StatusCode == http.StatusGone ||
+		strings.Contains(status.Body, "BadDeviceToken") || strings.Contains(status.Body, "Unregistered")) {
+		return fmt.Errorf("%w: %w", ErrDeviceNotRegistered, err)
+	}
+	return err
+}
+
+// providerToken returns the JWT APNs authenticates the key with, signing a
+// new one once the last is apnsTokenTTL old.
+func (c *APNsChannel) providerToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.token != "" && now.Sub(c.issuedAt) < apnsTokenTTL {
+		return c.token, nil
+	}
+
+	token, err := signJWT(c.key, c.keyID, map[string]any{"iss": c.teamID, "iat": now.Unix()})
+	if err != nil {
+		return "", err
+	}
+	c.token, c.issuedAt = token, now
+	return token, nil
+}
@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+)
+
+// httpTimeout bounds each request a channel makes.
+const httpTimeout = 10 * time.Second
+
+var ErrDeviceNotRegistered = errors.New("device not registered")
+
+// Message is a notification to deliver.
+type Message struct {
+	Type   models.NotificationType
+	Title  string
+	Body   string
+	SentAt time.Time
+}
+
+// Channel delivers notifications somewhere other than in-app.
+type Channel interface {
+	// Name identifies the channel in logs, e.g. "apns".
+	Name() string
+	// Kind is the preference the channel delivers for. Several channels may
+	// deliver the same kind, such as push.
+	Kind() models.NotificationChannel
+	// Addresses returns where the channel reaches the recipient: device
+	// tokens, an email address, a phone number or webhook URLs. It is empty
+	// when the channel has no address for them.
+	Addresses(to *models.NotificationRecipient) []string
+	// Send delivers msg to the given addresses of the recipient, returning a
+	// receipt for each. An error means none were attempted.
+	Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error)
+}
+
+// Receipt is the outcome of delivering to one address.
+type Receipt struct {
+	Address string
+	// TicketID is set when the provider accepted the message but reports
+	// whether it was delivered only later, as Expo does.
+	TicketID string
+	// Err is why delivery failed. It wraps ErrDeviceNotRegistered when the
+	// address is a device token that will never be delivered to again.
+	Err error
+}
+
+// ReceiptChecker is implemented by channels that hand out tickets, to look up
+// whether the messages were delivered.
+type ReceiptChecker interface {
+	// CheckReceipts returns the outcome of the tickets that have one yet:
+	// nil once delivered, or why delivery failed.
+	CheckReceipts(ctx context.Context, ticketIDs []string) (map[string]error, error)
+}
+
+// NewChannels returns Expo push, hotel webhooks and every other channel cfg
+// configures. A channel that is misconfigured is left out and reported in the
+// error, which does not stop the others being returned.
+func NewChannels(cfg config.Notifications) ([]Channel, error) {
+	channels := []Channel{NewExpoChannel(cfg.Expo), NewWebhookChannel()}
+	var errs []error
+
+	if cfg.APNs.Key != "" {
+		apns, err := NewAPNsChannel(cfg.APNs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("apns: %w", err))
+		} else {
+			channels = append(channels, apns)
+		}
+	}
+	if cfg.FCM.Credentials != "" {
+		fcm, err := NewFCMChannel(cfg.FCM)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fcm: %w", err))
+		} else {
+			channels = append(channels, fcm)
+		}
+	}
+	if cfg.SMTP.Host != "" {
+		channels = append(channels, NewEmailChannel(cfg.SMTP))
+	}
+	if cfg.SMS.GatewayURL != "" {
+		channels = append(channels, NewSMSChannel(cfg.SMS))
+	}
+
+	return channels, errors.Join(errs...)
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout}
+}
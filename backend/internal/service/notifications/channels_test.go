@@ -0,0 +1,518 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = Message{
+	Type:   models.TypeTaskAssigned,
+	Title:  "New task",
+	Body:   "Room 505 needs towels",
+	SentAt: time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC),
+}
+
+func ptr(s string) *string { return &s }
+
+// capturedRequest is a request received by a stand-in server.
+type capturedRequest struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// standIn starts a server that records requests and answers with status and
+// response.
+func standIn(t *testing.T, status int, response string) (*httptest.Server, chan capturedRequest) {
+	t.Helper()
+
+	received := make(chan capturedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- capturedRequest{Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func pkcs8PEM(t *testing.T, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// jwtParts decodes a JWT's header and claims and checks its signature with
+// verify.
+func jwtParts(t *testing.T, token string, verify func(signingInput, sig []byte) bool) (header, claims map[string]any) {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	for i, dst := range []*map[string]any{&header, &claims} {
+		raw, err := base64.RawURLEncoding.DecodeString(parts[i])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(raw, dst))
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	assert.True(t, verify([]byte(parts[0]+"."+parts[1]), sig), "signature does not verify")
+	return header, claims
+}
+
+// send delivers testMessage on c to each of the recipient's addresses.
+func send(t *testing.T, c Channel, to *models.NotificationRecipient) ([]Receipt, error) {
+	t.Helper()
+
+	return c.Send(context.Background(), to, c.Addresses(to), testMessage)
+}
+
+func TestExpoChannel_Send(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sends Expo tokens only and returns their tickets", func(t *testing.T) {
+		t.Parallel()
+
+		srv, received := standIn(t, http.StatusOK, `{"data":[
+			{"status":"ok","id":"ticket-a"},
+			{"status":"error","message":"not a registered push notification recipient","details":{"error":"DeviceNotRegistered"}}
+		]}`)
+		c := NewExpoChannel(config.Expo{URL: srv.URL + "/push/send", AccessToken: "expo-secret"})
+
+		receipts, err := send(t, c, &models.NotificationRecipient{Devices: []models.DeviceToken{
+			{Token: "ExponentPushToken[a]", Platform: "ios"},
+			{Token: "apns-device", Platform: "ios"},
+			{Token: "ExpoPushToken[b]", Platform: "android"},
+		}})
+		require.NoError(t, err)
+
+		req := <-received
+		assert.Equal(t, "/push/send", req.Path)
+		assert.Equal(t, "Bearer expo-secret", req.Header.Get("Authorization"))
+		assert.JSONEq(t, `[
+			{"to":"ExponentPushToken[a]","title":"New task","body":"Room 505 needs towels"},
+			{"to":"ExpoPushToken[b]","title":"New task","body":"Room 505 needs towels"}
+		]`, string(req.Body))
+
+		require.Len(t, receipts, 2)
+		assert.Equal(t, Receipt{Address: "ExponentPushToken[a]", TicketID: "ticket-a"}, receipts[0])
+		assert.Equal(t, "ExpoPushToken[b]", receipts[1].Address)
+		assert.ErrorIs(t, receipts[1].Err, ErrDeviceNotRegistered)
+	})
+
+	t.Run("has no addresses for users without Expo tokens", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewExpoChannel(config.Expo{})
+		assert.Empty(t, c.Addresses(&models.NotificationRecipient{Devices: []models.DeviceToken{{Token: "apns-device", Platform: "ios"}}}))
+	})
+
+	t.Run("returns an error when Expo rejects the push", func(t *testing.T) {
+		t.Parallel()
+
+		srv, _ := standIn(t, http.StatusBadRequest, `{"errors":[{"code":"VALIDATION_ERROR"}]}`)
+		c := NewExpoChannel(config.Expo{URL: srv.URL})
+
+		_, err := send(t, c, &models.NotificationRecipient{Devices: []models.DeviceToken{{Token: "ExpoPushToken[a]"}}})
+		assert.ErrorIs(t, err, ErrExpoPushRejected)
+	})
+}
+
+func TestExpoChannel_CheckReceipts(t *testing.T) {
+	t.Parallel()
+
+	srv, received := standIn(t, http.StatusOK, `{"data":{
+		"ticket-a":{"status":"ok"},
+		"ticket-b":{"status":"error","message":"gone","details":{"error":"DeviceNotRegistered"}},
+		"ticket-c":{"status":"error","message":"too many","details":{"error":"MessageRateExceeded"}}
+	}}`)
+	c := NewExpoChannel(config.Expo{URL: srv.URL + "/push/send"})
+
+	results, err := c.CheckReceipts(context.Background(), []string{"ticket-a", "ticket-b", "ticket-c", "ticket-d"})
+	require.NoError(t, err)
+
+	req := <-received
+	assert.Equal(t, "/push/getReceipts", req.Path)
+	assert.JSONEq(t, `{"ids":["ticket-a","ticket-b","ticket-c","ticket-d"]}`, string(req.Body))
+
+	require.Len(t, results, 3, "tickets without a receipt yet are left out")
+	assert.NoError(t, results["ticket-a"])
+	assert.ErrorIs(t, results["ticket-b"], ErrDeviceNotRegistered)
+	assert.ErrorContains(t, results["ticket-c"], "MessageRateExceeded")
+	assert.NotErrorIs(t, results["ticket-c"], ErrDeviceNotRegistered)
+}
+
+func TestAPNsChannel_Send(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	cfg := config.APNs{Key: pkcs8PEM(t, key), KeyID: "KEY123", TeamID: "TEAM123", Topic: "com.selfserve.app"}
+
+	t.Run("pushes to iOS devices with a signed provider token", func(t *testing.T) {
+		t.Parallel()
+
+		srv, received := standIn(t, http.StatusOK, "")
+		cfg := cfg
+		cfg.URL = srv.URL
+		c, err := NewAPNsChannel(cfg)
+		require.NoError(t, err)
+
+		receipts, err := send(t, c, &models.NotificationRecipient{Devices: []models.DeviceToken{
+			{Token: "ios-device", Platform: "ios"},
+			{Token: "android-device", Platform: "android"},
+			{Token: "ExponentPushToken[a]", Platform: "ios"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, []Receipt{{Address: "ios-device"}}, receipts)
+		require.Len(t, received, 1)
+
+		req := <-received
+		assert.Equal(t, "/3/device/ios-device", req.Path)
+		assert.Equal(t, "com.selfserve.app", req.Header.Get("Apns-Topic"))
+		assert.Equal(t, "alert", req.Header.Get("Apns-Push-Type"))
+		assert.JSONEq(t, `{"aps":{"alert":{"title":"New task","body":"Room 505 needs towels"},"sound":"default"}}`, string(req.Body))
+
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "bearer ")
+		require.True(t, ok)
+		header, claims := jwtParts(t, token, func(signingInput, sig []byte) bool {
+			digest := sha256.Sum256(signingInput)
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			return len(sig) == 64 && ecdsa.Verify(&key.PublicKey, digest[:], r, s)
+		})
+		assert.Equal(t, "ES256", header["alg"])
+		assert.Equal(t, "KEY123", header["kid"])
+		assert.Equal(t, "TEAM123", claims["iss"])
+	})
+
+	t.Run("reuses the provider token until it is stale", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := NewAPNsChannel(cfg)
+		require.NoError(t, err)
+		now := time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC)
+		c.now = func() time.Time { return now }
+
+		first, err := c.providerToken()
+		require.NoError(t, err)
+		now = now.Add(10 * time.Minute)
+		second, err := c.providerToken()
+		require.NoError(t, err)
+		now = now.Add(apnsTokenTTL)
+		third, err := c.providerToken()
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, third)
+	})
+
+	for _, tc := range []struct {
+		name         string
+		status       int
+		response     string
+		unregistered bool
+	}{
+		{name: "reports unregistered devices", status: http.StatusGone, response: `{"reason":"Unregistered"}`, unregistered: true},
+		{name: "reports bad device tokens as unregistered", status: http.StatusBadRequest, response: `{"reason":"BadDeviceToken"}`, unregistered: true},
+		{name: "returns other rejections to be retried", status: http.StatusServiceUnavailable, response: `{"reason":"ServiceUnavailable"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, _ := standIn(t, tc.status, tc.response)
+			cfg := cfg
+			cfg.URL = srv.URL
+			c, err := NewAPNsChannel(cfg)
+			require.NoError(t, err)
+
+			receipts, err := send(t, c, &models.NotificationRecipient{Devices: []models.DeviceToken{{Token: "ios-device", Platform: "ios"}}})
+			require.NoError(t, err)
+			require.Len(t, receipts, 1)
+			assert.ErrorContains(t, receipts[0].Err, tc.response)
+			assert.Equal(t, tc.unregistered, errors.Is(receipts[0].Err, ErrDeviceNotRegistered))
+		})
+	}
+
+	t.Run("requires the key id, team id and topic", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewAPNsChannel(config.APNs{Key: cfg.Key})
+		assert.ErrorIs(t, err, ErrAPNsMisconfigured)
+	})
+}
+
+func TestFCMChannel_Send(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var assertions []string
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		mu.Lock()
+		assertions = append(assertions, r.PostForm.Get("assertion"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"fcm-access","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	t.Cleanup(tokenSrv.Close)
+	srv, received := standIn(t, http.StatusOK, `{"name":"projects/selfserve/messages/1"}`)
+
+	credentials, err := json.Marshal(serviceAccount{
+		ClientEmail: "push@selfserve.iam.gserviceaccount.com",
+		PrivateKey:  pkcs8PEM(t, key),
+		ProjectID:   "selfserve",
+		TokenURI:    tokenSrv.URL,
+	})
+	require.NoError(t, err)
+	c, err := NewFCMChannel(config.FCM{Credentials: string(credentials), URL: srv.URL})
+	require.NoError(t, err)
+
+	recipient := &models.NotificationRecipient{Devices: []models.DeviceToken{
+		{Token: "android-device", Platform: "android"},
+		{Token: "ios-device", Platform: "ios"},
+	}}
+	receipts, err := send(t, c, recipient)
+	require.NoError(t, err)
+	assert.Equal(t, []Receipt{{Address: "android-device"}}, receipts)
+	_, err = send(t, c, recipient)
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+
+	req := <-received
+	assert.Equal(t, "/v1/projects/selfserve/messages:send", req.Path)
+	assert.Equal(t, "Bearer fcm-access", req.Header.Get("Authorization"))
+	assert.JSONEq(t, `{"message":{"token":"android-device","notification":{"title":"New task","body":"Room 505 needs towels"},"data":{"type":"task_assigned"}}}`, string(req.Body))
+
+	require.Len(t, assertions, 1, "the access token is reused")
+	header, claims := jwtParts(t, assertions[0], func(signingInput, sig []byte) bool {
+		digest := sha256.Sum256(signingInput)
+		return rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig) == nil
+	})
+	assert.Equal(t, "RS256", header["alg"])
+	assert.Equal(t, "push@selfserve.iam.gserviceaccount.com", claims["iss"])
+	assert.Equal(t, tokenSrv.URL, claims["aud"])
+	assert.Equal(t, fcmScope, claims["scope"])
+
+	t.Run("reports unregistered devices", func(t *testing.T) {
+		t.Parallel()
+
+		srv, _ := standIn(t, http.StatusNotFound, `{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`)
+		c, err := NewFCMChannel(config.FCM{Credentials: string(credentials), URL: srv.URL})
+		require.NoError(t, err)
+
+		receipts, err := send(t, c, recipient)
+		require.NoError(t, err)
+		require.Len(t, receipts, 1)
+		assert.ErrorIs(t, receipts[0].Err, ErrDeviceNotRegistered)
+	})
+}
+
+func TestSMSChannel_Send(t *testing.T) {
+	t.Parallel()
+
+	srv, received := standIn(t, http.StatusAccepted, "")
+	c := NewSMSChannel(config.SMS{GatewayURL: srv.URL, GatewayToken: "sms-secret", From: "+15550000000"})
+
+	assert.Empty(t, c.Addresses(&models.NotificationRecipient{}), "users without a phone number are skipped")
+
+	receipts, err := send(t, c, &models.NotificationRecipient{Phone: ptr("+15551234567")})
+	require.NoError(t, err)
+	assert.Equal(t, []Receipt{{Address: "+15551234567"}}, receipts)
+	req := <-received
+	assert.Equal(t, "Bearer sms-secret", req.Header.Get("Authorization"))
+	assert.JSONEq(t, `{"from":"+15550000000","to":"+15551234567","body":"New task: Room 505 needs towels"}`, string(req.Body))
+}
+
+func TestWebhookChannel_Send(t *testing.T) {
+	t.Parallel()
+
+	first, firstReceived := standIn(t, http.StatusOK, "")
+	second, secondReceived := standIn(t, http.StatusInternalServerError, "down")
+	c := NewWebhookChannel()
+
+	receipts, err := send(t, c, &models.NotificationRecipient{
+		UserID:        "user_1",
+		HotelID:       "hotel_1",
+		WebhookURLs:   []string{first.URL, second.URL},
+		WebhookSecret: "webhook-secret",
+	})
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	assert.Equal(t, Receipt{Address: first.URL}, receipts[0])
+	assert.Equal(t, second.URL, receipts[1].Address)
+	assert.ErrorContains(t, receipts[1].Err, "status 500")
+	<-secondReceived
+
+	req := <-firstReceived
+	assert.JSONEq(t, `{"type":"task_assigned","user_id":"user_1","hotel_id":"hotel_1","title":"New task","body":"Room 505 needs towels","sent_at":"2026-05-01T14:00:00Z"}`, string(req.Body))
+
+	mac := hmac.New(sha256.New, []byte("webhook-secret"))
+	mac.Write(req.Body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get(SignatureHeader))
+
+	assert.Empty(t, c.Addresses(&models.NotificationRecipient{UserID: "user_2", HotelID: "hotel_2"}), "hotels without webhooks are skipped")
+}
+
+// smtpStandIn is a minimal SMTP server that accepts one message per
+// connection and sends its envelope and data on the returned channel.
+func smtpStandIn(t *testing.T) (host string, port int, received chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received = make(chan string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, received)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func serveSMTP(conn net.Conn, received chan<- string) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	var transcript strings.Builder
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM"), strings.HasPrefix(cmd, "RCPT TO"):
+			transcript.WriteString(strings.TrimSpace(line) + "\n")
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			for {
+				data, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if data == ".\r\n" {
+					break
+				}
+				transcript.WriteString(data)
+			}
+			received <- transcript.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	t.Parallel()
+
+	host, port, received := smtpStandIn(t)
+	c := NewEmailChannel(config.SMTP{Host: host, Port: port, From: "alerts@selfserve.test"})
+
+	assert.Empty(t, c.Addresses(&models.NotificationRecipient{}), "users without an email are skipped")
+
+	receipts, err := send(t, c, &models.NotificationRecipient{Email: ptr("ana@hotel.test")})
+	require.NoError(t, err)
+	assert.Equal(t, []Receipt{{Address: "ana@hotel.test"}}, receipts)
+	transcript := <-received
+	assert.Contains(t, transcript, "MAIL FROM:<alerts@selfserve.test>")
+	assert.Contains(t, transcript, "RCPT TO:<ana@hotel.test>")
+	assert.Contains(t, transcript, "Subject: New task\r\n")
+	assert.Contains(t, transcript, "Content-Type: text/plain; charset=UTF-8\r\n")
+	assert.Contains(t, transcript, "\r\n\r\nRoom 505 needs towels\r\n")
+}
+
+func TestNewChannels(t *testing.T) {
+	t.Parallel()
+
+	names := func(channels []Channel) []string {
+		var out []string
+		for _, c := range channels {
+			out = append(out, c.Name())
+		}
+		return out
+	}
+
+	t.Run("always pushes through Expo and posts to hotel webhooks", func(t *testing.T) {
+		t.Parallel()
+
+		channels, err := NewChannels(config.Notifications{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"expo", "webhook"}, names(channels))
+	})
+
+	t.Run("adds the configured channels", func(t *testing.T) {
+		t.Parallel()
+
+		channels, err := NewChannels(config.Notifications{
+			SMTP: config.SMTP{Host: "smtp.test"},
+			SMS:  config.SMS{GatewayURL: "http://sms.test"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"expo", "webhook", "email", "sms"}, names(channels))
+	})
+
+	t.Run("leaves out misconfigured channels", func(t *testing.T) {
+		t.Parallel()
+
+		channels, err := NewChannels(config.Notifications{
+			APNs: config.APNs{Key: "not a key", KeyID: "KEY123", TeamID: "TEAM123", Topic: "com.selfserve.app"},
+			FCM:  config.FCM{Credentials: "{}"},
+			SMTP: config.SMTP{Host: "smtp.test", Port: 2525},
+		})
+		assert.ErrorIs(t, err, ErrInvalidKey)
+		assert.ErrorIs(t, err, ErrFCMMisconfigured)
+		assert.Equal(t, []string{"expo", "webhook", "email"}, names(channels))
+		assert.Equal(t, "smtp.test:2525", channels[2].(*EmailChannel).addr)
+	})
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+// digestBodies is how many of the coalesced notifications a digest names
+// before summing up the rest.
+const digestBodies = 3
+
+// digestTitles phrase the title of a digest of notifications that are all of
+// one type, given how many there are.
+var digestTitles = map[models.NotificationType]string{
+	models.TypeTaskAssigned:     "%d new tasks assigned",
+	models.TypeHighPriorityTask: "%d high priority tasks",
+	models.TypeSLABreached:      "%d requests missed their SLA",
+	models.TypeMentioned:        "You were mentioned %d times",
+}
+
+// digest coalesces entries, oldest first, into one message. A single entry is
+// delivered as it is; several are titled by how many there are and list the
+// first few of their bodies. The message has the type and time of the latest.
+func digest(entries []*models.NotificationOutboxEntry) Message {
+	latest := entries[len(entries)-1]
+	if len(entries) == 1 {
+		return Message{Type: latest.Type, Title: latest.Title, Body: latest.Body, SentAt: latest.CreatedAt}
+	}
+
+	format := "%d new notifications"
+	if f, ok := digestTitles[latest.Type]; ok && !slices.ContainsFunc(entries, func(e *models.NotificationOutboxEntry) bool {
+		return e.Type != latest.Type
+	}) {
+		format = f
+	}
+
+	var bodies []string
+	for _, e := range entries {
+		if e.Body != "" && !slices.Contains(bodies, e.Body) {
+			bodies = append(bodies, e.Body)
+		}
+	}
+	body := strings.Join(bodies[:min(len(bodies), digestBodies)], ", ")
+	if len(bodies) > digestBodies {
+		body += fmt.Sprintf(" and %d more", len(bodies)-digestBodies)
+	}
+
+	return Message{
+		Type:   latest.Type,
+		Title:  fmt.Sprintf(format, len(entries)),
+		Body:   body,
+		SentAt: latest.CreatedAt,
+	}
+}
@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+)
+
+const smtpPort = 587
+
+// EmailChannel emails users through an SMTP relay, upgrading to TLS when the
+// relay offers it.
+type EmailChannel struct {
+	host     string
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+func NewEmailChannel(cfg config.SMTP) *EmailChannel {
+	port := cfg.Port
+	if port == 0 {
+		port = smtpPort
+	}
+	return &EmailChannel{
+		host:     cfg.Host,
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     cfg.From,
+	}
+}
+
+func (c *EmailChannel) Name() string { return "email" }
+
+func (c *EmailChannel) Kind() models.NotificationChannel { return models.ChannelEmail }
+
+func (c *EmailChannel) Addresses(to *models.NotificationRecipient) []string {
+	if to.Email == nil || *to.Email == "" {
+		return nil
+	}
+	return []string{*to.Email}
+}
+
+func (c *EmailChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	receipts := make([]Receipt, len(addresses))
+	for i, address := range addresses {
+		receipts[i] = Receipt{Address: address, Err: c.send(ctx, address, msg)}
+	}
+	return receipts, nil
+}
+
+func (c *EmailChannel) send(ctx context.Context, to string, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if c.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(c.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(c.message(to, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (c *EmailChannel) message(to string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", msg.SentAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
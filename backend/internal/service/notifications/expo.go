@@ -0,0 +1,165 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+)
+
+const expoPushURL = "https://exp.host/--/exponent-push-api/v2/push/send"
+
+// maxExpoReceipts is how many receipts Expo returns per request.
+const maxExpoReceipts = 1000
+
+var (
+	ErrExpoPushFailed   = errors.New("expo push request failed")
+	ErrExpoPushRejected = errors.New("expo push rejected by server")
+)
+
+// ExpoChannel pushes to devices registered through Expo. Expo answers each
+// push with a ticket whose receipt says, some minutes later, whether it was
+// delivered.
+type ExpoChannel struct {
+	url         string
+	receiptsURL string
+	accessToken string
+	client      *http.Client
+}
+
+func NewExpoChannel(cfg config.Expo) *ExpoChannel {
+	url := cfg.URL
+	if url == "" {
+		url = expoPushURL
+	}
+	return &ExpoChannel{
+		url:         url,
+		receiptsURL: strings.TrimSuffix(url, "/send") + "/getReceipts",
+		accessToken: cfg.AccessToken,
+		client:      newHTTPClient(),
+	}
+}
+
+var _ ReceiptChecker = (*ExpoChannel)(nil)
+
+func (c *ExpoChannel) Name() string { return "expo" }
+
+func (c *ExpoChannel) Kind() models.NotificationChannel { return models.ChannelPush }
+
+func (c *ExpoChannel) Addresses(to *models.NotificationRecipient) []string {
+	var tokens []string
+	for _, device := range to.Devices {
+		if isExpoToken(device.Token) {
+			tokens = append(tokens, device.Token)
+		}
+	}
+	return tokens
+}
+
+type expoMessage struct {
+	To    string `json:"to"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// expoStatus is a push ticket or receipt.
+type expoStatus struct {
+	Status  string `json:"status"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+func (s expoStatus) err() error {
+	if s.Status == "ok" {
+		return nil
+	}
+	if s.Details.Error == "DeviceNotRegistered" {
+		return fmt.Errorf("%w: %s", ErrDeviceNotRegistered, s.Message)
+	}
+	if s.Details.Error != "" {
+		return fmt.Errorf("%s: %s", s.Details.Error, s.Message)
+	}
+	return errors.New(s.Message)
+}
+
+func (c *ExpoChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	msgs := make([]expoMessage, len(addresses))
+	for i, token := range addresses {
+		msgs[i] = expoMessage{To: token, Title: msg.Title, Body: msg.Body}
+	}
+
+	var tickets struct {
+		Data []expoStatus `json:"data"`
+	}
+	if err := c.post(ctx, c.url, msgs, &tickets); err != nil {
+		return nil, err
+	}
+	// Tickets come back in the order the messages were sent.
+	if len(tickets.Data) != len(msgs) {
+		return nil, fmt.Errorf("%w: %d tickets for %d messages", ErrExpoPushFailed, len(tickets.Data), len(msgs))
+	}
+
+	receipts := make([]Receipt, len(addresses))
+	for i, ticket := range tickets.Data {
+		receipts[i] = Receipt{Address: addresses[i], Err: ticket.err()}
+		if receipts[i].Err == nil {
+			receipts[i].TicketID = ticket.ID
+		}
+	}
+	return receipts, nil
+}
+
+func (c *ExpoChannel) CheckReceipts(ctx context.Context, ticketIDs []string) (map[string]error, error) {
+	results := make(map[string]error, len(ticketIDs))
+	for start := 0; start < len(ticketIDs); start += maxExpoReceipts {
+		ids := ticketIDs[start:min(start+maxExpoReceipts, len(ticketIDs))]
+
+		var receipts struct {
+			Data map[string]expoStatus `json:"data"`
+		}
+		if err := c.post(ctx, c.receiptsURL, map[string][]string{"ids": ids}, &receipts); err != nil {
+			return nil, err
+		}
+		for id, receipt := range receipts.Data {
+			results[id] = receipt.err()
+		}
+	}
+	return results, nil
+}
+
+func (c *ExpoChannel) post(ctx context.Context, url string, body, out any) error {
+	headers := http.Header{}
+	if c.accessToken != "" {
+		headers.Set("Authorization", "Bearer "+c.accessToken)
+	}
+
+	resp, err := postJSONResponse(ctx, c.client, url, headers, body)
+	var status *statusError
+	if errors.As(err, &status) {
+		return fmt.Errorf("%w: %w", ErrExpoPushRejected, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrExpoPushFailed, err)
+	}
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("%w: decode response: %w", ErrExpoPushFailed, err)
+	}
+	return nil
+}
+
+// isExpoToken reports whether token was issued by Expo rather than directly
+// by APNs or FCM.
+func isExpoToken(token string) bool {
+	return strings.HasPrefix(token, "ExponentPushToken[") || strings.HasPrefix(token, "ExpoPushToken[")
+}
@@ -0,0 +1,204 @@
+package notifications
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+)
+
+const (
+	fcmURL      = "https://fcm.googleapis.com"
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	googleToken = "https://oauth2.googleapis.com/token"
+)
+
+var ErrFCMMisconfigured = errors.New("fcm credentials need client_email, private_key and project_id")
+
+// FCMChannel pushes straight to Android devices registered without Expo,
+// authenticating as a Google service account.
+type FCMChannel struct {
+	url         string
+	tokenURL    string
+	projectID   string
+	clientEmail string
+	key         crypto.Signer
+	client      *http.Client
+	now         func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// serviceAccount is the subset of a Google service account JSON key FCM
+// needs.
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	ProjectID   string `json:"project_id"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func NewFCMChannel(cfg config.FCM) (*FCMChannel, error) {
+	var account serviceAccount
+	if err := json.Unmarshal([]byte(cfg.Credentials), &account); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" || account.ProjectID == "" {
+		return nil, ErrFCMMisconfigured
+	}
+	key, err := parsePrivateKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := cfg.URL
+	if baseURL == "" {
+		baseURL = fcmURL
+	}
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleToken
+	}
+	return &FCMChannel{
+		url:         strings.TrimRight(baseURL, "/"),
+		tokenURL:    tokenURL,
+		projectID:   account.ProjectID,
+		clientEmail: account.ClientEmail,
+		key:         key,
+		client:      newHTTPClient(),
+		now:         time.Now,
+	}, nil
+}
+
+func (c *FCMChannel) Name() string { return "fcm" }
+
+func (c *FCMChannel) Kind() models.NotificationChannel { return models.ChannelPush }
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (c *FCMChannel) Addresses(to *models.NotificationRecipient) []string {
+	var tokens []string
+	for _, device := range to.Devices {
+		if device.Platform == "android" && !isExpoToken(device.Token) {
+			tokens = append(tokens, device.Token)
+		}
+	}
+	return tokens
+}
+
+func (c *FCMChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	accessToken, err := c.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch access token: %w", err)
+	}
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer "+accessToken)
+	endpoint := c.url + "/v1/projects/" + url.PathEscape(c.projectID) + "/messages:send"
+
+	receipts := make([]Receipt, len(addresses))
+	for i, token := range addresses {
+		body := fcmRequest{Message: fcmMessage{
+			Token:        token,
+			Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+			Data:         map[string]string{"type": string(msg.Type)},
+		}}
+		receipts[i] = Receipt{Address: token, Err: fcmError(postJSON(ctx, c.client, endpoint, headers, body))}
+	}
+	return receipts, nil
+}
+
+// fcmError marks the response FCM gives for tokens that are no longer valid
+// as ErrDeviceNotRegistered.
+func fcmError(err error) error {
+	var status *statusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound && strings.Contains(status.Body, "UNREGISTERED") {
+		return fmt.Errorf("%w: %w", ErrDeviceNotRegistered, err)
+	}
+	return err
+}
+
+// token returns an OAuth access token for the service account, exchanging a
+// signed assertion for a new one shortly before the last expires.
+func (c *FCMChannel) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.accessToken != "" && now.Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+
+	assertion, err := signJWT(c.key, "", map[string]any{
+		"iss":   c.clientEmail,
+		"scope": fcmScope,
+		"aud":   c.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("no access token in response")
+	}
+
+	c.accessToken = body.AccessToken
+	// Refresh a minute early so a token never expires mid-send.
+	c.expiresAt = now.Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return c.accessToken, nil
+}
@@ -0,0 +1,294 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+)
+
+const (
+	// DefaultInterval is how often Run polls the outbox when it is not woken
+	// by a new notification.
+	DefaultInterval = 5 * time.Second
+	// DefaultReceiptInterval is how often Run checks tickets for receipts.
+	DefaultReceiptInterval = 5 * time.Minute
+	// deliveryTimeout bounds delivering one message.
+	deliveryTimeout = 30 * time.Second
+	// lease is how long a claimed entry stays locked. An entry whose worker has
+	// not finished it by then is claimed again, so it must outlast a delivery.
+	lease = 2 * deliveryTimeout
+	// maxAttempts and retryInterval space attempts 10s, 20s, 40s, 80s and
+	// 160s apart, giving up about five minutes after the first.
+	maxAttempts   = 6
+	retryInterval = 10 * time.Second
+	// receiptDelay is how long Expo asks to wait before checking a ticket,
+	// and receiptExpiry how long it keeps the receipt.
+	receiptDelay  = 15 * time.Minute
+	receiptExpiry = 24 * time.Hour
+	receiptBatch  = 1000
+)
+
+// Run delivers due outbox entries every Interval, or as soon as one is
+// queued, and checks for receipts every ReceiptInterval, until ctx is
+// cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	receipts := time.NewTicker(s.ReceiptInterval)
+	defer receipts.Stop()
+
+	for {
+		if err := s.Drain(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("notifications: failed to claim outbox entry", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		case <-receipts.C:
+			if err := s.CheckReceipts(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("notifications: failed to check receipts", "err", err)
+			}
+		}
+	}
+}
+
+// Drain delivers entries until none is due. An entry that fails is retried
+// or failed; only a failure to claim stops it.
+func (s *Service) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		entries, err := s.repo.ClaimNotificationOutbox(ctx, s.now(), lease)
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.deliver(ctx, entries)
+	}
+	return nil
+}
+
+// deliver sends the entries, due for one user on one channel, as a single
+// digest message to each of the recipient's addresses on the channel that
+// not all of them have been delivered to already, and records the receipts
+// against each entry.
+func (s *Service) deliver(ctx context.Context, entries []*models.NotificationOutboxEntry) {
+	first := entries[0]
+	channel := s.channel(first.Channel)
+	if channel == nil {
+		s.fail(ctx, entries, fmt.Errorf("channel %q is not configured", first.Channel))
+		return
+	}
+
+	recipient, err := s.repo.FindNotificationRecipient(ctx, first.UserID)
+	if errors.Is(err, errs.ErrNotFoundInDB) {
+		s.fail(ctx, entries, err)
+		return
+	}
+	if err != nil {
+		s.retryOrFail(ctx, entries, err)
+		return
+	}
+
+	// done holds, by address, the entries already delivered to it.
+	done := make(map[string]map[string]bool)
+	for _, entry := range entries {
+		previous, err := s.repo.FindNotificationDeliveries(ctx, entry.ID)
+		if err != nil {
+			s.retryOrFail(ctx, entries, err)
+			return
+		}
+		for _, d := range previous {
+			if d.Status == models.DeliveryRetrying {
+				continue
+			}
+			if done[d.Address] == nil {
+				done[d.Address] = make(map[string]bool)
+			}
+			done[d.Address][entry.ID] = true
+		}
+	}
+	var addresses []string
+	for _, address := range channel.Addresses(recipient) {
+		if len(done[address]) < len(entries) {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		s.complete(ctx, entries)
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	receipts, err := channel.Send(sendCtx, recipient, addresses, digest(entries))
+	cancel()
+	if err != nil {
+		s.retryOrFail(ctx, entries, err)
+		return
+	}
+
+	var deliveries []*models.NotificationDelivery
+	var retry []error
+	for _, r := range receipts {
+		status, ticketID := models.DeliveryDelivered, (*string)(nil)
+		switch {
+		case r.Err == nil && r.TicketID != "":
+			status, ticketID = models.DeliverySent, &r.TicketID
+		case r.Err == nil:
+		case errors.Is(r.Err, ErrDeviceNotRegistered):
+			status = models.DeliveryFailed
+			s.prune(ctx, r.Address)
+		default:
+			status = models.DeliveryRetrying
+			retry = append(retry, r.Err)
+		}
+		var reason *string
+		if r.Err != nil {
+			msg := r.Err.Error()
+			reason = &msg
+		}
+		for _, entry := range entries {
+			if done[r.Address][entry.ID] {
+				continue
+			}
+			deliveries = append(deliveries, &models.NotificationDelivery{
+				OutboxID: entry.ID,
+				Address:  r.Address,
+				Status:   status,
+				TicketID: ticketID,
+				Error:    reason,
+			})
+		}
+	}
+	if err := s.repo.UpsertNotificationDeliveries(ctx, deliveries); err != nil {
+		// The entries are claimed again once their lease expires and, with
+		// no receipts to go on, sent to every address again.
+		slog.Error("notifications: failed to record deliveries", "err", err, "outbox_id", first.ID)
+		return
+	}
+
+	if len(retry) > 0 {
+		s.retryOrFail(ctx, entries, errors.Join(retry...))
+		return
+	}
+	s.complete(ctx, entries)
+}
+
+// CheckReceipts looks up the receipts of tickets old enough to have one,
+// recording whether they were delivered and pruning devices that are no
+// longer registered.
+func (s *Service) CheckReceipts(ctx context.Context) error {
+	now := s.now()
+	var errs []error
+	for _, channel := range s.channels {
+		checker, ok := channel.(ReceiptChecker)
+		if !ok {
+			continue
+		}
+
+		tickets, err := s.repo.FindNotificationTickets(ctx, channel.Name(), now.Add(-receiptExpiry), now.Add(-receiptDelay), receiptBatch)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(tickets) == 0 {
+			continue
+		}
+		// A digest shares its tickets between the entries it stands for.
+		var ids []string
+		seen := make(map[string]bool, len(tickets))
+		for _, t := range tickets {
+			if !seen[*t.TicketID] {
+				seen[*t.TicketID] = true
+				ids = append(ids, *t.TicketID)
+			}
+		}
+
+		results, err := checker.CheckReceipts(ctx, ids)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+			continue
+		}
+
+		var resolved []*models.NotificationDelivery
+		pruned := make(map[string]bool)
+		for _, t := range tickets {
+			result, ok := results[*t.TicketID]
+			if !ok {
+				continue
+			}
+			t.Status = models.DeliveryDelivered
+			if result != nil {
+				msg := result.Error()
+				t.Status, t.Error = models.DeliveryFailed, &msg
+				if errors.Is(result, ErrDeviceNotRegistered) && !pruned[t.Address] {
+					pruned[t.Address] = true
+					s.prune(ctx, t.Address)
+				}
+			}
+			resolved = append(resolved, t)
+		}
+		if err := s.repo.UpsertNotificationDeliveries(ctx, resolved); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) channel(name string) Channel {
+	for _, channel := range s.channels {
+		if channel.Name() == name {
+			return channel
+		}
+	}
+	return nil
+}
+
+// prune forgets a device token its push service says is no longer
+// registered.
+func (s *Service) prune(ctx context.Context, token string) {
+	if err := s.repo.DeleteDeviceToken(ctx, token); err != nil {
+		slog.Error("notifications: failed to prune device token", "err", err)
+	}
+}
+
+func (s *Service) complete(ctx context.Context, entries []*models.NotificationOutboxEntry) {
+	for _, entry := range entries {
+		if err := s.repo.CompleteNotificationOutboxEntry(ctx, entry.ID); err != nil {
+			slog.Error("notifications: failed to complete outbox entry", "err", err, "outbox_id", entry.ID)
+		}
+	}
+}
+
+// retryOrFail schedules another attempt of each entry with backoff, or fails
+// it once it is out of attempts.
+func (s *Service) retryOrFail(ctx context.Context, entries []*models.NotificationOutboxEntry, cause error) {
+	for _, entry := range entries {
+		if entry.Attempts >= maxAttempts {
+			s.fail(ctx, []*models.NotificationOutboxEntry{entry}, cause)
+			continue
+		}
+
+		backoff := retryInterval << (entry.Attempts - 1)
+		if err := s.repo.RetryNotificationOutboxEntry(ctx, entry.ID, cause.Error(), s.now().Add(backoff)); err != nil {
+			slog.Error("notifications: failed to schedule retry", "err", err, "outbox_id", entry.ID)
+		}
+	}
+}
+
+func (s *Service) fail(ctx context.Context, entries []*models.NotificationOutboxEntry, cause error) {
+	for _, entry := range entries {
+		slog.Error("notifications: delivery failed", "err", cause, "channel", entry.Channel, "user_id", entry.UserID, "attempts", entry.Attempts)
+		if err := s.repo.FailNotificationOutboxEntry(ctx, entry.ID, cause.Error()); err != nil {
+			slog.Error("notifications: failed to record delivery failure", "err", err, "outbox_id", entry.ID)
+		}
+	}
+}
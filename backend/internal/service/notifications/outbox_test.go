@@ -0,0 +1,345 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ticketChannel hands out a ticket per address and answers receipt checks
+// from receipts.
+type ticketChannel struct {
+	*fakeChannel
+	receipts map[string]error
+	checked  []string
+}
+
+func (c *ticketChannel) CheckReceipts(ctx context.Context, ticketIDs []string) (map[string]error, error) {
+	c.checked = append(c.checked, ticketIDs...)
+	results := map[string]error{}
+	for _, id := range ticketIDs {
+		if err, ok := c.receipts[id]; ok {
+			results[id] = err
+		}
+	}
+	return results, nil
+}
+
+// queueOne notifies user_1 once, queueing it on every channel.
+func queueOne(t *testing.T, s *Service) {
+	t.Helper()
+
+	require.NoError(t, s.Notify(context.Background(), "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+}
+
+func newOutboxRepo() *mockNotificationsRepository {
+	return &mockNotificationsRepository{
+		prefs:     &models.NotificationPreferences{},
+		recipient: &models.NotificationRecipient{UserID: "user_1"},
+	}
+}
+
+func (m *mockNotificationsRepository) delivery(address string) *models.NotificationDelivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.deliveries {
+		if d.Address == address {
+			return d
+		}
+	}
+	return nil
+}
+
+func TestService_Drain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("delivers queued notifications and records a receipt per address", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush, "token-a", "token-b")
+		s := newTestService(repo, push)
+		queueOne(t, s)
+
+		require.NoError(t, s.Drain(ctx))
+
+		assert.Equal(t, [][]string{{"token-a", "token-b"}}, push.sent)
+		assert.Equal(t, models.OutboxDelivered, repo.outbox[0].status)
+		assert.Equal(t, models.DeliveryDelivered, repo.delivery("token-a").Status)
+		assert.Equal(t, models.DeliveryDelivered, repo.delivery("token-b").Status)
+	})
+
+	t.Run("retries only the failed addresses with backoff", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush, "token-a", "token-b")
+		failing := true
+		push.receipt = func(address string) Receipt {
+			if address == "token-b" && failing {
+				return Receipt{Address: address, Err: errors.New("timeout")}
+			}
+			return Receipt{Address: address}
+		}
+		s := newTestService(repo, push)
+		queueOne(t, s)
+
+		require.NoError(t, s.Drain(ctx))
+		assert.Equal(t, models.OutboxPending, repo.outbox[0].status)
+		assert.Equal(t, testNow.Add(retryInterval), repo.outbox[0].runAfter)
+		assert.Equal(t, models.DeliveryRetrying, repo.delivery("token-b").Status)
+
+		failing = false
+		s.now = func() time.Time { return testNow.Add(retryInterval) }
+		require.NoError(t, s.Drain(ctx))
+
+		assert.Equal(t, [][]string{{"token-a", "token-b"}, {"token-b"}}, push.sent)
+		assert.Equal(t, models.OutboxDelivered, repo.outbox[0].status)
+		assert.Equal(t, models.DeliveryDelivered, repo.delivery("token-b").Status)
+	})
+
+	t.Run("backs off exponentially and fails after the last attempt", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush, "token-a")
+		push.err = errors.New("expo down")
+		s := newTestService(repo, push)
+		queueOne(t, s)
+
+		now := testNow
+		var waits []time.Duration
+		for attempt := 1; attempt <= maxAttempts; attempt++ {
+			s.now = func() time.Time { return now }
+			require.NoError(t, s.Drain(ctx))
+			if attempt < maxAttempts {
+				waits = append(waits, repo.outbox[0].runAfter.Sub(now))
+				now = repo.outbox[0].runAfter
+			}
+		}
+
+		assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second}, waits)
+		assert.Len(t, push.sent, maxAttempts)
+		assert.Equal(t, models.OutboxFailed, repo.outbox[0].status)
+		assert.Equal(t, "expo down", repo.outbox[0].error)
+	})
+
+	t.Run("prunes devices that are not registered", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("apns", models.ChannelPush, "token-a", "token-b")
+		push.receipt = func(address string) Receipt {
+			if address == "token-b" {
+				return Receipt{Address: address, Err: fmt.Errorf("%w: Unregistered", ErrDeviceNotRegistered)}
+			}
+			return Receipt{Address: address}
+		}
+		s := newTestService(repo, push)
+		queueOne(t, s)
+
+		require.NoError(t, s.Drain(ctx))
+
+		assert.Equal(t, []string{"token-b"}, repo.pruned)
+		assert.Equal(t, models.DeliveryFailed, repo.delivery("token-b").Status)
+		assert.Equal(t, models.OutboxDelivered, repo.outbox[0].status, "unregistered devices are not retried")
+	})
+
+	t.Run("fails entries for unknown users and unconfigured channels", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		repo.recipient = nil
+		s := newTestService(repo, newFakeChannel("expo", models.ChannelPush, "token-a"))
+		queueOne(t, s)
+		repo.outbox = append(repo.outbox, &outboxRow{
+			entry:  models.NotificationOutboxEntry{ID: "removed", Channel: "pager", UserID: "user_1"},
+			status: models.OutboxPending,
+		})
+
+		require.NoError(t, s.Drain(ctx))
+
+		assert.Equal(t, models.OutboxFailed, repo.outbox[0].status)
+		assert.Equal(t, models.OutboxFailed, repo.outbox[1].status)
+		assert.Contains(t, repo.outbox[1].error, `"pager" is not configured`)
+	})
+
+	t.Run("completes entries the user has no address for", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush)
+		s := newTestService(repo, push)
+		queueOne(t, s)
+
+		require.NoError(t, s.Drain(ctx))
+
+		assert.Empty(t, push.sent)
+		assert.Equal(t, models.OutboxDelivered, repo.outbox[0].status)
+	})
+}
+
+// holdUntil holds back the outbox entries from the from'th on until runAfter,
+// as the repository does within a digest window.
+func holdUntil(repo *mockNotificationsRepository, from int, runAfter time.Time) {
+	for _, row := range repo.outbox[from:] {
+		row.runAfter = runAfter
+	}
+}
+
+func TestService_Digest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	assign := func(t *testing.T, s *Service, requestID, name string) {
+		t.Helper()
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, requestID, "New task assigned to you", name))
+	}
+
+	t.Run("coalesces notifications within the window into one push", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush, "token-a")
+		s := newTestService(repo, push)
+		s.DigestWindow = 2 * time.Minute
+
+		assign(t, s, "request_1", "Towels")
+		require.NoError(t, s.Drain(ctx))
+		require.Len(t, push.messages, 1, "the first goes out straight away")
+		assert.Equal(t, "New task assigned to you", push.messages[0].Title)
+
+		for i, name := range []string{"Sheets", "Pillows", "Sheets", "Soap", "Minibar"} {
+			repo.advance(10 * time.Second)
+			assign(t, s, fmt.Sprintf("request_%d", i+2), name)
+		}
+		// The repository holds the rest back until the window closes.
+		holdUntil(repo, 1, testNow.Add(2*time.Minute))
+		s.now = repo.now
+		require.NoError(t, s.Drain(ctx))
+		assert.Len(t, push.messages, 1, "the rest are held until the window closes")
+
+		s.now = func() time.Time { return testNow.Add(2 * time.Minute) }
+		require.NoError(t, s.Drain(ctx))
+
+		require.Len(t, push.messages, 2)
+		assert.Equal(t, Message{
+			Type:   models.TypeTaskAssigned,
+			Title:  "5 new tasks assigned",
+			Body:   "Sheets, Pillows, Soap and 1 more",
+			SentAt: testNow.Add(50 * time.Second),
+		}, push.messages[1])
+		for _, row := range repo.outbox {
+			assert.Equal(t, models.OutboxDelivered, row.status)
+		}
+		assert.Len(t, repo.deliveries, 6, "a receipt per entry")
+	})
+
+	t.Run("titles a digest of mixed types generically", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush, "token-a")
+		s := newTestService(repo, push)
+		s.DigestWindow = time.Minute
+
+		assign(t, s, "request_1", "Towels")
+		require.NoError(t, s.Drain(ctx))
+		repo.advance(10 * time.Second)
+		assign(t, s, "request_2", "Sheets")
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeHighPriorityTask, "request_3", "High priority task", "Flood"))
+		holdUntil(repo, 1, testNow.Add(time.Minute))
+
+		s.now = func() time.Time { return testNow.Add(time.Minute) }
+		require.NoError(t, s.Drain(ctx))
+
+		require.Len(t, push.messages, 2)
+		assert.Equal(t, "2 new notifications", push.messages[1].Title)
+		assert.Equal(t, "Sheets, Flood", push.messages[1].Body)
+	})
+
+	t.Run("sends the digest to every address still owed part of it", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		push := newFakeChannel("expo", models.ChannelPush, "token-a", "token-b")
+		s := newTestService(repo, push)
+		s.DigestWindow = time.Minute
+
+		assign(t, s, "request_1", "Towels")
+		repo.advance(10 * time.Second)
+		assign(t, s, "request_2", "Sheets")
+		// The first was delivered to token-a before the second arrived.
+		require.NoError(t, repo.UpsertNotificationDeliveries(ctx, []*models.NotificationDelivery{
+			{OutboxID: repo.outbox[0].entry.ID, Address: "token-a", Status: models.DeliveryDelivered},
+		}))
+		repo.outbox[1].runAfter = testNow
+
+		require.NoError(t, s.Drain(ctx))
+
+		assert.Equal(t, [][]string{{"token-a", "token-b"}}, push.sent, "token-a is still owed the second")
+		assert.Len(t, repo.deliveries, 4)
+	})
+
+	t.Run("checks the ticket a digest shares once", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOutboxRepo()
+		expo := &ticketChannel{
+			fakeChannel: newFakeChannel("expo", models.ChannelPush, "token-a"),
+			receipts:    map[string]error{"ticket-token-a": fmt.Errorf("%w: gone", ErrDeviceNotRegistered)},
+		}
+		expo.receipt = func(address string) Receipt { return Receipt{Address: address, TicketID: "ticket-" + address} }
+		s := newTestService(repo, expo)
+		s.DigestWindow = time.Minute
+
+		assign(t, s, "request_1", "Towels")
+		assign(t, s, "request_2", "Sheets")
+		require.NoError(t, s.Drain(ctx))
+		require.NoError(t, s.CheckReceipts(ctx))
+
+		assert.Equal(t, []string{"ticket-token-a"}, expo.checked)
+		assert.Equal(t, []string{"token-a"}, repo.pruned)
+		for _, d := range repo.deliveries {
+			assert.Equal(t, models.DeliveryFailed, d.Status)
+		}
+	})
+}
+
+func TestService_CheckReceipts(t *testing.T) {
+	t.Parallel()
+
+	repo := newOutboxRepo()
+	expo := &ticketChannel{
+		fakeChannel: newFakeChannel("expo", models.ChannelPush, "token-a", "token-b", "token-c"),
+		receipts: map[string]error{
+			"ticket-token-a": nil,
+			"ticket-token-b": fmt.Errorf("%w: gone", ErrDeviceNotRegistered),
+		},
+	}
+	expo.receipt = func(address string) Receipt { return Receipt{Address: address, TicketID: "ticket-" + address} }
+	s := newTestService(repo, expo)
+	queueOne(t, s)
+
+	require.NoError(t, s.Drain(context.Background()))
+	assert.Equal(t, models.OutboxDelivered, repo.outbox[0].status)
+	assert.Equal(t, models.DeliverySent, repo.delivery("token-a").Status)
+
+	require.NoError(t, s.CheckReceipts(context.Background()))
+
+	assert.ElementsMatch(t, []string{"ticket-token-a", "ticket-token-b", "ticket-token-c"}, expo.checked)
+	assert.Equal(t, models.DeliveryDelivered, repo.delivery("token-a").Status)
+	assert.Equal(t, models.DeliveryFailed, repo.delivery("token-b").Status)
+	assert.Equal(t, "ticket-token-b", *repo.delivery("token-b").TicketID)
+	assert.Equal(t, models.DeliverySent, repo.delivery("token-c").Status, "tickets without a receipt yet are checked again later")
+	assert.Equal(t, []string{"token-b"}, repo.pruned)
+}
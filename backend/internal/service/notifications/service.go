@@ -1,90 +1,114 @@
 package notifications
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
-	"net/http"
+	"slices"
 	"time"
 
+	"github.com/generate/selfserve/internal/errs"
 	"github.com/generate/selfserve/internal/models"
 	storage "github.com/generate/selfserve/internal/service/storage/postgres"
 )
 
-const expoPushURL = "https://exp.host/--/exponent-push-api/v2/push/send"
-
-var (
-	ErrExpoPushFailed   = errors.New("expo push request failed")
-	ErrExpoPushRejected = errors.New("expo push rejected by server")
-)
-
 // NotificationSender is implemented by Service. Handlers that trigger
 // notifications depend on this interface for testability.
 type NotificationSender interface {
-	Notify(ctx context.Context, userID string, notifType models.NotificationType, title, body string) error
+	Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error
 }
 
+// Service writes notifications and, from Run, delivers them on the channels
+// besides in-app.
 type Service struct {
-	repo   storage.NotificationsRepository
-	client *http.Client
+	repo     storage.NotificationsRepository
+	channels []Channel
+	// Interval is how often Run polls the outbox when it is not woken by a
+	// new notification, and ReceiptInterval how often it checks for receipts.
+	Interval        time.Duration
+	ReceiptInterval time.Duration
+	// DigestWindow coalesces a user's notifications on a channel that arrive
+	// within it of one another into a single message, and DedupeWindow drops
+	// repeats of a notification about the same request within it. Mentions
+	// are never repeats, as each is of another comment. Zero disables either.
+	DigestWindow time.Duration
+	DedupeWindow time.Duration
+	wake         chan struct{}
+	now          func() time.Time
 }
 
-func NewService(repo storage.NotificationsRepository) *Service {
+// NewService delivers notifications on the given channels besides in-app.
+func NewService(repo storage.NotificationsRepository, channels ...Channel) *Service {
 	return &Service{
-		repo:   repo,
-		client: &http.Client{Timeout: 10 * time.Second},
+		repo:            repo,
+		channels:        channels,
+		Interval:        DefaultInterval,
+		ReceiptInterval: DefaultReceiptInterval,
+		wake:            make(chan struct{}, 1),
+		now:             time.Now,
 	}
 }
 
-// Notify persists an in-app notification and fires an Expo push to any
-// registered device tokens (fire-and-forget — push errors are logged only).
-func (s *Service) Notify(ctx context.Context, userID string, notifType models.NotificationType, title, body string) error {
-	if _, err := s.repo.InsertNotification(ctx, userID, notifType, title, body); err != nil {
-		return err
-	}
-
-	tokens, err := s.repo.FindDeviceTokensByUserID(ctx, userID)
+// Notify persists an in-app notification, queueing it for delivery on the
+// channels the user's preferences allow for notifType, held back through their
+// quiet hours or until their shift starts, plus any webhooks. Run delivers it.
+// requestID names the request it is about, if any; a repeat within
+// DedupeWindow is dropped.
+func (s *Service) Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error {
+	prefs, err := s.repo.FindNotificationPreferences(ctx, userID)
 	if err != nil {
-		slog.Error("notifications: failed to fetch device tokens", "user_id", userID, "err", err)
-		return nil
+		slog.Error("notifications: failed to fetch preferences, using defaults", "user_id", userID, "err", err)
+		prefs = &models.NotificationPreferences{}
 	}
 
-	if len(tokens) > 0 {
-		go s.sendExpoPush(tokens, title, body)
+	now := s.now()
+	allowed := prefs.ChannelsFor(notifType)
+	deliverAt, deliver := prefs.DeliverAt(now)
+	var due, held []string
+	for _, channel := range s.channels {
+		switch {
+		case channel.Kind() == models.ChannelWebhook:
+			due = append(due, channel.Name())
+		case deliver && slices.Contains(allowed, channel.Kind()):
+			due = append(due, channel.Name())
+			if deliverAt.After(now) {
+				held = append(held, channel.Name())
+			}
+		}
 	}
 
-	return nil
-}
-
-type expoMessage struct {
-	To    string `json:"to"`
-	Title string `json:"title"`
-	Body  string `json:"body"`
-}
-
-func (s *Service) sendExpoPush(tokens []string, title, body string) {
-	msgs := make([]expoMessage, len(tokens))
-	for i, t := range tokens {
-		msgs[i] = expoMessage{To: t, Title: title, Body: body}
+	input := &models.NotificationInput{
+		UserID:       userID,
+		Type:         notifType,
+		RequestID:    requestID,
+		Title:        title,
+		Body:         body,
+		Channels:     due,
+		DigestWindow: s.DigestWindow,
 	}
-
-	payload, err := json.Marshal(msgs)
-	if err != nil {
-		slog.Error("notifications: failed to marshal expo payload", "err", err)
-		return
+	if notifType != models.TypeMentioned {
+		input.DedupeWindow = s.DedupeWindow
+	}
+	if len(held) > 0 {
+		input.Held, input.HeldUntil = held, deliverAt
+	}
+	_, err = s.repo.InsertNotification(ctx, input)
+	if errors.Is(err, errs.ErrAlreadyExistsInDB) {
+		return nil
 	}
-
-	resp, err := s.client.Post(expoPushURL, "application/json", bytes.NewReader(payload))
 	if err != nil {
-		slog.Error("notifications: expo push failed", "err", fmt.Errorf("%w: %w", ErrExpoPushFailed, err))
-		return
+		return err
+	}
+	if len(due) > 0 {
+		s.notify()
 	}
-	defer resp.Body.Close()
+	return nil
+}
 
-	if resp.StatusCode >= 400 {
-		slog.Error("notifications: expo push rejected", "err", fmt.Errorf("%w: status %d", ErrExpoPushRejected, resp.StatusCode))
+// notify wakes Run without blocking when it is already due to wake.
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
 	}
 }
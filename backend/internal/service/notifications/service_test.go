@@ -0,0 +1,476 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outboxRow is an outbox entry held by mockNotificationsRepository.
+type outboxRow struct {
+	entry       models.NotificationOutboxEntry
+	status      models.NotificationOutboxStatus
+	error       string
+	runAfter    time.Time
+	lockedUntil time.Time
+}
+
+// mockNotificationsRepository keeps notifications, the outbox and receipts
+// in memory. Notifications are written at clock, or testNow when it is zero,
+// with their outbox entries due straight away; InsertNotification returns
+// insertErr when set, as for a duplicate.
+type mockNotificationsRepository struct {
+	storage.NotificationsRepository
+	mu            sync.Mutex
+	clock         time.Time
+	prefs         *models.NotificationPreferences
+	prefsErr      error
+	recipient     *models.NotificationRecipient
+	insertErr     error
+	notifications []*models.NotificationInput
+	outbox        []*outboxRow
+	deliveries    []*models.NotificationDelivery
+	pruned        []string
+}
+
+func (m *mockNotificationsRepository) now() time.Time {
+	if m.clock.IsZero() {
+		return testNow
+	}
+	return m.clock
+}
+
+// advance moves the clock on by d.
+func (m *mockNotificationsRepository) advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.clock = m.now().Add(d)
+}
+
+func (m *mockNotificationsRepository) InsertNotification(ctx context.Context, input *models.NotificationInput) (*models.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.insertErr != nil {
+		return nil, m.insertErr
+	}
+	now := m.now()
+	n := &models.Notification{
+		ID:        fmt.Sprintf("notification-%d", len(m.notifications)),
+		UserID:    input.UserID,
+		Type:      input.Type,
+		Title:     input.Title,
+		Body:      input.Body,
+		CreatedAt: now,
+	}
+	m.notifications = append(m.notifications, input)
+	for _, channel := range input.Channels {
+		m.outbox = append(m.outbox, &outboxRow{
+			entry: models.NotificationOutboxEntry{
+				ID:             n.ID + "-" + channel,
+				NotificationID: n.ID,
+				Channel:        channel,
+				UserID:         input.UserID,
+				Type:           input.Type,
+				Title:          input.Title,
+				Body:           input.Body,
+				CreatedAt:      now,
+			},
+			status:   models.OutboxPending,
+			runAfter: now,
+		})
+	}
+	return n, nil
+}
+
+func (m *mockNotificationsRepository) FindNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	if m.prefsErr != nil {
+		return nil, m.prefsErr
+	}
+	return m.prefs, nil
+}
+
+func (m *mockNotificationsRepository) FindNotificationRecipient(ctx context.Context, userID string) (*models.NotificationRecipient, error) {
+	if m.recipient == nil || m.recipient.UserID != userID {
+		return nil, errs.ErrNotFoundInDB
+	}
+	return m.recipient, nil
+}
+
+func (m *mockNotificationsRepository) ClaimNotificationOutbox(ctx context.Context, now time.Time, lease time.Duration) ([]*models.NotificationOutboxEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := func(row *outboxRow) bool {
+		return row.status == models.OutboxPending && !row.runAfter.After(now) && !row.lockedUntil.After(now)
+	}
+	var next *outboxRow
+	for _, row := range m.outbox {
+		if due(row) && (next == nil || row.runAfter.Before(next.runAfter)) {
+			next = row
+		}
+	}
+	if next == nil {
+		return nil, errs.ErrNotFoundInDB
+	}
+
+	user, channel := next.entry.UserID, next.entry.Channel
+	var entries []*models.NotificationOutboxEntry
+	for _, row := range m.outbox {
+		if due(row) && row.entry.UserID == user && row.entry.Channel == channel {
+			row.entry.Attempts++
+			row.lockedUntil = now.Add(lease)
+			entry := row.entry
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockNotificationsRepository) row(id string) *outboxRow {
+	for _, row := range m.outbox {
+		if row.entry.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+func (m *mockNotificationsRepository) CompleteNotificationOutboxEntry(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row := m.row(id)
+	row.status, row.error, row.lockedUntil = models.OutboxDelivered, "", time.Time{}
+	return nil
+}
+
+func (m *mockNotificationsRepository) RetryNotificationOutboxEntry(ctx context.Context, id, reason string, runAfter time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row := m.row(id)
+	row.error, row.runAfter, row.lockedUntil = reason, runAfter, time.Time{}
+	return nil
+}
+
+func (m *mockNotificationsRepository) FailNotificationOutboxEntry(ctx context.Context, id, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row := m.row(id)
+	row.status, row.error, row.lockedUntil = models.OutboxFailed, reason, time.Time{}
+	for _, d := range m.deliveries {
+		if d.OutboxID == id && d.Status == models.DeliveryRetrying {
+			d.Status = models.DeliveryFailed
+		}
+	}
+	return nil
+}
+
+func (m *mockNotificationsRepository) FindNotificationDeliveries(ctx context.Context, outboxID string) ([]*models.NotificationDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []*models.NotificationDelivery
+	for _, d := range m.deliveries {
+		if d.OutboxID == outboxID {
+			copied := *d
+			out = append(out, &copied)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockNotificationsRepository) UpsertNotificationDeliveries(ctx context.Context, deliveries []*models.NotificationDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range deliveries {
+		copied := *d
+		replaced := false
+		for i, existing := range m.deliveries {
+			if existing.OutboxID == d.OutboxID && existing.Address == d.Address {
+				if copied.TicketID == nil {
+					copied.TicketID = existing.TicketID
+				}
+				m.deliveries[i], replaced = &copied, true
+			}
+		}
+		if !replaced {
+			m.deliveries = append(m.deliveries, &copied)
+		}
+	}
+	return nil
+}
+
+// FindNotificationTickets returns every ticket on channel awaiting a receipt,
+// however long ago it was sent.
+func (m *mockNotificationsRepository) FindNotificationTickets(ctx context.Context, channel string, sentAfter, sentBefore time.Time, limit int) ([]*models.NotificationDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []*models.NotificationDelivery
+	for _, d := range m.deliveries {
+		if d.Status == models.DeliverySent && d.TicketID != nil && m.row(d.OutboxID).entry.Channel == channel {
+			copied := *d
+			out = append(out, &copied)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockNotificationsRepository) DeleteDeviceToken(ctx context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruned = append(m.pruned, token)
+	return nil
+}
+
+// queued returns the channels notifications were queued for.
+func (m *mockNotificationsRepository) queued() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var channels []string
+	for _, row := range m.outbox {
+		channels = append(channels, row.entry.Channel)
+	}
+	return channels
+}
+
+// fakeChannel delivers to a fixed set of addresses, recording where it sends
+// and the messages.
+// Receipts come from receipt, or succeed when it is nil.
+type fakeChannel struct {
+	name      string
+	kind      models.NotificationChannel
+	addresses []string
+	receipt   func(address string) Receipt
+	err       error
+	sent      [][]string
+	messages  []Message
+}
+
+func newFakeChannel(name string, kind models.NotificationChannel, addresses ...string) *fakeChannel {
+	return &fakeChannel{name: name, kind: kind, addresses: addresses}
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+
+func (f *fakeChannel) Kind() models.NotificationChannel { return f.kind }
+
+func (f *fakeChannel) Addresses(to *models.NotificationRecipient) []string { return f.addresses }
+
+func (f *fakeChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	f.sent = append(f.sent, addresses)
+	f.messages = append(f.messages, msg)
+	if f.err != nil {
+		return nil, f.err
+	}
+	receipts := make([]Receipt, len(addresses))
+	for i, address := range addresses {
+		receipts[i] = Receipt{Address: address}
+		if f.receipt != nil {
+			receipts[i] = f.receipt(address)
+		}
+	}
+	return receipts, nil
+}
+
+var testNow = time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC)
+
+// newTestService returns a service delivering on channels at testNow.
+func newTestService(repo *mockNotificationsRepository, channels ...Channel) *Service {
+	s := NewService(repo, channels...)
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func TestService_Notify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	channels := func() []Channel {
+		return []Channel{
+			newFakeChannel("expo", models.ChannelPush),
+			newFakeChannel("apns", models.ChannelPush),
+			newFakeChannel("email", models.ChannelEmail),
+			newFakeChannel("sms", models.ChannelSMS),
+		}
+	}
+
+	t.Run("queues push by default", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{prefs: &models.NotificationPreferences{}}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		assert.Equal(t, []string{"expo", "apns"}, repo.queued())
+		assert.Len(t, s.wake, 1, "Run is woken")
+	})
+
+	t.Run("holds push back until quiet hours end", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{
+			prefs: &models.NotificationPreferences{UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+				QuietHours: &models.DailyWindow{Start: "13:00", End: "15:00"},
+			}},
+		}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		require.Len(t, repo.notifications, 1)
+		input := repo.notifications[0]
+		assert.Equal(t, []string{"expo", "apns"}, input.Channels)
+		assert.Equal(t, []string{"expo", "apns"}, input.Held)
+		assert.True(t, input.HeldUntil.Equal(testNow.Add(time.Hour)), input.HeldUntil)
+	})
+
+	t.Run("holds nothing back outside quiet hours and on shift", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{
+			prefs: &models.NotificationPreferences{UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+				QuietHours:  &models.DailyWindow{Start: "22:00", End: "07:00"},
+				OnShiftOnly: true,
+				Shift:       &models.DailyWindow{Start: "08:00", End: "16:00"},
+			}},
+		}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		require.Len(t, repo.notifications, 1)
+		assert.Equal(t, []string{"expo", "apns"}, repo.notifications[0].Channels)
+		assert.Empty(t, repo.notifications[0].Held)
+	})
+
+	t.Run("only writes the in-app record when quiet hours cover the day", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{
+			prefs: &models.NotificationPreferences{UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+				QuietHours: &models.DailyWindow{Start: "00:00", End: "00:00"},
+			}},
+		}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		assert.Empty(t, repo.queued())
+		assert.Empty(t, s.wake)
+	})
+
+	t.Run("does not push types opted out of push", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{
+			prefs: &models.NotificationPreferences{UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+				Channels: map[models.NotificationType][]models.NotificationChannel{models.TypeMentioned: {models.ChannelInApp}},
+			}},
+		}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeMentioned, "request_1", "Mentioned", "in a comment"))
+		assert.Empty(t, repo.queued())
+	})
+
+	t.Run("falls back to the defaults when preferences cannot be read", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{prefsErr: errors.New("db down")}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeSLABreached, "request_1", "SLA breached", "Towels"))
+		assert.Equal(t, []string{"expo", "apns"}, repo.queued())
+	})
+
+	t.Run("queues the channels opted in to", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{
+			prefs: &models.NotificationPreferences{UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+				Channels: map[models.NotificationType][]models.NotificationChannel{models.TypeSLABreached: {models.ChannelEmail, models.ChannelSMS}},
+			}},
+		}
+		s := newTestService(repo, channels()...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeSLABreached, "request_1", "SLA breached", "Towels"))
+		assert.Equal(t, []string{"email", "sms"}, repo.queued())
+	})
+
+	t.Run("queues webhooks regardless of preferences", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{
+			prefs: &models.NotificationPreferences{UpdateNotificationPreferencesInput: models.UpdateNotificationPreferencesInput{
+				Channels:   map[models.NotificationType][]models.NotificationChannel{models.TypeTaskAssigned: {}},
+				QuietHours: &models.DailyWindow{Start: "13:00", End: "15:00"},
+			}},
+		}
+		s := newTestService(repo, append(channels(), newFakeChannel("webhook", models.ChannelWebhook))...)
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		assert.Equal(t, []string{"webhook"}, repo.queued())
+		assert.Empty(t, repo.notifications[0].Held)
+	})
+
+	t.Run("asks the repository to drop repeats within the dedupe window", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{prefs: &models.NotificationPreferences{}}
+		s := newTestService(repo, newFakeChannel("expo", models.ChannelPush))
+		s.DedupeWindow = 10 * time.Minute
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeSLABreached, "request_1", "Request overdue", "Towels"))
+		require.Len(t, repo.notifications, 1)
+		assert.Equal(t, 10*time.Minute, repo.notifications[0].DedupeWindow)
+	})
+
+	t.Run("never dedupes mentions", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{prefs: &models.NotificationPreferences{}}
+		s := newTestService(repo, newFakeChannel("expo", models.ChannelPush))
+		s.DedupeWindow = 10 * time.Minute
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeMentioned, "request_1", "Mentioned", "@sam towels"))
+		require.Len(t, repo.notifications, 1)
+		assert.Zero(t, repo.notifications[0].DedupeWindow)
+	})
+
+	t.Run("passes the digest window", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{prefs: &models.NotificationPreferences{}}
+		s := newTestService(repo, newFakeChannel("expo", models.ChannelPush))
+		s.DigestWindow = 2 * time.Minute
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		require.Len(t, repo.notifications, 1)
+		assert.Equal(t, 2*time.Minute, repo.notifications[0].DigestWindow)
+	})
+
+	t.Run("ignores a duplicate without waking Run", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockNotificationsRepository{prefs: &models.NotificationPreferences{}, insertErr: errs.ErrAlreadyExistsInDB}
+		s := newTestService(repo, newFakeChannel("expo", models.ChannelPush))
+		s.DedupeWindow = 10 * time.Minute
+
+		require.NoError(t, s.Notify(ctx, "user_1", models.TypeTaskAssigned, "request_1", "New task", "Towels"))
+		assert.Empty(t, s.wake)
+	})
+}
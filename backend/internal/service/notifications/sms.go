@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/models"
+)
+
+// SMSChannel texts users through an HTTP gateway.
+type SMSChannel struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+func NewSMSChannel(cfg config.SMS) *SMSChannel {
+	return &SMSChannel{url: cfg.GatewayURL, token: cfg.GatewayToken, from: cfg.From, client: newHTTPClient()}
+}
+
+func (c *SMSChannel) Name() string { return "sms" }
+
+func (c *SMSChannel) Kind() models.NotificationChannel { return models.ChannelSMS }
+
+type smsMessage struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+func (c *SMSChannel) Addresses(to *models.NotificationRecipient) []string {
+	if to.Phone == nil || *to.Phone == "" {
+		return nil
+	}
+	return []string{*to.Phone}
+}
+
+func (c *SMSChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	headers := http.Header{}
+	if c.token != "" {
+		headers.Set("Authorization", "Bearer "+c.token)
+	}
+
+	receipts := make([]Receipt, len(addresses))
+	for i, phone := range addresses {
+		err := postJSON(ctx, c.client, c.url, headers, smsMessage{
+			From: c.from,
+			To:   phone,
+			Body: msg.Title + ": " + msg.Body,
+		})
+		receipts[i] = Receipt{Address: phone, Err: err}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,115 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+)
+
+const (
+	// DefaultSummaryInterval is how often Run checks for users due their
+	// daily summary.
+	DefaultSummaryInterval = time.Minute
+	// DefaultSummaryWindow is how long past the set time a daily summary is
+	// still sent, as to a server started late; the day is skipped after it.
+	DefaultSummaryWindow = time.Hour
+	// summaryBatchSize caps how many users are claimed at a time.
+	summaryBatchSize = 100
+	msgDailySummary  = "Daily summary"
+)
+
+// Summarizer sends each department member, once a day at a set time in their
+// timezone, the number of open and overdue requests in their departments.
+type Summarizer struct {
+	repo     storage.NotificationsRepository
+	notifier NotificationSender
+	at       string
+	Interval time.Duration
+	Window   time.Duration
+	now      func() time.Time
+}
+
+// NewSummarizer sends daily summaries at at, "HH:MM" in each user's timezone.
+func NewSummarizer(repo storage.NotificationsRepository, notifier NotificationSender, at string) *Summarizer {
+	return &Summarizer{
+		repo:     repo,
+		notifier: notifier,
+		at:       at,
+		Interval: DefaultSummaryInterval,
+		Window:   DefaultSummaryWindow,
+		now:      time.Now,
+	}
+}
+
+// Run sweeps every Interval until ctx is cancelled.
+func (s *Summarizer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx, s.now()); err != nil && ctx.Err() == nil {
+			slog.Error("notifications: daily summary sweep failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep sends the summary to every user due one at now, up to Window past the
+// set time in their timezone. A failure to send one is logged and does not
+// stop the others; it is not sent again that day.
+func (s *Summarizer) Sweep(ctx context.Context, now time.Time) error {
+	for ctx.Err() == nil {
+		recipients, err := s.repo.ClaimDailySummaries(ctx, s.at, s.Window, now, summaryBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, recipient := range recipients {
+			if err := s.send(ctx, recipient.UserID, now); err != nil {
+				slog.Error("notifications: failed to send daily summary", "err", err, "user_id", recipient.UserID)
+			}
+		}
+		if len(recipients) < summaryBatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *Summarizer) send(ctx context.Context, userID string, now time.Time) error {
+	workloads, err := s.repo.FindDepartmentWorkloads(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	if len(workloads) == 0 {
+		return nil
+	}
+	return s.notifier.Notify(ctx, userID, models.TypeDailySummary, "", msgDailySummary, summarize(workloads))
+}
+
+// summarize describes the workloads, e.g. "Housekeeping: 12 open, 3 overdue;
+// Front Desk: nothing open".
+func summarize(workloads []*models.DepartmentWorkload) string {
+	parts := make([]string, len(workloads))
+	for i, w := range workloads {
+		switch {
+		case w.Open == 0:
+			parts[i] = fmt.Sprintf("%s: nothing open", w.Name)
+		case w.Overdue == 0:
+			parts[i] = fmt.Sprintf("%s: %d open", w.Name, w.Open)
+		default:
+			parts[i] = fmt.Sprintf("%s: %d open, %d overdue", w.Name, w.Open, w.Overdue)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
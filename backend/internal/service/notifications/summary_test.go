@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryRepo answers for the summarizer: due lists the users due a summary
+// and workloads their departments' requests. window is the window last
+// claimed within.
+type summaryRepo struct {
+	*mockNotificationsRepository
+	due       []string
+	window    time.Duration
+	claimed   []string
+	workloads map[string][]*models.DepartmentWorkload
+	err       error
+}
+
+func (m *summaryRepo) ClaimDailySummaries(ctx context.Context, at string, window time.Duration, now time.Time, limit int) ([]*models.DailySummaryRecipient, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.window = window
+	var recipients []*models.DailySummaryRecipient
+	for len(m.due) > 0 && len(recipients) < limit {
+		recipients = append(recipients, &models.DailySummaryRecipient{UserID: m.due[0], SentOn: now})
+		m.claimed = append(m.claimed, m.due[0])
+		m.due = m.due[1:]
+	}
+	return recipients, nil
+}
+
+func (m *summaryRepo) FindDepartmentWorkloads(ctx context.Context, userID string, now time.Time) ([]*models.DepartmentWorkload, error) {
+	return m.workloads[userID], nil
+}
+
+func TestSummarizer_Sweep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("sends each department member due one their departments' workload", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &summaryRepo{
+			mockNotificationsRepository: &mockNotificationsRepository{prefs: &models.NotificationPreferences{}},
+			due:                         []string{"user_1", "user_2"},
+			workloads: map[string][]*models.DepartmentWorkload{
+				"user_1": {
+					{DepartmentID: "dept-fd", Name: "Front Desk"},
+					{DepartmentID: "dept-hk", Name: "Housekeeping", Open: 12, Overdue: 3},
+				},
+				"user_2": {{DepartmentID: "dept-hk", Name: "Housekeeping", Open: 12}},
+			},
+		}
+		s := NewSummarizer(repo, newTestService(repo.mockNotificationsRepository), "08:00")
+
+		require.NoError(t, s.Sweep(ctx, testNow))
+
+		require.Len(t, repo.notifications, 2)
+		assert.Equal(t, &models.NotificationInput{
+			UserID: "user_1",
+			Type:   models.TypeDailySummary,
+			Title:  "Daily summary",
+			Body:   "Front Desk: nothing open; Housekeeping: 12 open, 3 overdue",
+		}, repo.notifications[0])
+		assert.Equal(t, "Housekeeping: 12 open", repo.notifications[1].Body)
+	})
+
+	t.Run("claims users in batches until none is due", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &summaryRepo{mockNotificationsRepository: &mockNotificationsRepository{}}
+		for range summaryBatchSize + 1 {
+			repo.due = append(repo.due, "user_1")
+		}
+		s := NewSummarizer(repo, newTestService(repo.mockNotificationsRepository), "08:00")
+
+		require.NoError(t, s.Sweep(ctx, testNow))
+
+		assert.Len(t, repo.claimed, summaryBatchSize+1)
+		assert.Equal(t, DefaultSummaryWindow, repo.window)
+		assert.Empty(t, repo.notifications, "users without departments are skipped")
+	})
+
+	t.Run("returns a failure to claim", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &summaryRepo{mockNotificationsRepository: &mockNotificationsRepository{}, err: errors.New("db down")}
+		s := NewSummarizer(repo, newTestService(repo.mockNotificationsRepository), "08:00")
+
+		assert.EqualError(t, s.Sweep(ctx, testNow), "db down")
+	})
+}
@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of a webhook body, keyed with
+// the hotel's webhook secret, as "sha256=<hex>".
+const SignatureHeader = "X-Selfserve-Signature"
+
+// WebhookChannel posts every notification to the webhooks of the recipient's
+// hotel.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+func NewWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{client: newHTTPClient()}
+}
+
+func (c *WebhookChannel) Name() string { return "webhook" }
+
+func (c *WebhookChannel) Kind() models.NotificationChannel { return models.ChannelWebhook }
+
+type webhookPayload struct {
+	Type    models.NotificationType `json:"type"`
+	UserID  string                  `json:"user_id"`
+	HotelID string                  `json:"hotel_id"`
+	Title   string                  `json:"title"`
+	Body    string                  `json:"body"`
+	SentAt  time.Time               `json:"sent_at"`
+}
+
+func (c *WebhookChannel) Addresses(to *models.NotificationRecipient) []string {
+	return to.WebhookURLs
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, to *models.NotificationRecipient, addresses []string, msg Message) ([]Receipt, error) {
+	payload, err := json.Marshal(webhookPayload{
+		Type:    msg.Type,
+		UserID:  to.UserID,
+		HotelID: to.HotelID,
+		Title:   msg.Title,
+		Body:    msg.Body,
+		SentAt:  msg.SentAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	if to.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(to.WebhookSecret))
+		mac.Write(payload)
+		headers.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	receipts := make([]Receipt, len(addresses))
+	for i, url := range addresses {
+		receipts[i] = Receipt{Address: url, Err: postJSON(ctx, c.client, url, headers, json.RawMessage(payload))}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,76 @@
+package requestevents
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/generate/selfserve/internal/models"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before it is dropped: its channel is closed so the stream ends and the
+// client reconnects with Last-Event-ID to replay what it missed.
+const subscriberBuffer = 64
+
+// Broker fans request events out to in-process subscribers, scoped by hotel.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan *models.RequestEvent]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan *models.RequestEvent]struct{}),
+	}
+}
+
+// Publish delivers the event to every subscriber of event.HotelID without
+// blocking; subscribers whose buffer is full are dropped.
+func (b *Broker) Publish(event *models.RequestEvent) {
+	b.mu.RLock()
+	var slow []chan *models.RequestEvent
+	for ch := range b.subscribers[event.HotelID] {
+		select {
+		case ch <- event:
+		default:
+			slow = append(slow, ch)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, ch := range slow {
+		slog.Warn("requestevents: dropping slow subscriber", "hotel_id", event.HotelID, "event_id", event.ID)
+		b.remove(event.HotelID, ch)
+	}
+}
+
+// Subscribe registers a subscriber for the given hotel. The returned func
+// must be called to unsubscribe; it closes the channel unless Publish closed
+// it already for falling behind.
+func (b *Broker) Subscribe(hotelID string) (<-chan *models.RequestEvent, func()) {
+	ch := make(chan *models.RequestEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[hotelID] == nil {
+		b.subscribers[hotelID] = make(map[chan *models.RequestEvent]struct{})
+	}
+	b.subscribers[hotelID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() { b.remove(hotelID, ch) }
+}
+
+// remove unregisters ch and closes it, unless it was removed already.
+func (b *Broker) remove(hotelID string, ch chan *models.RequestEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[hotelID][ch]; !ok {
+		return
+	}
+	delete(b.subscribers[hotelID], ch)
+	if len(b.subscribers[hotelID]) == 0 {
+		delete(b.subscribers, hotelID)
+	}
+	close(ch)
+}
@@ -0,0 +1,113 @@
+package requestsearch
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+)
+
+// IndexingRepository wraps a RequestsRepository and reindexes a request after
+// every successful insert or update, so the search index follows writes from
+// handlers, workflows and the SLA evaluator alike. Indexing failures are only
+// logged: the write has already succeeded, and reindex-requests repairs drift.
+type IndexingRepository struct {
+	storage.RequestsRepository
+	index storage.RequestsSearchRepository
+}
+
+func NewIndexingRepository(repo storage.RequestsRepository, index storage.RequestsSearchRepository) *IndexingRepository {
+	return &IndexingRepository{RequestsRepository: repo, index: index}
+}
+
+func (r *IndexingRepository) InsertRequest(ctx context.Context, req *models.Request) (*models.Request, error) {
+	res, err := r.RequestsRepository.InsertRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r.reindex(ctx, res)
+	return res, nil
+}
+
+func (r *IndexingRepository) InsertRequests(ctx context.Context, reqs []*models.Request) ([]*models.Request, error) {
+	res, err := r.RequestsRepository.InsertRequests(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range res {
+		r.reindex(ctx, req)
+	}
+	return res, nil
+}
+
+func (r *IndexingRepository) UpdateRequest(ctx context.Context, id string, patch *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+	res, err := r.RequestsRepository.UpdateRequest(ctx, id, patch, changedBy)
+	if err != nil {
+		return nil, err
+	}
+	r.reindex(ctx, res)
+	return res, nil
+}
+
+func (r *IndexingRepository) UpdateRequests(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error) {
+	results, err := r.RequestsRepository.UpdateRequests(ctx, updates, changedBy)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range results {
+		if res.Err == nil {
+			r.reindex(ctx, res.Request)
+		}
+	}
+	return results, nil
+}
+
+func (r *IndexingRepository) reindex(ctx context.Context, req *models.Request) {
+	reindex(ctx, r.RequestsRepository, r.index, req)
+}
+
+// IndexingDraftsRepository wraps a RequestDraftsRepository the same way
+// IndexingRepository wraps a RequestsRepository. Drafts stay out of the feed,
+// so only ready requests and approved or rejected drafts are indexed.
+type IndexingDraftsRepository struct {
+	storage.RequestDraftsRepository
+	requests storage.RequestsRepository
+	index    storage.RequestsSearchRepository
+}
+
+func NewIndexingDraftsRepository(repo storage.RequestDraftsRepository, requests storage.RequestsRepository, index storage.RequestsSearchRepository) *IndexingDraftsRepository {
+	return &IndexingDraftsRepository{RequestDraftsRepository: repo, requests: requests, index: index}
+}
+
+func (r *IndexingDraftsRepository) InsertGeneratedRequests(ctx context.Context, drafts []*models.RequestDraft, ready []*models.Request) error {
+	if err := r.RequestDraftsRepository.InsertGeneratedRequests(ctx, drafts, ready); err != nil {
+		return err
+	}
+	for _, req := range ready {
+		reindex(ctx, r.requests, r.index, req)
+	}
+	return nil
+}
+
+func (r *IndexingDraftsRepository) UpdateRequestDraft(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string, review *models.DraftReview) (*models.Request, error) {
+	res, err := r.RequestDraftsRepository.UpdateRequestDraft(ctx, id, update, changedBy, review)
+	if err != nil {
+		return nil, err
+	}
+	if res.Status != string(models.StatusDraft) {
+		reindex(ctx, r.requests, r.index, res)
+	}
+	return res, nil
+}
+
+func reindex(ctx context.Context, requests storage.RequestsRepository, index storage.RequestsSearchRepository, req *models.Request) {
+	latest, err := requests.FindGuestRequest(ctx, req.ID)
+	if err != nil {
+		slog.Error("requestsearch: failed to load request for indexing", "err", err, "request_id", req.ID)
+		return
+	}
+	if err := index.IndexRequest(ctx, models.NewRequestDocument(req.HotelID, latest)); err != nil {
+		slog.Error("requestsearch: failed to index request", "err", err, "request_id", req.ID)
+	}
+}
@@ -0,0 +1,139 @@
+package requestsearch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRequestsRepository struct {
+	storage.RequestsRepository
+	err error
+}
+
+func (m *mockRequestsRepository) InsertRequest(ctx context.Context, req *models.Request) (*models.Request, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return req, nil
+}
+
+func (m *mockRequestsRepository) UpdateRequest(ctx context.Context, id string, patch *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Request{ID: id, MakeRequest: models.MakeRequest{HotelID: "org_1", Priority: *patch.Priority}}, nil
+}
+
+func (m *mockRequestsRepository) FindGuestRequest(ctx context.Context, id string) (*models.GuestRequest, error) {
+	return &models.GuestRequest{ID: id, Priority: "high"}, nil
+}
+
+type mockSearchRepository struct {
+	indexed []*models.RequestDocument
+}
+
+func (m *mockSearchRepository) IndexRequest(ctx context.Context, doc *models.RequestDocument) error {
+	m.indexed = append(m.indexed, doc)
+	return nil
+}
+
+func (m *mockSearchRepository) SearchRequests(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, *models.RequestFacets, error) {
+	return nil, nil, nil
+}
+
+type mockDraftsRepository struct {
+	storage.RequestDraftsRepository
+}
+
+func (m *mockDraftsRepository) InsertGeneratedRequests(ctx context.Context, drafts []*models.RequestDraft, ready []*models.Request) error {
+	return nil
+}
+
+func (m *mockDraftsRepository) UpdateRequestDraft(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string, review *models.DraftReview) (*models.Request, error) {
+	return &models.Request{ID: id, MakeRequest: models.MakeRequest{HotelID: "org_1", Status: *update.Status}}, nil
+}
+
+func TestIndexingRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("indexes the latest version after an update", func(t *testing.T) {
+		t.Parallel()
+
+		search := &mockSearchRepository{}
+		repo := NewIndexingRepository(&mockRequestsRepository{}, search)
+
+		high := "high"
+		_, err := repo.UpdateRequest(context.Background(), "req-1", &models.RequestUpdateInput{Priority: &high}, nil)
+		require.NoError(t, err)
+
+		require.Len(t, search.indexed, 1)
+		assert.Equal(t, "req-1", search.indexed[0].ID)
+		assert.Equal(t, "org_1", search.indexed[0].HotelID)
+		assert.Equal(t, 1, search.indexed[0].PriorityRank)
+	})
+
+	t.Run("does not index when the write fails", func(t *testing.T) {
+		t.Parallel()
+
+		search := &mockSearchRepository{}
+		repo := NewIndexingRepository(&mockRequestsRepository{err: errors.New("db down")}, search)
+
+		_, err := repo.InsertRequest(context.Background(), &models.Request{ID: "req-1"})
+		require.Error(t, err)
+		assert.Empty(t, search.indexed)
+	})
+}
+
+func TestIndexingDraftsRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("indexes an approved draft", func(t *testing.T) {
+		t.Parallel()
+
+		search := &mockSearchRepository{}
+		repo := NewIndexingDraftsRepository(&mockDraftsRepository{}, &mockRequestsRepository{}, search)
+
+		pending := string(models.StatusPending)
+		_, err := repo.UpdateRequestDraft(context.Background(), "req-1", &models.RequestUpdateInput{Status: &pending}, nil, &models.DraftReview{Action: models.DraftApproved})
+		require.NoError(t, err)
+
+		require.Len(t, search.indexed, 1)
+		assert.Equal(t, "req-1", search.indexed[0].ID)
+		assert.Equal(t, "org_1", search.indexed[0].HotelID)
+	})
+
+	t.Run("does not index an edit that leaves the request a draft", func(t *testing.T) {
+		t.Parallel()
+
+		search := &mockSearchRepository{}
+		repo := NewIndexingDraftsRepository(&mockDraftsRepository{}, &mockRequestsRepository{}, search)
+
+		draft := string(models.StatusDraft)
+		_, err := repo.UpdateRequestDraft(context.Background(), "req-1", &models.RequestUpdateInput{Status: &draft}, nil, nil)
+		require.NoError(t, err)
+
+		assert.Empty(t, search.indexed)
+	})
+
+	t.Run("indexes ready requests but not drafts from a generated batch", func(t *testing.T) {
+		t.Parallel()
+
+		search := &mockSearchRepository{}
+		repo := NewIndexingDraftsRepository(&mockDraftsRepository{}, &mockRequestsRepository{}, search)
+
+		err := repo.InsertGeneratedRequests(context.Background(),
+			[]*models.RequestDraft{{Request: models.Request{ID: "draft-1"}}},
+			[]*models.Request{{ID: "req-1", MakeRequest: models.MakeRequest{HotelID: "org_1"}}})
+		require.NoError(t, err)
+
+		require.Len(t, search.indexed, 1)
+		assert.Equal(t, "req-1", search.indexed[0].ID)
+	})
+}
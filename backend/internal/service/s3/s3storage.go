@@ -2,13 +2,17 @@ package s3
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/generate/selfserve/config"
+	"github.com/generate/selfserve/internal/errs"
 	"github.com/generate/selfserve/internal/httpx"
 	"github.com/generate/selfserve/internal/models"
 )
@@ -45,10 +49,18 @@ func (s *Storage) GeneratePresignedUploadURL(ctx context.Context, in models.Pres
 		return "", err
 	}
 
-	presignedURL, err := s.URL.PresignPutObject(ctx, &s3.PutObjectInput{
+	put := &s3.PutObjectInput{
 		Bucket: aws.String(s.BucketName),
 		Key:    aws.String(in.Key),
-	}, func(opts *s3.PresignOptions) {
+	}
+	if in.ContentType != "" {
+		put.ContentType = aws.String(in.ContentType)
+	}
+	if in.ContentLength > 0 {
+		put.ContentLength = aws.Int64(in.ContentLength)
+	}
+
+	presignedURL, err := s.URL.PresignPutObject(ctx, put, func(opts *s3.PresignOptions) {
 		opts.Expires = in.Expiration
 	})
 	if err != nil {
@@ -74,6 +86,60 @@ func (s *Storage) GeneratePresignedGetURL(ctx context.Context, in models.Presign
 	return presignedURL.URL, nil
 }
 
+// HeadFile returns the stored content type and size of key, or
+// errs.ErrNotFoundInStorage when nothing has been uploaded there.
+func (s *Storage) HeadFile(ctx context.Context, key string) (*models.S3ObjectInfo, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+
+	out, err := s.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, errs.ErrNotFoundInStorage
+		}
+		return nil, fmt.Errorf("failed to head file with key %s: %w", key, err)
+	}
+	return &models.S3ObjectInfo{
+		ContentType:   aws.ToString(out.ContentType),
+		ContentLength: aws.ToInt64(out.ContentLength),
+	}, nil
+}
+
+// GetFile reads the object at key, failing when it is larger than maxSize
+// bytes. A missing object is errs.ErrNotFoundInStorage.
+func (s *Storage) GetFile(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+
+	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, errs.ErrNotFoundInStorage
+		}
+		return nil, fmt.Errorf("failed to get file with key %s: %w", key, err)
+	}
+	defer func() { _ = out.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file with key %s: %w", key, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file with key %s is larger than %d bytes", key, maxSize)
+	}
+	return data, nil
+}
+
 func (s *Storage) DeleteFile(ctx context.Context, key string) error {
 	if key == "" {
 		return fmt.Errorf("key is required")
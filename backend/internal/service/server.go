@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	clerksdk "github.com/clerk/clerk-sdk-go/v2"
 	"github.com/generate/selfserve/config"
@@ -15,9 +16,14 @@ import (
 	"github.com/generate/selfserve/internal/handler"
 	"github.com/generate/selfserve/internal/repository"
 	temporalservice "github.com/generate/selfserve/internal/temporal"
+	"github.com/generate/selfserve/internal/temporal/activities"
 
 	"github.com/generate/selfserve/internal/service/clerk"
+	"github.com/generate/selfserve/internal/service/generatequeue"
 	notificationssvc "github.com/generate/selfserve/internal/service/notifications"
+	"github.com/generate/selfserve/internal/service/requestevents"
+	"github.com/generate/selfserve/internal/service/requestsearch"
+	slasvc "github.com/generate/selfserve/internal/service/sla"
 	"github.com/generate/selfserve/internal/storage/redis"
 
 	s3storage "github.com/generate/selfserve/internal/service/s3"
@@ -44,6 +50,28 @@ type App struct {
 	RedisClient    *goredis.Client
 	TemporalClient client.Client
 	TemporalWorker worker.Worker
+	// StopBackground stops background jobs such as the SLA evaluator.
+	StopBackground context.CancelFunc
+}
+
+// Close stops the background jobs and the Temporal worker, then closes the
+// connections they use.
+func (a *App) Close() error {
+	if a.StopBackground != nil {
+		a.StopBackground()
+	}
+	if a.TemporalWorker != nil {
+		a.TemporalWorker.Stop()
+	}
+	if a.TemporalClient != nil {
+		a.TemporalClient.Close()
+	}
+	if a.RedisClient != nil {
+		if err := a.RedisClient.Close(); err != nil {
+			log.Printf("Warning: failed to close Redis: %v", err)
+		}
+	}
+	return a.Repo.Close()
 }
 
 func InitApp(cfg *config.Config) (*App, error) {
@@ -72,12 +100,45 @@ func InitApp(cfg *config.Config) (*App, error) {
 	guestsRepo := repository.NewGuestsRepository(repo.DB)
 	usersLookupRepo := repository.NewUsersRepository(repo.DB)
 	hotelsLookupRepo := repository.NewHotelsRepository(repo.DB)
-	genkitInstance := aiflows.InitGenkit(context.Background(), &cfg.LLM, roomsRepo, guestsRepo, usersLookupRepo, hotelsLookupRepo)
-	workflowClient, temporalClient, temporalWorker := tryInitTemporal(cfg, genkitInstance)
+	generateService := tryInitGenkit(cfg, roomsRepo, guestsRepo, usersLookupRepo, hotelsLookupRepo)
+	var requestsRepo storage.RequestsRepository = repository.NewRequestsRepo(repo.DB)
+	if openSearchRepos.Requests != nil {
+		requestsRepo = requestsearch.NewIndexingRepository(requestsRepo, openSearchRepos.Requests)
+	}
+	seriesRepo := repository.NewRequestSeriesRepository(repo.DB)
+	requestBroker := requestevents.NewBroker()
+	channels, err := notificationssvc.NewChannels(cfg.Notifications)
+	if err != nil {
+		log.Printf("Warning: notification channels disabled: %v", err)
+	}
+	notificationsRepo := repository.NewNotificationsRepository(repo.DB)
+	notifier := notificationssvc.NewService(notificationsRepo, channels...)
+	notifier.DigestWindow = cfg.Notifications.DigestWindow
+	notifier.DedupeWindow = cfg.Notifications.DedupeWindow
+	var draftsRepo storage.RequestDraftsRepository = repository.NewRequestDraftsRepository(repo.DB)
+	if openSearchRepos.Requests != nil {
+		draftsRepo = requestsearch.NewIndexingDraftsRepository(draftsRepo, requestsRepo, openSearchRepos.Requests)
+	}
+	workflowClient, temporalClient, temporalWorker := tryInitTemporal(cfg, generateService, requestsRepo, seriesRepo, draftsRepo, notifier, requestBroker)
+	generateQueue := generatequeue.NewQueue(repository.NewGenerateRequestJobsRepository(repo.DB), &activities.Activities{
+		Service:           generateService,
+		RequestRepository: requestsRepo,
+		SeriesRepository:  seriesRepo,
+		DraftRepository:   draftsRepo,
+		ReviewThreshold:   cfg.LLM.ReviewThreshold,
+		Notifier:          notifier,
+		Events:            requestBroker,
+	})
+	var generateClient temporalservice.GenerateRequestWorkflowClient = generateQueue
+	if workflowClient != nil {
+		generateClient = generatequeue.NewFallback(workflowClient, generateQueue)
+	} else {
+		log.Printf("Warning: running async request generation in-process until Temporal is available")
+	}
 	app := setupApp()
 	setupClerk(cfg)
 
-	if err = setupRoutes(app, repo, genkitInstance, workflowClient, cfg, s3Store, openSearchRepos); err != nil { //nolint:wsl
+	if err = setupRoutes(app, repo, requestsRepo, draftsRepo, generateService, generateClient, workflowClient, requestBroker, notifier, cfg, s3Store, openSearchRepos); err != nil { //nolint:wsl
 		if e := repo.Close(); e != nil {
 			return nil, errors.Join(err, e)
 		}
@@ -87,6 +148,26 @@ func InitApp(cfg *config.Config) (*App, error) {
 		return nil, err
 	}
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	slaEvaluator := slasvc.NewEvaluator(
+		repository.NewSLARepository(repo.DB),
+		requestsRepo,
+		notifier,
+	)
+	slaEvaluator.Events = requestBroker
+	go slaEvaluator.Run(backgroundCtx)
+	go notifier.Run(backgroundCtx)
+	if at := cfg.Notifications.DailySummaryAt; at != "" {
+		if _, err := time.Parse("15:04", at); err != nil {
+			log.Printf("Warning: daily summaries disabled: invalid time %q", at)
+		} else {
+			go notificationssvc.NewSummarizer(notificationsRepo, notifier, at).Run(backgroundCtx)
+		}
+	}
+	// The queue also runs alongside Temporal, to run jobs queued while
+	// Temporal is unavailable.
+	go generateQueue.Run(backgroundCtx)
+
 	return &App{
 		Server:         app,
 		Repo:           repo,
@@ -94,26 +175,46 @@ func InitApp(cfg *config.Config) (*App, error) {
 		S3Storage:      s3Store,
 		TemporalClient: temporalClient,
 		TemporalWorker: temporalWorker,
+		StopBackground: stopBackground,
 	}, nil
 }
 
 type openSearchRepositories struct {
-	Guests storage.GuestsSearchRepository
+	Guests   storage.GuestsSearchRepository
+	Requests storage.RequestsSearchRepository
 }
 
 func tryInitOpenSearchRepositories(cfg *config.Config) openSearchRepositories {
+	var repos openSearchRepositories
+
 	client, err := opensearchstorage.NewClient(cfg.OpenSearch)
 	if err != nil {
 		log.Printf("Warning: OpenSearch not available: %v", err)
-		return openSearchRepositories{}
+		return repos
 	}
 	if err := opensearchstorage.EnsureGuestsIndex(context.Background(), client); err != nil {
 		log.Printf("Warning: failed to ensure OpenSearch guests index: %v", err)
-		return openSearchRepositories{}
+	} else {
+		repos.Guests = repository.NewOpenSearchGuestsRepository(client)
 	}
-	return openSearchRepositories{
-		Guests: repository.NewOpenSearchGuestsRepository(client),
+	if err := opensearchstorage.EnsureRequestsIndex(context.Background(), client); err != nil {
+		log.Printf("Warning: failed to ensure OpenSearch requests index: %v", err)
+	} else {
+		repos.Requests = repository.NewOpenSearchRequestsRepository(client)
+	}
+	return repos
+}
+
+// tryInitGenkit returns nil when no LLM provider is available; request
+// generation endpoints then respond 503.
+func tryInitGenkit(cfg *config.Config, roomsRepo aiflows.RoomLookupRepository, guestsRepo aiflows.GuestLookupRepository,
+	usersRepo aiflows.UserLookupRepository, hotelsRepo aiflows.HotelLookupRepository) aiflows.GenerateRequestService {
+	genkitService, err := aiflows.InitGenkit(context.Background(), &cfg.LLM, roomsRepo, guestsRepo, usersRepo, hotelsRepo)
+	if err != nil {
+		log.Printf("Warning: request generation not available: %v", err)
+		return nil
 	}
+	return genkitService
 }
 
 func tryInitRedis() *goredis.Client {
@@ -125,7 +226,8 @@ func tryInitRedis() *goredis.Client {
 	return redisClient
 }
 
-func tryInitTemporal(cfg *config.Config, genkitService aiflows.GenerateRequestService) (temporalservice.GenerateRequestWorkflowClient, client.Client, worker.Worker) {
+func tryInitTemporal(cfg *config.Config, genkitService aiflows.GenerateRequestService, requestsRepo storage.RequestsRepository, seriesRepo storage.RequestSeriesRepository,
+	draftsRepo storage.RequestDraftsRepository, notifier *notificationssvc.Service, requestBroker *requestevents.Broker) (*temporalservice.Service, client.Client, worker.Worker) {
 	temporalClient, err := temporalservice.NewClient(cfg.Temporal)
 	if err != nil {
 		log.Printf("Warning: Temporal not available: %v", err)
@@ -133,7 +235,7 @@ func tryInitTemporal(cfg *config.Config, genkitService aiflows.GenerateRequestSe
 	}
 
 	workflowClient := temporalservice.NewService(temporalClient)
-	temporalWorker := temporalservice.NewWorker(temporalClient, genkitService)
+	temporalWorker := temporalservice.NewWorker(temporalClient, genkitService, requestsRepo, seriesRepo, draftsRepo, cfg.LLM.ReviewThreshold, notifier, requestBroker)
 	if err := temporalWorker.Start(); err != nil {
 		log.Printf("Warning: failed to start Temporal worker: %v", err)
 		temporalClient.Close()
@@ -143,8 +245,8 @@ func tryInitTemporal(cfg *config.Config, genkitService aiflows.GenerateRequestSe
 	return workflowClient, temporalClient, temporalWorker
 }
 
-func setupRoutes(app *fiber.App, repo *storage.Repository, genkitInstance *aiflows.GenkitService,
-	workflowClient temporalservice.GenerateRequestWorkflowClient, cfg *config.Config, s3Store *s3storage.Storage, openSearchRepos openSearchRepositories) error {
+func setupRoutes(app *fiber.App, repo *storage.Repository, requestsRepo storage.RequestsRepository, draftsRepo storage.RequestDraftsRepository, generateService aiflows.GenerateRequestService,
+	generateClient temporalservice.GenerateRequestWorkflowClient, workflowClient *temporalservice.Service, requestBroker *requestevents.Broker, notifier *notificationssvc.Service, cfg *config.Config, s3Store *s3storage.Storage, openSearchRepos openSearchRepositories) error {
 	// Swagger documentation
 	app.Get("/swagger/*", handler.ServeSwagger)
 
@@ -164,7 +266,6 @@ func setupRoutes(app *fiber.App, repo *storage.Repository, genkitInstance *aiflo
 
 	// initialize notifications
 	notifRepo := repository.NewNotificationsRepository(repo.DB)
-	notifService := notificationssvc.NewService(notifRepo)
 	notifHandler := handler.NewNotificationsHandler(notifRepo)
 
 	// initialize handler(s)
@@ -172,13 +273,31 @@ func setupRoutes(app *fiber.App, repo *storage.Repository, genkitInstance *aiflo
 	devsHandler := handler.NewDevsHandler(repository.NewDevsRepository(repo.DB))
 	usersHandler := handler.NewUsersHandler(repository.NewUsersRepository(repo.DB), s3Store)
 	guestsHandler := handler.NewGuestsHandler(repository.NewGuestsRepository(repo.DB), repository.NewUsersRepository(repo.DB), openSearchRepos.Guests)
-	reqsHandler := handler.NewRequestsHandler(repository.NewRequestsRepo(repo.DB), genkitInstance, notifService)
-	reqsHandler.WorkflowClient = workflowClient
+	reqsHandler := handler.NewRequestsHandler(requestsRepo, generateService, notifier)
+	reqsHandler.SearchRepository = openSearchRepos.Requests
+	reqsHandler.WorkflowClient = generateClient
+	reqsHandler.EventBroker = requestBroker
+	reqsHandler.StatusRepository = repository.NewRequestStatusesRepository(repo.DB)
+	attachmentsRepo := repository.NewRequestAttachmentsRepository(repo.DB)
+	reqsHandler.AttachmentRepository = attachmentsRepo
+	requestAttachmentsHandler := handler.NewRequestAttachmentsHandler(attachmentsRepo, s3Store)
+	commentsRepo := repository.NewRequestCommentsRepository(repo.DB)
+	reqsHandler.CommentRepository = commentsRepo
+	reqsHandler.DraftRepository = draftsRepo
+	reqsHandler.ReviewThreshold = cfg.LLM.ReviewThreshold
+	reqsHandler.S3Storage = s3Store
+	requestCommentsHandler := handler.NewRequestCommentsHandler(commentsRepo, usersRepo, notifier)
+	requestSeriesHandler := handler.NewRequestSeriesHandler(repository.NewRequestSeriesRepository(repo.DB), nil)
+	if workflowClient != nil {
+		requestSeriesHandler.WorkflowClient = workflowClient
+	}
 	hotelsHandler := handler.NewHotelsHandler(repository.NewHotelsRepository(repo.DB), repository.NewUsersRepository(repo.DB))
 	s3Handler := handler.NewS3Handler(s3Store)
 	roomsHandler := handler.NewRoomsHandler(repository.NewRoomsRepository(repo.DB))
 	guestBookingsHandler := handler.NewGuestBookingsHandler(repository.NewGuestBookingsRepository(repo.DB))
 	viewsHandler := handler.NewViewsHandler(repository.NewViewsRepository(repo.DB))
+	slaPoliciesHandler := handler.NewSLAPoliciesHandler(repository.NewSLARepository(repo.DB))
+	requestStatusesHandler := handler.NewRequestStatusesHandler(repository.NewRequestStatusesRepository(repo.DB))
 
 	clerkWhSignatureVerifier, err := handler.NewWebhookVerifier(cfg)
 	if err != nil {
@@ -236,28 +355,70 @@ func setupRoutes(app *fiber.App, repo *storage.Repository, genkitInstance *aiflo
 
 	// Request routes
 	api.Post("/requests/feed", reqsHandler.GetRequestsFeed)
+	api.Get("/requests/stream", reqsHandler.StreamRequests)
+	api.Post("/requests/bulk", reqsHandler.BulkUpdateRequests)
+	api.Route("/requests/drafts", func(r fiber.Router) {
+		r.Get("/", reqsHandler.GetRequestDrafts)
+		r.Get("/accuracy", reqsHandler.GetDraftAccuracy)
+		r.Put("/:id", reqsHandler.UpdateRequestDraft)
+		r.Post("/:id/approve", reqsHandler.ApproveRequestDraft)
+		r.Post("/:id/reject", reqsHandler.RejectRequestDraft)
+	})
 	api.Route("/request", func(r fiber.Router) {
 		r.Post("/", reqsHandler.CreateRequest)
 		r.Post("/generate", reqsHandler.GenerateRequest)
+		r.Post("/generate/batch", reqsHandler.GenerateRequestBatch)
+		r.Post("/generate/audio", reqsHandler.GenerateRequestFromAudio)
+		r.Post("/generate/photo", reqsHandler.GenerateRequestFromPhoto)
 		r.Post("/generate/async", reqsHandler.StartGenerateRequestAsync)
+		r.Get("/generate/async", reqsHandler.ListGenerateRequests)
 		r.Get("/generate/async/:workflowId", reqsHandler.GetGenerateRequestStatus)
+		r.Post("/generate/async/:workflowId/cancel", reqsHandler.CancelGenerateRequest)
+		r.Post("/generate/async/:workflowId/amend", reqsHandler.AmendGenerateRequest)
 		r.Put("/:id", reqsHandler.UpdateRequest)
 		r.Get("/:id", reqsHandler.GetRequest)
 		r.Get("/guest/:id", reqsHandler.GetRequestsByGuest)
 		r.Get("/room/:id", reqsHandler.GetRequestsByRoomID)
 		r.Post("/:id/assign", reqsHandler.AssignRequest)
+		r.Post("/:id/reopen", reqsHandler.ReopenRequest)
 		r.Get("/:id/activity", reqsHandler.GetRequestActivity)
+		r.Get("/:id/attachments", requestAttachmentsHandler.GetRequestAttachments)
+		r.Post("/:id/attachments", requestAttachmentsHandler.CreateRequestAttachment)
+		r.Post("/:id/attachments/:attachmentId/confirm", requestAttachmentsHandler.ConfirmRequestAttachment)
+		r.Delete("/:id/attachments/:attachmentId", requestAttachmentsHandler.DeleteRequestAttachment)
+		r.Get("/:id/comments", requestCommentsHandler.GetRequestComments)
+		r.Post("/:id/comments", requestCommentsHandler.CreateRequestComment)
+		r.Put("/:id/comments/:commentId", requestCommentsHandler.UpdateRequestComment)
+		r.Delete("/:id/comments/:commentId", requestCommentsHandler.DeleteRequestComment)
+	})
+
+	// Recurring request series routes
+	api.Route("/request-series", func(r fiber.Router) {
+		r.Post("/", requestSeriesHandler.CreateRequestSeries)
+		r.Get("/:id", requestSeriesHandler.GetRequestSeries)
+		r.Put("/:id", requestSeriesHandler.UpdateRequestSeries)
+		r.Post("/:id/pause", requestSeriesHandler.PauseRequestSeries)
+		r.Post("/:id/resume", requestSeriesHandler.ResumeRequestSeries)
+		r.Delete("/:id", requestSeriesHandler.CancelRequestSeries)
 	})
 
 	// Hotel routes
 	api.Route("/hotels", func(r fiber.Router) {
 		r.Get("/:id", hotelsHandler.GetHotelByID)
 		r.Post("/", hotelsHandler.CreateHotel)
+		r.Put("/:id/settings", adminOnly, hotelsHandler.UpdateHotelSettings)
 		r.Get("/:id/users", hotelsHandler.GetHotelUsers)
 		r.Get("/:id/departments", hotelsHandler.GetDepartmentsByHotelID)
 		r.Post("/:id/departments", adminOnly, hotelsHandler.CreateDepartment)
 		r.Put("/:id/departments/:deptId", adminOnly, hotelsHandler.UpdateDepartment)
 		r.Delete("/:id/departments/:deptId", adminOnly, hotelsHandler.DeleteDepartment)
+		r.Get("/:id/sla-policies", slaPoliciesHandler.GetSLAPolicies)
+		r.Post("/:id/sla-policies", adminOnly, slaPoliciesHandler.CreateSLAPolicy)
+		r.Put("/:id/sla-policies/:policyId", adminOnly, slaPoliciesHandler.UpdateSLAPolicy)
+		r.Delete("/:id/sla-policies/:policyId", adminOnly, slaPoliciesHandler.DeleteSLAPolicy)
+		r.Get("/:id/request-statuses", requestStatusesHandler.GetRequestStatuses)
+		r.Post("/:id/request-statuses", adminOnly, requestStatusesHandler.CreateRequestStatus)
+		r.Delete("/:id/request-statuses/:name", adminOnly, requestStatusesHandler.DeleteRequestStatus)
 	})
 
 	// s3 routes
@@ -290,6 +451,8 @@ func setupRoutes(app *fiber.App, repo *storage.Repository, genkitInstance *aiflo
 	// notification routes
 	api.Route("/notifications", func(r fiber.Router) {
 		r.Get("/", notifHandler.ListNotifications)
+		r.Get("/preferences", notifHandler.GetPreferences)
+		r.Put("/preferences", notifHandler.UpdatePreferences)
 		r.Put("/read-all", notifHandler.MarkAllRead)
 		r.Put("/:id/read", notifHandler.MarkRead)
 	})
@@ -317,6 +480,10 @@ func setupApp() *fiber.App {
 	}))
 	app.Use(favicon.New())
 	app.Use(compress.New(compress.Config{
+		// Compression buffers the body, which would hold back SSE events.
+		Next: func(c *fiber.Ctx) bool {
+			return c.Get(fiber.HeaderAccept) == "text/event-stream"
+		},
 		Level: compress.LevelBestSpeed,
 	}))
 
@@ -324,7 +491,8 @@ func setupApp() *fiber.App {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     "GET,POST,PUT,DELETE",
-		AllowHeaders:     "Origin, Content-Type, Authorization, X-Hotel-ID",
+		AllowHeaders:     "Origin, Content-Type, Authorization, X-Hotel-ID, Last-Event-ID, If-Match",
+		ExposeHeaders:    "ETag",
 		AllowCredentials: true,
 	}))
 
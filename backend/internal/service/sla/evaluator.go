@@ -0,0 +1,171 @@
+package sla
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+)
+
+const (
+	// DefaultInterval is how often Run sweeps for breached requests.
+	DefaultInterval = time.Minute
+	// sweepBatchSize caps how many breaches a single sweep escalates.
+	sweepBatchSize = 100
+)
+
+type NotificationSender interface {
+	Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error
+}
+
+type EventPublisher interface {
+	Publish(event *models.RequestEvent)
+}
+
+// Evaluator periodically finds requests that missed their SLA and escalates
+// them: priority is bumped one level, the request is reassigned to the
+// policy's escalation user, and both the escalation user and the previous
+// assignee are notified. Each breach is escalated at most once.
+type Evaluator struct {
+	slaRepo      storage.SLARepository
+	requestsRepo storage.RequestsRepository
+	notifier     NotificationSender
+	// Events is optional; when set, escalations are published to request streams.
+	Events   EventPublisher
+	Interval time.Duration
+	now      func() time.Time
+}
+
+func NewEvaluator(slaRepo storage.SLARepository, requestsRepo storage.RequestsRepository, notifier NotificationSender) *Evaluator {
+	return &Evaluator{
+		slaRepo:      slaRepo,
+		requestsRepo: requestsRepo,
+		notifier:     notifier,
+		Interval:     DefaultInterval,
+		now:          time.Now,
+	}
+}
+
+// Run sweeps every Interval until ctx is cancelled.
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Sweep(ctx, e.now()); err != nil && ctx.Err() == nil {
+			slog.Error("sla: sweep failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep escalates every breach that is due at now. A failure to escalate one
+// request is logged and does not stop the others.
+func (e *Evaluator) Sweep(ctx context.Context, now time.Time) error {
+	breaches, err := e.slaRepo.FindSLABreaches(ctx, now, sweepBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, breach := range breaches {
+		if err := e.escalate(ctx, breach); err != nil {
+			slog.Error("sla: failed to escalate request", "err", err, "request_id", breach.RequestID, "kind", breach.Kind)
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) escalate(ctx context.Context, breach *models.SLABreach) error {
+	recorded, err := e.slaRepo.RecordSLABreach(ctx, breach)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return nil
+	}
+
+	update := &models.RequestUpdateInput{}
+	if next := nextPriority(breach.Priority); next != breach.Priority {
+		update.Priority = &next
+	}
+	if breach.EscalateToUserID != nil && (breach.UserID == nil || *breach.UserID != *breach.EscalateToUserID) {
+		update.UserID = breach.EscalateToUserID
+	}
+
+	if update.Priority != nil || update.UserID != nil {
+		updated, err := e.requestsRepo.UpdateRequest(ctx, breach.RequestID, update, nil)
+		if err != nil {
+			return fmt.Errorf("update request: %w", err)
+		}
+		e.publish(ctx, breach.HotelID, updated)
+	}
+
+	title := breachTitle(breach.Kind)
+	for _, userID := range notifyTargets(breach) {
+		if err := e.notifier.Notify(ctx, userID, models.TypeSLABreached, breach.RequestID, title, breach.Name); err != nil {
+			slog.Error("sla: failed to notify", "err", err, "user_id", userID, "request_id", breach.RequestID)
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) publish(ctx context.Context, hotelID string, updated *models.Request) {
+	if e.Events == nil {
+		return
+	}
+
+	req, err := e.requestsRepo.FindGuestRequest(ctx, updated.ID)
+	if err != nil {
+		slog.Error("sla: failed to load request for stream event", "err", err, "request_id", updated.ID)
+		return
+	}
+
+	eventType := models.RequestEventUpdated
+	if req.UserID != nil {
+		eventType = models.RequestEventAssigned
+	}
+	e.Events.Publish(&models.RequestEvent{
+		ID:      strconv.FormatInt(req.RequestVersion.UnixNano(), 10),
+		Type:    eventType,
+		HotelID: hotelID,
+		Request: req,
+	})
+}
+
+// notifyTargets returns the escalation user and the previous assignee, without
+// duplicates.
+func notifyTargets(breach *models.SLABreach) []string {
+	var targets []string
+	if breach.EscalateToUserID != nil {
+		targets = append(targets, *breach.EscalateToUserID)
+	}
+	if breach.UserID != nil && (breach.EscalateToUserID == nil || *breach.UserID != *breach.EscalateToUserID) {
+		targets = append(targets, *breach.UserID)
+	}
+	return targets
+}
+
+func nextPriority(priority string) string {
+	switch priority {
+	case "low":
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+func breachTitle(kind models.SLABreachKind) string {
+	if kind == models.SLABreachAcknowledge {
+		return "Request not acknowledged in time"
+	}
+	return "Request overdue"
+}
@@ -0,0 +1,165 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSLARepository struct {
+	storage.SLARepository
+	breaches []*models.SLABreach
+	recorded map[string]bool
+}
+
+func (m *mockSLARepository) FindSLABreaches(ctx context.Context, now time.Time, limit int) ([]*models.SLABreach, error) {
+	return m.breaches, nil
+}
+
+func (m *mockSLARepository) RecordSLABreach(ctx context.Context, breach *models.SLABreach) (bool, error) {
+	key := breach.RequestID + "/" + string(breach.Kind) + "/" + breach.OpenedAt.String()
+	if m.recorded[key] {
+		return false, nil
+	}
+	m.recorded[key] = true
+	return true, nil
+}
+
+type mockRequestsRepository struct {
+	storage.RequestsRepository
+	updates map[string]*models.RequestUpdateInput
+	err     error
+}
+
+func (m *mockRequestsRepository) UpdateRequest(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string) (*models.Request, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.updates[id] = update
+	return &models.Request{ID: id}, nil
+}
+
+type sentNotification struct {
+	userID    string
+	notifType models.NotificationType
+}
+
+type mockNotifier struct {
+	sent []sentNotification
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error {
+	m.sent = append(m.sent, sentNotification{userID: userID, notifType: notifType})
+	return nil
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestEvaluator_Sweep(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bumps priority, reassigns to the lead and notifies both users", func(t *testing.T) {
+		t.Parallel()
+
+		slaRepo := &mockSLARepository{
+			recorded: map[string]bool{},
+			breaches: []*models.SLABreach{{
+				RequestID:        "req-1",
+				Name:             "extra towels",
+				Priority:         "low",
+				UserID:           strPtr("user_staff"),
+				Kind:             models.SLABreachComplete,
+				EscalateToUserID: strPtr("user_lead"),
+			}},
+		}
+		requestsRepo := &mockRequestsRepository{updates: map[string]*models.RequestUpdateInput{}}
+		notifier := &mockNotifier{}
+
+		e := NewEvaluator(slaRepo, requestsRepo, notifier)
+		require.NoError(t, e.Sweep(context.Background(), time.Now()))
+
+		update := requestsRepo.updates["req-1"]
+		require.NotNil(t, update)
+		assert.Equal(t, "medium", *update.Priority)
+		assert.Equal(t, "user_lead", *update.UserID)
+		assert.Equal(t, []sentNotification{
+			{userID: "user_lead", notifType: models.TypeSLABreached},
+			{userID: "user_staff", notifType: models.TypeSLABreached},
+		}, notifier.sent)
+	})
+
+	t.Run("escalates each breach only once", func(t *testing.T) {
+		t.Parallel()
+
+		slaRepo := &mockSLARepository{
+			recorded: map[string]bool{},
+			breaches: []*models.SLABreach{{
+				RequestID: "req-1",
+				Priority:  "high",
+				Kind:      models.SLABreachAcknowledge,
+				UserID:    strPtr("user_staff"),
+			}},
+		}
+		requestsRepo := &mockRequestsRepository{updates: map[string]*models.RequestUpdateInput{}}
+		notifier := &mockNotifier{}
+
+		e := NewEvaluator(slaRepo, requestsRepo, notifier)
+		require.NoError(t, e.Sweep(context.Background(), time.Now()))
+		require.NoError(t, e.Sweep(context.Background(), time.Now()))
+
+		// already high and no lead configured: nothing to update, assignee still notified once
+		assert.Empty(t, requestsRepo.updates)
+		assert.Len(t, notifier.sent, 1)
+	})
+
+	t.Run("escalates a reopened request again", func(t *testing.T) {
+		t.Parallel()
+
+		opened := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+		breach := &models.SLABreach{
+			RequestID: "req-1",
+			Priority:  "high",
+			Kind:      models.SLABreachComplete,
+			UserID:    strPtr("user_staff"),
+			OpenedAt:  opened,
+		}
+		slaRepo := &mockSLARepository{recorded: map[string]bool{}, breaches: []*models.SLABreach{breach}}
+		notifier := &mockNotifier{}
+
+		e := NewEvaluator(slaRepo, &mockRequestsRepository{updates: map[string]*models.RequestUpdateInput{}}, notifier)
+		require.NoError(t, e.Sweep(context.Background(), time.Now()))
+		reopened := *breach
+		reopened.OpenedAt = opened.Add(48 * time.Hour)
+		slaRepo.breaches = []*models.SLABreach{&reopened}
+		require.NoError(t, e.Sweep(context.Background(), time.Now()))
+
+		assert.Len(t, notifier.sent, 2)
+	})
+
+	t.Run("does not notify when the escalation update fails", func(t *testing.T) {
+		t.Parallel()
+
+		slaRepo := &mockSLARepository{
+			recorded: map[string]bool{},
+			breaches: []*models.SLABreach{{
+				RequestID:        "req-1",
+				Priority:         "medium",
+				Kind:             models.SLABreachComplete,
+				EscalateToUserID: strPtr("user_lead"),
+			}},
+		}
+		requestsRepo := &mockRequestsRepository{err: errors.New("db down")}
+		notifier := &mockNotifier{}
+
+		e := NewEvaluator(slaRepo, requestsRepo, notifier)
+		require.NoError(t, e.Sweep(context.Background(), time.Now()))
+
+		assert.Empty(t, notifier.sent)
+	})
+}
@@ -33,9 +33,18 @@ func NewClient(cfg config.OpenSearch) (*opensearch.Client, error) {
 
 // EnsureGuestsIndex creates the guests index with its mapping if it doesn't already exist.
 func EnsureGuestsIndex(ctx context.Context, client *opensearch.Client) error {
-	res, err := opensearchapi.IndicesExistsRequest{Index: []string{GuestsIndex}}.Do(ctx, client)
+	return ensureIndex(ctx, client, GuestsIndex, GuestsIndexMapping)
+}
+
+// EnsureRequestsIndex creates the requests index with its mapping if it doesn't already exist.
+func EnsureRequestsIndex(ctx context.Context, client *opensearch.Client) error {
+	return ensureIndex(ctx, client, RequestsIndex, RequestsIndexMapping)
+}
+
+func ensureIndex(ctx context.Context, client *opensearch.Client, index string, mapping map[string]interface{}) error {
+	res, err := opensearchapi.IndicesExistsRequest{Index: []string{index}}.Do(ctx, client)
 	if err != nil {
-		return fmt.Errorf("checking guests index: %w", err)
+		return fmt.Errorf("checking %s index: %w", index, err)
 	}
 	defer res.Body.Close()
 
@@ -43,22 +52,22 @@ func EnsureGuestsIndex(ctx context.Context, client *opensearch.Client) error {
 		return nil
 	}
 
-	body, err := json.Marshal(GuestsIndexMapping)
+	body, err := json.Marshal(mapping)
 	if err != nil {
 		return err
 	}
 
 	createRes, err := opensearchapi.IndicesCreateRequest{
-		Index: GuestsIndex,
+		Index: index,
 		Body:  bytes.NewReader(body),
 	}.Do(ctx, client)
 	if err != nil {
-		return fmt.Errorf("creating guests index: %w", err)
+		return fmt.Errorf("creating %s index: %w", index, err)
 	}
 	defer createRes.Body.Close()
 
 	if createRes.IsError() {
-		return fmt.Errorf("guests index creation failed: %s", createRes.String())
+		return fmt.Errorf("%s index creation failed: %s", index, createRes.String())
 	}
 	return nil
 }
@@ -3,7 +3,10 @@ package opensearchstorage
 // Index name constants and their mappings. Each entity gets a name constant
 // and a mapping var here — both are used together in EnsureIndex calls.
 
-const GuestsIndex = "guests"
+const (
+	GuestsIndex   = "guests"
+	RequestsIndex = "requests"
+)
 
 // GuestsIndexMapping defines the guests index schema. Fields are denormalized from
 // guests + guest_bookings + rooms so all filtering can happen in one query.
@@ -44,3 +47,32 @@ var GuestsIndexMapping = map[string]interface{}{
 		},
 	},
 }
+
+// RequestsIndexMapping defines the requests index schema. There is one document
+// per request holding its latest version, denormalized with room, department
+// and SLA deadline so the feed can be served and faceted from one query.
+var RequestsIndexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"id":               map[string]string{"type": "keyword"},
+			"hotel_id":         map[string]string{"type": "keyword"},
+			"name":             map[string]interface{}{"type": "text"},
+			"description":      map[string]interface{}{"type": "text"},
+			"notes":            map[string]interface{}{"type": "text"},
+			"priority":         map[string]string{"type": "keyword"},
+			"priority_rank":    map[string]string{"type": "integer"},
+			"status":           map[string]string{"type": "keyword"},
+			"room_number":      map[string]string{"type": "integer"},
+			"floor":            map[string]string{"type": "integer"},
+			"request_type":     map[string]string{"type": "keyword"},
+			"request_category": map[string]string{"type": "keyword"},
+			"department_id":    map[string]string{"type": "keyword"},
+			"department_name":  map[string]string{"type": "keyword"},
+			"user_id":          map[string]string{"type": "keyword"},
+			"created_at":       map[string]interface{}{"type": "date", "format": "strict_date_optional_time"},
+			"request_version":  map[string]interface{}{"type": "date", "format": "strict_date_optional_time"},
+			"sla_due_at":       map[string]interface{}{"type": "date", "format": "strict_date_optional_time"},
+			"is_overdue":       map[string]string{"type": "boolean"},
+		},
+	},
+}
@@ -2,18 +2,31 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/generate/selfserve/internal/models"
 )
 
 type NotificationsRepository interface {
-	InsertNotification(ctx context.Context, userID string, notifType models.NotificationType, title, body string) (*models.Notification, error)
+	InsertNotification(ctx context.Context, input *models.NotificationInput) (*models.Notification, error)
 	FindByUserID(ctx context.Context, userID string) ([]*models.Notification, error)
 	MarkRead(ctx context.Context, id, userID string) error
 	MarkAllRead(ctx context.Context, userID string) error
 	UpsertDeviceToken(ctx context.Context, userID, token, platform string) error
-	FindDeviceTokensByUserID(ctx context.Context, userID string) ([]string, error)
+	FindNotificationRecipient(ctx context.Context, userID string) (*models.NotificationRecipient, error)
+	FindNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	UpsertNotificationPreferences(ctx context.Context, userID string, input *models.UpdateNotificationPreferencesInput) (*models.NotificationPreferences, error)
+	ClaimNotificationOutbox(ctx context.Context, now time.Time, lease time.Duration) ([]*models.NotificationOutboxEntry, error)
+	CompleteNotificationOutboxEntry(ctx context.Context, id string) error
+	RetryNotificationOutboxEntry(ctx context.Context, id, reason string, runAfter time.Time) error
+	FailNotificationOutboxEntry(ctx context.Context, id, reason string) error
+	FindNotificationDeliveries(ctx context.Context, outboxID string) ([]*models.NotificationDelivery, error)
+	UpsertNotificationDeliveries(ctx context.Context, deliveries []*models.NotificationDelivery) error
+	FindNotificationTickets(ctx context.Context, channel string, sentAfter, sentBefore time.Time, limit int) ([]*models.NotificationDelivery, error)
+	DeleteDeviceToken(ctx context.Context, token string) error
+	ClaimDailySummaries(ctx context.Context, at string, window time.Duration, now time.Time, limit int) ([]*models.DailySummaryRecipient, error)
+	FindDepartmentWorkloads(ctx context.Context, userID string, now time.Time) ([]*models.DepartmentWorkload, error)
 }
 
 type UsersRepository interface {
@@ -47,21 +60,97 @@ type GuestsSearchRepository interface {
 	DeleteGuest(ctx context.Context, id string) error
 }
 
+// RequestsSearchRepository is implemented by OpenSearch. It indexes the
+// denormalized RequestDocument and serves the requests feed with facets.
+type RequestsSearchRepository interface {
+	IndexRequest(ctx context.Context, doc *models.RequestDocument) error
+	SearchRequests(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, *models.RequestFacets, error)
+}
+
 type RequestsRepository interface {
 	InsertRequest(ctx context.Context, req *models.Request) (*models.Request, error)
+	InsertRequests(ctx context.Context, reqs []*models.Request) ([]*models.Request, error)
 	UpdateRequest(ctx context.Context, id string, patch *models.RequestUpdateInput, changedBy *string) (*models.Request, error)
+	UpdateRequests(ctx context.Context, updates []*models.RequestBulkUpdate, changedBy *string) ([]*models.RequestBulkUpdateResult, error)
 	FindRequest(ctx context.Context, id string) (*models.Request, error)
+	FindLatestRequest(ctx context.Context, id string) (*models.Request, error)
 	FindRequests(ctx context.Context) ([]models.Request, error)
 	FindRequestsByGuestID(ctx context.Context, guestID, hotelID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error)
 	FindRequestsByRoomIDAndUserID(ctx context.Context, roomID, hotelID, userID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error)
 	FindUnassignedRequestsByRoomIDAndUserID(ctx context.Context, roomID, hotelID, cursorID string, cursorVersion time.Time, limit int) ([]*models.GuestRequest, error)
-	FindRequestsPaginated(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, limit int) ([]*models.GuestRequest, error)
+	FindRequestsPaginated(ctx context.Context, input *models.RequestsFeedInput, cursorID string, cursorCreatedAt time.Time, cursorPriorityRank int, cursorSLADueAt *time.Time, limit int) ([]*models.GuestRequest, error)
 	FindRequestVersions(ctx context.Context, id string) ([]*models.Request, error)
+	FindGuestRequest(ctx context.Context, id string) (*models.GuestRequest, error)
+	FindRequestsChangedSince(ctx context.Context, hotelID string, since time.Time, limit int) ([]*models.GuestRequest, error)
+}
+
+type RequestSeriesRepository interface {
+	InsertRequestSeries(ctx context.Context, input *models.CreateRequestSeriesInput, createdBy *string) (*models.RequestSeries, error)
+	FindRequestSeries(ctx context.Context, id string) (*models.RequestSeries, error)
+	UpdateRequestSeries(ctx context.Context, id string, input *models.UpdateRequestSeriesInput) (*models.RequestSeries, error)
+	UpdateRequestSeriesStatus(ctx context.Context, id string, status models.RequestSeriesStatus) (*models.RequestSeries, error)
+	MarkOccurrence(ctx context.Context, id string, at time.Time) error
+}
+
+type RequestStatusesRepository interface {
+	FindRequestStatusesByHotelID(ctx context.Context, hotelID string) ([]*models.HotelRequestStatus, error)
+	InsertRequestStatus(ctx context.Context, hotelID, name string) (*models.HotelRequestStatus, error)
+	DeleteRequestStatus(ctx context.Context, hotelID, name string) error
+}
+
+type RequestAttachmentsRepository interface {
+	InsertRequestAttachment(ctx context.Context, attachment *models.RequestAttachment) (*models.RequestAttachment, error)
+	FindRequestAttachment(ctx context.Context, requestID, id string) (*models.RequestAttachment, error)
+	FindConfirmedRequestAttachments(ctx context.Context, requestID string) ([]*models.RequestAttachment, error)
+	ConfirmRequestAttachment(ctx context.Context, requestID, id string, info *models.S3ObjectInfo) (*models.RequestAttachment, error)
+	DeleteRequestAttachment(ctx context.Context, requestID, id string) error
+}
+
+type RequestCommentsRepository interface {
+	InsertRequestComment(ctx context.Context, comment *models.RequestComment) (*models.RequestComment, error)
+	FindRequestComment(ctx context.Context, requestID, id string) (*models.RequestComment, error)
+	FindRequestComments(ctx context.Context, requestID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestComment, error)
+	FindActiveRequestComments(ctx context.Context, requestID string) ([]*models.RequestComment, error)
+	UpdateRequestComment(ctx context.Context, requestID, id, body string, mentions []string) (*models.RequestComment, error)
+	SoftDeleteRequestComment(ctx context.Context, requestID, id string) error
+}
+
+type RequestDraftsRepository interface {
+	InsertGeneratedRequests(ctx context.Context, drafts []*models.RequestDraft, ready []*models.Request) error
+	FindRequestDraft(ctx context.Context, id string) (*models.RequestDraft, error)
+	FindRequestDrafts(ctx context.Context, hotelID string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.RequestDraft, error)
+	UpdateRequestDraft(ctx context.Context, id string, update *models.RequestUpdateInput, changedBy *string, review *models.DraftReview) (*models.Request, error)
+	DraftAccuracy(ctx context.Context, hotelID string, since time.Time) (*models.DraftAccuracy, error)
+}
+
+// GenerateRequestJobsRepository stores the in-process generate queue. The
+// update methods return false when the job is not in a state they apply to.
+type GenerateRequestJobsRepository interface {
+	InsertGenerateRequestJob(ctx context.Context, job *models.GenerateRequestJob) (*models.GenerateRequestJob, error)
+	FindGenerateRequestJob(ctx context.Context, id string) (*models.GenerateRequestJob, error)
+	FindGenerateRequestJobs(ctx context.Context, hotelID, requestedBy string, cursorCreatedAt time.Time, cursorID string, limit int) ([]*models.GenerateRequestJob, error)
+	ClaimGenerateRequestJob(ctx context.Context, now time.Time, lease time.Duration) (*models.GenerateRequestJob, error)
+	MarkGenerateRequestJobGenerated(ctx context.Context, id string, revision int, output json.RawMessage) (bool, error)
+	CompleteGenerateRequestJob(ctx context.Context, id, requestID string) error
+	RetryGenerateRequestJob(ctx context.Context, id, reason string, runAfter time.Time) error
+	FailGenerateRequestJob(ctx context.Context, id, reason string) error
+	CancelGenerateRequestJob(ctx context.Context, id string) (bool, error)
+	AmendGenerateRequestJob(ctx context.Context, id, rawText string) (bool, error)
+}
+
+type SLARepository interface {
+	FindSLAPoliciesByHotelID(ctx context.Context, hotelID string) ([]*models.SLAPolicy, error)
+	InsertSLAPolicy(ctx context.Context, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error)
+	UpdateSLAPolicy(ctx context.Context, id, hotelID string, input *models.SLAPolicyInput) (*models.SLAPolicy, error)
+	DeleteSLAPolicy(ctx context.Context, id, hotelID string) error
+	FindSLABreaches(ctx context.Context, now time.Time, limit int) ([]*models.SLABreach, error)
+	RecordSLABreach(ctx context.Context, breach *models.SLABreach) (bool, error)
 }
 
 type HotelsRepository interface {
 	FindByID(ctx context.Context, id string) (*models.Hotel, error)
 	InsertHotel(ctx context.Context, hotel *models.CreateHotelRequest) (*models.Hotel, error)
+	UpdateHotelSettings(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error)
 	GetDepartmentsByHotelID(ctx context.Context, hotelID string) ([]*models.Department, error)
 	InsertDepartment(ctx context.Context, hotelID, name string) (*models.Department, error)
 	UpdateDepartment(ctx context.Context, id, hotelID, name string) (*models.Department, error)
@@ -72,12 +161,14 @@ type HotelsRepository interface {
 type S3Storage interface {
 	GeneratePresignedUploadURL(ctx context.Context, in models.PresignedURLInput) (string, error)
 	GeneratePresignedGetURL(ctx context.Context, in models.PresignedURLInput) (string, error)
+	HeadFile(ctx context.Context, key string) (*models.S3ObjectInfo, error)
+	GetFile(ctx context.Context, key string, maxSize int64) ([]byte, error)
 	DeleteFile(ctx context.Context, key string) error
 }
 type RoomsRepository interface {
 	FindRoomsWithOptionalGuestBookingsByFloor(ctx context.Context, filter *models.FilterRoomsRequest, hotelID string, cursorRoomNumber int) ([]*models.RoomWithOptionalGuestBooking, error)
 	FindAllFloors(ctx context.Context, hotelID string) ([]int, error)
-	FindRoomByNumber(ctx context.Context, hotelID string, roomReference string) (*models.Room, error)
+	FindRoomCandidates(ctx context.Context, hotelID string, ref models.RoomReference, minScore float64, limit int) ([]*models.LookupCandidate, error)
 }
 
 type GuestBookingsRepository interface {
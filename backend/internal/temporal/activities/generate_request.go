@@ -2,14 +2,116 @@ package activities
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/generate/selfserve/internal/validation"
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/temporal"
 )
 
+// generatedRequestNamespace seeds the IDs of requests stored by the generate
+// workflow, so a retried activity finds the request it already inserted.
+var generatedRequestNamespace = uuid.MustParse("9d1f6c2e-5b7a-4e38-8c0d-2a6e4f1b7c93")
+
+type NotificationSender interface {
+	Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error
+}
+
+type EventPublisher interface {
+	Publish(event *models.RequestEvent)
+}
+
 type Activities struct {
-	Service aiflows.GenerateRequestService
+	Service           aiflows.GenerateRequestService
+	RequestRepository storage.RequestsRepository
+	SeriesRepository  storage.RequestSeriesRepository
+	// DraftRepository is nilable; when set, generated requests that need
+	// review are held as drafts instead of going straight to the feed.
+	DraftRepository storage.RequestDraftsRepository
+	// ReviewThreshold is the confidence below which a generated request needs review.
+	ReviewThreshold float64
+	// Notifier is nilable; without it, assignees of generated requests are not notified.
+	Notifier NotificationSender
+	// Events is nilable; without it, generated requests are not published to request streams.
+	Events EventPublisher
 }
 
 func (a *Activities) RunGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+	if a.Service == nil {
+		// Retrying cannot help until the worker restarts with a provider.
+		return aiflows.EnrichedGenerateRequestOutput{}, temporal.NewNonRetryableApplicationError(aiflows.ErrNoLLMProvider.Error(), "NoLLMProvider", aiflows.ErrNoLLMProvider)
+	}
 	return a.Service.RunGenerateRequest(ctx, input)
 }
+
+// GeneratedRequestID is the ID the request generated by a workflow is stored
+// under.
+func GeneratedRequestID(workflowID string) string {
+	return uuid.NewSHA1(generatedRequestNamespace, []byte(workflowID)).String()
+}
+
+// PersistGeneratedRequest validates a generated request and inserts it, held
+// as a draft when it needs review. It is idempotent per workflow: a retry
+// returns the request already inserted.
+func (a *Activities) PersistGeneratedRequest(ctx context.Context, input workflows.PersistGeneratedRequestInput) (*models.Request, error) {
+	if err := validation.Validate.Struct(input.Output); err != nil {
+		// The model's answer will not change on retry.
+		return nil, temporal.NewNonRetryableApplicationError("generated request failed validation: "+err.Error(), "InvalidGeneratedRequest", err)
+	}
+
+	id := GeneratedRequestID(input.WorkflowID)
+	if existing, err := a.RequestRepository.FindLatestRequest(ctx, id); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, errs.ErrNotFoundInDB) {
+		return nil, err
+	}
+
+	req := models.Request{
+		ID:          id,
+		MakeRequest: input.Output.ToMakeRequest(input.HotelID, input.RawText),
+		ChangedBy:   input.RequestedBy,
+	}
+	if resp := input.Output.ToResponse(req); a.DraftRepository != nil && resp.NeedsReview(a.ReviewThreshold) {
+		draft := resp.ToDraft(input.RawText)
+		if err := a.DraftRepository.InsertGeneratedRequests(ctx, []*models.RequestDraft{draft}, nil); err != nil {
+			return nil, err
+		}
+		return &draft.Request, nil
+	}
+	return a.RequestRepository.InsertRequest(ctx, &req)
+}
+
+// NotifyRequestAssignee tells the staff member a generated request was
+// assigned to about it.
+func (a *Activities) NotifyRequestAssignee(ctx context.Context, req models.Request) error {
+	if a.Notifier == nil || req.UserID == nil {
+		return nil
+	}
+	return a.Notifier.Notify(ctx, *req.UserID, models.TypeTaskAssigned, req.ID, models.TaskAssignedTitle, req.Name)
+}
+
+// PublishRequestCreated announces a generated request on its hotel's request
+// stream.
+func (a *Activities) PublishRequestCreated(ctx context.Context, hotelID, requestID string) error {
+	if a.Events == nil {
+		return nil
+	}
+
+	req, err := a.RequestRepository.FindGuestRequest(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	a.Events.Publish(&models.RequestEvent{
+		ID:      strconv.FormatInt(req.RequestVersion.UnixNano(), 10),
+		Type:    models.RequestEventCreated,
+		HotelID: hotelID,
+		Request: req,
+	})
+	return nil
+}
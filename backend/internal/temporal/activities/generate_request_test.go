@@ -5,8 +5,12 @@ import (
 	"testing"
 
 	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/generate/selfserve/internal/temporal/workflows"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
 )
 
 type mockGenerateRequestService struct {
@@ -17,6 +21,18 @@ func (m *mockGenerateRequestService) RunGenerateRequest(ctx context.Context, inp
 	return m.runGenerateRequestFunc(ctx, input)
 }
 
+func (m *mockGenerateRequestService) RunGenerateRequestBatch(ctx context.Context, input aiflows.GenerateRequestInput) (aiflows.GenerateRequestBatchOutput, error) {
+	return aiflows.GenerateRequestBatchOutput{}, nil
+}
+
+func (m *mockGenerateRequestService) RunTranscribeAudio(ctx context.Context, input aiflows.TranscribeAudioInput) (aiflows.TranscribeAudioOutput, error) {
+	return aiflows.TranscribeAudioOutput{}, nil
+}
+
+func (m *mockGenerateRequestService) RunGeneratePhotoRequest(ctx context.Context, input aiflows.GeneratePhotoRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+	return aiflows.EnrichedGenerateRequestOutput{}, nil
+}
+
 func TestActivities_RunGenerateRequest(t *testing.T) {
 	t.Parallel()
 
@@ -44,3 +60,190 @@ func TestActivities_RunGenerateRequest(t *testing.T) {
 	assert.Equal(t, "Towels", out.Name)
 	assert.Equal(t, "one-time", out.RequestType)
 }
+
+func TestActivities_RunGenerateRequest_NoProvider(t *testing.T) {
+	t.Parallel()
+
+	acts := &Activities{}
+	_, err := acts.RunGenerateRequest(context.Background(), aiflows.GenerateRequestInput{RawText: "towels", HotelID: "org_1"})
+	require.ErrorIs(t, err, aiflows.ErrNoLLMProvider)
+
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, err, &appErr)
+	assert.True(t, appErr.NonRetryable())
+}
+
+type recordingNotifier struct {
+	notified []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, userID string, notifType models.NotificationType, requestID, title, body string) error {
+	n.notified = append(n.notified, userID+": "+body)
+	return nil
+}
+
+type recordingPublisher struct {
+	events []*models.RequestEvent
+}
+
+func (p *recordingPublisher) Publish(event *models.RequestEvent) {
+	p.events = append(p.events, event)
+}
+
+// mockRequestDraftsRepository stores drafts alongside requests, as drafts are
+// requests in status draft.
+type mockRequestDraftsRepository struct {
+	storage.RequestDraftsRepository
+	requests *mockRequestsRepository
+	inserted []*models.RequestDraft
+}
+
+func (m *mockRequestDraftsRepository) InsertGeneratedRequests(ctx context.Context, drafts []*models.RequestDraft, ready []*models.Request) error {
+	for _, draft := range drafts {
+		draft.Request.Status = string(models.StatusDraft)
+		m.requests.inserted = append(m.requests.inserted, &draft.Request)
+	}
+	m.requests.inserted = append(m.requests.inserted, ready...)
+	m.inserted = append(m.inserted, drafts...)
+	return nil
+}
+
+func generatedTowels() workflows.PersistGeneratedRequestInput {
+	requestedBy := "user_1"
+	assignee := "user_2"
+	return workflows.PersistGeneratedRequestInput{
+		WorkflowID:  "generate-request-123",
+		HotelID:     "org_1",
+		RawText:     "room 301 needs towels",
+		RequestedBy: &requestedBy,
+		Output: aiflows.EnrichedGenerateRequestOutput{
+			UserID:     &assignee,
+			Confidence: 0.9,
+			GenerateRequestOutput: aiflows.GenerateRequestOutput{
+				Name:        "Towels",
+				RequestType: "one-time",
+				Status:      "pending",
+				Priority:    "medium",
+			},
+		},
+	}
+}
+
+func TestActivities_PersistGeneratedRequest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("inserts the request under the workflow's request ID", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestsRepository{}
+		acts := &Activities{RequestRepository: repo}
+
+		req, err := acts.PersistGeneratedRequest(context.Background(), generatedTowels())
+		require.NoError(t, err)
+
+		assert.Equal(t, GeneratedRequestID("generate-request-123"), req.ID)
+		assert.Equal(t, "org_1", req.HotelID)
+		assert.Equal(t, "Towels", req.Name)
+		assert.Equal(t, "user_2", *req.UserID)
+		assert.Equal(t, "user_1", *req.ChangedBy)
+		assert.Equal(t, "room 301 needs towels", *req.OriginalText)
+		require.Len(t, repo.inserted, 1)
+	})
+
+	t.Run("is idempotent per workflow", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestsRepository{}
+		acts := &Activities{RequestRepository: repo}
+
+		first, err := acts.PersistGeneratedRequest(context.Background(), generatedTowels())
+		require.NoError(t, err)
+		second, err := acts.PersistGeneratedRequest(context.Background(), generatedTowels())
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ID, second.ID)
+		assert.Len(t, repo.inserted, 1)
+	})
+
+	t.Run("holds a request that needs review as a draft", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestsRepository{}
+		drafts := &mockRequestDraftsRepository{requests: repo}
+		acts := &Activities{RequestRepository: repo, DraftRepository: drafts, ReviewThreshold: 0.7}
+		lowConfidence := generatedTowels()
+		lowConfidence.Output.Confidence = 0.4
+		warned := generatedTowels()
+		warned.WorkflowID = "generate-request-456"
+		warned.Output.Warning = &aiflows.GenerateRequestWarning{Code: "room_not_found", Message: "Room 301 could not be resolved."}
+
+		for _, input := range []workflows.PersistGeneratedRequestInput{lowConfidence, warned} {
+			req, err := acts.PersistGeneratedRequest(context.Background(), input)
+			require.NoError(t, err)
+			assert.Equal(t, GeneratedRequestID(input.WorkflowID), req.ID)
+			assert.Equal(t, string(models.StatusDraft), req.Status)
+		}
+		retried, err := acts.PersistGeneratedRequest(context.Background(), lowConfidence)
+		require.NoError(t, err)
+
+		assert.Equal(t, string(models.StatusDraft), retried.Status)
+		require.Len(t, drafts.inserted, 2)
+		assert.Equal(t, "room 301 needs towels", drafts.inserted[0].RawText)
+		assert.InDelta(t, 0.4, drafts.inserted[0].Confidence, 0.001)
+		assert.Equal(t, "room_not_found", drafts.inserted[1].Warning.Code)
+	})
+
+	t.Run("inserts a confident request without a warning", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestsRepository{}
+		drafts := &mockRequestDraftsRepository{requests: repo}
+		acts := &Activities{RequestRepository: repo, DraftRepository: drafts, ReviewThreshold: 0.7}
+
+		req, err := acts.PersistGeneratedRequest(context.Background(), generatedTowels())
+		require.NoError(t, err)
+
+		assert.Equal(t, "pending", req.Status)
+		assert.Empty(t, drafts.inserted)
+	})
+
+	t.Run("rejects an invalid generated request without retrying", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockRequestsRepository{}
+		acts := &Activities{RequestRepository: repo}
+		input := generatedTowels()
+		input.Output.Priority = "whenever"
+
+		_, err := acts.PersistGeneratedRequest(context.Background(), input)
+		var appErr *temporal.ApplicationError
+		require.ErrorAs(t, err, &appErr)
+		assert.True(t, appErr.NonRetryable())
+		assert.Empty(t, repo.inserted)
+	})
+}
+
+func TestActivities_AnnounceGeneratedRequest(t *testing.T) {
+	t.Parallel()
+
+	repo := &mockRequestsRepository{}
+	notifier := &recordingNotifier{}
+	events := &recordingPublisher{}
+	acts := &Activities{RequestRepository: repo, Notifier: notifier, Events: events}
+
+	req, err := acts.PersistGeneratedRequest(context.Background(), generatedTowels())
+	require.NoError(t, err)
+
+	require.NoError(t, acts.NotifyRequestAssignee(context.Background(), *req))
+	assert.Equal(t, []string{"user_2: Towels"}, notifier.notified)
+
+	require.NoError(t, acts.PublishRequestCreated(context.Background(), req.HotelID, req.ID))
+	require.Len(t, events.events, 1)
+	assert.Equal(t, models.RequestEventCreated, events.events[0].Type)
+	assert.Equal(t, "org_1", events.events[0].HotelID)
+	assert.Equal(t, req.ID, events.events[0].Request.ID)
+
+	quiet := &Activities{RequestRepository: repo}
+	require.NoError(t, quiet.NotifyRequestAssignee(context.Background(), *req))
+	require.NoError(t, quiet.PublishRequestCreated(context.Background(), req.HotelID, req.ID))
+}
@@ -0,0 +1,85 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	"github.com/google/uuid"
+)
+
+// occurrenceNamespace seeds deterministic occurrence IDs so that a retried
+// activity finds the request it already inserted instead of duplicating it.
+var occurrenceNamespace = uuid.MustParse("4b7e3f4a-2d0c-4c55-9a57-8f1c0d6f2b11")
+
+// PlanRequestSeries reports the series status and its next occurrence after
+// the later of `after` and the last materialized occurrence.
+func (a *Activities) PlanRequestSeries(ctx context.Context, seriesID string, after time.Time) (models.RequestSeriesPlan, error) {
+	series, err := a.SeriesRepository.FindRequestSeries(ctx, seriesID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return models.RequestSeriesPlan{Status: models.SeriesStatusCancelled}, nil
+		}
+		return models.RequestSeriesPlan{}, err
+	}
+
+	plan := models.RequestSeriesPlan{Status: series.Status}
+	if series.Status != models.SeriesStatusActive {
+		return plan, nil
+	}
+
+	if series.LastOccurrenceAt != nil && series.LastOccurrenceAt.After(after) {
+		after = *series.LastOccurrenceAt
+	}
+
+	next, ok, err := series.Rule.Next(after)
+	if err != nil {
+		return models.RequestSeriesPlan{}, fmt.Errorf("plan series %s: %w", seriesID, err)
+	}
+	if ok {
+		plan.NextRunAt = &next
+	}
+	return plan, nil
+}
+
+// CreateRequestOccurrence materializes one occurrence of the series as a new
+// pending request scheduled for the given time. It is idempotent per
+// (series, scheduledFor) and a no-op once the series is no longer active.
+func (a *Activities) CreateRequestOccurrence(ctx context.Context, seriesID string, scheduledFor time.Time) (string, error) {
+	series, err := a.SeriesRepository.FindRequestSeries(ctx, seriesID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFoundInDB) {
+			return "", nil
+		}
+		return "", err
+	}
+	if series.Status != models.SeriesStatusActive {
+		return "", nil
+	}
+
+	id := uuid.NewSHA1(occurrenceNamespace, []byte(seriesID+"|"+scheduledFor.UTC().Format(time.RFC3339))).String()
+
+	// An occurrence archived since, or held as a draft, still counts.
+	if _, err := a.RequestRepository.FindLatestRequest(ctx, id); err == nil {
+		return id, a.SeriesRepository.MarkOccurrence(ctx, seriesID, scheduledFor)
+	} else if !errors.Is(err, errs.ErrNotFoundInDB) {
+		return "", err
+	}
+
+	template := series.Template
+	template.HotelID = series.HotelID
+	template.RequestType = "recurring"
+	template.Status = string(models.StatusPending)
+	template.ScheduledTime = &scheduledFor
+	template.CompletedAt = nil
+
+	req := models.Request{ID: id, MakeRequest: template, ChangedBy: series.CreatedBy}
+	if _, err := a.RequestRepository.InsertRequest(ctx, &req); err != nil {
+		return "", err
+	}
+
+	return id, a.SeriesRepository.MarkOccurrence(ctx, seriesID, scheduledFor)
+}
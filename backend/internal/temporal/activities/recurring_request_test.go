@@ -0,0 +1,189 @@
+package activities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/errs"
+	"github.com/generate/selfserve/internal/models"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSeriesRepository struct {
+	storage.RequestSeriesRepository
+	series *models.RequestSeries
+	marked []time.Time
+}
+
+func (m *mockSeriesRepository) FindRequestSeries(ctx context.Context, id string) (*models.RequestSeries, error) {
+	if m.series == nil || m.series.ID != id {
+		return nil, errs.ErrNotFoundInDB
+	}
+	return m.series, nil
+}
+
+func (m *mockSeriesRepository) MarkOccurrence(ctx context.Context, id string, at time.Time) error {
+	m.marked = append(m.marked, at)
+	return nil
+}
+
+type mockRequestsRepository struct {
+	storage.RequestsRepository
+	inserted []*models.Request
+}
+
+func (m *mockRequestsRepository) FindRequest(ctx context.Context, id string) (*models.Request, error) {
+	req, err := m.FindLatestRequest(ctx, id)
+	if err == nil && (req.Status == string(models.StatusArchived) || req.Status == string(models.StatusDraft)) {
+		return nil, errs.ErrNotFoundInDB
+	}
+	return req, err
+}
+
+func (m *mockRequestsRepository) FindLatestRequest(ctx context.Context, id string) (*models.Request, error) {
+	for _, r := range m.inserted {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, errs.ErrNotFoundInDB
+}
+
+func (m *mockRequestsRepository) FindGuestRequest(ctx context.Context, id string) (*models.GuestRequest, error) {
+	req, err := m.FindRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GuestRequest{ID: req.ID, Name: req.Name, RequestVersion: req.RequestVersion}, nil
+}
+
+func (m *mockRequestsRepository) InsertRequest(ctx context.Context, req *models.Request) (*models.Request, error) {
+	m.inserted = append(m.inserted, req)
+	return req, nil
+}
+
+func testSeries(status models.RequestSeriesStatus) *models.RequestSeries {
+	createdBy := "user_1"
+	return &models.RequestSeries{
+		ID:      "series-1",
+		HotelID: "org_1",
+		Template: models.MakeRequest{
+			Name:        "Turn-down service",
+			RequestType: "one-time",
+			Status:      "completed",
+			Priority:    "low",
+		},
+		Rule: models.RecurrenceRule{
+			Frequency: models.FrequencyHourly,
+			Interval:  2,
+			StartAt:   time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC),
+		},
+		Status:    status,
+		CreatedBy: &createdBy,
+	}
+}
+
+func TestActivities_PlanRequestSeries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the next occurrence after the last one", func(t *testing.T) {
+		t.Parallel()
+
+		series := testSeries(models.SeriesStatusActive)
+		last := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		series.LastOccurrenceAt = &last
+		acts := &Activities{SeriesRepository: &mockSeriesRepository{series: series}}
+
+		plan, err := acts.PlanRequestSeries(context.Background(), "series-1", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.NotNil(t, plan.NextRunAt)
+		assert.Equal(t, time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC), *plan.NextRunAt)
+	})
+
+	t.Run("omits next run for paused series", func(t *testing.T) {
+		t.Parallel()
+
+		acts := &Activities{SeriesRepository: &mockSeriesRepository{series: testSeries(models.SeriesStatusPaused)}}
+
+		plan, err := acts.PlanRequestSeries(context.Background(), "series-1", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, models.SeriesStatusPaused, plan.Status)
+		assert.Nil(t, plan.NextRunAt)
+	})
+
+	t.Run("treats a deleted series as cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		acts := &Activities{SeriesRepository: &mockSeriesRepository{}}
+
+		plan, err := acts.PlanRequestSeries(context.Background(), "series-1", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, models.SeriesStatusCancelled, plan.Status)
+	})
+}
+
+func TestActivities_CreateRequestOccurrence(t *testing.T) {
+	t.Parallel()
+
+	t.Run("inserts a pending recurring request once per occurrence", func(t *testing.T) {
+		t.Parallel()
+
+		seriesRepo := &mockSeriesRepository{series: testSeries(models.SeriesStatusActive)}
+		requestsRepo := &mockRequestsRepository{}
+		acts := &Activities{SeriesRepository: seriesRepo, RequestRepository: requestsRepo}
+		at := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+		id, err := acts.CreateRequestOccurrence(context.Background(), "series-1", at)
+		require.NoError(t, err)
+		retryID, err := acts.CreateRequestOccurrence(context.Background(), "series-1", at)
+		require.NoError(t, err)
+
+		assert.Equal(t, id, retryID)
+		require.Len(t, requestsRepo.inserted, 1)
+		req := requestsRepo.inserted[0]
+		assert.Equal(t, "org_1", req.HotelID)
+		assert.Equal(t, "recurring", req.RequestType)
+		assert.Equal(t, "pending", req.Status)
+		require.NotNil(t, req.ScheduledTime)
+		assert.Equal(t, at, *req.ScheduledTime)
+		assert.Equal(t, "user_1", *req.ChangedBy)
+		assert.Len(t, seriesRepo.marked, 2)
+	})
+
+	t.Run("does not recreate an occurrence archived since", func(t *testing.T) {
+		t.Parallel()
+
+		requestsRepo := &mockRequestsRepository{}
+		acts := &Activities{
+			SeriesRepository:  &mockSeriesRepository{series: testSeries(models.SeriesStatusActive)},
+			RequestRepository: requestsRepo,
+		}
+		at := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+		_, err := acts.CreateRequestOccurrence(context.Background(), "series-1", at)
+		require.NoError(t, err)
+		requestsRepo.inserted[0].Status = string(models.StatusArchived)
+		_, err = acts.CreateRequestOccurrence(context.Background(), "series-1", at)
+		require.NoError(t, err)
+
+		assert.Len(t, requestsRepo.inserted, 1)
+	})
+
+	t.Run("skips inactive series", func(t *testing.T) {
+		t.Parallel()
+
+		requestsRepo := &mockRequestsRepository{}
+		acts := &Activities{
+			SeriesRepository:  &mockSeriesRepository{series: testSeries(models.SeriesStatusCancelled)},
+			RequestRepository: requestsRepo,
+		}
+
+		id, err := acts.CreateRequestOccurrence(context.Background(), "series-1", time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, id)
+		assert.Empty(t, requestsRepo.inserted)
+	})
+}
@@ -0,0 +1,13 @@
+package activities
+
+import (
+	"os"
+	"testing"
+
+	"github.com/generate/selfserve/internal/validation"
+)
+
+func TestMain(m *testing.M) {
+	validation.Init()
+	os.Exit(m.Run())
+}
@@ -2,25 +2,102 @@ package temporal
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/generate/selfserve/internal/aiflows"
 	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/generate/selfserve/internal/utils"
 	"github.com/google/uuid"
+	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
 )
 
+// ErrGenerationFinished is returned when amending or cancelling a generate
+// workflow whose request has already been generated.
+var ErrGenerationFinished = errors.New("request generation has already finished")
+
+// ErrWorkflowNotFound is returned by GenerateRequestWorkflowClient
+// implementations other than Service for a workflow ID they do not know.
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// ErrInvalidCursor is returned when listing with a cursor this package did not
+// hand out.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// hotelIDMemo is the memo field holding the hotel a generate workflow belongs
+// to, checked before it is cancelled or amended.
+const hotelIDMemo = "hotel_id"
+
+// generateRequestWorkflowType is the type name of generate workflows.
+const generateRequestWorkflowType = "GenerateRequestWorkflow"
+
+// Search attributes set on generate workflows so a hotel's generations can be
+// listed. They must be registered on the namespace as Keyword attributes (make
+// temporal-setup); without them, workflows are started unlisted.
+var (
+	hotelIDSearchAttribute     = temporal.NewSearchAttributeKeyKeyword("HotelID")
+	requestedBySearchAttribute = temporal.NewSearchAttributeKeyKeyword("RequestedBy")
+)
+
+// GenerateRequestResult is the state of a generate workflow. Once completed,
+// RequestID is the ID the generated request was stored under.
 type GenerateRequestResult struct {
-	Status string                                 `json:"status"`
-	Output *aiflows.EnrichedGenerateRequestOutput `json:"output,omitempty"`
-	Error  *string                                `json:"error,omitempty"`
+	Status    string                                 `json:"status"`
+	RequestID *string                                `json:"request_id,omitempty"`
+	Output    *aiflows.EnrichedGenerateRequestOutput `json:"output,omitempty"`
+	Error     *string                                `json:"error,omitempty"`
+}
+
+// GenerateRequestExecution summarises a generate workflow for listing.
+type GenerateRequestExecution struct {
+	WorkflowID  string     `json:"workflow_id"`
+	Status      string     `json:"status"`
+	HotelID     string     `json:"hotel_id"`
+	RequestedBy *string    `json:"requested_by,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+}
+
+// GenerateRequestFilter selects the generate workflows of a hotel, optionally
+// only those submitted by one user. Cursor is the NextCursor of the previous
+// page.
+type GenerateRequestFilter struct {
+	HotelID     string
+	RequestedBy string
+	Cursor      string
+	Limit       int
 }
 
 type GenerateRequestWorkflowClient interface {
-	StartGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (workflowID string, err error)
+	StartGenerateRequest(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (workflowID string, err error)
 	GetGenerateRequestResult(ctx context.Context, workflowID string) (GenerateRequestResult, error)
+	// CancelGenerateRequest abandons a hotel's generation still in progress.
+	// It returns ErrGenerationFinished once the request has been generated,
+	// and a not found error for another hotel's.
+	CancelGenerateRequest(ctx context.Context, hotelID, workflowID string) error
+	// AmendGenerateRequest restarts a hotel's generation still in progress
+	// with new raw text. It returns ErrGenerationFinished once the request has
+	// been generated, and a not found error for another hotel's.
+	AmendGenerateRequest(ctx context.Context, hotelID, workflowID, rawText string) error
+	// ListGenerateRequests returns a hotel's generations, most recent first.
+	ListGenerateRequests(ctx context.Context, filter GenerateRequestFilter) (utils.CursorPage[GenerateRequestExecution], error)
+}
+
+// RequestSeriesWorkflowClient drives the per-series recurring workflow.
+type RequestSeriesWorkflowClient interface {
+	// SignalRequestSeries tells the series workflow to re-read its row,
+	// starting the workflow if it is not running.
+	SignalRequestSeries(ctx context.Context, seriesID string) error
 }
 
 type Service struct {
@@ -31,12 +108,27 @@ func NewService(c client.Client) *Service {
 	return &Service{client: c}
 }
 
-func (s *Service) StartGenerateRequest(ctx context.Context, input aiflows.GenerateRequestInput) (string, error) {
+func (s *Service) StartGenerateRequest(ctx context.Context, input workflows.GenerateRequestWorkflowInput) (string, error) {
 	workflowID := "generate-request-" + uuid.NewString()
-	_, err := s.client.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-		ID:        workflowID,
-		TaskQueue: workflows.GenerateRequestTaskQueue,
-	}, workflows.GenerateRequestWorkflow, input)
+	attributes := []temporal.SearchAttributeUpdate{hotelIDSearchAttribute.ValueSet(input.HotelID)}
+	if input.RequestedBy != nil {
+		attributes = append(attributes, requestedBySearchAttribute.ValueSet(*input.RequestedBy))
+	}
+	options := client.StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             workflows.GenerateRequestTaskQueue,
+		TypedSearchAttributes: temporal.NewSearchAttributes(attributes...),
+		Memo:                  map[string]interface{}{hotelIDMemo: input.HotelID},
+	}
+	_, err := s.client.ExecuteWorkflow(ctx, options, workflows.GenerateRequestWorkflow, input)
+	var invalid *serviceerror.InvalidArgument
+	if errors.As(err, &invalid) {
+		// The namespace is missing the search attributes. Generate the request
+		// anyway; it just cannot be listed.
+		slog.Warn("temporal: starting generate workflow without search attributes", "err", err)
+		options.TypedSearchAttributes = temporal.SearchAttributes{}
+		_, err = s.client.ExecuteWorkflow(ctx, options, workflows.GenerateRequestWorkflow, input)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -54,12 +146,18 @@ func (s *Service) GetGenerateRequestResult(ctx context.Context, workflowID strin
 	case enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING:
 		return GenerateRequestResult{Status: "pending"}, nil
 	case enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED:
-		var output aiflows.EnrichedGenerateRequestOutput
+		var output workflows.GenerateRequestWorkflowOutput
 		getErr := s.client.GetWorkflow(ctx, workflowID, "").Get(ctx, &output)
 		if getErr != nil {
 			return GenerateRequestResult{}, getErr
 		}
-		return GenerateRequestResult{Status: "completed", Output: &output}, nil
+		result := GenerateRequestResult{Status: "completed", Output: &output.EnrichedGenerateRequestOutput}
+		if output.RequestID != "" {
+			result.RequestID = &output.RequestID
+		}
+		return result, nil
+	case enumspb.WORKFLOW_EXECUTION_STATUS_CANCELED:
+		return GenerateRequestResult{Status: "cancelled"}, nil
 	case enumspb.WORKFLOW_EXECUTION_STATUS_FAILED, enumspb.WORKFLOW_EXECUTION_STATUS_TERMINATED, enumspb.WORKFLOW_EXECUTION_STATUS_TIMED_OUT:
 		var output aiflows.EnrichedGenerateRequestOutput
 		getErr := s.client.GetWorkflow(ctx, workflowID, "").Get(ctx, &output)
@@ -74,7 +172,181 @@ func (s *Service) GetGenerateRequestResult(ctx context.Context, workflowID strin
 	}
 }
 
+func (s *Service) CancelGenerateRequest(ctx context.Context, hotelID, workflowID string) error {
+	if err := s.ensureGenerating(ctx, hotelID, workflowID); err != nil {
+		return err
+	}
+	return s.client.CancelWorkflow(ctx, workflowID, "")
+}
+
+// AmendGenerateRequest amends the workflow with an update, which it rejects
+// once the request has been generated.
+func (s *Service) AmendGenerateRequest(ctx context.Context, hotelID, workflowID, rawText string) error {
+	if err := s.ensureHotel(ctx, hotelID, workflowID); err != nil {
+		return err
+	}
+	handle, err := s.client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   workflows.AmendGenerateRequestUpdate,
+		Args:         []interface{}{rawText},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err == nil {
+		err = handle.Get(ctx, nil)
+	}
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.Type() == workflows.GenerationFinishedError {
+		return ErrGenerationFinished
+	}
+	if IsWorkflowNotFound(err) {
+		// It finished since ensureHotel found it.
+		return ErrGenerationFinished
+	}
+	return err
+}
+
+// ensureHotel returns ErrWorkflowNotFound unless the workflow generates a
+// request for the hotel, and ErrGenerationFinished unless it is running.
+func (s *Service) ensureHotel(ctx context.Context, hotelID, workflowID string) error {
+	description, err := s.client.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		return err
+	}
+	info := description.GetWorkflowExecutionInfo()
+	owner := keywordAttribute(info.GetMemo().GetFields(), hotelIDMemo)
+	if owner == "" {
+		owner = keywordAttribute(info.GetSearchAttributes().GetIndexedFields(), hotelIDSearchAttribute.GetName())
+	}
+	if info.GetType().GetName() != generateRequestWorkflowType || owner != hotelID {
+		return ErrWorkflowNotFound
+	}
+	if info.GetStatus() != enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return ErrGenerationFinished
+	}
+	return nil
+}
+
+// ensureGenerating returns ErrGenerationFinished unless the workflow is still
+// generating its request. The workflow may move on right after the check, in
+// which case a cancellation no longer has any effect.
+func (s *Service) ensureGenerating(ctx context.Context, hotelID, workflowID string) error {
+	if err := s.ensureHotel(ctx, hotelID, workflowID); err != nil {
+		return err
+	}
+	value, err := s.client.QueryWorkflow(ctx, workflowID, "", workflows.GenerateRequestStageQuery)
+	if err != nil {
+		return err
+	}
+	var stage string
+	if err := value.Get(&stage); err != nil {
+		return err
+	}
+	if stage != workflows.StageGenerating {
+		return ErrGenerationFinished
+	}
+	return nil
+}
+
+func (s *Service) ListGenerateRequests(ctx context.Context, filter GenerateRequestFilter) (utils.CursorPage[GenerateRequestExecution], error) {
+	var pageToken []byte
+	if filter.Cursor != "" {
+		token, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+		if err != nil {
+			return utils.CursorPage[GenerateRequestExecution]{}, ErrInvalidCursor
+		}
+		pageToken = token
+	}
+
+	query := fmt.Sprintf("WorkflowType = '%s' AND HotelID = %s", generateRequestWorkflowType, quoteVisibilityValue(filter.HotelID))
+	if filter.RequestedBy != "" {
+		query += " AND RequestedBy = " + quoteVisibilityValue(filter.RequestedBy)
+	}
+
+	resp, err := s.client.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		Query:         query,
+		PageSize:      int32(utils.ResolveLimit(filter.Limit)), //nolint:gosec // limits are validated to at most 100
+		NextPageToken: pageToken,
+	})
+	if err != nil {
+		return utils.CursorPage[GenerateRequestExecution]{}, err
+	}
+
+	page := utils.CursorPage[GenerateRequestExecution]{Items: make([]GenerateRequestExecution, 0, len(resp.Executions))}
+	for _, info := range resp.Executions {
+		execution := GenerateRequestExecution{
+			WorkflowID: info.GetExecution().GetWorkflowId(),
+			Status:     executionStatus(info.GetStatus()),
+			StartedAt:  info.GetStartTime().AsTime(),
+		}
+		if info.GetCloseTime() != nil {
+			closedAt := info.GetCloseTime().AsTime()
+			execution.ClosedAt = &closedAt
+		}
+		fields := info.GetSearchAttributes().GetIndexedFields()
+		execution.HotelID = keywordAttribute(fields, hotelIDSearchAttribute.GetName())
+		if requestedBy := keywordAttribute(fields, requestedBySearchAttribute.GetName()); requestedBy != "" {
+			execution.RequestedBy = &requestedBy
+		}
+		page.Items = append(page.Items, execution)
+	}
+	if len(resp.NextPageToken) > 0 {
+		cursor := base64.RawURLEncoding.EncodeToString(resp.NextPageToken)
+		page.NextCursor = &cursor
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// executionStatus names a workflow status as GetGenerateRequestResult does.
+func executionStatus(status enumspb.WorkflowExecutionStatus) string {
+	switch status {
+	case enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING:
+		return "pending"
+	case enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		return "completed"
+	case enumspb.WORKFLOW_EXECUTION_STATUS_CANCELED:
+		return "cancelled"
+	default:
+		return "failed"
+	}
+}
+
+// keywordAttribute decodes the string field name of a search attribute or
+// memo, or returns "" when it is missing.
+func keywordAttribute(fields map[string]*commonpb.Payload, name string) string {
+	payload, ok := fields[name]
+	if !ok {
+		return ""
+	}
+	var value string
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// quoteVisibilityValue quotes a value for a visibility list query.
+func quoteVisibilityValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func (s *Service) SignalRequestSeries(ctx context.Context, seriesID string) error {
+	workflowID := workflows.RequestSeriesWorkflowID(seriesID)
+	_, err := s.client.SignalWithStartWorkflow(ctx, workflowID, workflows.RequestSeriesChangedSignal, nil, client.StartWorkflowOptions{
+		ID:        workflowID,
+		TaskQueue: workflows.GenerateRequestTaskQueue,
+	}, workflows.RecurringRequestWorkflow, seriesID)
+	return err
+}
+
+// IsUnavailable reports whether err means Temporal could not be reached, in
+// which case the call had no effect.
+func IsUnavailable(err error) bool {
+	var unavailableErr *serviceerror.Unavailable
+	return errors.As(err, &unavailableErr)
+}
+
 func IsWorkflowNotFound(err error) bool {
 	var notFoundErr *serviceerror.NotFound
-	return errors.As(err, &notFoundErr)
+	return errors.As(err, &notFoundErr) || errors.Is(err, ErrWorkflowNotFound)
 }
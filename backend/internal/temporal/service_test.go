@@ -0,0 +1,152 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/temporal/workflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestService_StartGenerateRequest(t *testing.T) {
+	t.Parallel()
+
+	input := workflows.GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	}
+	withAttributes := mock.MatchedBy(func(o client.StartWorkflowOptions) bool { return o.TypedSearchAttributes.Size() > 0 })
+	withoutAttributes := mock.MatchedBy(func(o client.StartWorkflowOptions) bool { return o.TypedSearchAttributes.Size() == 0 })
+
+	t.Run("sets the search attributes", func(t *testing.T) {
+		t.Parallel()
+
+		c := &mocks.Client{}
+		c.On("ExecuteWorkflow", mock.Anything, withAttributes, mock.Anything, input).Return(&mocks.WorkflowRun{}, nil).Once()
+
+		id, err := NewService(c).StartGenerateRequest(context.Background(), input)
+
+		require.NoError(t, err)
+		assert.Contains(t, id, "generate-request-")
+		c.AssertExpectations(t)
+	})
+
+	t.Run("starts without them when the namespace has not registered them", func(t *testing.T) {
+		t.Parallel()
+
+		c := &mocks.Client{}
+		c.On("ExecuteWorkflow", mock.Anything, withAttributes, mock.Anything, input).
+			Return(nil, serviceerror.NewInvalidArgument("search attribute HotelID is not defined")).Once()
+		c.On("ExecuteWorkflow", mock.Anything, withoutAttributes, mock.Anything, input).Return(&mocks.WorkflowRun{}, nil).Once()
+
+		_, err := NewService(c).StartGenerateRequest(context.Background(), input)
+
+		require.NoError(t, err)
+		c.AssertExpectations(t)
+	})
+}
+
+// describeGenerate has c describe workflow-1 as a generate workflow of
+// hotelID in status.
+func describeGenerate(t *testing.T, c *mocks.Client, hotelID string, status enumspb.WorkflowExecutionStatus) {
+	t.Helper()
+
+	payload, err := converter.GetDefaultDataConverter().ToPayload(hotelID)
+	require.NoError(t, err)
+	c.On("DescribeWorkflowExecution", mock.Anything, "workflow-1", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			Type:   &commonpb.WorkflowType{Name: generateRequestWorkflowType},
+			Status: status,
+			Memo:   &commonpb.Memo{Fields: map[string]*commonpb.Payload{hotelIDMemo: payload}},
+		},
+	}, nil)
+}
+
+func TestService_AmendGenerateRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	update := func(err error) *mocks.Client {
+		c := &mocks.Client{}
+		handle := &mocks.WorkflowUpdateHandle{}
+		handle.On("Get", mock.Anything, nil).Return(err)
+		c.On("UpdateWorkflow", mock.Anything, mock.MatchedBy(func(o client.UpdateWorkflowOptions) bool {
+			return o.WorkflowID == "workflow-1" && o.UpdateName == workflows.AmendGenerateRequestUpdate
+		})).Return(handle, nil)
+		return c
+	}
+
+	t.Run("amends the hotel's generation", func(t *testing.T) {
+		t.Parallel()
+
+		c := update(nil)
+		describeGenerate(t, c, "org_1", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+		require.NoError(t, NewService(c).AmendGenerateRequest(ctx, "org_1", "workflow-1", "need soap"))
+		c.AssertExpectations(t)
+	})
+
+	t.Run("does not find another hotel's generation", func(t *testing.T) {
+		t.Parallel()
+
+		c := &mocks.Client{}
+		describeGenerate(t, c, "org_2", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+		err := NewService(c).AmendGenerateRequest(ctx, "org_1", "workflow-1", "need soap")
+		assert.ErrorIs(t, err, ErrWorkflowNotFound)
+		c.AssertNotCalled(t, "UpdateWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reports an amendment the workflow rejects as too late", func(t *testing.T) {
+		t.Parallel()
+
+		c := update(temporal.NewNonRetryableApplicationError("request generation has already finished", workflows.GenerationFinishedError, nil))
+		describeGenerate(t, c, "org_1", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+		err := NewService(c).AmendGenerateRequest(ctx, "org_1", "workflow-1", "need soap")
+		assert.ErrorIs(t, err, ErrGenerationFinished)
+	})
+
+	t.Run("reports a finished generation as too late", func(t *testing.T) {
+		t.Parallel()
+
+		c := &mocks.Client{}
+		describeGenerate(t, c, "org_1", enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED)
+
+		err := NewService(c).AmendGenerateRequest(ctx, "org_1", "workflow-1", "need soap")
+		assert.ErrorIs(t, err, ErrGenerationFinished)
+	})
+
+	t.Run("passes on other failures", func(t *testing.T) {
+		t.Parallel()
+
+		c := update(errors.New("temporal down"))
+		describeGenerate(t, c, "org_1", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+		assert.EqualError(t, NewService(c).AmendGenerateRequest(ctx, "org_1", "workflow-1", "need soap"), "temporal down")
+	})
+}
+
+func TestService_CancelGenerateRequest(t *testing.T) {
+	t.Parallel()
+
+	c := &mocks.Client{}
+	describeGenerate(t, c, "org_2", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING)
+
+	err := NewService(c).CancelGenerateRequest(context.Background(), "org_1", "workflow-1")
+
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+	c.AssertNotCalled(t, "CancelWorkflow", mock.Anything, mock.Anything, mock.Anything)
+}
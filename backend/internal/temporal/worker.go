@@ -2,6 +2,7 @@ package temporal
 
 import (
 	"github.com/generate/selfserve/internal/aiflows"
+	storage "github.com/generate/selfserve/internal/service/storage/postgres"
 	"github.com/generate/selfserve/internal/temporal/activities"
 	"github.com/generate/selfserve/internal/temporal/workflows"
 	"go.temporal.io/sdk/activity"
@@ -9,14 +10,45 @@ import (
 	"go.temporal.io/sdk/worker"
 )
 
-func NewWorker(c client.Client, genkitSvc aiflows.GenerateRequestService) worker.Worker {
+// NewWorker registers the workflows and their activities. drafts holds
+// generated requests with a confidence below reviewThreshold for review; it
+// is nilable, as are notifier and events, without which generated requests
+// are stored but not announced.
+func NewWorker(c client.Client, genkitSvc aiflows.GenerateRequestService, requestsRepo storage.RequestsRepository, seriesRepo storage.RequestSeriesRepository,
+	drafts storage.RequestDraftsRepository, reviewThreshold float64, notifier activities.NotificationSender, events activities.EventPublisher) worker.Worker {
 	w := worker.New(c, workflows.GenerateRequestTaskQueue, worker.Options{})
-	acts := &activities.Activities{Service: genkitSvc}
+	acts := &activities.Activities{
+		Service:           genkitSvc,
+		RequestRepository: requestsRepo,
+		SeriesRepository:  seriesRepo,
+		DraftRepository:   drafts,
+		ReviewThreshold:   reviewThreshold,
+		Notifier:          notifier,
+		Events:            events,
+	}
 
 	w.RegisterWorkflow(workflows.GenerateRequestWorkflow)
 	w.RegisterActivityWithOptions(acts.RunGenerateRequest, activity.RegisterOptions{
 		Name: "RunGenerateRequest",
 	})
+	w.RegisterActivityWithOptions(acts.PersistGeneratedRequest, activity.RegisterOptions{
+		Name: "PersistGeneratedRequest",
+	})
+	w.RegisterActivityWithOptions(acts.NotifyRequestAssignee, activity.RegisterOptions{
+		Name: "NotifyRequestAssignee",
+	})
+	w.RegisterActivityWithOptions(acts.PublishRequestCreated, activity.RegisterOptions{
+		Name: "PublishRequestCreated",
+	})
+
+	// Recurring series share the generate-request task queue.
+	w.RegisterWorkflow(workflows.RecurringRequestWorkflow)
+	w.RegisterActivityWithOptions(acts.PlanRequestSeries, activity.RegisterOptions{
+		Name: "PlanRequestSeries",
+	})
+	w.RegisterActivityWithOptions(acts.CreateRequestOccurrence, activity.RegisterOptions{
+		Name: "CreateRequestOccurrence",
+	})
 
 	return w
 }
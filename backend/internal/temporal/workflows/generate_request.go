@@ -4,14 +4,85 @@ import (
 	"time"
 
 	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/models"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
 const GenerateRequestTaskQueue = "generate-request-queue"
+
+// maxPendingAmendments bounds the amendments waiting for generation to start
+// over with them.
+const maxPendingAmendments = 10
+
 const runGenerateRequestActivityName = "RunGenerateRequest"
+const persistGeneratedRequestActivityName = "PersistGeneratedRequest"
+const notifyRequestAssigneeActivityName = "NotifyRequestAssignee"
+const publishRequestCreatedActivityName = "PublishRequestCreated"
+
+// AmendGenerateRequestUpdate replaces the raw text of a generate workflow,
+// restarting generation with it. Once generation has finished it is rejected
+// with a GenerationFinishedError.
+const AmendGenerateRequestUpdate = "amend"
+
+// AmendGenerateRequestSignal is how amendments were sent before
+// AmendGenerateRequestUpdate. It is ignored once generation has finished.
+const AmendGenerateRequestSignal = "amend-raw-text"
+
+// GenerationFinishedError is the type of the application error an amendment
+// is rejected with once the request has been generated.
+const GenerationFinishedError = "GenerationFinished"
 
-func GenerateRequestWorkflow(ctx workflow.Context, input aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+// GenerateRequestStageQuery reports the stage a generate workflow is in.
+const GenerateRequestStageQuery = "stage"
+
+// Stages of a generate workflow. The request can only be amended or
+// cancelled while it is being generated.
+const (
+	StageGenerating = "generating"
+	StageStoring    = "storing"
+	StageDone       = "done"
+)
+
+// persistGeneratedRequestChange marks the version of the workflow that stores
+// the generated request, so workflows started before it replay unchanged.
+const persistGeneratedRequestChange = "persist-generated-request"
+
+// GenerateRequestWorkflowInput is the message to generate a request from.
+// RequestedBy is the user who submitted it, recorded as the author of the
+// created request.
+type GenerateRequestWorkflowInput struct {
+	aiflows.GenerateRequestInput
+	RequestedBy *string `json:"requested_by,omitempty"`
+}
+
+// GenerateRequestWorkflowOutput is the generated request and the ID it was
+// stored under. RequestID is empty for workflows started before requests were
+// stored.
+type GenerateRequestWorkflowOutput struct {
+	RequestID string `json:"request_id,omitempty"`
+	aiflows.EnrichedGenerateRequestOutput
+}
+
+// PersistGeneratedRequestInput is what the persist activity needs to store a
+// generated request. The workflow ID keys the stored request, so a retried
+// activity finds it instead of creating it twice.
+type PersistGeneratedRequestInput struct {
+	WorkflowID  string                                `json:"workflow_id"`
+	HotelID     string                                `json:"hotel_id"`
+	RawText     string                                `json:"raw_text"`
+	RequestedBy *string                               `json:"requested_by,omitempty"`
+	Output      aiflows.EnrichedGenerateRequestOutput `json:"output"`
+}
+
+// GenerateRequestWorkflow generates a request from a message, stores it,
+// notifies its assignee and announces it on the hotel's request stream; a
+// request that needs review is instead held as a draft, unannounced. Until
+// generation finishes, AmendGenerateRequestUpdate restarts it with new text and
+// cancelling the workflow abandons it; after that, the request is stored
+// whatever happens. The notification and announcement are best effort: once
+// the request is stored, their failure does not fail the workflow.
+func GenerateRequestWorkflow(ctx workflow.Context, input GenerateRequestWorkflowInput) (GenerateRequestWorkflowOutput, error) {
 	activityOptions := workflow.ActivityOptions{
 		StartToCloseTimeout: 2 * time.Minute,
 		RetryPolicy: &temporal.RetryPolicy{
@@ -20,14 +91,115 @@ func GenerateRequestWorkflow(ctx workflow.Context, input aiflows.GenerateRequest
 			MaximumAttempts:    3,
 		},
 	}
-
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
-	var output aiflows.EnrichedGenerateRequestOutput
-	err := workflow.ExecuteActivity(ctx, runGenerateRequestActivityName, input).Get(ctx, &output)
+	stage := StageGenerating
+	if err := workflow.SetQueryHandler(ctx, GenerateRequestStageQuery, func() (string, error) {
+		return stage, nil
+	}); err != nil {
+		return GenerateRequestWorkflowOutput{}, err
+	}
+
+	// Amendments are queued for generateRequest; the validator rejects them
+	// once it has returned, so none is accepted and then dropped.
+	amendments := workflow.NewBufferedChannel(ctx, maxPendingAmendments)
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, AmendGenerateRequestUpdate,
+		func(ctx workflow.Context, rawText string) error {
+			amendments.Send(ctx, rawText)
+			return nil
+		},
+		workflow.UpdateHandlerOptions{Validator: func(rawText string) error {
+			if stage != StageGenerating {
+				return temporal.NewNonRetryableApplicationError("request generation has already finished", GenerationFinishedError, nil)
+			}
+			if amendments.Len() >= maxPendingAmendments {
+				return temporal.NewApplicationError("too many amendments pending", "TooManyAmendments")
+			}
+			return nil
+		}},
+	); err != nil {
+		return GenerateRequestWorkflowOutput{}, err
+	}
+
+	output, err := generateRequest(ctx, &input.GenerateRequestInput, amendments)
 	if err != nil {
-		return aiflows.EnrichedGenerateRequestOutput{}, err
+		return GenerateRequestWorkflowOutput{}, err
+	}
+	stage = StageStoring
+	defer func() { stage = StageDone }()
+
+	if workflow.GetVersion(ctx, persistGeneratedRequestChange, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return GenerateRequestWorkflowOutput{EnrichedGenerateRequestOutput: output}, nil
+	}
+
+	// The request is generated; cancelling the workflow no longer stops it
+	// from being stored.
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+
+	var req models.Request
+	err = workflow.ExecuteActivity(ctx, persistGeneratedRequestActivityName, PersistGeneratedRequestInput{
+		WorkflowID:  workflow.GetInfo(ctx).WorkflowExecution.ID,
+		HotelID:     input.HotelID,
+		RawText:     input.RawText,
+		RequestedBy: input.RequestedBy,
+		Output:      output,
+	}).Get(ctx, &req)
+	if err != nil {
+		return GenerateRequestWorkflowOutput{}, err
+	}
+
+	// A draft is announced once a reviewer approves it.
+	if req.Status != string(models.StatusDraft) {
+		logger := workflow.GetLogger(ctx)
+		if req.UserID != nil {
+			if err := workflow.ExecuteActivity(ctx, notifyRequestAssigneeActivityName, req).Get(ctx, nil); err != nil {
+				logger.Warn("failed to notify the assignee of a generated request", "request_id", req.ID, "error", err)
+			}
+		}
+		if err := workflow.ExecuteActivity(ctx, publishRequestCreatedActivityName, req.HotelID, req.ID).Get(ctx, nil); err != nil {
+			logger.Warn("failed to publish a generated request", "request_id", req.ID, "error", err)
+		}
+	}
+
+	return GenerateRequestWorkflowOutput{RequestID: req.ID, EnrichedGenerateRequestOutput: output}, nil
+}
+
+// generateRequest runs the generate activity, starting it over with the new
+// text whenever an amendment arrives before it finishes. An amendment accepted
+// as the activity finished still starts it over. input is left holding the
+// text the request was generated from.
+func generateRequest(ctx workflow.Context, input *aiflows.GenerateRequestInput, amendments workflow.ReceiveChannel) (aiflows.EnrichedGenerateRequestOutput, error) {
+	signals := workflow.GetSignalChannel(ctx, AmendGenerateRequestSignal)
+	var amended bool
+	amend := func(c workflow.ReceiveChannel, _ bool) {
+		c.Receive(ctx, &input.RawText)
+		amended = true
 	}
 
-	return output, nil
+	for {
+		activityCtx, cancelActivity := workflow.WithCancel(ctx)
+		future := workflow.ExecuteActivity(activityCtx, runGenerateRequestActivityName, *input)
+
+		var output aiflows.EnrichedGenerateRequestOutput
+		var err error
+		amended = false
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(future, func(f workflow.Future) {
+			err = f.Get(ctx, &output)
+		})
+		selector.AddReceive(amendments, amend)
+		selector.AddReceive(signals, amend)
+		selector.Select(ctx)
+
+		// Take the latest amendment accepted meanwhile.
+		for amendments.ReceiveAsync(&input.RawText) {
+			amended = true
+		}
+
+		cancelActivity()
+		if !amended {
+			return output, err
+		}
+		workflow.GetLogger(ctx).Info("raw text amended, generating again")
+	}
 }
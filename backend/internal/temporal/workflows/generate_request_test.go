@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/generate/selfserve/internal/aiflows"
+	"github.com/generate/selfserve/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
 )
 
@@ -27,52 +30,294 @@ func (s *workflowTestSuite) AfterTest(_, _ string) {
 	s.env.AssertExpectations(s.T())
 }
 
+// registerPersistActivities registers the activities that run after
+// generation. The stored request takes the generated status and is assigned
+// to assignee when it is set; the activity names of the calls are appended to
+// calls.
+func (s *workflowTestSuite) registerPersistActivities(assignee *string, calls *[]string, notifyErr error) {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, input PersistGeneratedRequestInput) (*models.Request, error) {
+			*calls = append(*calls, "persist")
+			assert.NotEmpty(s.T(), input.WorkflowID)
+			return &models.Request{ID: "request-1", MakeRequest: models.MakeRequest{
+				HotelID: input.HotelID, Name: input.Output.Name, Status: input.Output.Status, UserID: assignee,
+			}}, nil
+		},
+		activity.RegisterOptions{Name: "PersistGeneratedRequest"},
+	)
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, req models.Request) error {
+			*calls = append(*calls, "notify")
+			return notifyErr
+		},
+		activity.RegisterOptions{Name: "NotifyRequestAssignee"},
+	)
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, hotelID, requestID string) error {
+			*calls = append(*calls, "publish")
+			assert.Equal(s.T(), "org_1", hotelID)
+			assert.Equal(s.T(), "request-1", requestID)
+			return nil
+		},
+		activity.RegisterOptions{Name: "PublishRequestCreated"},
+	)
+}
+
 func (s *workflowTestSuite) TestSuccess() {
-	input := aiflows.GenerateRequestInput{
-		RawText: "need towels",
-		HotelID: "org_1",
+	requestedBy := "user_1"
+	input := GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{
+			RawText: "need towels",
+			HotelID: "org_1",
+		},
+		RequestedBy: &requestedBy,
 	}
-	expected := aiflows.GenerateRequestOutput{
-		Name:        "Towels",
-		RequestType: "one-time",
-		Status:      "pending",
-		Priority:    "low",
+	expected := aiflows.EnrichedGenerateRequestOutput{
+		GenerateRequestOutput: aiflows.GenerateRequestOutput{
+			Name:        "Towels",
+			RequestType: "one-time",
+			Status:      "pending",
+			Priority:    "low",
+		},
 	}
 
 	s.env.RegisterActivityWithOptions(
-		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.GenerateRequestOutput, error) {
-			assert.Equal(s.T(), input, gotInput)
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			assert.Equal(s.T(), input.GenerateRequestInput, gotInput)
 			return expected, nil
 		},
 		activity.RegisterOptions{Name: "RunGenerateRequest"},
 	)
+	assignee := "user_2"
+	var calls []string
+	s.registerPersistActivities(&assignee, &calls, nil)
 	s.env.ExecuteWorkflow(GenerateRequestWorkflow, input)
 
 	require.True(s.T(), s.env.IsWorkflowCompleted())
 	require.NoError(s.T(), s.env.GetWorkflowError())
 
-	var output aiflows.GenerateRequestOutput
+	var output GenerateRequestWorkflowOutput
+	require.NoError(s.T(), s.env.GetWorkflowResult(&output))
+	assert.Equal(s.T(), "request-1", output.RequestID)
+	assert.Equal(s.T(), expected, output.EnrichedGenerateRequestOutput)
+	assert.Equal(s.T(), []string{"persist", "notify", "publish"}, calls)
+}
+
+func (s *workflowTestSuite) TestUnassignedRequestIsNotNotified() {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			return aiflows.EnrichedGenerateRequestOutput{GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "Towels"}}, nil
+		},
+		activity.RegisterOptions{Name: "RunGenerateRequest"},
+	)
+	var calls []string
+	s.registerPersistActivities(nil, &calls, nil)
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	assert.Equal(s.T(), []string{"persist", "publish"}, calls)
+}
+
+func (s *workflowTestSuite) TestDraftIsNotAnnounced() {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			// Stored as it is, the request is held for review.
+			return aiflows.EnrichedGenerateRequestOutput{GenerateRequestOutput: aiflows.GenerateRequestOutput{
+				Name: "Towels", Status: string(models.StatusDraft),
+			}}, nil
+		},
+		activity.RegisterOptions{Name: "RunGenerateRequest"},
+	)
+	assignee := "user_2"
+	var calls []string
+	s.registerPersistActivities(&assignee, &calls, nil)
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	var output GenerateRequestWorkflowOutput
 	require.NoError(s.T(), s.env.GetWorkflowResult(&output))
-	assert.Equal(s.T(), expected, output)
+	assert.Equal(s.T(), "request-1", output.RequestID)
+	assert.Equal(s.T(), []string{"persist"}, calls)
+}
+
+func (s *workflowTestSuite) TestNotificationFailureKeepsTheRequest() {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			return aiflows.EnrichedGenerateRequestOutput{GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "Towels"}}, nil
+		},
+		activity.RegisterOptions{Name: "RunGenerateRequest"},
+	)
+	assignee := "user_2"
+	var calls []string
+	s.registerPersistActivities(&assignee, &calls, errors.New("push service down"))
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	var output GenerateRequestWorkflowOutput
+	require.NoError(s.T(), s.env.GetWorkflowResult(&output))
+	assert.Equal(s.T(), "request-1", output.RequestID)
+	assert.Contains(s.T(), calls, "publish")
 }
 
 func (s *workflowTestSuite) TestActivityFailure() {
-	input := aiflows.GenerateRequestInput{
-		RawText: "need towels",
-		HotelID: "org_1",
+	input := GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{
+			RawText: "need towels",
+			HotelID: "org_1",
+		},
 	}
 
 	s.env.RegisterActivityWithOptions(
-		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.GenerateRequestOutput, error) {
-			assert.Equal(s.T(), input, gotInput)
-			return aiflows.GenerateRequestOutput{}, errors.New("llm unavailable")
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			assert.Equal(s.T(), input.GenerateRequestInput, gotInput)
+			return aiflows.EnrichedGenerateRequestOutput{}, errors.New("llm unavailable")
 		},
 		activity.RegisterOptions{Name: "RunGenerateRequest"},
 	)
+	var calls []string
+	s.registerPersistActivities(nil, &calls, nil)
 	s.env.ExecuteWorkflow(GenerateRequestWorkflow, input)
 
 	require.True(s.T(), s.env.IsWorkflowCompleted())
 	require.Error(s.T(), s.env.GetWorkflowError())
+	assert.Empty(s.T(), calls)
+}
+
+// registerBlockingGenerate registers a generate activity that blocks on
+// "need towels" until it is cancelled and generates from any other text. The
+// texts it is called with are appended to texts.
+func (s *workflowTestSuite) registerBlockingGenerate(texts *[]string) {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			*texts = append(*texts, gotInput.RawText)
+			if gotInput.RawText == "need towels" {
+				select {
+				case <-ctx.Done():
+					return aiflows.EnrichedGenerateRequestOutput{}, ctx.Err()
+				case <-time.After(10 * time.Second):
+					return aiflows.EnrichedGenerateRequestOutput{}, errors.New("generation was not cancelled")
+				}
+			}
+			return aiflows.EnrichedGenerateRequestOutput{GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "Towels"}}, nil
+		},
+		activity.RegisterOptions{Name: "RunGenerateRequest"},
+	)
+}
+
+func (s *workflowTestSuite) TestAmendRestartsGeneration() {
+	var texts []string
+	s.registerBlockingGenerate(&texts)
+	var persisted []string
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, input PersistGeneratedRequestInput) (*models.Request, error) {
+			persisted = append(persisted, input.RawText)
+			return &models.Request{ID: "request-1", MakeRequest: models.MakeRequest{HotelID: input.HotelID}}, nil
+		},
+		activity.RegisterOptions{Name: "PersistGeneratedRequest"},
+	)
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, hotelID, requestID string) error { return nil },
+		activity.RegisterOptions{Name: "PublishRequestCreated"},
+	)
+	s.env.RegisterDelayedCallback(func() {
+		value, err := s.env.QueryWorkflow(GenerateRequestStageQuery)
+		s.Require().NoError(err)
+		var stage string
+		s.Require().NoError(value.Get(&stage))
+		s.Equal(StageGenerating, stage)
+
+		s.env.UpdateWorkflowNoRejection(AmendGenerateRequestUpdate, "amend-1", s.T(), "need towels in room 505")
+	}, time.Millisecond)
+
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	assert.Equal(s.T(), []string{"need towels", "need towels in room 505"}, texts)
+	assert.Equal(s.T(), []string{"need towels in room 505"}, persisted)
+
+	value, err := s.env.QueryWorkflow(GenerateRequestStageQuery)
+	require.NoError(s.T(), err)
+	var stage string
+	require.NoError(s.T(), value.Get(&stage))
+	assert.Equal(s.T(), StageDone, stage)
+}
+
+func (s *workflowTestSuite) TestAmendSignalRestartsGeneration() {
+	var texts []string
+	s.registerBlockingGenerate(&texts)
+	var calls []string
+	s.registerPersistActivities(nil, &calls, nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(AmendGenerateRequestSignal, "need towels in room 505")
+	}, time.Millisecond)
+
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	assert.Equal(s.T(), []string{"need towels", "need towels in room 505"}, texts)
+}
+
+func (s *workflowTestSuite) TestAmendAfterGenerationIsRejected() {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, gotInput aiflows.GenerateRequestInput) (aiflows.EnrichedGenerateRequestOutput, error) {
+			return aiflows.EnrichedGenerateRequestOutput{GenerateRequestOutput: aiflows.GenerateRequestOutput{Name: "Towels"}}, nil
+		},
+		activity.RegisterOptions{Name: "RunGenerateRequest"},
+	)
+	var rejected error
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, input PersistGeneratedRequestInput) (*models.Request, error) {
+			s.env.UpdateWorkflow(AmendGenerateRequestUpdate, "amend-1", &testsuite.TestUpdateCallback{
+				OnReject:   func(err error) { rejected = err },
+				OnAccept:   func() { s.Fail("amendment accepted while storing") },
+				OnComplete: func(interface{}, error) {},
+			}, "need towels in room 505")
+			return &models.Request{ID: "request-1", MakeRequest: models.MakeRequest{HotelID: input.HotelID}}, nil
+		},
+		activity.RegisterOptions{Name: "PersistGeneratedRequest"},
+	)
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, hotelID, requestID string) error { return nil },
+		activity.RegisterOptions{Name: "PublishRequestCreated"},
+	)
+
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(s.T(), rejected, &appErr)
+	assert.Equal(s.T(), GenerationFinishedError, appErr.Type())
+}
+
+func (s *workflowTestSuite) TestCancelDuringGenerationStoresNothing() {
+	var texts []string
+	s.registerBlockingGenerate(&texts)
+	var calls []string
+	s.registerPersistActivities(nil, &calls, nil)
+	s.env.RegisterDelayedCallback(s.env.CancelWorkflow, time.Millisecond)
+
+	s.env.ExecuteWorkflow(GenerateRequestWorkflow, GenerateRequestWorkflowInput{
+		GenerateRequestInput: aiflows.GenerateRequestInput{RawText: "need towels", HotelID: "org_1"},
+	})
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var canceled *temporal.CanceledError
+	require.ErrorAs(s.T(), s.env.GetWorkflowError(), &canceled)
+	assert.Empty(s.T(), calls)
 }
 
 func TestGenerateRequestWorkflowSuite(t *testing.T) {
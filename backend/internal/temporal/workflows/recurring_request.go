@@ -0,0 +1,90 @@
+package workflows
+
+import (
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// RequestSeriesChangedSignal wakes a series workflow after its row was edited,
+// paused, resumed or cancelled so it re-plans from the database.
+const RequestSeriesChangedSignal = "request-series-changed"
+
+const planRequestSeriesActivityName = "PlanRequestSeries"
+const createRequestOccurrenceActivityName = "CreateRequestOccurrence"
+
+// maxSeriesIterations bounds the history of a single run; the workflow
+// continues as new once it is reached.
+const maxSeriesIterations = 200
+
+func RequestSeriesWorkflowID(seriesID string) string {
+	return "request-series-" + seriesID
+}
+
+// RecurringRequestWorkflow sleeps until the next occurrence of a request
+// series and materializes it, for as long as the series stays active.
+func RecurringRequestWorkflow(ctx workflow.Context, seriesID string) error {
+	activityOptions := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    2 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumAttempts:    5,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	changed := workflow.GetSignalChannel(ctx, RequestSeriesChangedSignal)
+	after := workflow.Now(ctx)
+
+	for i := 0; i < maxSeriesIterations; i++ {
+		var plan models.RequestSeriesPlan
+		if err := workflow.ExecuteActivity(ctx, planRequestSeriesActivityName, seriesID, after).Get(ctx, &plan); err != nil {
+			return err
+		}
+
+		switch {
+		case plan.Status == models.SeriesStatusCancelled:
+			return nil
+		case plan.Status == models.SeriesStatusPaused:
+			changed.Receive(ctx, nil)
+			// Occurrences missed while paused are skipped, not backfilled.
+			after = workflow.Now(ctx)
+			continue
+		case plan.NextRunAt == nil:
+			return nil
+		}
+
+		wait := plan.NextRunAt.Sub(workflow.Now(ctx))
+		if wait < 0 {
+			wait = 0
+		}
+
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		timer := workflow.NewTimer(timerCtx, wait)
+
+		fired := false
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(timer, func(f workflow.Future) {
+			fired = f.Get(ctx, nil) == nil
+		})
+		selector.AddReceive(changed, func(c workflow.ReceiveChannel, _ bool) {
+			c.Receive(ctx, nil)
+			cancelTimer()
+		})
+		selector.Select(ctx)
+
+		if !fired {
+			continue
+		}
+
+		if err := workflow.ExecuteActivity(ctx, createRequestOccurrenceActivityName, seriesID, *plan.NextRunAt).Get(ctx, nil); err != nil {
+			return err
+		}
+		after = *plan.NextRunAt
+	}
+
+	return workflow.NewContinueAsNewError(ctx, RecurringRequestWorkflow, seriesID)
+}
@@ -0,0 +1,124 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/generate/selfserve/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type recurringWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func (s *recurringWorkflowTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+}
+
+func (s *recurringWorkflowTestSuite) registerActivities(plan func(after time.Time) models.RequestSeriesPlan, created *[]time.Time) {
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, seriesID string, after time.Time) (models.RequestSeriesPlan, error) {
+			assert.Equal(s.T(), "series-1", seriesID)
+			return plan(after), nil
+		},
+		activity.RegisterOptions{Name: "PlanRequestSeries"},
+	)
+	s.env.RegisterActivityWithOptions(
+		func(ctx context.Context, seriesID string, scheduledFor time.Time) (string, error) {
+			*created = append(*created, scheduledFor)
+			return "req-" + scheduledFor.String(), nil
+		},
+		activity.RegisterOptions{Name: "CreateRequestOccurrence"},
+	)
+}
+
+func (s *recurringWorkflowTestSuite) TestCreatesOccurrencesUntilRuleEnds() {
+	start := s.env.Now()
+	var created []time.Time
+
+	s.registerActivities(func(after time.Time) models.RequestSeriesPlan {
+		if len(created) >= 3 {
+			return models.RequestSeriesPlan{Status: models.SeriesStatusActive}
+		}
+		next := after.Add(time.Hour)
+		return models.RequestSeriesPlan{Status: models.SeriesStatusActive, NextRunAt: &next}
+	}, &created)
+
+	s.env.ExecuteWorkflow(RecurringRequestWorkflow, "series-1")
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	require.Len(s.T(), created, 3)
+	assert.WithinDuration(s.T(), start.Add(3*time.Hour), created[2], time.Second)
+}
+
+func (s *recurringWorkflowTestSuite) TestCancelledSeriesStops() {
+	var created []time.Time
+	s.registerActivities(func(after time.Time) models.RequestSeriesPlan {
+		return models.RequestSeriesPlan{Status: models.SeriesStatusCancelled}
+	}, &created)
+
+	s.env.ExecuteWorkflow(RecurringRequestWorkflow, "series-1")
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	assert.Empty(s.T(), created)
+}
+
+func (s *recurringWorkflowTestSuite) TestSignalReplansBeforeTimerFires() {
+	status := models.SeriesStatusActive
+	var created []time.Time
+	s.registerActivities(func(after time.Time) models.RequestSeriesPlan {
+		next := after.Add(24 * time.Hour)
+		return models.RequestSeriesPlan{Status: status, NextRunAt: &next}
+	}, &created)
+
+	s.env.RegisterDelayedCallback(func() {
+		status = models.SeriesStatusCancelled
+		s.env.SignalWorkflow(RequestSeriesChangedSignal, nil)
+	}, time.Hour)
+
+	s.env.ExecuteWorkflow(RecurringRequestWorkflow, "series-1")
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	assert.Empty(s.T(), created)
+}
+
+func (s *recurringWorkflowTestSuite) TestPausedSeriesWaitsForSignal() {
+	status := models.SeriesStatusPaused
+	var created []time.Time
+	s.registerActivities(func(after time.Time) models.RequestSeriesPlan {
+		if len(created) > 0 {
+			return models.RequestSeriesPlan{Status: models.SeriesStatusCancelled}
+		}
+		next := after.Add(time.Hour)
+		return models.RequestSeriesPlan{Status: status, NextRunAt: &next}
+	}, &created)
+
+	resumedAt := s.env.Now().Add(48 * time.Hour)
+	s.env.RegisterDelayedCallback(func() {
+		status = models.SeriesStatusActive
+		s.env.SignalWorkflow(RequestSeriesChangedSignal, nil)
+	}, 48*time.Hour)
+
+	s.env.ExecuteWorkflow(RecurringRequestWorkflow, "series-1")
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	require.Len(s.T(), created, 1)
+	assert.WithinDuration(s.T(), resumedAt.Add(time.Hour), created[0], time.Second)
+}
+
+func TestRecurringRequestWorkflowSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(recurringWorkflowTestSuite))
+}
@@ -91,6 +91,10 @@ func (m *mockHotelsRepositoryClerk) InsertHotel(ctx context.Context, hotel *mode
 	return nil, nil
 }
 
+func (m *mockHotelsRepositoryClerk) UpdateHotelSettings(ctx context.Context, id string, settings *models.UpdateHotelSettingsInput) (*models.Hotel, error) {
+	return nil, nil
+}
+
 func (m *mockHotelsRepositoryClerk) GetDepartmentsByHotelID(ctx context.Context, hotelID string) ([]*models.Department, error) {
 	return nil, nil
 }
@@ -33,6 +33,8 @@ func ToFieldErrors(err error) map[string]string {
 			fieldErrors[fieldName] = "invalid uuid"
 		case "timezone":
 			fieldErrors[fieldName] = "invalid IANA timezone"
+		case "bcp47_language_tag":
+			fieldErrors[fieldName] = "invalid language tag"
 		case "oneof":
 			if strings.EqualFold(fieldName, "priority") {
 				fieldErrors[fieldName] = "must be one of: low, medium, high"
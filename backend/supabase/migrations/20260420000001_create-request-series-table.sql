@@ -0,0 +1,17 @@
+-- Recurring request series: a request template plus a recurrence rule.
+-- Occurrences are materialized into public.requests by a Temporal workflow.
+CREATE TABLE IF NOT EXISTS public.request_series (
+    id                 UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    hotel_id           TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    template           JSONB       NOT NULL,
+    rule               JSONB       NOT NULL,
+    status             TEXT        NOT NULL DEFAULT 'active', -- 'active' | 'paused' | 'cancelled'
+    last_occurrence_at TIMESTAMPTZ,
+    created_by         TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ DEFAULT now(),
+    updated_at         TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_series_hotel_id ON public.request_series (hotel_id);
+
+ALTER TABLE public.request_series ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,30 @@
+-- SLA policies: per hotel, optionally narrowed by department and/or priority.
+-- The most specific matching policy applies (department + priority first).
+CREATE TABLE IF NOT EXISTS public.sla_policies (
+    id                         UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    hotel_id                   TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    department_id              UUID        REFERENCES public.departments(id) ON DELETE CASCADE,
+    priority                   TEXT,       -- 'low' | 'medium' | 'high' | NULL for any
+    acknowledge_within_minutes INTEGER,
+    complete_within_minutes    INTEGER,
+    escalate_to_user_id        TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at                 TIMESTAMPTZ DEFAULT now(),
+    updated_at                 TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sla_policies_scope
+    ON public.sla_policies (hotel_id, COALESCE(department_id::text, ''), COALESCE(priority, ''));
+
+ALTER TABLE public.sla_policies ENABLE ROW LEVEL SECURITY;
+
+-- One row per request and breach kind; the insert doubles as the lock that
+-- keeps concurrent evaluators from escalating the same breach twice.
+CREATE TABLE IF NOT EXISTS public.request_sla_breaches (
+    request_id  UUID        NOT NULL,
+    kind        TEXT        NOT NULL, -- 'acknowledge' | 'complete'
+    due_at      TIMESTAMPTZ NOT NULL,
+    breached_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (request_id, kind)
+);
+
+ALTER TABLE public.request_sla_breaches ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,14 @@
+-- started_at is stamped the first time a request moves to 'in progress';
+-- completed_at is now maintained by the server on status changes.
+ALTER TABLE public.requests ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+
+-- Extra per-hotel request statuses (e.g. 'blocked', 'awaiting guest') on top
+-- of the built-in pending / in progress / completed / archived lifecycle.
+CREATE TABLE IF NOT EXISTS public.hotel_request_statuses (
+    hotel_id   TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (hotel_id, name)
+);
+
+ALTER TABLE public.hotel_request_statuses ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,21 @@
+-- Files attached to a request (photos of a broken fixture, signed receipts).
+-- Rows start out 'pending' when an upload URL is handed out and become
+-- 'confirmed' once the object has been checked in S3.
+CREATE TABLE IF NOT EXISTS public.request_attachments (
+    id           UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id   UUID        NOT NULL,
+    hotel_id     TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    s3_key       TEXT        NOT NULL UNIQUE,
+    file_name    TEXT        NOT NULL,
+    content_type TEXT        NOT NULL,
+    size_bytes   BIGINT      NOT NULL,
+    status       TEXT        NOT NULL DEFAULT 'pending', -- 'pending' | 'confirmed'
+    uploaded_by  TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_attachments_request
+    ON public.request_attachments (request_id, created_at);
+
+ALTER TABLE public.request_attachments ENABLE ROW LEVEL SECURITY;
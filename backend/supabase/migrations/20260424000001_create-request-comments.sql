@@ -0,0 +1,20 @@
+-- Comments on a request. parent_id threads replies under another comment of
+-- the same request; mentions holds the ids of users @-mentioned in body.
+-- Deleting a comment only stamps deleted_at so replies keep their parent.
+CREATE TABLE IF NOT EXISTS public.request_comments (
+    id         UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID        NOT NULL,
+    hotel_id   TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    parent_id  UUID        REFERENCES public.request_comments(id) ON DELETE CASCADE,
+    author_id  TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    body       TEXT        NOT NULL,
+    mentions   TEXT[]      NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    edited_at  TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_comments_request
+    ON public.request_comments (request_id, created_at, id);
+
+ALTER TABLE public.request_comments ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,73 @@
+-- Latest version of every request. public.requests keeps the full version
+-- history; this projection is written in the same transaction as each new
+-- version so read paths no longer need DISTINCT ON over the history.
+-- `cli backfill-requests-current` rebuilds it for rows written outside the
+-- server (e.g. seed data).
+CREATE TABLE IF NOT EXISTS public.requests_current (
+    id                        UUID        PRIMARY KEY,
+    hotel_id                  TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    guest_id                  UUID,
+    reservation_id            TEXT,
+    name                      TEXT        NOT NULL,
+    description               TEXT,
+    room_id                   TEXT,
+    request_category          TEXT,
+    request_type              TEXT        NOT NULL,
+    department                TEXT,
+    status                    TEXT        NOT NULL,
+    priority                  TEXT        NOT NULL,
+    estimated_completion_time INTEGER,
+    scheduled_time            TIMESTAMPTZ,
+    completed_at              TIMESTAMPTZ,
+    notes                     TEXT,
+    created_at                TIMESTAMPTZ,
+    user_id                   TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    request_version           TIMESTAMPTZ NOT NULL,
+    changed_by                TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    started_at                TIMESTAMPTZ
+);
+
+-- Feed sorts (newest/oldest, priority) and filters (assignee, status) within
+-- a hotel. Archived requests are never listed, so they are left out.
+CREATE INDEX IF NOT EXISTS idx_requests_current_hotel_created
+    ON public.requests_current (hotel_id, created_at, id)
+    WHERE status <> 'archived';
+
+CREATE INDEX IF NOT EXISTS idx_requests_current_hotel_priority
+    ON public.requests_current (hotel_id, (CASE priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END), id)
+    WHERE status <> 'archived';
+
+CREATE INDEX IF NOT EXISTS idx_requests_current_hotel_user
+    ON public.requests_current (hotel_id, user_id)
+    WHERE status <> 'archived';
+
+CREATE INDEX IF NOT EXISTS idx_requests_current_hotel_status
+    ON public.requests_current (hotel_id, status);
+
+-- Live feed updates poll for requests changed since a version.
+CREATE INDEX IF NOT EXISTS idx_requests_current_hotel_version
+    ON public.requests_current (hotel_id, request_version);
+
+CREATE INDEX IF NOT EXISTS idx_requests_current_guest
+    ON public.requests_current (guest_id, hotel_id);
+
+CREATE INDEX IF NOT EXISTS idx_requests_current_room
+    ON public.requests_current (room_id, hotel_id);
+
+ALTER TABLE public.requests_current ENABLE ROW LEVEL SECURITY;
+
+-- Copy in the latest version of existing requests.
+INSERT INTO public.requests_current (
+    id, hotel_id, guest_id, reservation_id, name, description,
+    room_id, request_category, request_type, department, status,
+    priority, estimated_completion_time, scheduled_time, completed_at, notes,
+    created_at, user_id, request_version, changed_by, started_at
+)
+SELECT DISTINCT ON (id)
+    id, hotel_id, guest_id, reservation_id, name, description,
+    room_id, request_category, request_type, department, status,
+    priority, estimated_completion_time, scheduled_time, completed_at, notes,
+    created_at, user_id, request_version, changed_by, started_at
+FROM public.requests
+ORDER BY id, request_version DESC
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Trigram similarity and double metaphone for resolving room, guest and staff
+-- names written by hand ("rm 504", "the Lopez family", "Johnny") during AI
+-- request generation. Candidates are scored within one hotel, so no indexes
+-- are needed.
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA extensions;
+CREATE EXTENSION IF NOT EXISTS fuzzystrmatch WITH SCHEMA extensions;
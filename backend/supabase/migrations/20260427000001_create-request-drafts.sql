@@ -0,0 +1,32 @@
+-- AI-generated requests held for human review. The request itself is stored
+-- with status 'draft' (hidden from the feed); this table keeps what the model
+-- produced so reviewer corrections can be measured against it.
+-- corrections lists the fields changed on approval as
+-- [{"field", "generated", "corrected"}].
+CREATE TABLE IF NOT EXISTS public.request_drafts (
+    request_id       UUID        PRIMARY KEY REFERENCES public.requests_current(id) ON DELETE CASCADE,
+    hotel_id         TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    raw_text         TEXT        NOT NULL,
+    generated        JSONB       NOT NULL,
+    confidence       DOUBLE PRECISION NOT NULL,
+    field_confidence JSONB       NOT NULL DEFAULT '{}',
+    warning          JSONB,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    reviewed_by      TEXT        REFERENCES public.users(id) ON DELETE SET NULL,
+    reviewed_at      TIMESTAMPTZ,
+    review_action    TEXT        CHECK (review_action IN ('approved', 'rejected')),
+    review_reason    TEXT,
+    corrections      JSONB       NOT NULL DEFAULT '[]'
+);
+
+-- The review queue: pending drafts of a hotel, oldest first.
+CREATE INDEX IF NOT EXISTS idx_request_drafts_pending
+    ON public.request_drafts (hotel_id, created_at, request_id)
+    WHERE reviewed_at IS NULL;
+
+-- Accuracy reporting over reviewed drafts.
+CREATE INDEX IF NOT EXISTS idx_request_drafts_reviewed
+    ON public.request_drafts (hotel_id, reviewed_at)
+    WHERE reviewed_at IS NOT NULL;
+
+ALTER TABLE public.request_drafts ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,16 @@
+-- Language staff work in at the hotel; generated request names and
+-- descriptions are written in it. ISO 639-1 / BCP 47 code.
+ALTER TABLE public.hotels ADD COLUMN IF NOT EXISTS staff_language TEXT NOT NULL DEFAULT 'en';
+
+-- Requests generated from a guest message keep the message as written, an
+-- English rendering of it, and the guest's language so replies and
+-- notifications can be sent back in it.
+ALTER TABLE public.requests
+    ADD COLUMN IF NOT EXISTS original_text  TEXT,
+    ADD COLUMN IF NOT EXISTS english_text   TEXT,
+    ADD COLUMN IF NOT EXISTS guest_language TEXT;
+
+ALTER TABLE public.requests_current
+    ADD COLUMN IF NOT EXISTS original_text  TEXT,
+    ADD COLUMN IF NOT EXISTS english_text   TEXT,
+    ADD COLUMN IF NOT EXISTS guest_language TEXT;
@@ -0,0 +1,33 @@
+-- Async request generations run in-process while Temporal is unavailable.
+-- A job is claimed by setting locked_until; a job whose lock expired (the
+-- server restarted mid-run) is claimed again. revision is bumped whenever the
+-- raw text is amended so a generation from the old text is discarded.
+-- status: 'pending' | 'generating' | 'storing' | 'completed' | 'failed' | 'cancelled'
+CREATE TABLE IF NOT EXISTS public.generate_request_jobs (
+    id           TEXT        PRIMARY KEY,
+    hotel_id     TEXT        NOT NULL REFERENCES public.hotels(id) ON DELETE CASCADE,
+    requested_by TEXT,
+    raw_text     TEXT        NOT NULL,
+    revision     INTEGER     NOT NULL DEFAULT 0,
+    status       TEXT        NOT NULL DEFAULT 'pending',
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    output       JSONB,
+    request_id   UUID,
+    error        TEXT,
+    run_after    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at  TIMESTAMPTZ
+);
+
+-- Jobs still to run, in the order they are claimed.
+CREATE INDEX IF NOT EXISTS idx_generate_request_jobs_open
+    ON public.generate_request_jobs (run_after, created_at)
+    WHERE status IN ('pending', 'generating', 'storing');
+
+-- A hotel's generations, most recent first.
+CREATE INDEX IF NOT EXISTS idx_generate_request_jobs_hotel
+    ON public.generate_request_jobs (hotel_id, created_at DESC, id DESC);
+
+ALTER TABLE public.generate_request_jobs ENABLE ROW LEVEL SECURITY;
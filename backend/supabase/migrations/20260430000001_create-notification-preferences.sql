@@ -0,0 +1,19 @@
+-- How each user is notified. channels maps notification types to the channels
+-- they are delivered on ({"task_assigned": ["in_app", "push", "email"]});
+-- types left out use the defaults. Windows are 'HH:MM' in the user's timezone.
+-- In-app notifications are written regardless.
+CREATE TABLE IF NOT EXISTS public.notification_preferences (
+    user_id       TEXT        PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    channels      JSONB       NOT NULL DEFAULT '{}',
+    quiet_start   TEXT,
+    quiet_end     TEXT,
+    on_shift_only BOOLEAN     NOT NULL DEFAULT false,
+    shift_start   TEXT,
+    shift_end     TEXT,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((quiet_start IS NULL) = (quiet_end IS NULL)),
+    CHECK ((shift_start IS NULL) = (shift_end IS NULL)),
+    CHECK (NOT on_shift_only OR shift_start IS NOT NULL)
+);
+
+ALTER TABLE public.notification_preferences ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,49 @@
+-- Notifications waiting to be delivered on a channel other than in-app,
+-- written in the same transaction as the notification. channel is the name of
+-- the channel ('expo', 'apns', 'email', ...). An entry is claimed by setting
+-- locked_until; one whose lock expired (the server restarted mid-delivery) is
+-- claimed again.
+-- status: 'pending' | 'delivered' | 'failed'
+CREATE TABLE IF NOT EXISTS public.notification_outbox (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    notification_id UUID        NOT NULL REFERENCES public.notifications(id) ON DELETE CASCADE,
+    channel         TEXT        NOT NULL,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    error           TEXT,
+    run_after       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ,
+    UNIQUE (notification_id, channel)
+);
+
+-- Entries still to deliver, in the order they are claimed.
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_pending
+    ON public.notification_outbox (run_after, created_at)
+    WHERE status = 'pending';
+
+ALTER TABLE public.notification_outbox ENABLE ROW LEVEL SECURITY;
+
+-- The receipt for each address (device token, email address, phone number or
+-- webhook URL) an outbox entry was delivered to. ticket_id is the provider's
+-- ticket while it is yet to report delivery.
+-- status: 'sent' | 'delivered' | 'retrying' | 'failed'
+CREATE TABLE IF NOT EXISTS public.notification_deliveries (
+    outbox_id  UUID        NOT NULL REFERENCES public.notification_outbox(id) ON DELETE CASCADE,
+    address    TEXT        NOT NULL,
+    status     TEXT        NOT NULL,
+    ticket_id  TEXT,
+    error      TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (outbox_id, address)
+);
+
+-- Tickets still awaiting a receipt, oldest first.
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_tickets
+    ON public.notification_deliveries (updated_at)
+    WHERE status = 'sent' AND ticket_id IS NOT NULL;
+
+ALTER TABLE public.notification_deliveries ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,16 @@
+-- Looks up recent notifications of a type about the same request, to drop
+-- repeats within the dedupe window.
+CREATE INDEX IF NOT EXISTS idx_notifications_user_request
+    ON public.notifications (user_id, type, (data->>'request_id'), created_at DESC);
+
+-- The users sent a daily summary, by the date in their timezone it was sent
+-- on. A row is written before the summary is sent, so each user gets at most
+-- one a day.
+CREATE TABLE IF NOT EXISTS public.notification_daily_summaries (
+    user_id    TEXT        NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    sent_on    DATE        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, sent_on)
+);
+
+ALTER TABLE public.notification_daily_summaries ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,5 @@
+-- Where each hotel's own systems receive its staff's notifications, and the
+-- secret the payloads are signed with. Webhooks are off with no URLs.
+ALTER TABLE public.hotels
+    ADD COLUMN IF NOT EXISTS webhook_urls   TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS webhook_secret TEXT;
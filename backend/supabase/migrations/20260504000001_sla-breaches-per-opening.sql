@@ -0,0 +1,25 @@
+-- A request reopened after it was completed or archived can breach its SLA
+-- again, so breaches are recorded once per opening of the request: opened_at
+-- is the first version after it was last closed.
+ALTER TABLE public.request_sla_breaches
+    ADD COLUMN IF NOT EXISTS opened_at TIMESTAMPTZ;
+
+-- Existing breaches belong to the opening in effect when they were recorded.
+UPDATE public.request_sla_breaches b
+SET opened_at = COALESCE((
+    SELECT MIN(v.request_version)
+    FROM public.requests v
+    WHERE v.id = b.request_id
+      AND v.request_version > COALESCE((
+        SELECT MAX(c.request_version)
+        FROM public.requests c
+        WHERE c.id = b.request_id AND c.status IN ('completed', 'archived')
+          AND c.request_version <= b.breached_at
+      ), '-infinity')
+), b.breached_at)
+WHERE b.opened_at IS NULL;
+
+ALTER TABLE public.request_sla_breaches
+    ALTER COLUMN opened_at SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS request_sla_breaches_pkey,
+    ADD PRIMARY KEY (request_id, kind, opened_at);
@@ -0,0 +1,25 @@
+-- When each request was last opened: its first version after the version
+-- that last completed or archived it. SLA deadlines count from it, so a
+-- reopened request gets its full time again.
+ALTER TABLE public.requests_current
+    ADD COLUMN IF NOT EXISTS opened_at TIMESTAMPTZ;
+
+UPDATE public.requests_current rc
+SET opened_at = (
+    SELECT MIN(v.request_version)
+    FROM public.requests v
+    WHERE v.id = rc.id AND v.request_version <= rc.request_version
+      AND v.request_version > COALESCE((
+        SELECT MAX(c.request_version)
+        FROM public.requests c
+        WHERE c.id = rc.id AND c.request_version < rc.request_version
+          AND c.status IN ('completed', 'archived')
+      ), '-infinity')
+)
+WHERE rc.opened_at IS NULL;
+
+-- Requests whose history predates versioning fall back to their creation.
+UPDATE public.requests_current SET opened_at = created_at WHERE opened_at IS NULL;
+
+ALTER TABLE public.requests_current
+    ALTER COLUMN opened_at SET NOT NULL;
@@ -693,6 +693,22 @@ VALUES
 
 ON CONFLICT (id, request_version) DO NOTHING;
 
+-- Rebuild the latest-version projection from the rows inserted above.
+DELETE FROM public.requests_current;
+INSERT INTO public.requests_current (
+    id, hotel_id, guest_id, reservation_id, name, description,
+    room_id, request_category, request_type, department, status,
+    priority, estimated_completion_time, scheduled_time, completed_at, notes,
+    created_at, user_id, request_version, changed_by, started_at
+)
+SELECT DISTINCT ON (id)
+    id, hotel_id, guest_id, reservation_id, name, description,
+    room_id, request_category, request_type, department, status,
+    priority, estimated_completion_time, scheduled_time, completed_at, notes,
+    created_at, user_id, request_version, changed_by, started_at
+FROM public.requests
+ORDER BY id, request_version DESC;
+
 COMMIT;
 
 -- Quick sanity-check (printed after the transaction commits)